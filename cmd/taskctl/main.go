@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/nzb3/workmate_test/internal/taskctl"
+)
+
+func main() {
+	taskctl.Execute()
+}
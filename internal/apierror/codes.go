@@ -0,0 +1,84 @@
+// Package apierror is the catalog of machine-readable codes returned in the
+// "error" field of error responses. Clients branch on the code; the message
+// next to it is for humans and may change.
+package apierror
+
+import "net/http"
+
+// Code identifies the kind of error independently of its message.
+type Code string
+
+const (
+	// ValidationError: the request body or a parameter is invalid.
+	ValidationError Code = "validation_error"
+	// InvalidID: a path parameter is not a valid UUID.
+	InvalidID Code = "invalid_id"
+	// Unauthorized: the caller is not authenticated.
+	Unauthorized Code = "unauthorized"
+	// Forbidden: the caller may not perform the request.
+	Forbidden Code = "forbidden"
+	// AdminDisabled: the admin API is disabled because no token is configured.
+	AdminDisabled Code = "admin_disabled"
+	// NotFound: the route does not exist or is switched off by a feature flag.
+	NotFound Code = "not_found"
+	// TaskNotFound: no task has the given ID.
+	TaskNotFound Code = "task_not_found"
+	// ProjectNotFound: no project has the given ID.
+	ProjectNotFound Code = "project_not_found"
+	// ProjectNotEmpty: the project still has tasks and cannot be deleted.
+	ProjectNotEmpty Code = "project_not_empty"
+	// TemplateNotFound: no task template has the given ID.
+	TemplateNotFound Code = "template_not_found"
+	// TaskNameConflict: another active task already has the unique name.
+	TaskNameConflict Code = "task_name_conflict"
+	// TaskFinished: the task has finished and can no longer be cancelled.
+	TaskFinished Code = "task_finished"
+	// TaskNotWaiting: the task has started and its priority can no longer
+	// be changed.
+	TaskNotWaiting Code = "task_not_waiting"
+	// ArtifactNotFound: the task has no artifact with the given name.
+	ArtifactNotFound Code = "artifact_not_found"
+	// PayloadTooLarge: the request body exceeds the upload limit.
+	PayloadTooLarge Code = "payload_too_large"
+	// Maintenance: the service is in read-only maintenance mode.
+	Maintenance Code = "maintenance"
+	// ServiceDraining: the instance is draining before a restart.
+	ServiceDraining Code = "service_draining"
+	// Overloaded: the executors are too far behind to accept more tasks.
+	Overloaded Code = "overloaded"
+	// Timeout: the request was not processed within the request timeout.
+	Timeout Code = "timeout"
+	// InternalError: an unexpected server-side failure.
+	InternalError Code = "internal_error"
+)
+
+// Entry documents one error code.
+type Entry struct {
+	Code        Code
+	Status      int
+	Description string
+}
+
+// Catalog lists every code with the HTTP status it is returned with.
+var Catalog = []Entry{
+	{ValidationError, http.StatusBadRequest, "The request body or a parameter is invalid"},
+	{InvalidID, http.StatusBadRequest, "A path parameter is not a valid UUID"},
+	{Unauthorized, http.StatusUnauthorized, "The caller is not authenticated"},
+	{Forbidden, http.StatusForbidden, "The caller may not perform the request"},
+	{AdminDisabled, http.StatusForbidden, "The admin API is disabled"},
+	{NotFound, http.StatusNotFound, "The route does not exist or is switched off"},
+	{TaskNotFound, http.StatusNotFound, "No task has the given ID"},
+	{ProjectNotFound, http.StatusNotFound, "No project has the given ID"},
+	{ProjectNotEmpty, http.StatusConflict, "The project still has tasks"},
+	{TemplateNotFound, http.StatusNotFound, "No task template has the given ID"},
+	{TaskNameConflict, http.StatusConflict, "Another active task already has the unique name"},
+	{TaskFinished, http.StatusConflict, "The task has finished and can no longer be cancelled"},
+	{TaskNotWaiting, http.StatusConflict, "The task has started and its priority can no longer be changed"},
+	{ArtifactNotFound, http.StatusNotFound, "The task has no artifact with the given name"},
+	{PayloadTooLarge, http.StatusRequestEntityTooLarge, "The request body exceeds the upload limit"},
+	{Maintenance, http.StatusServiceUnavailable, "The service is in read-only maintenance mode"},
+	{ServiceDraining, http.StatusServiceUnavailable, "The instance is draining before a restart"},
+	{Overloaded, http.StatusServiceUnavailable, "The executors are too far behind to accept more tasks"},
+	{Timeout, http.StatusGatewayTimeout, "The request was not processed within the request timeout"},
+	{InternalError, http.StatusInternalServerError, "Unexpected server-side failure"},
+}
@@ -0,0 +1,13 @@
+package apierror
+
+import (
+	"strconv"
+	"time"
+)
+
+// RetryAfter formats d as the value of a Retry-After header: whole seconds,
+// rounded up, and at least one so that clients never retry in a busy loop.
+func RetryAfter(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
@@ -3,38 +3,222 @@ package app
 import (
 	"context"
 	"errors"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
+
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+	"github.com/nzb3/workmate_test/internal/graceful"
+	"github.com/nzb3/workmate_test/internal/loadgen"
 )
 
-func Start() {
-	container := NewDIContainer()
-	ctx := context.Background()
+// estimateSaveInterval is how often learned task durations are stored.
+const estimateSaveInterval = time.Minute
+
+// Start runs the server until it is stopped by a signal. configOverrides,
+// keyed by environment variable names, take precedence over the environment
+// and the config file.
+func Start(configOverrides map[string]string) {
+	container := NewDIContainer(WithConfigOverrides(configOverrides))
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	slog.SetDefault(container.Logger(ctx))
+
+	build := buildinfo.Get()
+	slog.Info("Starting workmate",
+		slog.String("version", build.Version),
+		slog.String("commit", build.Commit),
+		slog.String("build_time", build.BuildTime),
+		slog.String("go_version", build.GoVersion),
+	)
+	slog.Info("Configuration loaded", slog.Any("config", container.Config(ctx)))
 
 	server := container.Server(ctx)
 
+	go container.RetentionService(ctx).Run(ctx)
+	go container.DurationModel(ctx).Run(ctx, estimateSaveInterval)
+
+	if relay := container.OutboxRelay(ctx); relay != nil {
+		go relay.Run(ctx)
+	}
+
+	go func() {
+		if err := container.TaskService(ctx).Consume(ctx); err != nil {
+			log.Printf("Ошибка получения задач из очереди: %v", err)
+		}
+	}()
+	go func() {
+		if err := container.TaskService(ctx).WatchCancellations(ctx); err != nil {
+			log.Printf("Ошибка получения отмен задач: %v", err)
+		}
+	}()
+
+	for _, exporter := range container.MetricExporters(ctx) {
+		go exporter.Run(ctx)
+	}
+
+	if reloader := container.CertReloader(ctx); reloader != nil {
+		go reloader.Watch(ctx, container.Config(ctx).TLS.ReloadInterval)
+	}
+
+	listeners := slices.Clone(container.Listeners(ctx))
+	conns := &graceful.Conns{}
+	serve(server, listeners, conns)
+
+	adminServer := container.AdminServer(ctx)
+	if adminServer != nil {
+		adminListener := container.AdminListener(ctx)
+		serve(adminServer, []*graceful.Listener{adminListener}, conns)
+		listeners = append(listeners, adminListener)
+	}
+
+	// Readiness stays failed until interrupted tasks are recovered, so that
+	// traffic is not routed here while their state is still being settled.
 	go func() {
-		log.Printf("🚀 Сервер запущен на порту %s\n", server.Addr)
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("Ошибка запуска сервера: %v", err)
+		recovered, err := container.TaskService(ctx).Recover(ctx)
+		if err != nil {
+			log.Printf("Ошибка восстановления прерванных задач: %v", err)
+			return
+		}
+		if recovered > 0 {
+			log.Printf("Восстановлено прерванных задач: %d", recovered)
+		}
+		if n := container.Config(ctx).Dev.SeedTasks; n > 0 {
+			seeded, err := loadgen.Seed(ctx, container.TaskService(ctx), n)
+			if err != nil {
+				log.Printf("Ошибка создания тестовых задач: %v", err)
+			}
+			log.Printf("Создано тестовых задач: %d", seeded)
+		}
+		container.HealthChecker(ctx).MarkReady()
+		if err := graceful.NotifyReady(); err != nil {
+			log.Printf("Ошибка уведомления о готовности: %v", err)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Получен сигнал завершения работы...")
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range quit {
+		if sig != syscall.SIGHUP {
+			log.Println("Получен сигнал завершения работы...")
+			break
+		}
+
+		// The new process shares the listener, so the connections this one
+		// stops accepting below are served there.
+		log.Println("Получен сигнал перезапуска...")
+		if err := graceful.Restart(ctx, listeners, container.Config(ctx).Server.RestartTimeout, container.Logger(ctx)); err != nil {
+			log.Printf("Ошибка перезапуска, продолжаем работу: %v", err)
+			continue
+		}
+		log.Println("Новый процесс готов, завершение работы...")
 
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// Connections accepted before the listener is closed would be
+		// dropped by Shutdown if their request arrived after it started.
+		for _, listener := range listeners {
+			listener.KeepSocketFile()
+			listener.Close()
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := conns.WaitFresh(waitCtx); err != nil {
+			log.Printf("Не все принятые соединения успели отправить запрос: %v", err)
+		}
+		cancel()
+		break
+	}
+	container.HealthChecker(ctx).MarkDraining()
+	container.TaskService(ctx).Drain()
+	stop()
+
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), container.Config(ctx).Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctxShutdown); err != nil {
 		log.Fatalf("Принудительное завершение работы сервера: %v", err)
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctxShutdown); err != nil {
+			log.Printf("Принудительное завершение работы административного сервера: %v", err)
+		}
+	}
+
+	// Tasks live in the memory of this process and are not handed over to
+	// the new one on restart, so they get the rest of the shutdown timeout
+	// to finish; the unfinished ones are interrupted rather than abandoned.
+	if err := container.TaskService(ctx).Wait(ctxShutdown); err != nil {
+		log.Printf("Не все задачи завершились до остановки, они будут прерваны: %v", err)
+	}
+	if err := container.TaskService(ctx).Shutdown(context.Background()); err != nil {
+		log.Printf("Ошибка остановки выполнения задач: %v", err)
+	}
+
+	if publisher, ok := container.EventPublisher(ctx).(io.Closer); ok {
+		if err := publisher.Close(); err != nil {
+			log.Printf("Ошибка закрытия издателя событий: %v", err)
+		}
+	}
+	if coordinator, ok := container.Coordinator(ctx).(io.Closer); ok {
+		if err := coordinator.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения с Redis: %v", err)
+		}
+	}
+	if queue, ok := container.Dispatcher(ctx).(io.Closer); ok {
+		if err := queue.Close(); err != nil {
+			log.Printf("Ошибка закрытия очереди задач: %v", err)
+		}
+	}
+
+	if err := container.DurationModel(ctx).Save(); err != nil {
+		log.Printf("Ошибка сохранения длительностей задач: %v", err)
+	}
+
+	for _, exporter := range container.MetricExporters(ctx) {
+		if err := exporter.Shutdown(ctxShutdown); err != nil {
+			log.Printf("Ошибка отправки метрик при завершении: %v", err)
+		}
+	}
+
+	if provider := container.TracerProvider(ctx); provider != nil {
+		if err := provider.Shutdown(ctxShutdown); err != nil {
+			log.Printf("Ошибка отправки трасс при завершении: %v", err)
+		}
+	}
+
+	if provider := container.LoggerProvider(ctx); provider != nil {
+		if err := provider.Shutdown(ctxShutdown); err != nil {
+			log.Printf("Ошибка отправки логов при завершении: %v", err)
+		}
+	}
 
 	log.Println("Сервер корректно остановлен")
 }
+
+func serve(server *http.Server, listeners []*graceful.Listener, conns *graceful.Conns) {
+	conns.Track(server)
+
+	// Serving fills in server.TLSConfig for HTTP/2, so TLS is decided upfront.
+	tlsEnabled := server.TLSConfig != nil
+	for _, listener := range listeners {
+		go func() {
+			log.Printf("🚀 Сервер запущен на %s\n", listener.Addr())
+
+			var err error
+			if tlsEnabled {
+				err = server.ServeTLS(listener, "", "")
+			} else {
+				err = server.Serve(listener)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+				log.Fatalf("Ошибка запуска сервера: %v", err)
+			}
+		}()
+	}
+}
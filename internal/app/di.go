@@ -2,29 +2,350 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"log/slog"
+	"maps"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/blobstore"
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+	"github.com/nzb3/workmate_test/internal/certs"
+	"github.com/nzb3/workmate_test/internal/clock"
+	"github.com/nzb3/workmate_test/internal/config"
 	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/controllers/admincontroller"
+	"github.com/nzb3/workmate_test/internal/controllers/healthcontroller"
+	"github.com/nzb3/workmate_test/internal/controllers/loadgencontroller"
+	"github.com/nzb3/workmate_test/internal/controllers/projectcontroller"
 	"github.com/nzb3/workmate_test/internal/controllers/taskcontroller"
+	"github.com/nzb3/workmate_test/internal/controllers/templatecontroller"
+	"github.com/nzb3/workmate_test/internal/coordination/rediscoord"
+	"github.com/nzb3/workmate_test/internal/estimate"
+	"github.com/nzb3/workmate_test/internal/features"
+	"github.com/nzb3/workmate_test/internal/federation"
+	"github.com/nzb3/workmate_test/internal/graceful"
+	"github.com/nzb3/workmate_test/internal/health"
+	"github.com/nzb3/workmate_test/internal/loadgen"
+	"github.com/nzb3/workmate_test/internal/logger"
+	"github.com/nzb3/workmate_test/internal/maintenance"
+	"github.com/nzb3/workmate_test/internal/metrics"
+	"github.com/nzb3/workmate_test/internal/middleware"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/openapi"
+	"github.com/nzb3/workmate_test/internal/outbox"
+	"github.com/nzb3/workmate_test/internal/outbox/kafkapublisher"
+	"github.com/nzb3/workmate_test/internal/outbox/webhookpublisher"
+	"github.com/nzb3/workmate_test/internal/panicreport"
+	"github.com/nzb3/workmate_test/internal/queue/natsqueue"
+	"github.com/nzb3/workmate_test/internal/queue/rabbitqueue"
+	"github.com/nzb3/workmate_test/internal/repository/projectrepository"
 	"github.com/nzb3/workmate_test/internal/repository/taskrepository"
+	"github.com/nzb3/workmate_test/internal/repository/taskview"
+	"github.com/nzb3/workmate_test/internal/repository/templaterepository"
+	"github.com/nzb3/workmate_test/internal/requestid"
+	"github.com/nzb3/workmate_test/internal/service/projectservice"
+	"github.com/nzb3/workmate_test/internal/service/retentionservice"
 	"github.com/nzb3/workmate_test/internal/service/taskservice"
+	"github.com/nzb3/workmate_test/internal/service/templateservice"
+	"github.com/nzb3/workmate_test/internal/tracing"
+	"github.com/nzb3/workmate_test/internal/ui"
 )
 
 type DIContainer struct {
-	taskController *taskcontroller.Controller
-	taskService    *taskservice.Service
-	taskRepository *taskrepository.InMemoryTaskRepository
-	server         *http.Server
-	ginEngine      *gin.Engine
+	// configOverrides take precedence over the environment and config file.
+	configOverrides map[string]string
+
+	config             *config.Config
+	clock              clock.Clock
+	redactor           *logger.Redactor
+	logger             *slog.Logger
+	logLevel           *slog.LevelVar
+	certReloader       *certs.Reloader
+	metrics            *metrics.Metrics
+	metricExporters    []metrics.Exporter
+	tracerProvider     *sdktrace.TracerProvider
+	loggerProvider     *sdklog.LoggerProvider
+	panicReporter      panicreport.Reporter
+	healthChecker      *health.Checker
+	maintenance        *maintenance.Mode
+	featureFlags       *features.Flags
+	taskController     *taskcontroller.Controller
+	adminController    *admincontroller.Controller
+	projectController  *projectcontroller.Controller
+	templateController *templatecontroller.Controller
+	healthController   *healthcontroller.Controller
+	loadgenController  *loadgencontroller.Controller
+	loadGenerator      *loadgen.Generator
+	openAPI            *openapi.Spec
+	taskService        *taskservice.Service
+	durationModel      *estimate.Model
+	retentionService   *retentionservice.Service
+	projectService     *projectservice.Service
+	templateService    *templateservice.Service
+	taskRepository     TaskRepository
+	taskView           *taskview.View
+	eventPublisher     outbox.Publisher
+	outboxRelay        *outbox.Relay
+	dispatcher         taskservice.Dispatcher
+	blobStore          taskservice.BlobStore
+	coordinator        taskservice.Coordinator
+	projectRepository  ProjectRepository
+	templateRepository TemplateRepository
+	server             *http.Server
+	adminServer        *http.Server
+	listeners          []*graceful.Listener
+	adminListener      *graceful.Listener
+	ginEngine          *gin.Engine
+	adminGinEngine     *gin.Engine
+	// shutdown is closed once a server shuts down, ending log streams.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewDIContainer(opts ...Option) *DIContainer {
+	c := &DIContainer{shutdown: make(chan struct{})}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *DIContainer) Config(ctx context.Context) *config.Config {
+	if c.config != nil {
+		return c.config
+	}
+
+	cfg, err := config.LoadWithOverrides(c.configOverrides)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+	}
+	c.config = cfg
+
+	return cfg
+}
+
+func (c *DIContainer) Clock(ctx context.Context) clock.Clock {
+	if c.clock != nil {
+		return c.clock
+	}
+
+	clk := clock.Real{}
+	c.clock = clk
+
+	return clk
+}
+
+func (c *DIContainer) Logger(ctx context.Context) *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+
+	var bridges []slog.Handler
+	if provider := c.LoggerProvider(ctx); provider != nil {
+		bridges = append(bridges, otelslog.NewHandler(c.Config(ctx).Tracing.ServiceName, otelslog.WithLoggerProvider(provider)))
+	}
+
+	l := logger.New(os.Stderr, c.Config(ctx).Log.Format, c.LogLevel(ctx), c.Redactor(ctx), bridges...)
+	c.logger = l
+
+	return l
+}
+
+func (c *DIContainer) LogLevel(ctx context.Context) *slog.LevelVar {
+	if c.logLevel != nil {
+		return c.logLevel
+	}
+
+	level, err := logger.ParseLevel(c.Config(ctx).Log.Level)
+	if err != nil {
+		log.Fatalf("Ошибка настройки уровня логирования: %v", err)
+	}
+
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(level)
+	c.logLevel = levelVar
+
+	return levelVar
+}
+
+func (c *DIContainer) Redactor(ctx context.Context) *logger.Redactor {
+	if c.redactor != nil {
+		return c.redactor
+	}
+
+	logConfig := c.Config(ctx).Log
+	redactor, err := logger.NewRedactor(logConfig.RedactKeys, logConfig.RedactPatterns)
+	if err != nil {
+		log.Fatalf("Ошибка настройки маскирования логов: %v", err)
+	}
+	c.redactor = redactor
+
+	return redactor
+}
+
+func (c *DIContainer) Metrics(ctx context.Context) *metrics.Metrics {
+	if c.metrics != nil {
+		return c.metrics
+	}
+
+	m := metrics.New()
+	m.RegisterTaskCounter(c.TaskRepository(ctx))
+	c.metrics = m
+
+	return m
 }
 
-func NewDIContainer() *DIContainer {
-	return &DIContainer{}
+// MetricExporters returns the push exporters enabled in the config;
+// Prometheus is served from /metrics and is not among them.
+func (c *DIContainer) MetricExporters(ctx context.Context) []metrics.Exporter {
+	if c.metricExporters != nil {
+		return c.metricExporters
+	}
+
+	metricsConfig := c.Config(ctx).Metrics
+	gatherer := c.Metrics(ctx).Gatherer()
+	exporters := []metrics.Exporter{}
+
+	if metricsConfig.Enabled(config.MetricsExporterStatsD) {
+		exporter, err := metrics.NewStatsDExporter(gatherer, metricsConfig.StatsDAddr, metricsConfig.PushInterval, c.Logger(ctx))
+		if err != nil {
+			log.Fatalf("Ошибка настройки экспорта метрик в StatsD: %v", err)
+		}
+		exporters = append(exporters, exporter)
+	}
+	if metricsConfig.Enabled(config.MetricsExporterOTLP) {
+		exporter, err := metrics.NewOTLPExporter(ctx, gatherer, c.Config(ctx).Tracing.ServiceName, metricsConfig.PushInterval)
+		if err != nil {
+			log.Fatalf("Ошибка настройки экспорта метрик по OTLP: %v", err)
+		}
+		exporters = append(exporters, exporter)
+	}
+	c.metricExporters = exporters
+
+	return exporters
+}
+
+// TracerProvider returns nil when tracing is disabled.
+func (c *DIContainer) TracerProvider(ctx context.Context) *sdktrace.TracerProvider {
+	if c.tracerProvider != nil {
+		return c.tracerProvider
+	}
+
+	tracing.SetupPropagation()
+
+	tracingConfig := c.Config(ctx).Tracing
+	if !tracingConfig.Enabled {
+		return nil
+	}
+
+	provider, err := tracing.NewProvider(ctx, tracingConfig.ServiceName)
+	if err != nil {
+		log.Fatalf("Ошибка настройки трассировки: %v", err)
+	}
+	c.tracerProvider = provider
+
+	return provider
+}
+
+// LoggerProvider is nil unless log export over OTLP is enabled.
+func (c *DIContainer) LoggerProvider(ctx context.Context) *sdklog.LoggerProvider {
+	if c.loggerProvider != nil {
+		return c.loggerProvider
+	}
+
+	tracingConfig := c.Config(ctx).Tracing
+	if !tracingConfig.Logs {
+		return nil
+	}
+
+	provider, err := tracing.NewLoggerProvider(ctx, tracingConfig.ServiceName)
+	if err != nil {
+		log.Fatalf("Ошибка настройки экспорта логов: %v", err)
+	}
+	c.loggerProvider = provider
+
+	return provider
+}
+
+func (c *DIContainer) PanicReporter(ctx context.Context) panicreport.Reporter {
+	if c.panicReporter != nil {
+		return c.panicReporter
+	}
+
+	panicConfig := c.Config(ctx).Panic
+
+	var reporter panicreport.Reporter = panicreport.Nop{}
+	switch {
+	case panicConfig.SentryDSN != "":
+		sentry, err := panicreport.NewSentryReporter(panicConfig.SentryDSN, panicConfig.SentryEnvironment)
+		if err != nil {
+			log.Fatalf("Ошибка настройки отправки паник в Sentry: %v", err)
+		}
+		reporter = sentry
+	case panicConfig.WebhookURL != "":
+		reporter = panicreport.NewWebhookReporter(panicConfig.WebhookURL)
+	}
+	c.panicReporter = reporter
+
+	return reporter
+}
+
+func (c *DIContainer) HealthChecker(ctx context.Context) *health.Checker {
+	if c.healthChecker != nil {
+		return c.healthChecker
+	}
+
+	checker := health.NewChecker()
+	checker.AddCheck("task_repository", c.TaskRepository(ctx).Ping)
+	checker.AddCheck("project_repository", c.ProjectRepository(ctx).Ping)
+	checker.AddCheck("template_repository", c.TemplateRepository(ctx).Ping)
+	checker.AddCheck("workers", c.TaskService(ctx).CheckWorkers)
+	if queue, ok := c.Dispatcher(ctx).(pinger); ok {
+		checker.AddCheck("task_queue", queue.Ping)
+	}
+	if coordinator, ok := c.Coordinator(ctx).(pinger); ok {
+		checker.AddCheck("coordination", coordinator.Ping)
+	}
+	c.healthChecker = checker
+
+	return checker
+}
+
+func (c *DIContainer) Maintenance(ctx context.Context) *maintenance.Mode {
+	if c.maintenance != nil {
+		return c.maintenance
+	}
+
+	mode := maintenance.New(c.Config(ctx).Admin.Maintenance, c.Logger(ctx))
+	c.maintenance = mode
+
+	return mode
+}
+
+func (c *DIContainer) FeatureFlags(ctx context.Context) *features.Flags {
+	if c.featureFlags != nil {
+		return c.featureFlags
+	}
+
+	flags := features.New(c.Config(ctx).Features.Flags, c.Logger(ctx))
+	c.featureFlags = flags
+
+	return flags
 }
 
 func (c *DIContainer) TaskController(ctx context.Context) *taskcontroller.Controller {
@@ -32,55 +353,645 @@ func (c *DIContainer) TaskController(ctx context.Context) *taskcontroller.Contro
 		return c.taskController
 	}
 
-	controller := taskcontroller.NewController(c.TaskService(ctx))
+	federationConfig := c.Config(ctx).Federation
+	peers := make([]federation.Peer, len(federationConfig.Peers))
+	for i, peer := range federationConfig.Peers {
+		peers[i] = federation.Peer{Name: peer.Name, URL: peer.URL}
+	}
+	tasksConfig := c.Config(ctx).Tasks
+	nameRules, err := taskcontroller.NewNameRules(tasksConfig.NameMinLength, tasksConfig.NameMaxLength,
+		tasksConfig.NamePattern, tasksConfig.NameCharset, tasksConfig.NameReservedPrefixes)
+	if err != nil {
+		log.Fatalf("Ошибка настройки правил названий задач: %v", err)
+	}
+	controller := taskcontroller.NewController(
+		c.TaskService(ctx),
+		c.ProjectService(ctx),
+		taskcontroller.WithFederation(federationConfig.Name, federation.NewClient(peers, federationConfig.Timeout)),
+		taskcontroller.WithTemplates(c.TemplateService(ctx)),
+		taskcontroller.WithMaxUploadSize(int64(c.Config(ctx).Blob.MaxUploadSize)),
+		taskcontroller.WithNameRules(nameRules),
+		taskcontroller.WithShutdown(c.shutdown),
+	)
 	c.taskController = controller
 
 	return controller
 }
 
+func (c *DIContainer) AdminController(ctx context.Context) *admincontroller.Controller {
+	if c.adminController != nil {
+		return c.adminController
+	}
+
+	controller := admincontroller.NewController(
+		c.TaskService(ctx),
+		c.RetentionService(ctx),
+		c.HealthChecker(ctx),
+		c.Maintenance(ctx),
+		c.LogLevel(ctx),
+		c.FeatureFlags(ctx),
+		c.Config(ctx).Tasks.StuckThreshold,
+	)
+	c.adminController = controller
+
+	return controller
+}
+
+// LoadGenerator is nil unless dev mode is enabled.
+func (c *DIContainer) LoadGenerator(ctx context.Context) *loadgen.Generator {
+	if c.loadGenerator != nil {
+		return c.loadGenerator
+	}
+	if !c.Config(ctx).Dev.Enabled {
+		return nil
+	}
+
+	generator := loadgen.New(ctx, c.TaskService(ctx), c.Logger(ctx))
+	c.loadGenerator = generator
+
+	return generator
+}
+
+// LoadgenController is nil unless dev mode is enabled.
+func (c *DIContainer) LoadgenController(ctx context.Context) *loadgencontroller.Controller {
+	if c.loadgenController != nil {
+		return c.loadgenController
+	}
+	generator := c.LoadGenerator(ctx)
+	if generator == nil {
+		return nil
+	}
+
+	controller := loadgencontroller.NewController(generator)
+	c.loadgenController = controller
+
+	return controller
+}
+
+func (c *DIContainer) ProjectController(ctx context.Context) *projectcontroller.Controller {
+	if c.projectController != nil {
+		return c.projectController
+	}
+
+	controller := projectcontroller.NewController(c.ProjectService(ctx))
+	c.projectController = controller
+
+	return controller
+}
+
+func (c *DIContainer) TemplateController(ctx context.Context) *templatecontroller.Controller {
+	if c.templateController != nil {
+		return c.templateController
+	}
+
+	controller := templatecontroller.NewController(c.TemplateService(ctx), c.ProjectService(ctx))
+	c.templateController = controller
+
+	return controller
+}
+
+func (c *DIContainer) HealthController(ctx context.Context) *healthcontroller.Controller {
+	if c.healthController != nil {
+		return c.healthController
+	}
+
+	controller := healthcontroller.NewController(
+		c.HealthChecker(ctx),
+		buildinfo.Get(),
+		c.TaskService(ctx),
+		c.TaskRepository(ctx),
+		c.ProjectRepository(ctx),
+		c.TemplateRepository(ctx),
+	)
+	c.healthController = controller
+
+	return controller
+}
+
+// OpenAPI documents the handlers of every controller; each engine serves
+// the document of its own routes.
+func (c *DIContainer) OpenAPI(ctx context.Context) *openapi.Spec {
+	if c.openAPI != nil {
+		return c.openAPI
+	}
+
+	spec := openapi.New(openapi.Info{
+		Title:            "Workmate API",
+		Description:      "API for task management",
+		Version:          "1.0",
+		AdminDescription: `Admin token in the form "Bearer <token>"`,
+	})
+	c.TaskController(ctx).DescribeRoutes(spec)
+	c.ProjectController(ctx).DescribeRoutes(spec)
+	c.TemplateController(ctx).DescribeRoutes(spec)
+	c.AdminController(ctx).DescribeRoutes(spec)
+	c.HealthController(ctx).DescribeRoutes(spec)
+	if controller := c.LoadgenController(ctx); controller != nil {
+		controller.DescribeRoutes(spec)
+	}
+	spec.Describe(ui.Page, openapi.Operation{
+		Summary:     "Operator web UI",
+		Description: "Serves the task list page while the ui feature flag is enabled",
+		Tags:        []string{"ui"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "HTML page"},
+			{Status: http.StatusNotFound, Description: "The ui feature flag is disabled"},
+		},
+	})
+
+	codes := make([]any, len(apierror.Catalog))
+	for i, entry := range apierror.Catalog {
+		codes[i] = entry.Code
+	}
+	spec.Enum(apierror.Code(""), codes...)
+	statuses := make([]any, len(taskmodel.Statuses()))
+	for i, status := range taskmodel.Statuses() {
+		statuses[i] = status
+	}
+	spec.Enum(taskmodel.TaskStatus(""), statuses...)
+	c.openAPI = spec
+
+	return spec
+}
+
 func (c *DIContainer) TaskService(ctx context.Context) *taskservice.Service {
 	if c.taskService != nil {
 		return c.taskService
 	}
 
-	service := taskservice.NewService(c.TaskRepository(ctx))
+	tasksConfig := c.Config(ctx).Tasks
+	sheddingConfig := c.Config(ctx).Shedding
+	opts := []taskservice.Option{
+		taskservice.WithWorkers(tasksConfig.Workers),
+		taskservice.WithPools(tasksConfig.Pools),
+		taskservice.WithPreemption(tasksConfig.Preemption),
+		taskservice.WithTimeout(tasksConfig.Timeout),
+		taskservice.WithMaxRuntime(tasksConfig.MaxRuntime),
+		taskservice.WithShutdownTimeout(c.Config(ctx).Server.ShutdownTimeout),
+		taskservice.WithRetryPolicy(taskservice.RetryPolicy{
+			MaxAttempts: tasksConfig.MaxAttempts,
+			Backoff:     tasksConfig.RetryBackoff,
+		}),
+		taskservice.WithClock(c.Clock(ctx)),
+		taskservice.WithLogger(c.Logger(ctx)),
+		taskservice.WithMetrics(c.Metrics(ctx)),
+		taskservice.WithDurationModel(c.DurationModel(ctx)),
+		taskservice.WithBlobStore(c.BlobStore(ctx)),
+		taskservice.WithLogLines(tasksConfig.LogLines),
+		taskservice.WithDedupWindow(tasksConfig.DedupWindow),
+		taskservice.WithUniqueNames(tasksConfig.UniqueNames),
+		taskservice.WithSlowThresholds(tasksConfig.SlowThreshold, tasksConfig.SlowThresholds),
+		taskservice.WithPanicReporter(c.PanicReporter(ctx)),
+		taskservice.WithShedding(taskservice.ShedPolicy{
+			MaxQueued:  sheddingConfig.QueueDepth,
+			MaxWait:    sheddingConfig.QueueWait,
+			RetryAfter: sheddingConfig.RetryAfter,
+		}),
+	}
+	if view := c.TaskView(ctx); view != nil {
+		opts = append(opts, taskservice.WithReadModel(view))
+	}
+	if dispatcher := c.Dispatcher(ctx); dispatcher != nil {
+		opts = append(opts, taskservice.WithDispatcher(dispatcher))
+	}
+	if coordinator := c.Coordinator(ctx); coordinator != nil {
+		opts = append(opts, taskservice.WithCoordinator(coordinator))
+	}
+	service := taskservice.NewService(c.TaskRepository(ctx), opts...)
+	c.Metrics(ctx).RegisterWorkerPool(service)
 	c.taskService = service
 	return service
 }
 
-func (c *DIContainer) TaskRepository(ctx context.Context) *taskrepository.InMemoryTaskRepository {
+// DurationModel learns task durations for queue ETAs, loading the ones
+// stored by the previous run.
+func (c *DIContainer) DurationModel(ctx context.Context) *estimate.Model {
+	if c.durationModel != nil {
+		return c.durationModel
+	}
+
+	tasksConfig := c.Config(ctx).Tasks
+	model := estimate.NewModel(tasksConfig.EstimateWindow, tasksConfig.EstimateFile, c.Logger(ctx))
+	if err := model.Load(); err != nil {
+		log.Printf("Ошибка загрузки длительностей задач, оценки начнутся заново: %v", err)
+	}
+	c.durationModel = model
+	return model
+}
+
+func (c *DIContainer) ProjectService(ctx context.Context) *projectservice.Service {
+	if c.projectService != nil {
+		return c.projectService
+	}
+
+	service := projectservice.NewService(c.ProjectRepository(ctx), c.TaskService(ctx))
+	c.projectService = service
+	return service
+}
+
+func (c *DIContainer) TemplateService(ctx context.Context) *templateservice.Service {
+	if c.templateService != nil {
+		return c.templateService
+	}
+
+	service := templateservice.NewService(c.TemplateRepository(ctx), c.Clock(ctx))
+	c.templateService = service
+	return service
+}
+
+func (c *DIContainer) RetentionService(ctx context.Context) *retentionservice.Service {
+	if c.retentionService != nil {
+		return c.retentionService
+	}
+
+	retentionConfig := c.Config(ctx).Retention
+	rules := make([]retentionservice.Rule, len(retentionConfig.Rules))
+	for i, rule := range retentionConfig.Rules {
+		rules[i] = retentionservice.Rule{Status: rule.Status, MaxAge: rule.MaxAge}
+	}
+
+	service := retentionservice.NewService(c.TaskService(ctx), rules, retentionConfig.Interval, c.Logger(ctx))
+	c.retentionService = service
+	return service
+}
+
+func (c *DIContainer) TaskRepository(ctx context.Context) TaskRepository {
 	if c.taskRepository != nil {
 		return c.taskRepository
 	}
 
-	repository := taskrepository.NewInMemoryTaskRepository()
+	repository := taskrepository.NewInMemoryTaskRepository(taskrepository.WithClock(c.Clock(ctx)))
 	c.taskRepository = repository
 	return repository
 }
 
+// taskEventSource is a task repository publishing the events of its tasks.
+type taskEventSource interface {
+	Subscribe(handler func(taskmodel.Event))
+}
+
+// TaskView is nil when the task repository does not publish its events;
+// listings then read the repository itself.
+func (c *DIContainer) TaskView(ctx context.Context) *taskview.View {
+	if c.taskView != nil {
+		return c.taskView
+	}
+
+	source, ok := c.TaskRepository(ctx).(taskEventSource)
+	if !ok {
+		return nil
+	}
+
+	view := taskview.New()
+	source.Subscribe(view.Apply)
+	tasks, err := c.TaskRepository(ctx).GetAll(ctx)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить задачи в модель чтения: %v", err)
+	}
+	view.Seed(tasks)
+
+	c.taskView = view
+	return view
+}
+
+// outboxRepository is a task repository with an outbox of its events.
+type outboxRepository interface {
+	outbox.Store
+	EnableOutbox()
+}
+
+// EventPublisher is nil unless Kafka brokers or a webhook are configured or a publisher
+// is given with WithEventPublisher.
+func (c *DIContainer) EventPublisher(ctx context.Context) outbox.Publisher {
+	if c.eventPublisher != nil {
+		return c.eventPublisher
+	}
+
+	eventsConfig := c.Config(ctx).Events
+	var publishers outbox.Publishers
+	if kafkaConfig := eventsConfig.Kafka; kafkaConfig.Enabled() {
+		publishers = append(publishers, kafkapublisher.New(kafkaConfig.Brokers, kafkaConfig.Topic))
+	}
+	if webhookConfig := eventsConfig.Webhook; webhookConfig.Enabled() {
+		publisher, err := webhookpublisher.New(webhookpublisher.Config{
+			URL:          webhookConfig.URL,
+			Template:     webhookConfig.Template,
+			Headers:      webhookConfig.Headers,
+			AllowedHosts: webhookConfig.AllowedHosts,
+			Proxy:        webhookConfig.Proxy,
+			Timeout:      webhookConfig.Timeout,
+		}, c.TaskRepository(ctx))
+		if err != nil {
+			log.Fatalf("Ошибка настройки вебхука событий: %v", err)
+		}
+		publishers = append(publishers, publisher)
+	}
+
+	switch len(publishers) {
+	case 0:
+		return nil
+	case 1:
+		c.eventPublisher = publishers[0]
+	default:
+		c.eventPublisher = publishers
+	}
+	return c.eventPublisher
+}
+
+// OutboxRelay is nil unless an event publisher is configured. It enables
+// the outbox of the task repository, so it must be built before tasks are
+// stored; a repository without an outbox cannot publish events.
+func (c *DIContainer) OutboxRelay(ctx context.Context) *outbox.Relay {
+	if c.outboxRelay != nil {
+		return c.outboxRelay
+	}
+	publisher := c.EventPublisher(ctx)
+	if publisher == nil {
+		return nil
+	}
+
+	repository, ok := c.TaskRepository(ctx).(outboxRepository)
+	if !ok {
+		log.Fatalf("Хранилище задач не поддерживает публикацию событий")
+	}
+	repository.EnableOutbox()
+
+	c.outboxRelay = outbox.NewRelay(repository, publisher, outbox.DefaultInterval, c.Logger(ctx))
+	return c.outboxRelay
+}
+
+// pinger is a component whose connection can be checked.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Dispatcher is nil with the memory queue backend: tasks are then executed
+// by the instance that created them.
+func (c *DIContainer) Dispatcher(ctx context.Context) taskservice.Dispatcher {
+	if c.dispatcher != nil {
+		return c.dispatcher
+	}
+
+	queueConfig := c.Config(ctx).Queue
+	switch queueConfig.Backend {
+	case config.QueueBackendNATS:
+		queue, err := natsqueue.New(ctx, natsqueue.Config{
+			URL:      queueConfig.NATS.URL,
+			Stream:   queueConfig.NATS.Stream,
+			Subject:  queueConfig.NATS.Subject,
+			Consumer: queueConfig.NATS.Consumer,
+		}, c.Logger(ctx))
+		if err != nil {
+			log.Fatalf("Не удалось подключиться к очереди задач NATS: %v", err)
+		}
+		c.dispatcher = queue
+	case config.QueueBackendRabbitMQ:
+		queue, err := rabbitqueue.New(ctx, rabbitqueue.Config{
+			URL:   queueConfig.RabbitMQ.URL,
+			Queue: queueConfig.RabbitMQ.Queue,
+		}, c.Logger(ctx))
+		if err != nil {
+			log.Fatalf("Не удалось подключиться к очереди задач RabbitMQ: %v", err)
+		}
+		c.dispatcher = queue
+	default:
+		return nil
+	}
+
+	return c.dispatcher
+}
+
+// BlobStore keeps the inputs and artifacts of tasks in the storage selected
+// by BLOB_BACKEND, encrypted with BLOB_ENCRYPTION_KEY if it is set.
+func (c *DIContainer) BlobStore(ctx context.Context) taskservice.BlobStore {
+	if c.blobStore != nil {
+		return c.blobStore
+	}
+
+	blobConfig := c.Config(ctx).Blob
+	switch blobConfig.Backend {
+	case config.BlobBackendDisk:
+		store, err := blobstore.NewDisk(blobConfig.Dir)
+		if err != nil {
+			log.Fatalf("Не удалось открыть каталог вложений: %v", err)
+		}
+		c.blobStore = store
+	case config.BlobBackendS3:
+		store, err := blobstore.NewS3(blobstore.S3Config{
+			Endpoint:        blobConfig.S3.Endpoint,
+			Bucket:          blobConfig.S3.Bucket,
+			Region:          blobConfig.S3.Region,
+			AccessKeyID:     blobConfig.S3.AccessKeyID,
+			SecretAccessKey: blobConfig.S3.SecretAccessKey,
+		})
+		if err != nil {
+			log.Fatalf("Не удалось настроить хранилище вложений S3: %v", err)
+		}
+		c.blobStore = store
+	default:
+		c.blobStore = blobstore.NewMemory()
+	}
+
+	if blobConfig.EncryptionKey != "" {
+		key, err := blobConfig.EncryptionKeyBytes()
+		if err != nil {
+			log.Fatalf("Некорректный ключ шифрования вложений: %v", err)
+		}
+		store, err := blobstore.NewEncrypted(c.blobStore, key)
+		if err != nil {
+			log.Fatalf("Некорректный ключ шифрования вложений: %v", err)
+		}
+		c.blobStore = store
+	}
+
+	return c.blobStore
+}
+
+// Coordinator is nil unless REDIS_URL is set: instances then neither lock
+// the tasks they execute nor cancel each other's executors.
+func (c *DIContainer) Coordinator(ctx context.Context) taskservice.Coordinator {
+	if c.coordinator != nil {
+		return c.coordinator
+	}
+
+	redisConfig := c.Config(ctx).Redis
+	if !redisConfig.Enabled() {
+		return nil
+	}
+
+	coordinator, err := rediscoord.New(ctx, redisConfig.URL, redisConfig.KeyPrefix, c.Logger(ctx))
+	if err != nil {
+		log.Fatalf("Не удалось подключиться к Redis: %v", err)
+	}
+
+	c.coordinator = coordinator
+	return coordinator
+}
+
+func (c *DIContainer) ProjectRepository(ctx context.Context) ProjectRepository {
+	if c.projectRepository != nil {
+		return c.projectRepository
+	}
+
+	repository := projectrepository.NewInMemoryProjectRepository()
+	c.projectRepository = repository
+	return repository
+}
+
+func (c *DIContainer) TemplateRepository(ctx context.Context) TemplateRepository {
+	if c.templateRepository != nil {
+		return c.templateRepository
+	}
+
+	repository := templaterepository.NewInMemoryTemplateRepository()
+	c.templateRepository = repository
+	return repository
+}
+
 func (c *DIContainer) Server(ctx context.Context) *http.Server {
 	if c.server != nil {
 		return c.server
 	}
 
+	s := c.newServer(ctx, c.Config(ctx).Server.Addr, c.GinEngine(ctx))
+	c.server = s
+	return s
+}
+
+// AdminServer is nil unless ADMIN_ADDR is set.
+func (c *DIContainer) AdminServer(ctx context.Context) *http.Server {
+	if c.adminServer != nil || c.Config(ctx).Admin.Addr == "" {
+		return c.adminServer
+	}
+
+	s := c.newServer(ctx, c.Config(ctx).Admin.Addr, c.AdminGinEngine(ctx))
+	c.adminServer = s
+	return s
+}
+
+func (c *DIContainer) newServer(ctx context.Context, addr string, handler http.Handler) *http.Server {
+	serverConfig := c.Config(ctx).Server
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(serverConfig.HTTP2)
+	protocols.SetUnencryptedHTTP2(serverConfig.H2C)
+
 	s := &http.Server{
-		Addr:    ":8080",
-		Handler: c.GinEngine(ctx),
+		Addr:      addr,
+		Handler:   handler,
+		Protocols: protocols,
+	}
+	// Shutdown waits for active connections, which long-lived streams would
+	// hold until the timeout.
+	s.RegisterOnShutdown(c.CloseStreams)
+
+	if reloader := c.CertReloader(ctx); reloader != nil {
+		s.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+
+		if caFile := c.Config(ctx).TLS.ClientCAFile; caFile != "" {
+			s.TLSConfig.ClientCAs = loadCertPool(caFile)
+			s.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
 	}
 
-	c.server = s
 	return s
 }
 
+// Listeners returns the TCP and Unix socket listeners of the HTTP server,
+// inherited from the previous process after a graceful restart.
+func (c *DIContainer) Listeners(ctx context.Context) []*graceful.Listener {
+	if c.listeners != nil {
+		return c.listeners
+	}
+
+	serverConfig := c.Config(ctx).Server
+
+	var listeners []*graceful.Listener
+	if serverConfig.Addr != "" {
+		listener, err := graceful.Listen("tcp", serverConfig.Addr)
+		if err != nil {
+			log.Fatalf("Ошибка открытия порта %s: %v", serverConfig.Addr, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	if serverConfig.Socket != "" {
+		listener, err := graceful.Listen("unix", serverConfig.Socket)
+		if err != nil {
+			log.Fatalf("Ошибка открытия сокета %s: %v", serverConfig.Socket, err)
+		}
+		if err := os.Chmod(serverConfig.Socket, serverConfig.SocketMode); err != nil {
+			log.Fatalf("Ошибка установки прав на сокет %s: %v", serverConfig.Socket, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	c.listeners = listeners
+
+	return listeners
+}
+
+// AdminListener is nil unless ADMIN_ADDR is set.
+func (c *DIContainer) AdminListener(ctx context.Context) *graceful.Listener {
+	addr := c.Config(ctx).Admin.Addr
+	if c.adminListener != nil || addr == "" {
+		return c.adminListener
+	}
+
+	listener, err := graceful.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Ошибка открытия административного порта %s: %v", addr, err)
+	}
+	c.adminListener = listener
+
+	return listener
+}
+
+// CertReloader returns nil when TLS is not configured.
+func (c *DIContainer) CertReloader(ctx context.Context) *certs.Reloader {
+	if c.certReloader != nil {
+		return c.certReloader
+	}
+
+	tlsConfig := c.Config(ctx).TLS
+	if !tlsConfig.Enabled() {
+		return nil
+	}
+
+	reloader, err := certs.NewReloader(tlsConfig.CertFile, tlsConfig.KeyFile, c.Logger(ctx))
+	if err != nil {
+		log.Fatalf("Ошибка загрузки TLS сертификата: %v", err)
+	}
+	c.certReloader = reloader
+
+	return reloader
+}
+
 func (c *DIContainer) GinEngine(ctx context.Context) *gin.Engine {
 	if c.ginEngine != nil {
 		return c.ginEngine
 	}
 
-	engine := gin.Default()
+	separateAdmin := c.Config(ctx).Admin.Addr != ""
+
+	engine := c.newEngine(ctx)
+	if !separateAdmin {
+		c.registerMetrics(ctx, engine)
+	}
+	c.HealthController(ctx).RegisterRoutes(&engine.RouterGroup)
+	engine.GET("/openapi.json", c.OpenAPI(ctx).Handler(engine))
+	ui.RegisterRoutes(engine.Group("", middleware.Feature(c.FeatureFlags(ctx), "ui")))
 
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowAllOrigins = true
+	if origins := c.Config(ctx).CORS; origins.AllowAll() {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = origins.AllowedOrigins
+	}
+	corsConfig.AddAllowHeaders(requestid.Header)
+	corsConfig.AddExposeHeaders(requestid.Header, "Location")
 
 	engine.Use(cors.New(corsConfig))
 
@@ -88,12 +999,130 @@ func (c *DIContainer) GinEngine(ctx context.Context) *gin.Engine {
 	{
 		v1 := api.Group("/v1")
 		{
-			c.TaskController(ctx).RegisterRoutes(v1)
-			v1.GET("/health", controllers.HealthCheck)
-			v1.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+			c.RegisterTaskRoutes(ctx, v1)
+			v1.GET("/health", c.HealthController(ctx).HealthCheck)
+			v1.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json")))
+
+			if !separateAdmin {
+				c.registerAdmin(ctx, v1)
+			}
 		}
 	}
 
 	c.ginEngine = engine
 	return engine
 }
+
+// RegisterTaskRoutes registers the task, project and template routes on
+// router.
+func (c *DIContainer) RegisterTaskRoutes(ctx context.Context, router *gin.RouterGroup) {
+	// Admin routes stay writable in maintenance mode so it can be switched off.
+	public := router.Group("", middleware.Maintenance(c.Maintenance(ctx)))
+	c.TaskController(ctx).RegisterRoutes(public)
+	c.ProjectController(ctx).RegisterRoutes(public)
+	c.TemplateController(ctx).RegisterRoutes(public)
+}
+
+// AdminGinEngine serves the admin API, metrics and pprof on their own
+// listener; it is nil unless ADMIN_ADDR is set.
+func (c *DIContainer) AdminGinEngine(ctx context.Context) *gin.Engine {
+	if c.adminGinEngine != nil || c.Config(ctx).Admin.Addr == "" {
+		return c.adminGinEngine
+	}
+
+	engine := c.newEngine(ctx)
+	c.registerMetrics(ctx, engine)
+	c.HealthController(ctx).RegisterRoutes(&engine.RouterGroup)
+	engine.GET("/openapi.json", c.OpenAPI(ctx).Handler(engine))
+
+	c.registerAdmin(ctx, engine.Group("/api/v1"))
+
+	c.adminGinEngine = engine
+	return engine
+}
+
+// newEngine creates an engine with the middleware shared by the public and
+// admin listeners.
+func (c *DIContainer) newEngine(ctx context.Context) *gin.Engine {
+	engine := gin.New()
+	engine.Use(middleware.RequestID(), middleware.Language())
+
+	// The span is started before the access log so that request logs carry
+	// its IDs.
+	if provider := c.TracerProvider(ctx); provider != nil {
+		engine.Use(otelgin.Middleware(c.Config(ctx).Tracing.ServiceName, otelgin.WithTracerProvider(provider)))
+	}
+
+	engine.Use(middleware.AccessLog(c.Logger(ctx)))
+	if compression := c.Config(ctx).Compression; len(compression.Encodings) > 0 {
+		engine.Use(middleware.Compress(compression.Encodings, compression.MinSize))
+	}
+	engine.Use(
+		middleware.Recovery(c.PanicReporter(ctx)),
+		c.Metrics(ctx).Middleware(),
+		middleware.Timezone(),
+	)
+
+	if threshold := c.Config(ctx).Log.SlowRequestThreshold; threshold > 0 {
+		engine.Use(middleware.SlowRequests(threshold, c.Logger(ctx), c.Metrics(ctx)))
+	}
+
+	engine.Use(middleware.ClientDisconnect())
+
+	// Profiles and traces record for as long as the caller asks, log
+	// streams last until the task has finished.
+	routeTimeouts := map[string]time.Duration{
+		"GET /api/v1/admin/debug/pprof/profile":  0,
+		"GET /api/v1/admin/debug/pprof/trace":    0,
+		"GET /api/v1/admin/debug/pprof/:profile": 0,
+		"GET /api/v1/task/:id/logs/stream":       0,
+	}
+	maps.Copy(routeTimeouts, c.Config(ctx).Server.RouteTimeouts)
+	engine.Use(middleware.Timeout(c.Config(ctx).Server.RequestTimeout, routeTimeouts))
+
+	// The connections of every route carry a verified client certificate,
+	// so probes, metrics and the UI are identified like the API, before any
+	// route is registered.
+	if tlsConfig := c.Config(ctx).TLS; tlsConfig.MutualEnabled() {
+		engine.Use(middleware.ClientCertIdentity(tlsConfig.ClientIdentity, c.Config(ctx).Admin.Identities))
+	}
+
+	return engine
+}
+
+func (c *DIContainer) registerMetrics(ctx context.Context, engine *gin.Engine) {
+	if c.Config(ctx).Metrics.Enabled(config.MetricsExporterPrometheus) {
+		engine.GET("/metrics", gin.WrapH(c.Metrics(ctx).Handler()))
+	}
+}
+
+func (c *DIContainer) registerAdmin(ctx context.Context, v1 *gin.RouterGroup) {
+	admin := v1.Group("/admin", middleware.AdminAuth(c.Config(ctx).Admin.Token))
+	c.AdminController(ctx).RegisterRoutes(admin)
+	if controller := c.LoadgenController(ctx); controller != nil {
+		controller.RegisterRoutes(admin)
+	}
+	if c.Config(ctx).Admin.PprofEnabled {
+		controllers.RegisterPprof(admin.Group("/debug/pprof"))
+	}
+}
+
+func loadCertPool(path string) *x509.CertPool {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Ошибка чтения CA сертификата клиентов: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		log.Fatalf("Файл %s не содержит CA сертификатов", path)
+	}
+
+	return pool
+}
+
+// CloseStreams ends the open log streams with a shutdown event telling
+// their clients to reconnect. The servers call it when they shut down.
+func (c *DIContainer) CloseStreams() {
+	c.shutdownOnce.Do(func() { close(c.shutdown) })
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/clock"
+	"github.com/nzb3/workmate_test/internal/config"
+	"github.com/nzb3/workmate_test/internal/metrics"
+	"github.com/nzb3/workmate_test/internal/outbox"
+	"github.com/nzb3/workmate_test/internal/service/projectservice"
+	"github.com/nzb3/workmate_test/internal/service/taskservice"
+	"github.com/nzb3/workmate_test/internal/service/templateservice"
+)
+
+// TaskRepository is the task storage used by the container; the in-memory
+// repository is used unless another one is given with WithTaskRepository.
+type TaskRepository interface {
+	taskservice.Repository
+	metrics.TaskCounter
+	Ping(ctx context.Context) error
+	LastWrite() time.Time
+}
+
+// ProjectRepository is the project storage used by the container; the
+// in-memory repository is used unless another one is given with
+// WithProjectRepository.
+type ProjectRepository interface {
+	projectservice.Repository
+	Ping(ctx context.Context) error
+	LastWrite() time.Time
+}
+
+// TemplateRepository is the task template storage used by the container;
+// the in-memory repository is used unless another one is given with
+// WithTemplateRepository.
+type TemplateRepository interface {
+	templateservice.Repository
+	Ping(ctx context.Context) error
+	LastWrite() time.Time
+}
+
+// Option replaces a component the container would otherwise build itself.
+type Option func(*DIContainer)
+
+// WithConfig uses cfg instead of loading the configuration.
+func WithConfig(cfg *config.Config) Option {
+	return func(c *DIContainer) {
+		c.config = cfg
+	}
+}
+
+// WithConfigOverrides loads the configuration with overrides, keyed by
+// environment variable names, that take precedence over the environment
+// and the config file.
+func WithConfigOverrides(overrides map[string]string) Option {
+	return func(c *DIContainer) {
+		c.configOverrides = overrides
+	}
+}
+
+// WithTaskRepository stores tasks in repository.
+func WithTaskRepository(repository TaskRepository) Option {
+	return func(c *DIContainer) {
+		c.taskRepository = repository
+	}
+}
+
+// WithProjectRepository stores projects in repository.
+func WithProjectRepository(repository ProjectRepository) Option {
+	return func(c *DIContainer) {
+		c.projectRepository = repository
+	}
+}
+
+// WithTemplateRepository stores task templates in repository.
+func WithTemplateRepository(repository TemplateRepository) Option {
+	return func(c *DIContainer) {
+		c.templateRepository = repository
+	}
+}
+
+// WithEventPublisher publishes task events to publisher through the
+// outbox of the task repository.
+func WithEventPublisher(publisher outbox.Publisher) Option {
+	return func(c *DIContainer) {
+		c.eventPublisher = publisher
+	}
+}
+
+// WithDispatcher queues created tasks in dispatcher instead of the
+// backend selected by QUEUE_BACKEND.
+func WithDispatcher(dispatcher taskservice.Dispatcher) Option {
+	return func(c *DIContainer) {
+		c.dispatcher = dispatcher
+	}
+}
+
+// WithCoordinator coordinates instances through coordinator instead of
+// the Redis server given by REDIS_URL.
+func WithCoordinator(coordinator taskservice.Coordinator) Option {
+	return func(c *DIContainer) {
+		c.coordinator = coordinator
+	}
+}
+
+// WithClock measures task execution with clk, e.g. a clock.Accelerated that
+// lets tests see tasks finish.
+func WithClock(clk clock.Clock) Option {
+	return func(c *DIContainer) {
+		c.clock = clk
+	}
+}
@@ -0,0 +1,21 @@
+package auth
+
+import "context"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID    string
+	Admin bool
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller stored in ctx, if the request was authenticated.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,247 @@
+// Package bench measures the throughput and latency of the task API, either
+// of a running server or of the service started in-process.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/nzb3/workmate_test/internal/app"
+	"github.com/nzb3/workmate_test/internal/config"
+	"github.com/nzb3/workmate_test/internal/timing"
+	"github.com/nzb3/workmate_test/pkg/client"
+)
+
+// Operations, benchmarked in this order.
+const (
+	OpCreate = "create"
+	OpGet    = "get"
+	OpList   = "list"
+)
+
+// Options configure a benchmark run.
+type Options struct {
+	// Server is the base URL of the benchmarked server; the service is
+	// started in-process when it is empty.
+	Server string
+	Token  string
+	// Concurrency is the number of workers sending requests.
+	Concurrency int
+	// Duration is how long each operation is benchmarked.
+	Duration time.Duration
+	// Operations are the operations to benchmark; create always runs first
+	// so that get and list have tasks to read.
+	Operations []string
+}
+
+// Result summarizes the requests of one operation.
+type Result struct {
+	Operation  string        `json:"operation"`
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	Throughput float64       `json:"throughput"`
+	P50        time.Duration `json:"p50"`
+	P90        time.Duration `json:"p90"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+}
+
+// Execute runs the command line and exits the process on error.
+func Execute() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	var (
+		opts   Options
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark the create, get and list requests of the task API",
+		Long: "Benchmark the create, get and list requests of the task API.\n\n" +
+			"Each operation is sent by --concurrency workers for --duration; the throughput and\n" +
+			"latency percentiles are reported per operation. Without --server the service is\n" +
+			"started in-process with the default configuration and requests skip the network.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != outputTable && output != outputJSON {
+				return fmt.Errorf("unknown output format %q, expected table or json", output)
+			}
+			results, err := Run(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return printResults(cmd.OutOrStdout(), output, results)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Server, "server", "", "base URL of the server to benchmark, in-process when empty")
+	flags.StringVar(&opts.Token, "token", "", "admin token sent as a bearer token")
+	flags.IntVarP(&opts.Concurrency, "concurrency", "c", 10, "number of concurrent workers")
+	flags.DurationVarP(&opts.Duration, "duration", "d", 10*time.Second, "how long each operation is benchmarked")
+	flags.StringSliceVar(&opts.Operations, "ops", []string{OpCreate, OpGet, OpList}, "operations to benchmark: create, get, list")
+	flags.StringVarP(&output, "output", "o", outputTable, "output format: table or json")
+	return cmd
+}
+
+// Run benchmarks the selected operations one after another.
+func Run(ctx context.Context, opts Options) ([]Result, error) {
+	if opts.Concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+	if opts.Duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	for _, op := range opts.Operations {
+		if op != OpCreate && op != OpGet && op != OpList {
+			return nil, fmt.Errorf("unknown operation %q", op)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c, err := newClient(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// get and list read the tasks created by create, or a few seeded ones
+	// when create is not benchmarked.
+	var (
+		mu  sync.Mutex
+		ids []uuid.UUID
+	)
+	create := func(ctx context.Context) error {
+		task, err := c.Create(ctx, client.CreateRequest{Name: "Benchmark task", Type: "bench"})
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		ids = append(ids, task.ID)
+		mu.Unlock()
+		return nil
+	}
+	if !slices.Contains(opts.Operations, OpCreate) {
+		for range opts.Concurrency {
+			if err := create(ctx); err != nil {
+				return nil, fmt.Errorf("failed to create the tasks to read: %w", err)
+			}
+		}
+	}
+
+	var results []Result
+	for _, op := range []string{OpCreate, OpGet, OpList} {
+		if !slices.Contains(opts.Operations, op) {
+			continue
+		}
+		var request func(ctx context.Context, worker, n int) error
+		switch op {
+		case OpCreate:
+			request = func(ctx context.Context, _, _ int) error { return create(ctx) }
+		case OpGet:
+			request = func(ctx context.Context, worker, n int) error {
+				mu.Lock()
+				id := ids[(worker+n*opts.Concurrency)%len(ids)]
+				mu.Unlock()
+				_, err := c.Get(ctx, id)
+				return err
+			}
+		case OpList:
+			request = func(ctx context.Context, _, _ int) error {
+				_, err := c.List(ctx, client.ListOptions{})
+				return err
+			}
+		}
+		results = append(results, measure(ctx, op, opts, request))
+	}
+	return results, nil
+}
+
+// newClient connects to the server, or to the service started in-process
+// for as long as ctx lives.
+func newClient(ctx context.Context, opts Options) (*client.Client, error) {
+	clientOpts := []client.Option{client.WithRetries(0, 0)}
+	if opts.Token != "" {
+		clientOpts = append(clientOpts, client.WithHeader("Authorization", "Bearer "+opts.Token))
+	}
+	if opts.Server != "" {
+		return client.New(opts.Server, clientOpts...), nil
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	cfg := config.Defaults()
+	// Request logs would dominate the measured latency.
+	cfg.Log.Level = "error"
+	cfg.Log.SlowRequestThreshold = 0
+	engine := app.NewDIContainer(app.WithConfig(cfg)).GinEngine(ctx)
+	transport := &http.Client{Transport: handlerTransport{engine}}
+	return client.New("http://bench", append(clientOpts, client.WithHTTPClient(transport))...), nil
+}
+
+// handlerTransport serves requests with a handler instead of sending them.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, req)
+	return recorder.Result(), nil
+}
+
+// measure sends requests from opts.Concurrency workers for opts.Duration;
+// requests in flight at the end are awaited and counted.
+func measure(ctx context.Context, op string, opts Options, request func(ctx context.Context, worker, n int) error) Result {
+	deadline := time.Now().Add(opts.Duration)
+	latencies := make([][]time.Duration, opts.Concurrency)
+	errs := make([]int, opts.Concurrency)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for worker := range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ctx.Err() == nil && time.Now().Before(deadline); n++ {
+				began := time.Now()
+				err := request(ctx, worker, n)
+				latencies[worker] = append(latencies[worker], time.Since(began))
+				if err != nil {
+					errs[worker]++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	all := slices.Concat(latencies...)
+	slices.Sort(all)
+	result := Result{Operation: op, Requests: len(all)}
+	for _, n := range errs {
+		result.Errors += n
+	}
+	if len(all) > 0 {
+		result.Throughput = float64(len(all)) / elapsed.Seconds()
+		result.P50 = timing.Percentile(all, 50)
+		result.P90 = timing.Percentile(all, 90)
+		result.P99 = timing.Percentile(all, 99)
+		result.Max = all[len(all)-1]
+	}
+	return result
+}
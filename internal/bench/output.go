@@ -0,0 +1,35 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func printResults(w io.Writer, format string, results []Result) error {
+	if format == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tERRORS\tREQ/S\tP50\tP90\tP99\tMAX\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n", r.Operation, r.Requests, r.Errors, r.Throughput,
+			round(r.P50), round(r.P90), round(r.P99), round(r.Max))
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,67 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrNotFound is returned by Get for a key that holds no blob.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps the files attached to tasks. Keys are slash-separated paths.
+type Store interface {
+	// Put stores the size bytes read from r under key, replacing any blob
+	// stored there.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the blob stored under key; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key; a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Memory keeps blobs in the memory of the process.
+type Memory struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string][]byte)}
+}
+
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(io.LimitReader(r, size+1))
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("blob %s is %d bytes long, expected %d", key, len(data), size)
+	}
+
+	m.mu.Lock()
+	m.blobs[key] = data
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	data, ok := m.blobs[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.blobs, key)
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,93 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Disk keeps blobs as files under a directory of the local file system.
+type Disk struct {
+	dir string
+}
+
+// NewDisk stores blobs under dir, creating it if needed.
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &Disk{dir: dir}, nil
+}
+
+func (d *Disk) path(key string) (string, error) {
+	path := filepath.FromSlash(key)
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(d.dir, path), nil
+}
+
+// Put writes the blob to a temporary file first, so a failed upload never
+// leaves a truncated blob behind.
+func (d *Disk) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, io.LimitReader(r, size+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	if written != size {
+		return fmt.Errorf("blob %s is %d bytes long, expected %d", key, written, size)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	return nil
+}
+
+func (d *Disk) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+	return file, nil
+}
+
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob %s: %w", key, err)
+	}
+	return nil
+}
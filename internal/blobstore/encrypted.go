@@ -0,0 +1,132 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptedVersion leads every blob written by Encrypted, so that the
+// format can change without breaking the blobs already stored.
+const encryptedVersion byte = 1
+
+// dataKeySize is the size of the AES-256 key each blob is encrypted with.
+const dataKeySize = 32
+
+// Encrypted encrypts the blobs of another store at rest with envelope
+// encryption: every blob is sealed with AES-GCM under a fresh data key, and
+// the data key, itself sealed under the configured key, is stored in front
+// of the blob. Blobs are decrypted on Get, so callers see plaintext only.
+type Encrypted struct {
+	store Store
+	key   cipher.AEAD
+}
+
+// NewEncrypted wraps store, sealing data keys under key, which must be 16,
+// 24 or 32 bytes long.
+func NewEncrypted(store Store, key []byte) (*Encrypted, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blob encryption key: %w", err)
+	}
+	return &Encrypted{store: store, key: aead}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Put reads the whole blob to seal it; uploads are limited in size, so
+// blobs fit in memory.
+func (e *Encrypted) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(io.LimitReader(r, size+1))
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("blob %s is %d bytes long, expected %d", key, len(data), size)
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	rand.Read(dataKey)
+	blob, err := newGCM(dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt blob %s: %w", key, err)
+	}
+
+	// The blob key is bound to both seals, so a blob copied under another
+	// key fails to decrypt.
+	sealed := []byte{encryptedVersion}
+	sealed = seal(e.key, sealed, dataKey, []byte(key))
+	sealed = seal(blob, sealed, data, []byte(key))
+	return e.store.Put(ctx, key, bytes.NewReader(sealed), int64(len(sealed)))
+}
+
+// seal appends a random nonce and plaintext sealed under aead to dst.
+func seal(aead cipher.AEAD, dst, plaintext, additional []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plaintext, additional)
+}
+
+func (e *Encrypted) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := e.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", key, err)
+	}
+	data, err := e.open(key, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt blob %s: %w", key, err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (e *Encrypted) open(key string, sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 || sealed[0] != encryptedVersion {
+		return nil, errors.New("unknown blob format")
+	}
+	sealed = sealed[1:]
+
+	wrappedSize := e.key.NonceSize() + dataKeySize + e.key.Overhead()
+	if len(sealed) < wrappedSize {
+		return nil, errors.New("blob is truncated")
+	}
+	dataKey, err := open(e.key, sealed[:wrappedSize], []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	blob, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(blob, sealed[wrappedSize:], []byte(key))
+}
+
+// open opens a nonce followed by ciphertext sealed under aead.
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("blob is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+func (e *Encrypted) Delete(ctx context.Context, key string) error {
+	return e.store.Delete(ctx, key)
+}
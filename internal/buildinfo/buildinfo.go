@@ -0,0 +1,62 @@
+// Package buildinfo holds the version of the running binary. The values are
+// set at build time:
+//
+//	go build -ldflags "-X github.com/nzb3/workmate_test/internal/buildinfo.Version=v1.2.0 \
+//		-X github.com/nzb3/workmate_test/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X github.com/nzb3/workmate_test/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build info. When the binary was built without ldflags,
+// commit and build time fall back to the VCS stamp embedded by the Go
+// toolchain, if any.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+
+	return info
+}
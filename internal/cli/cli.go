@@ -0,0 +1,129 @@
+// Package cli is the command line of the workmate binary.
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nzb3/workmate_test/internal/app"
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+	"github.com/nzb3/workmate_test/internal/config"
+)
+
+// settingFlags map command line flags to the settings they override; every
+// setting is also reachable through --set.
+var settingFlags = []struct {
+	name  string
+	key   string
+	usage string
+}{
+	{"config", "CONFIG_FILE", "path to the YAML config file"},
+	{"http-addr", "HTTP_ADDR", "TCP address of the HTTP server"},
+	{"http-socket", "HTTP_SOCKET", "Unix socket path of the HTTP server"},
+	{"admin-addr", "ADMIN_ADDR", "separate address of the admin API, metrics and pprof"},
+	{"log-level", "LOG_LEVEL", "log level: debug, info, warn, error"},
+	{"log-format", "LOG_FORMAT", "log format: text or json"},
+	{"task-workers", "TASK_WORKERS", "maximum number of concurrently executing tasks"},
+	{"task-timeout", "TASK_TIMEOUT", "time after which an unfinished task is cancelled"},
+	{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "time in-flight requests and tasks get to finish on shutdown"},
+	{"dev-mode", "DEV_MODE", "dev mode: seed sample tasks and serve the load generator"},
+	{"dev-seed-tasks", "DEV_SEED_TASKS", "sample tasks created at startup in dev mode"},
+}
+
+// Execute runs the command line and exits the process on error.
+func Execute() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// overrides collects the settings given on the command line, keyed by
+// their environment variable names: the setting flags that were set, then
+// the --set pairs, which win over them.
+func overrides(flags *pflag.FlagSet, sets []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, flag := range settingFlags {
+		if f := flags.Lookup(flag.name); f != nil && f.Changed {
+			values[flag.key] = f.Value.String()
+		}
+	}
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--set %q must have the form KEY=VALUE", set)
+		}
+		values[strings.ToUpper(key)] = value
+	}
+	return values, nil
+}
+
+func newRootCommand() *cobra.Command {
+	var sets []string
+
+	serve := func(cmd *cobra.Command, _ []string) error {
+		values, err := overrides(cmd.Flags(), sets)
+		if err != nil {
+			return err
+		}
+		app.Start(values)
+		return nil
+	}
+
+	root := &cobra.Command{
+		Use:          "workmate",
+		Short:        "Task management API with asynchronous processing",
+		Long:         "Task management API with asynchronous processing.\n\nSettings are read from flags, then environment variables, then the config file.",
+		Args:         cobra.NoArgs,
+		RunE:         serve,
+		SilenceUsage: true,
+	}
+
+	flags := root.PersistentFlags()
+	for _, flag := range settingFlags {
+		flags.String(flag.name, "", fmt.Sprintf("%s (%s)", flag.usage, flag.key))
+	}
+	flags.StringArrayVar(&sets, "set", nil, "override any setting by its environment variable name, e.g. --set RETENTION_RULES=DONE=7d")
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Run the HTTP server (default command)",
+			Args:  cobra.NoArgs,
+			RunE:  serve,
+		},
+		&cobra.Command{
+			Use:   "migrate",
+			Short: "Prepare the task storage schema",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				values, err := overrides(cmd.Flags(), sets)
+				if err != nil {
+					return err
+				}
+				if _, err := config.LoadWithOverrides(values); err != nil {
+					return err
+				}
+				// The in-memory storage has no schema; the command exists so
+				// deploy pipelines need no change once a persistent one does.
+				fmt.Fprintln(cmd.OutOrStdout(), "in-memory storage: nothing to migrate")
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "version",
+			Short: "Print the version, commit and build time",
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, _ []string) {
+				build := buildinfo.Get()
+				fmt.Fprintf(cmd.OutOrStdout(), "workmate %s\ncommit: %s\nbuilt: %s\ngo: %s\n",
+					build.Version, build.Commit, build.BuildTime, build.GoVersion)
+			},
+		},
+	)
+
+	return root
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+)
+
+func TestOverrides(t *testing.T) {
+	flags := newRootCommand().PersistentFlags()
+	require.NoError(t, flags.Parse([]string{"--http-addr", ":9000", "--task-workers=7"}))
+
+	values, err := overrides(flags, []string{"task_workers=9", "RETENTION_RULES=DONE=7d", "LOG_LEVEL="})
+	require.NoError(t, err)
+	// --set wins over the flags, keys are upper-cased and values may hold
+	// '=' or be empty.
+	assert.Equal(t, map[string]string{
+		"HTTP_ADDR":       ":9000",
+		"TASK_WORKERS":    "9",
+		"RETENTION_RULES": "DONE=7d",
+		"LOG_LEVEL":       "",
+	}, values)
+
+	for _, set := range []string{"TASK_WORKERS", "=7"} {
+		_, err := overrides(flags, []string{set})
+		assert.ErrorContains(t, err, "must have the form KEY=VALUE", set)
+	}
+}
+
+func TestSettingFlags(t *testing.T) {
+	keys := make(map[string]bool)
+	for _, flag := range settingFlags {
+		assert.False(t, keys[flag.key], "%s is mapped twice", flag.key)
+		keys[flag.key] = true
+
+		flags := newRootCommand().PersistentFlags()
+		require.NoError(t, flags.Parse([]string{"--" + flag.name + "=value"}))
+		values, err := overrides(flags, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{flag.key: "value"}, values, flag.name)
+		assert.Contains(t, flags.Lookup(flag.name).Usage, flag.key)
+	}
+}
+
+func execute(args ...string) (string, error) {
+	root := newRootCommand()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestMigrate(t *testing.T) {
+	out, err := execute("migrate")
+	require.NoError(t, err)
+	assert.Equal(t, "in-memory storage: nothing to migrate\n", out)
+
+	// The settings are checked before anything is migrated.
+	_, err = execute("migrate", "--task-workers", "many")
+	assert.ErrorContains(t, err, "invalid TASK_WORKERS")
+	_, err = execute("migrate", "--set", "SHUTDOWN_TIMEOUT=soon")
+	assert.ErrorContains(t, err, "invalid SHUTDOWN_TIMEOUT")
+	_, err = execute("migrate", "--set", "SHUTDOWN_TIMEOUT")
+	assert.ErrorContains(t, err, "must have the form KEY=VALUE")
+}
+
+func TestVersion(t *testing.T) {
+	out, err := execute("version")
+	require.NoError(t, err)
+
+	build := buildinfo.Get()
+	assert.Equal(t, fmt.Sprintf("workmate %s\ncommit: %s\nbuilt: %s\ngo: %s\n",
+		build.Version, build.Commit, build.BuildTime, build.GoVersion), out)
+}
@@ -0,0 +1,59 @@
+// Package clock abstracts the passing of time for task execution, so that
+// tests can run tasks that take minutes in milliseconds.
+package clock
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTicker(d time.Duration) *time.Ticker
+	// AfterFunc calls f in its own goroutine once d has passed.
+	AfterFunc(d time.Duration, f func()) *time.Timer
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (Real) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) *time.Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Accelerated runs factor times faster than the wall clock from the moment
+// it is created.
+type Accelerated struct {
+	start  time.Time
+	factor float64
+}
+
+func NewAccelerated(factor float64) *Accelerated {
+	return &Accelerated{start: time.Now(), factor: factor}
+}
+
+func (c *Accelerated) Now() time.Time {
+	return c.start.Add(time.Duration(float64(time.Since(c.start)) * c.factor))
+}
+
+func (c *Accelerated) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *Accelerated) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(max(time.Duration(float64(d)/c.factor), time.Microsecond))
+}
+
+func (c *Accelerated) AfterFunc(d time.Duration, f func()) *time.Timer {
+	return time.AfterFunc(time.Duration(float64(d)/c.factor), f)
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+http_addr: ":9000"
+shutdown_timeout: 10s
+log:
+  level: debug
+task:
+  workers: 5
+  timeout: 1m
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("SHUTDOWN_TIMEOUT", "20s")
+	t.Setenv("TASK_WORKERS", "7")
+
+	cfg, err := LoadWithOverrides(map[string]string{"SHUTDOWN_TIMEOUT": "40s"})
+	require.NoError(t, err)
+
+	assert.Equal(t, path, cfg.File)
+	// Flags win over the environment, which wins over the file.
+	assert.Equal(t, 40*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, 7, cfg.Tasks.Workers)
+	assert.Equal(t, ":9000", cfg.Server.Addr)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, time.Minute, cfg.Tasks.Timeout)
+	// Settings set nowhere keep their defaults.
+	assert.Equal(t, Defaults().Server.RestartTimeout, cfg.Server.RestartTimeout)
+
+	// A config file given as a flag replaces the one in the environment.
+	other := writeConfigFile(t, `http_addr: ":9100"`)
+	cfg, err = LoadWithOverrides(map[string]string{"CONFIG_FILE": other})
+	require.NoError(t, err)
+	assert.Equal(t, ":9100", cfg.Server.Addr)
+	assert.Equal(t, 20*time.Second, cfg.Server.ShutdownTimeout)
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		file      string
+		err       string
+	}{
+		{name: "duration", overrides: map[string]string{"SHUTDOWN_TIMEOUT": "soon"}, err: "invalid SHUTDOWN_TIMEOUT"},
+		{name: "number", overrides: map[string]string{"TASK_WORKERS": "many"}, err: "invalid TASK_WORKERS"},
+		{name: "bool", overrides: map[string]string{"HTTP2_ENABLED": "maybe"}, err: "invalid HTTP2_ENABLED"},
+		{name: "socket mode", overrides: map[string]string{"HTTP_SOCKET_MODE": "999"}, err: "invalid HTTP_SOCKET_MODE"},
+		{name: "out of range", overrides: map[string]string{"SHUTDOWN_TIMEOUT": "0s"}, err: "shutdown timeout must be positive"},
+		{name: "no address", overrides: map[string]string{"HTTP_ADDR": ""}, err: "HTTP address or Unix socket must be set"},
+		{name: "unknown flag", overrides: map[string]string{"TASK_WORKRES": "5"}, err: "unknown settings: TASK_WORKRES"},
+		{name: "unknown file key", file: "task:\n  workres: 5\n", err: "unknown settings: TASK_WORKRES"},
+		{name: "file value", file: "task:\n  workers: many\n", err: "invalid TASK_WORKERS"},
+		{name: "file syntax", file: "task: [", err: "failed to parse config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides := tt.overrides
+			if tt.file != "" {
+				overrides = map[string]string{"CONFIG_FILE": writeConfigFile(t, tt.file)}
+			}
+			_, err := LoadWithOverrides(overrides)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	_, err := LoadWithOverrides(map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.ErrorContains(t, err, "failed to read config file")
+}
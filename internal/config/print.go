@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// LogValue renders the effective configuration, defaults included, for the
+// startup log. Secrets are only reported as set or unset.
+func (c *Config) LogValue() slog.Value {
+	rules := make([]string, 0, len(c.Retention.Rules))
+	for _, rule := range c.Retention.Rules {
+		rules = append(rules, fmt.Sprintf("%s=%s", rule.Status, rule.MaxAge))
+	}
+
+	routeTimeouts := make([]string, 0, len(c.Server.RouteTimeouts))
+	for route, timeout := range c.Server.RouteTimeouts {
+		routeTimeouts = append(routeTimeouts, fmt.Sprintf("%s=%s", route, timeout))
+	}
+	sort.Strings(routeTimeouts)
+
+	slowThresholds := make([]string, 0, len(c.Tasks.SlowThresholds))
+	for taskType, threshold := range c.Tasks.SlowThresholds {
+		slowThresholds = append(slowThresholds, fmt.Sprintf("%s=%s", taskType, threshold))
+	}
+	sort.Strings(slowThresholds)
+
+	pools := make([]string, 0, len(c.Tasks.Pools))
+	for name, workers := range c.Tasks.Pools {
+		pools = append(pools, fmt.Sprintf("%s=%d", name, workers))
+	}
+	sort.Strings(pools)
+
+	flags := make([]string, 0, len(c.Features.Flags))
+	for name, enabled := range c.Features.Flags {
+		flags = append(flags, fmt.Sprintf("%s=%t", name, enabled))
+	}
+	sort.Strings(flags)
+
+	// Header values may be credentials, so only the names are logged.
+	webhookHeaders := make([]string, 0, len(c.Events.Webhook.Headers))
+	for name := range c.Events.Webhook.Headers {
+		webhookHeaders = append(webhookHeaders, name)
+	}
+	sort.Strings(webhookHeaders)
+
+	return slog.GroupValue(
+		slog.String("file", c.File),
+		slog.Group("server",
+			slog.String("addr", c.Server.Addr),
+			slog.String("socket", c.Server.Socket),
+			slog.String("socket_mode", fmt.Sprintf("%04o", c.Server.SocketMode)),
+			slog.Duration("shutdown_timeout", c.Server.ShutdownTimeout),
+			slog.Duration("restart_timeout", c.Server.RestartTimeout),
+			slog.Bool("http2", c.Server.HTTP2),
+			slog.Bool("h2c", c.Server.H2C),
+			slog.Duration("request_timeout", c.Server.RequestTimeout),
+			slog.String("route_timeouts", strings.Join(routeTimeouts, ",")),
+		),
+		slog.Group("cors",
+			slog.String("allowed_origins", strings.Join(c.CORS.AllowedOrigins, ",")),
+		),
+		slog.Group("compression",
+			slog.String("encodings", strings.Join(c.Compression.Encodings, ",")),
+			slog.Int("min_size", c.Compression.MinSize),
+		),
+		slog.Group("shedding",
+			slog.Int("queue_depth", c.Shedding.QueueDepth),
+			slog.Duration("queue_wait", c.Shedding.QueueWait),
+			slog.Duration("retry_after", c.Shedding.RetryAfter),
+		),
+		slog.Group("log",
+			slog.String("format", c.Log.Format),
+			slog.String("level", c.Log.Level),
+			slog.Duration("slow_request_threshold", c.Log.SlowRequestThreshold),
+		),
+		slog.Group("admin",
+			slog.String("addr", c.Admin.Addr),
+			slog.String("token", secret(c.Admin.Token)),
+			slog.String("identities", strings.Join(c.Admin.Identities, ",")),
+			slog.Bool("pprof", c.Admin.PprofEnabled),
+			slog.Bool("maintenance", c.Admin.Maintenance),
+		),
+		slog.Group("retention",
+			slog.String("rules", strings.Join(rules, ",")),
+			slog.Duration("interval", c.Retention.Interval),
+		),
+		slog.Group("tls",
+			slog.Bool("enabled", c.TLS.Enabled()),
+			slog.Bool("mutual", c.TLS.MutualEnabled()),
+			slog.Duration("reload_interval", c.TLS.ReloadInterval),
+		),
+		slog.Group("tracing",
+			slog.Bool("enabled", c.Tracing.Enabled),
+			slog.Bool("logs", c.Tracing.Logs),
+			slog.String("service_name", c.Tracing.ServiceName),
+		),
+		slog.Group("metrics",
+			slog.String("exporters", strings.Join(c.Metrics.Exporters, ",")),
+			slog.String("statsd_addr", c.Metrics.StatsDAddr),
+			slog.Duration("push_interval", c.Metrics.PushInterval),
+		),
+		slog.Group("tasks",
+			slog.Int("workers", c.Tasks.Workers),
+			slog.String("pools", strings.Join(pools, ",")),
+			slog.Duration("timeout", c.Tasks.Timeout),
+			slog.Duration("max_runtime", c.Tasks.MaxRuntime),
+			slog.Duration("stuck_threshold", c.Tasks.StuckThreshold),
+			slog.Int("max_attempts", c.Tasks.MaxAttempts),
+			slog.Duration("retry_backoff", c.Tasks.RetryBackoff),
+			slog.Int("estimate_window", c.Tasks.EstimateWindow),
+			slog.String("estimate_file", c.Tasks.EstimateFile),
+			slog.Int("log_lines", c.Tasks.LogLines),
+			slog.Duration("dedup_window", c.Tasks.DedupWindow),
+			slog.Bool("unique_names", c.Tasks.UniqueNames),
+			slog.Int("name_min_length", c.Tasks.NameMinLength),
+			slog.Int("name_max_length", c.Tasks.NameMaxLength),
+			slog.String("name_pattern", c.Tasks.NamePattern),
+			slog.String("name_charset", c.Tasks.NameCharset),
+			slog.String("name_reserved_prefixes", strings.Join(c.Tasks.NameReservedPrefixes, ",")),
+			slog.Duration("slow_threshold", c.Tasks.SlowThreshold),
+			slog.String("slow_thresholds", strings.Join(slowThresholds, ",")),
+			slog.Bool("preemption", c.Tasks.Preemption),
+		),
+		slog.Group("queue",
+			slog.String("backend", c.Queue.Backend),
+			slog.String("nats_url", c.Queue.NATS.URL),
+			slog.String("nats_stream", c.Queue.NATS.Stream),
+			slog.String("rabbitmq_url", secret(c.Queue.RabbitMQ.URL)),
+			slog.String("rabbitmq_queue", c.Queue.RabbitMQ.Queue),
+		),
+		slog.Group("blob",
+			slog.String("backend", c.Blob.Backend),
+			slog.String("dir", c.Blob.Dir),
+			slog.Int("max_upload_size", c.Blob.MaxUploadSize),
+			slog.String("encryption_key", secret(c.Blob.EncryptionKey)),
+			slog.String("s3_endpoint", c.Blob.S3.Endpoint),
+			slog.String("s3_bucket", c.Blob.S3.Bucket),
+			slog.String("s3_region", c.Blob.S3.Region),
+			slog.String("s3_access_key_id", c.Blob.S3.AccessKeyID),
+			slog.String("s3_secret_access_key", secret(c.Blob.S3.SecretAccessKey)),
+		),
+		slog.Group("redis",
+			slog.String("url", secret(c.Redis.URL)),
+			slog.String("key_prefix", c.Redis.KeyPrefix),
+		),
+		slog.Group("federation",
+			slog.String("name", c.Federation.Name),
+			slog.Int("peers", len(c.Federation.Peers)),
+			slog.Duration("timeout", c.Federation.Timeout),
+		),
+		slog.Group("events",
+			slog.String("kafka_brokers", strings.Join(c.Events.Kafka.Brokers, ",")),
+			slog.String("kafka_topic", c.Events.Kafka.Topic),
+			slog.String("webhook_url", secret(c.Events.Webhook.URL)),
+			slog.Bool("webhook_template", c.Events.Webhook.Template != ""),
+			slog.String("webhook_headers", strings.Join(webhookHeaders, ",")),
+			slog.String("webhook_allowed_hosts", strings.Join(c.Events.Webhook.AllowedHosts, ",")),
+			slog.String("webhook_proxy", secret(c.Events.Webhook.Proxy)),
+			slog.Duration("webhook_timeout", c.Events.Webhook.Timeout),
+		),
+		slog.String("feature_flags", strings.Join(flags, ",")),
+		slog.Group("dev",
+			slog.Bool("enabled", c.Dev.Enabled),
+			slog.Int("seed_tasks", c.Dev.SeedTasks),
+		),
+		slog.Group("panic",
+			slog.String("sentry_dsn", secret(c.Panic.SentryDSN)),
+			slog.String("webhook_url", secret(c.Panic.WebhookURL)),
+		),
+	)
+}
+
+func secret(value string) string {
+	if value == "" {
+		return "unset"
+	}
+	return "set"
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source looks settings up in the overrides given on the command line
+// first, then in the environment and finally in the optional config file.
+//
+// The file is YAML whose keys are the environment variable names; nested
+// mappings join their keys with an underscore and lists are joined with
+// commas, so
+//
+//	task:
+//	  workers: 50
+//	cors_allowed_origins: [https://a.example, https://b.example]
+//
+// sets TASK_WORKERS=50 and CORS_ALLOWED_ORIGINS=https://a.example,https://b.example.
+type source struct {
+	overrides map[string]string
+	file      map[string]string
+	used      map[string]bool
+}
+
+func newSource(path string, overrides map[string]string) (*source, error) {
+	s := &source{
+		overrides: overrides,
+		file:      map[string]string{},
+		used:      map[string]bool{},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := flatten("", root, s.file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+func flatten(prefix string, values map[string]any, out map[string]string) error {
+	for key, value := range values {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(name, v, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				switch item.(type) {
+				case map[string]any, []any:
+					return fmt.Errorf("%s: list items must be scalars", name)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			out[name] = strings.Join(items, ",")
+		case nil:
+			out[name] = ""
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func (s *source) lookup(key string) (string, bool) {
+	s.used[key] = true
+	if value, ok := s.overrides[key]; ok {
+		return value, true
+	}
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	value, ok := s.file[key]
+	return value, ok
+}
+
+func (s *source) get(key string) string {
+	value, _ := s.lookup(key)
+	return value
+}
+
+// unknown returns the file settings and overrides that no lookup asked for,
+// which are most likely typos.
+func (s *source) unknown() []string {
+	var keys []string
+	for _, settings := range []map[string]string{s.overrides, s.file} {
+		for key := range settings {
+			if !s.used[key] && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
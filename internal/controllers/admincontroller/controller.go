@@ -0,0 +1,569 @@
+package admincontroller
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/logger"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/service/retentionservice"
+	"github.com/nzb3/workmate_test/internal/service/taskservice"
+)
+
+type TaskService interface {
+	PurgeTasks(ctx context.Context, filter taskmodel.Filter) (int, error)
+	Drain()
+	Undrain()
+	Draining() bool
+	RunningTasks() int
+	QueuedTasks() int
+	WorkerCapacity() int
+	WorkerPools() []taskservice.WorkerPoolStats
+	Queue(ctx context.Context) ([]taskservice.QueuedTask, error)
+	StuckTasks(ctx context.Context, threshold time.Duration) ([]taskservice.StuckTask, error)
+	RebuildTasks(ctx context.Context) (int, error)
+}
+
+// Readiness is flipped together with draining so load balancers stop
+// routing traffic to a drained instance.
+type Readiness interface {
+	MarkReady()
+	MarkDraining()
+}
+
+type RetentionService interface {
+	Rules() []retentionservice.Rule
+	DryRun(ctx context.Context) ([]retentionservice.Candidate, error)
+}
+
+// PurgeRequest represents a request to permanently erase tasks.
+// Filter selecting the tasks to erase. At least one criterion or "all" is required.
+type PurgeRequest struct {
+	Owner       string                 `json:"owner"`
+	CreatedFrom *time.Time             `json:"created_from"`
+	CreatedTo   *time.Time             `json:"created_to"`
+	Statuses    []taskmodel.TaskStatus `json:"statuses"`
+	All         bool                   `json:"all"`
+}
+
+// PurgeResponse represents the result of a purge.
+type PurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// RebuildResponse represents the result of replaying the task events.
+type RebuildResponse struct {
+	Repaired int `json:"repaired"`
+}
+
+// RetentionRuleResponse represents a configured retention rule.
+// Retention rule: tasks with the status are kept for max_age seconds.
+type RetentionRuleResponse struct {
+	Status taskmodel.TaskStatus `json:"status"`
+	MaxAge int64                `json:"max_age"`
+}
+
+// RetentionCandidateResponse represents a task that retention would delete.
+type RetentionCandidateResponse struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	Status    taskmodel.TaskStatus `json:"status"`
+	CreatedAt time.Time            `json:"created_at"`
+	ExpiredAt time.Time            `json:"expired_at"`
+}
+
+// RetentionDryRunResponse represents the result of a retention dry run.
+// Configured rules and the tasks they would delete right now.
+type RetentionDryRunResponse struct {
+	Rules []RetentionRuleResponse      `json:"rules"`
+	Tasks []RetentionCandidateResponse `json:"tasks"`
+}
+
+// MaintenanceRequest switches maintenance mode.
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+	// Until is when the maintenance is expected to end; rejected clients
+	// are told to retry then.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// MaintenanceResponse represents the maintenance mode state.
+type MaintenanceResponse struct {
+	Enabled bool       `json:"enabled"`
+	Until   *time.Time `json:"until,omitempty"`
+}
+
+// LogLevelRequest changes the log level.
+// New minimum log level: debug, info, warn or error.
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevelResponse represents the current log level.
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// FeatureFlagRequest toggles a feature flag.
+type FeatureFlagRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// FeatureFlagsResponse represents the feature flags.
+type FeatureFlagsResponse struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// QueuedTaskResponse represents a task waiting for a worker.
+// Queued task with its dispatch position within its worker pool, time waited so far and estimated start and finish.
+type QueuedTaskResponse struct {
+	Position        int       `json:"position"`
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Type            string    `json:"type"`
+	Pool            string    `json:"pool"`
+	Owner           string    `json:"owner,omitempty"`
+	Priority        string    `json:"priority" enums:"low,normal,high,critical"`
+	CreatedAt       time.Time `json:"created_at"`
+	WaitSeconds     float64   `json:"wait_seconds"`
+	EstimatedStart  time.Time `json:"estimated_start"`
+	EstimatedFinish time.Time `json:"estimated_finish"`
+}
+
+// QueueResponse represents the state of the task queue.
+// Worker pool state and the queued tasks in dispatch order; worker_capacity and running_tasks count all pools.
+type QueueResponse struct {
+	WorkerCapacity int                  `json:"worker_capacity"`
+	RunningTasks   int                  `json:"running_tasks"`
+	Pools          []WorkerPoolResponse `json:"pools"`
+	Tasks          []QueuedTaskResponse `json:"tasks"`
+}
+
+// WorkerPoolResponse represents a named pool of workers.
+// Named pool of workers with its number of workers and the tasks holding and waiting for one.
+type WorkerPoolResponse struct {
+	Name         string `json:"name"`
+	Capacity     int    `json:"capacity"`
+	RunningTasks int    `json:"running_tasks"`
+	QueuedTasks  int    `json:"queued_tasks"`
+}
+
+// StuckTaskResponse represents a running task that may need to be cancelled.
+// Running task exceeding the threshold or without recent executor progress.
+type StuckTaskResponse struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Type                string    `json:"type"`
+	Owner               string    `json:"owner,omitempty"`
+	RequestID           string    `json:"request_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	StartedAt           time.Time `json:"started_at"`
+	RunningSeconds      float64   `json:"running_seconds"`
+	ExpectedSeconds     float64   `json:"expected_seconds"`
+	LastHeartbeat       time.Time `json:"last_heartbeat"`
+	HeartbeatAgeSeconds float64   `json:"heartbeat_age_seconds"`
+	Reasons             []string  `json:"reasons"`
+}
+
+// StuckTasksResponse represents the stuck task report.
+// Threshold used and the tasks exceeding it, longest running first.
+type StuckTasksResponse struct {
+	ThresholdSeconds float64             `json:"threshold_seconds"`
+	Tasks            []StuckTaskResponse `json:"tasks"`
+}
+
+// DrainResponse represents the drain state of the instance.
+// Whether new tasks are rejected and how many accepted tasks are still pending.
+type DrainResponse struct {
+	Draining     bool `json:"draining"`
+	RunningTasks int  `json:"running_tasks"`
+	QueuedTasks  int  `json:"queued_tasks"`
+}
+
+// ErrorResponse represents an error response.
+type ErrorResponse struct {
+	Error   apierror.Code `json:"error"`
+	Message string        `json:"message,omitempty"`
+	// Fields lists the invalid fields of a request body.
+	Fields []controllers.FieldError `json:"fields,omitempty"`
+}
+
+type Maintenance interface {
+	Enabled() bool
+	SetEnabled(enabled bool, until time.Time)
+	Until() time.Time
+}
+
+// LogLevel is the runtime-adjustable minimum level of the service logger.
+type LogLevel interface {
+	Level() slog.Level
+	Set(level slog.Level)
+}
+
+// FeatureFlags are the runtime-toggleable feature flags.
+type FeatureFlags interface {
+	All() map[string]bool
+	Set(name string, enabled bool) error
+}
+
+type Controller struct {
+	taskService      TaskService
+	retentionService RetentionService
+	readiness        Readiness
+	maintenance      Maintenance
+	logLevel         LogLevel
+	featureFlags     FeatureFlags
+	stuckThreshold   time.Duration
+}
+
+func NewController(
+	taskService TaskService,
+	retentionService RetentionService,
+	readiness Readiness,
+	maintenance Maintenance,
+	logLevel LogLevel,
+	featureFlags FeatureFlags,
+	stuckThreshold time.Duration,
+) *Controller {
+	return &Controller{
+		taskService:      taskService,
+		retentionService: retentionService,
+		readiness:        readiness,
+		maintenance:      maintenance,
+		logLevel:         logLevel,
+		featureFlags:     featureFlags,
+		stuckThreshold:   stuckThreshold,
+	}
+}
+
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/purge", c.Purge)
+	router.GET("/retention/dry-run", c.RetentionDryRun)
+	router.GET("/queue", c.GetQueue)
+	router.GET("/tasks/stuck", c.GetStuckTasks)
+	router.POST("/tasks/rebuild", c.RebuildTasks)
+	router.POST("/drain", c.Drain)
+	router.POST("/undrain", c.Undrain)
+	router.GET("/maintenance", c.GetMaintenance)
+	router.PUT("/maintenance", c.SetMaintenance)
+	router.GET("/loglevel", c.GetLogLevel)
+	router.PUT("/loglevel", c.SetLogLevel)
+	router.GET("/features", c.GetFeatureFlags)
+	router.PUT("/features/:name", c.SetFeatureFlag)
+}
+
+// Purge serves POST /admin/purge.
+func (c *Controller) Purge(ctx *gin.Context) {
+	var req PurgeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	filter := taskmodel.Filter{Owner: req.Owner, Statuses: req.Statuses}
+	if req.CreatedFrom != nil {
+		filter.CreatedFrom = *req.CreatedFrom
+	}
+	if req.CreatedTo != nil {
+		filter.CreatedTo = *req.CreatedTo
+	}
+
+	if filter.IsEmpty() && !req.All {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: "At least one filter criterion is required, use \"all\": true to purge every task",
+		})
+		return
+	}
+
+	purged, err := c.taskService.PurgeTasks(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to purge tasks",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, PurgeResponse{Purged: purged})
+}
+
+// RebuildTasks serves POST /admin/tasks/rebuild.
+func (c *Controller) RebuildTasks(ctx *gin.Context) {
+	repaired, err := c.taskService.RebuildTasks(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to rebuild tasks",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, RebuildResponse{Repaired: repaired})
+}
+
+// RetentionDryRun serves GET /admin/retention/dry-run.
+func (c *Controller) RetentionDryRun(ctx *gin.Context) {
+	candidates, err := c.retentionService.DryRun(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to evaluate retention rules",
+		})
+		return
+	}
+
+	rules := c.retentionService.Rules()
+	response := RetentionDryRunResponse{
+		Rules: make([]RetentionRuleResponse, len(rules)),
+		Tasks: make([]RetentionCandidateResponse, len(candidates)),
+	}
+
+	for i, rule := range rules {
+		response.Rules[i] = RetentionRuleResponse{
+			Status: rule.Status,
+			MaxAge: int64(rule.MaxAge.Seconds()),
+		}
+	}
+
+	for i, candidate := range candidates {
+		response.Tasks[i] = RetentionCandidateResponse{
+			ID:        candidate.Task.ID.String(),
+			Name:      candidate.Task.Name,
+			Status:    candidate.Task.Status,
+			CreatedAt: i18n.In(ctx.Request.Context(), candidate.Task.CreatedAt),
+			ExpiredAt: i18n.In(ctx.Request.Context(), candidate.ExpiredAt),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Drain serves POST /admin/drain.
+func (c *Controller) Drain(ctx *gin.Context) {
+	c.taskService.Drain()
+	c.readiness.MarkDraining()
+
+	ctx.JSON(http.StatusOK, c.drainResponse())
+}
+
+// Undrain serves POST /admin/undrain.
+func (c *Controller) Undrain(ctx *gin.Context) {
+	c.taskService.Undrain()
+	c.readiness.MarkReady()
+
+	ctx.JSON(http.StatusOK, c.drainResponse())
+}
+
+func (c *Controller) drainResponse() DrainResponse {
+	return DrainResponse{
+		Draining:     c.taskService.Draining(),
+		RunningTasks: c.taskService.RunningTasks(),
+		QueuedTasks:  c.taskService.QueuedTasks(),
+	}
+}
+
+// GetMaintenance serves GET /admin/maintenance.
+func (c *Controller) GetMaintenance(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.maintenanceResponse(ctx.Request.Context()))
+}
+
+// SetMaintenance serves PUT /admin/maintenance.
+func (c *Controller) SetMaintenance(ctx *gin.Context) {
+	var req MaintenanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	var until time.Time
+	if req.Until != nil {
+		until = *req.Until
+	}
+	c.maintenance.SetEnabled(*req.Enabled, until)
+
+	ctx.JSON(http.StatusOK, c.maintenanceResponse(ctx.Request.Context()))
+}
+
+func (c *Controller) maintenanceResponse(ctx context.Context) MaintenanceResponse {
+	response := MaintenanceResponse{Enabled: c.maintenance.Enabled()}
+	if until := c.maintenance.Until(); !until.IsZero() {
+		until = i18n.In(ctx, until)
+		response.Until = &until
+	}
+	return response
+}
+
+// GetLogLevel serves GET /admin/loglevel.
+func (c *Controller) GetLogLevel(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, LogLevelResponse{Level: logger.LevelName(c.logLevel.Level())})
+}
+
+// SetLogLevel serves PUT /admin/loglevel.
+func (c *Controller) SetLogLevel(ctx *gin.Context) {
+	var req LogLevelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: "Level must be one of debug, info, warn, error",
+		})
+		return
+	}
+
+	previous := c.logLevel.Level()
+	c.logLevel.Set(level)
+	log.Printf("Log level changed from %s to %s", logger.LevelName(previous), logger.LevelName(level))
+
+	ctx.JSON(http.StatusOK, LogLevelResponse{Level: logger.LevelName(level)})
+}
+
+// GetFeatureFlags serves GET /admin/features.
+func (c *Controller) GetFeatureFlags(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, FeatureFlagsResponse{Flags: c.featureFlags.All()})
+}
+
+// SetFeatureFlag serves PUT /admin/features/{name}.
+func (c *Controller) SetFeatureFlag(ctx *gin.Context) {
+	var req FeatureFlagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	if err := c.featureFlags.Set(ctx.Param("name"), *req.Enabled); err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, FeatureFlagsResponse{Flags: c.featureFlags.All()})
+}
+
+// GetQueue serves GET /admin/queue.
+func (c *Controller) GetQueue(ctx *gin.Context) {
+	queue, err := c.taskService.Queue(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to inspect the queue",
+		})
+		return
+	}
+
+	response := QueueResponse{
+		WorkerCapacity: c.taskService.WorkerCapacity(),
+		RunningTasks:   c.taskService.RunningTasks(),
+		Tasks:          make([]QueuedTaskResponse, len(queue)),
+	}
+	for _, pool := range c.taskService.WorkerPools() {
+		response.Pools = append(response.Pools, WorkerPoolResponse{
+			Name:         pool.Name,
+			Capacity:     pool.Capacity,
+			RunningTasks: pool.Running,
+			QueuedTasks:  pool.Queued,
+		})
+	}
+	for i, queued := range queue {
+		response.Tasks[i] = QueuedTaskResponse{
+			Position:        queued.Position,
+			ID:              queued.Task.ID.String(),
+			Name:            queued.Task.Name,
+			Type:            queued.Task.Type,
+			Pool:            queued.Task.Pool,
+			Owner:           queued.Task.Owner,
+			Priority:        queued.Task.Priority.String(),
+			CreatedAt:       i18n.In(ctx.Request.Context(), queued.Task.CreatedAt),
+			WaitSeconds:     queued.Wait.Seconds(),
+			EstimatedStart:  i18n.In(ctx.Request.Context(), queued.EstimatedStart),
+			EstimatedFinish: i18n.In(ctx.Request.Context(), queued.EstimatedFinish),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetStuckTasks serves GET /admin/tasks/stuck.
+func (c *Controller) GetStuckTasks(ctx *gin.Context) {
+	threshold := c.stuckThreshold
+	if value := ctx.Query("threshold"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   apierror.ValidationError,
+				Message: "Threshold must be a positive duration, e.g. 10m",
+			})
+			return
+		}
+		threshold = parsed
+	}
+
+	stuck, err := c.taskService.StuckTasks(ctx.Request.Context(), threshold)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to find stuck tasks",
+		})
+		return
+	}
+
+	now := time.Now()
+	response := StuckTasksResponse{
+		ThresholdSeconds: threshold.Seconds(),
+		Tasks:            make([]StuckTaskResponse, len(stuck)),
+	}
+	for i, task := range stuck {
+		response.Tasks[i] = StuckTaskResponse{
+			ID:                  task.Task.ID.String(),
+			Name:                task.Task.Name,
+			Type:                task.Task.Type,
+			Owner:               task.Task.Owner,
+			RequestID:           task.Task.RequestID,
+			CreatedAt:           i18n.In(ctx.Request.Context(), task.Task.CreatedAt),
+			StartedAt:           i18n.In(ctx.Request.Context(), task.StartedAt),
+			RunningSeconds:      task.Running.Seconds(),
+			ExpectedSeconds:     task.Expected.Seconds(),
+			LastHeartbeat:       i18n.In(ctx.Request.Context(), task.LastHeartbeat),
+			HeartbeatAgeSeconds: now.Sub(task.LastHeartbeat).Seconds(),
+			Reasons:             task.Reasons,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
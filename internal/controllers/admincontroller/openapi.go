@@ -0,0 +1,161 @@
+package admincontroller
+
+import (
+	"net/http"
+
+	"github.com/nzb3/workmate_test/internal/openapi"
+)
+
+// DescribeRoutes documents the handlers of the controller in spec.
+func (c *Controller) DescribeRoutes(spec *openapi.Spec) {
+	spec.Describe(c.Purge, openapi.Operation{
+		Summary:     "Purge tasks",
+		Description: "Permanently erases all tasks matching the filter, cancelling running ones",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Request:     PurgeRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Tasks erased", Body: PurgeResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid filter", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.RebuildTasks, openapi.Operation{
+		Summary:     "Rebuild tasks from events",
+		Description: "Replays the event history of every task and repairs the tasks whose stored state diverged from it",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Tasks rebuilt", Body: RebuildResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.RetentionDryRun, openapi.Operation{
+		Summary:     "Retention dry run",
+		Description: "Shows the configured retention rules and the tasks they would delete right now",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Retention preview", Body: RetentionDryRunResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.Drain, openapi.Operation{
+		Summary:     "Drain the instance",
+		Description: "Stops accepting new tasks and fails readiness; running and queued tasks still finish",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Instance drained", Body: DrainResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.Undrain, openapi.Operation{
+		Summary:     "Undrain the instance",
+		Description: "Accepts new tasks again and restores readiness",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Instance accepts tasks", Body: DrainResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetMaintenance, openapi.Operation{
+		Summary:     "Get maintenance mode",
+		Description: "Reports whether the service is in read-only maintenance mode",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Maintenance mode state", Body: MaintenanceResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.SetMaintenance, openapi.Operation{
+		Summary:     "Switch maintenance mode",
+		Description: "Enables or disables read-only maintenance mode: mutating endpoints return 503 with Retry-After pointing to until, reads keep working",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Request:     MaintenanceRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Maintenance mode state", Body: MaintenanceResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid input", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetLogLevel, openapi.Operation{
+		Summary:     "Get log level",
+		Description: "Returns the current minimum log level",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Current log level", Body: LogLevelResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.SetLogLevel, openapi.Operation{
+		Summary:     "Set log level",
+		Description: "Changes the minimum log level at runtime without a restart",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Request:     LogLevelRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Log level changed", Body: LogLevelResponse{}},
+			{Status: http.StatusBadRequest, Description: "Unknown log level", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetFeatureFlags, openapi.Operation{
+		Summary:     "List feature flags",
+		Description: "Returns every known feature flag and whether it is enabled",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Feature flags", Body: FeatureFlagsResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.SetFeatureFlag, openapi.Operation{
+		Summary:     "Toggle a feature flag",
+		Description: "Enables or disables a feature flag at runtime without a redeploy; unknown flags are created",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Params: []openapi.Param{
+			{Name: "name", In: openapi.InPath, Description: "Feature flag name"},
+		},
+		Request: FeatureFlagRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Feature flags", Body: FeatureFlagsResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid flag name or request", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetQueue, openapi.Operation{
+		Summary:     "Inspect the task queue",
+		Description: "Lists tasks waiting for a worker in dispatch order with their wait time and estimated start",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Queued tasks", Body: QueueResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetStuckTasks, openapi.Operation{
+		Summary:     "Report stuck tasks",
+		Description: "Lists running tasks executing longer than the threshold or whose executor heartbeat is stale",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Params: []openapi.Param{
+			{Name: "threshold", In: openapi.InQuery, Description: "Running time threshold as a Go duration, defaults to TASK_STUCK_THRESHOLD"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Stuck tasks", Body: StuckTasksResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid threshold", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import "time"
+
+// HumanDuration formats d for people, e.g. "2m31s": rounded to whole
+// seconds from a second up and to milliseconds below; zero is "".
+func HumanDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return ""
+	case d >= time.Second:
+		return d.Round(time.Second).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
@@ -0,0 +1,173 @@
+package healthcontroller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+	"github.com/nzb3/workmate_test/internal/health"
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// unavailableRetryAfter is sent as Retry-After with 503 responses: failed
+// checks are run again on the next request, so it only paces the client.
+const unavailableRetryAfter = 5 * time.Second
+
+type CheckResponse struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type StatsResponse struct {
+	UptimeSeconds       float64    `json:"uptime_seconds"`
+	ActiveTasks         int        `json:"active_tasks"`
+	QueueDepth          int        `json:"queue_depth"`
+	LastRepositoryWrite *time.Time `json:"last_repository_write"`
+}
+
+type HealthResponse struct {
+	Status    string                   `json:"status"`
+	Timestamp time.Time                `json:"timestamp"`
+	Build     buildinfo.Info           `json:"build"`
+	Stats     StatsResponse            `json:"stats"`
+	Checks    map[string]CheckResponse `json:"checks"`
+}
+
+// WorkerPool reports the load of the task executors.
+type WorkerPool interface {
+	RunningTasks() int
+	QueuedTasks() int
+}
+
+// Repository reports when a repository last accepted a write.
+type Repository interface {
+	LastWrite() time.Time
+}
+
+type Controller struct {
+	checker      *health.Checker
+	build        buildinfo.Info
+	workers      WorkerPool
+	repositories []Repository
+}
+
+func NewController(checker *health.Checker, build buildinfo.Info, workers WorkerPool, repositories ...Repository) *Controller {
+	return &Controller{
+		checker:      checker,
+		build:        build,
+		workers:      workers,
+		repositories: repositories,
+	}
+}
+
+// RegisterRoutes mounts the probe endpoints; they are expected at the root
+// of the engine, next to /metrics.
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/livez", c.Livez)
+	router.GET("/readyz", c.Readyz)
+	router.GET("/version", c.Version)
+}
+
+// HealthCheck runs every dependency check and reports its status and
+// latency along with build info and service statistics; it responds 503
+// when any check fails.
+func (c *Controller) HealthCheck(ctx *gin.Context) {
+	results := c.checker.Run(ctx.Request.Context())
+
+	response := HealthResponse{
+		Status:    "healthy",
+		Timestamp: i18n.In(ctx.Request.Context(), time.Now()),
+		Build:     c.build,
+		Stats:     c.stats(ctx.Request.Context()),
+		Checks:    make(map[string]CheckResponse, len(results)),
+	}
+
+	code := http.StatusOK
+	for _, result := range results {
+		check := CheckResponse{
+			Status:    "ok",
+			LatencyMs: float64(result.Latency.Microseconds()) / 1000,
+		}
+		if result.Err != nil {
+			check.Status = "error"
+			check.Error = result.Err.Error()
+			response.Status = "unhealthy"
+			code = http.StatusServiceUnavailable
+		}
+		response.Checks[result.Name] = check
+	}
+
+	if code == http.StatusServiceUnavailable {
+		ctx.Header("Retry-After", apierror.RetryAfter(unavailableRetryAfter))
+	}
+	ctx.JSON(code, response)
+}
+
+func (c *Controller) stats(ctx context.Context) StatsResponse {
+	stats := StatsResponse{
+		UptimeSeconds: c.checker.Uptime().Seconds(),
+		ActiveTasks:   c.workers.RunningTasks(),
+		QueueDepth:    c.workers.QueuedTasks(),
+	}
+
+	var lastWrite time.Time
+	for _, repository := range c.repositories {
+		if written := repository.LastWrite(); written.After(lastWrite) {
+			lastWrite = written
+		}
+	}
+	if !lastWrite.IsZero() {
+		lastWrite = i18n.In(ctx, lastWrite)
+		stats.LastRepositoryWrite = &lastWrite
+	}
+
+	return stats
+}
+
+// Version reports the version, commit and build time of the running binary.
+func (c *Controller) Version(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.build)
+}
+
+// Livez reports that the process is up and serving HTTP.
+func (c *Controller) Livez(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// Readyz reports whether the service can accept traffic: startup has
+// finished, the process is not draining and all dependency checks pass.
+func (c *Controller) Readyz(ctx *gin.Context) {
+	status := c.checker.Ready(ctx.Request.Context())
+
+	code := http.StatusOK
+	state := string(status.State)
+	if !status.Ready {
+		code = http.StatusServiceUnavailable
+		if status.State == health.StateReady {
+			state = "unavailable"
+		}
+	}
+
+	checks := make(map[string]string, len(status.Checks))
+	for _, result := range status.Checks {
+		checks[result.Name] = "ok"
+		if result.Err != nil {
+			checks[result.Name] = result.Err.Error()
+		}
+	}
+
+	if code == http.StatusServiceUnavailable {
+		ctx.Header("Retry-After", apierror.RetryAfter(unavailableRetryAfter))
+	}
+	ctx.JSON(code, gin.H{
+		"status": state,
+		"checks": checks,
+	})
+}
@@ -0,0 +1,45 @@
+package healthcontroller
+
+import (
+	"net/http"
+
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+	"github.com/nzb3/workmate_test/internal/openapi"
+)
+
+// DescribeRoutes documents the handlers of the controller in spec.
+func (c *Controller) DescribeRoutes(spec *openapi.Spec) {
+	spec.Describe(c.HealthCheck, openapi.Operation{
+		Summary:     "Check service health",
+		Description: "Runs every dependency check and reports its status and latency along with build info and service statistics",
+		Tags:        []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "All checks passed", Body: HealthResponse{}},
+			{Status: http.StatusServiceUnavailable, Description: "A check failed", Body: HealthResponse{}},
+		},
+	})
+	spec.Describe(c.Version, openapi.Operation{
+		Summary: "Get build info",
+		Tags:    []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Version, commit and build time", Body: buildinfo.Info{}},
+		},
+	})
+	spec.Describe(c.Livez, openapi.Operation{
+		Summary:     "Liveness probe",
+		Description: "Reports that the process is up and serving HTTP",
+		Tags:        []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Process is alive"},
+		},
+	})
+	spec.Describe(c.Readyz, openapi.Operation{
+		Summary:     "Readiness probe",
+		Description: "Reports whether startup has finished, the process is not draining and all dependency checks pass",
+		Tags:        []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Ready to accept traffic"},
+			{Status: http.StatusServiceUnavailable, Description: "Starting, draining or a check failed"},
+		},
+	})
+}
@@ -0,0 +1,116 @@
+package loadgencontroller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/loadgen"
+)
+
+type Generator interface {
+	Start(rate float64, duration time.Duration, taskType string) (loadgen.Status, error)
+	Stop() bool
+	Status() loadgen.Status
+}
+
+// LoadgenRequest starts generating tasks.
+type LoadgenRequest struct {
+	// Rate is the number of tasks created per second.
+	Rate float64 `json:"rate" binding:"required,gt=0,max=1000"`
+	// Duration in seconds; 0 runs until stopped.
+	Duration int    `json:"duration" binding:"min=0"`
+	Type     string `json:"type" binding:"omitempty,max=50"`
+}
+
+// LoadgenResponse represents the current or last run of the generator.
+type LoadgenResponse struct {
+	Running   bool       `json:"running"`
+	Rate      float64    `json:"rate"`
+	Type      string     `json:"type,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+	Created   int        `json:"created"`
+	Failed    int        `json:"failed"`
+}
+
+type ErrorResponse struct {
+	Error   apierror.Code `json:"error"`
+	Message string        `json:"message,omitempty"`
+	// Fields lists the invalid fields of a request body.
+	Fields []controllers.FieldError `json:"fields,omitempty"`
+}
+
+type Controller struct {
+	generator Generator
+}
+
+func NewController(generator Generator) *Controller {
+	return &Controller{generator: generator}
+}
+
+// RegisterRoutes mounts the load generator; it is expected in the admin group.
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/loadgen", c.GetLoadgen)
+	router.POST("/loadgen", c.StartLoadgen)
+	router.DELETE("/loadgen", c.StopLoadgen)
+}
+
+// GetLoadgen serves GET /admin/loadgen.
+func (c *Controller) GetLoadgen(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, mapStatus(ctx.Request.Context(), c.generator.Status()))
+}
+
+// StartLoadgen serves POST /admin/loadgen.
+func (c *Controller) StartLoadgen(ctx *gin.Context) {
+	var req LoadgenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	status, err := c.generator.Start(req.Rate, time.Duration(req.Duration)*time.Second, req.Type)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: err.Error(),
+		})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, mapStatus(ctx.Request.Context(), status))
+}
+
+// StopLoadgen serves DELETE /admin/loadgen.
+func (c *Controller) StopLoadgen(ctx *gin.Context) {
+	c.generator.Stop()
+	ctx.JSON(http.StatusOK, mapStatus(ctx.Request.Context(), c.generator.Status()))
+}
+
+func mapStatus(ctx context.Context, status loadgen.Status) LoadgenResponse {
+	response := LoadgenResponse{
+		Running: status.Running,
+		Rate:    status.Rate,
+		Type:    status.TaskType,
+		Created: status.Created,
+		Failed:  status.Failed,
+	}
+	if !status.StartedAt.IsZero() {
+		startedAt := i18n.In(ctx, status.StartedAt)
+		response.StartedAt = &startedAt
+	}
+	if !status.Until.IsZero() {
+		until := i18n.In(ctx, status.Until)
+		response.Until = &until
+	}
+	return response
+}
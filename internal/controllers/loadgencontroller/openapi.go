@@ -0,0 +1,43 @@
+package loadgencontroller
+
+import (
+	"net/http"
+
+	"github.com/nzb3/workmate_test/internal/openapi"
+)
+
+// DescribeRoutes documents the handlers of the controller in spec.
+func (c *Controller) DescribeRoutes(spec *openapi.Spec) {
+	spec.Describe(c.GetLoadgen, openapi.Operation{
+		Summary:     "Get load generation status",
+		Description: "Returns the current or last run of the load generator. Available in dev mode only",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Load generator status", Body: LoadgenResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.StartLoadgen, openapi.Operation{
+		Summary:     "Start load generation",
+		Description: "Creates synthetic tasks at the given rate, replacing the current run. Available in dev mode only",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Request:     LoadgenRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusAccepted, Description: "Load generation started", Body: LoadgenResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid rate or duration", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.StopLoadgen, openapi.Operation{
+		Summary:     "Stop load generation",
+		Description: "Stops the current run. Available in dev mode only",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Load generation stopped", Body: LoadgenResponse{}},
+			{Status: http.StatusUnauthorized, Description: "Missing or invalid admin token", Body: ErrorResponse{}},
+		},
+	})
+}
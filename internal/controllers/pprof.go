@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPprof mounts the net/http/pprof handlers on router.
+func RegisterPprof(router *gin.RouterGroup) {
+	router.GET("/", gin.WrapF(pprof.Index))
+	router.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	router.GET("/profile", gin.WrapF(pprof.Profile))
+	router.GET("/symbol", gin.WrapF(pprof.Symbol))
+	router.POST("/symbol", gin.WrapF(pprof.Symbol))
+	router.GET("/trace", gin.WrapF(pprof.Trace))
+	// pprof.Index resolves named profiles only under /debug/pprof/, so they are routed explicitly.
+	router.GET("/:profile", func(ctx *gin.Context) {
+		pprof.Handler(ctx.Param("profile")).ServeHTTP(ctx.Writer, ctx.Request)
+	})
+}
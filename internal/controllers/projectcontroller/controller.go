@@ -0,0 +1,313 @@
+package projectcontroller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/projectmodel"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/service/projectservice"
+)
+
+type ProjectService interface {
+	CreateProject(ctx context.Context, name, description string) (*projectmodel.Project, error)
+	GetProject(ctx context.Context, projectID uuid.UUID) (*projectmodel.Project, error)
+	UpdateProject(ctx context.Context, projectID uuid.UUID, name, description string) (*projectmodel.Project, error)
+	DeleteProject(ctx context.Context, projectID uuid.UUID) error
+	ListProjects(ctx context.Context) ([]*projectmodel.Project, error)
+	ProjectTasks(ctx context.Context, projectID uuid.UUID) ([]*taskmodel.Task, error)
+	ProjectStats(ctx context.Context, projectID uuid.UUID) (*projectservice.Stats, error)
+}
+
+// ProjectRequest represents a request to create or update a project.
+type ProjectRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+// ProjectResponse represents a response with project information.
+type ProjectResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProjectListResponse represents a response with a list of projects.
+type ProjectListResponse struct {
+	Projects []ProjectResponse `json:"projects"`
+}
+
+// ProjectTaskResponse represents a task that belongs to a project.
+type ProjectTaskResponse struct {
+	ID        uuid.UUID            `json:"id"`
+	Name      string               `json:"name"`
+	Status    taskmodel.TaskStatus `json:"status"`
+	CreatedAt time.Time            `json:"created_at"`
+	// ProcessingTime is in nanoseconds and kept for compatibility; use
+	// ProcessingTimeMs instead.
+	ProcessingTime      time.Duration `json:"processing_time"`
+	ProcessingTimeMs    int64         `json:"processing_time_ms"`
+	ProcessingTimeHuman string        `json:"processing_time_human,omitempty" example:"2m31s"`
+}
+
+// ProjectTaskListResponse represents a response with the tasks of a project.
+type ProjectTaskListResponse struct {
+	Tasks []ProjectTaskResponse `json:"tasks"`
+}
+
+// ProjectStatsResponse represents task statistics of a project.
+// Number of project tasks in total and per status.
+type ProjectStatsResponse struct {
+	ProjectID uuid.UUID                    `json:"project_id"`
+	Total     int                          `json:"total"`
+	ByStatus  map[taskmodel.TaskStatus]int `json:"by_status"`
+}
+
+// ErrorResponse represents an error response.
+type ErrorResponse struct {
+	Error   apierror.Code `json:"error"`
+	Message string        `json:"message,omitempty"`
+	// Fields lists the invalid fields of a request body.
+	Fields []controllers.FieldError `json:"fields,omitempty"`
+}
+
+type Controller struct {
+	projectService ProjectService
+}
+
+func NewController(service ProjectService) *Controller {
+	return &Controller{
+		projectService: service,
+	}
+}
+
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("", c.ListProjects)
+	}
+	project := router.Group("/project")
+	{
+		project.POST("/create", c.CreateProject)
+		project.GET("/:id", c.GetProject)
+		project.PUT("/:id", c.UpdateProject)
+		project.DELETE("/:id", c.DeleteProject)
+		project.GET("/:id/tasks", c.ListProjectTasks)
+		project.GET("/:id/stats", c.GetProjectStats)
+	}
+}
+
+// CreateProject serves POST /project/create.
+func (c *Controller) CreateProject(ctx *gin.Context) {
+	var req ProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	project, err := c.projectService.CreateProject(ctx.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to create project",
+		})
+		return
+	}
+
+	ctx.Header("Location", "/api/v1/project/"+project.ID.String())
+	ctx.JSON(http.StatusCreated, c.mapProjectToResponse(ctx.Request.Context(), project))
+}
+
+// GetProject serves GET /project/{id}.
+func (c *Controller) GetProject(ctx *gin.Context) {
+	projectID, ok := c.parseProjectID(ctx)
+	if !ok {
+		return
+	}
+
+	project, err := c.projectService.GetProject(ctx.Request.Context(), projectID)
+	if err != nil {
+		c.projectError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.mapProjectToResponse(ctx.Request.Context(), project))
+}
+
+// UpdateProject serves PUT /project/{id}.
+func (c *Controller) UpdateProject(ctx *gin.Context) {
+	projectID, ok := c.parseProjectID(ctx)
+	if !ok {
+		return
+	}
+
+	var req ProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	project, err := c.projectService.UpdateProject(ctx.Request.Context(), projectID, req.Name, req.Description)
+	if err != nil {
+		c.projectError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.mapProjectToResponse(ctx.Request.Context(), project))
+}
+
+// DeleteProject serves DELETE /project/{id}.
+func (c *Controller) DeleteProject(ctx *gin.Context) {
+	projectID, ok := c.parseProjectID(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.projectService.DeleteProject(ctx.Request.Context(), projectID)
+	if errors.Is(err, projectservice.ErrProjectNotEmpty) {
+		ctx.JSON(http.StatusConflict, ErrorResponse{
+			Error:   apierror.ProjectNotEmpty,
+			Message: "Project still has tasks",
+		})
+		return
+	}
+	if err != nil {
+		c.projectError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListProjects serves GET /projects.
+func (c *Controller) ListProjects(ctx *gin.Context) {
+	projects, err := c.projectService.ListProjects(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to retrieve projects",
+		})
+		return
+	}
+
+	response := ProjectListResponse{
+		Projects: make([]ProjectResponse, len(projects)),
+	}
+
+	for i, project := range projects {
+		response.Projects[i] = c.mapProjectToResponse(ctx.Request.Context(), project)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ListProjectTasks serves GET /project/{id}/tasks.
+func (c *Controller) ListProjectTasks(ctx *gin.Context) {
+	projectID, ok := c.parseProjectID(ctx)
+	if !ok {
+		return
+	}
+
+	tasks, err := c.projectService.ProjectTasks(ctx.Request.Context(), projectID)
+	if err != nil {
+		c.projectError(ctx, err)
+		return
+	}
+
+	response := ProjectTaskListResponse{
+		Tasks: make([]ProjectTaskResponse, len(tasks)),
+	}
+
+	for i, task := range tasks {
+		response.Tasks[i] = ProjectTaskResponse{
+			ID:                  task.ID,
+			Name:                task.Name,
+			Status:              task.Status,
+			CreatedAt:           i18n.In(ctx.Request.Context(), task.CreatedAt),
+			ProcessingTime:      task.ProcessingTime,
+			ProcessingTimeMs:    task.ProcessingTime.Milliseconds(),
+			ProcessingTimeHuman: controllers.HumanDuration(task.ProcessingTime),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetProjectStats serves GET /project/{id}/stats.
+func (c *Controller) GetProjectStats(ctx *gin.Context) {
+	projectID, ok := c.parseProjectID(ctx)
+	if !ok {
+		return
+	}
+
+	stats, err := c.projectService.ProjectStats(ctx.Request.Context(), projectID)
+	if err != nil {
+		c.projectError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ProjectStatsResponse{
+		ProjectID: projectID,
+		Total:     stats.Total,
+		ByStatus:  stats.ByStatus,
+	})
+}
+
+func (c *Controller) parseProjectID(ctx *gin.Context) (uuid.UUID, bool) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid project ID format"),
+		})
+		return uuid.Nil, false
+	}
+
+	return projectID, true
+}
+
+// projectError responds to a failed request for a single project: a
+// missing project is reported as such, any other failure as an internal
+// error.
+func (c *Controller) projectError(ctx *gin.Context, err error) {
+	if errors.Is(err, projectmodel.ErrProjectNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   apierror.ProjectNotFound,
+			Message: i18n.T(ctx.Request.Context(), "Project not found"),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   apierror.InternalError,
+		Message: "Failed to process project request",
+	})
+}
+
+func (c *Controller) mapProjectToResponse(ctx context.Context, project *projectmodel.Project) ProjectResponse {
+	return ProjectResponse{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		CreatedAt:   i18n.In(ctx, project.CreatedAt),
+	}
+}
@@ -0,0 +1,103 @@
+package projectcontroller
+
+import (
+	"net/http"
+
+	"github.com/nzb3/workmate_test/internal/openapi"
+)
+
+// DescribeRoutes documents the handlers of the controller in spec.
+func (c *Controller) DescribeRoutes(spec *openapi.Spec) {
+	spec.Describe(c.CreateProject, openapi.Operation{
+		Summary:     "Create a new project",
+		Description: "Creates a project that tasks can be grouped into",
+		Tags:        []string{"projects"},
+		Request:     ProjectRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Description: "Project created", Body: ProjectResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid input", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetProject, openapi.Operation{
+		Summary:     "Get project info",
+		Description: "Returns information about a project by its ID",
+		Tags:        []string{"projects"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Project ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Project found", Body: ProjectResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.UpdateProject, openapi.Operation{
+		Summary:     "Update a project",
+		Description: "Replaces the name and description of a project",
+		Tags:        []string{"projects"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Project ID (UUID)"},
+		},
+		Request: ProjectRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Project updated", Body: ProjectResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid input", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.DeleteProject, openapi.Operation{
+		Summary:     "Delete a project",
+		Description: "Deletes a project that has no tasks",
+		Tags:        []string{"projects"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Project ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusNoContent, Description: "Project deleted"},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusConflict, Description: "Project still has tasks", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.ListProjects, openapi.Operation{
+		Summary:     "List all projects",
+		Description: "Returns a list of all projects",
+		Tags:        []string{"projects"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "List of projects", Body: ProjectListResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.ListProjectTasks, openapi.Operation{
+		Summary:     "List project tasks",
+		Description: "Returns the tasks that belong to a project",
+		Tags:        []string{"projects"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Project ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "List of project tasks", Body: ProjectTaskListResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetProjectStats, openapi.Operation{
+		Summary:     "Get project statistics",
+		Description: "Returns the number of project tasks in total and per status",
+		Tags:        []string{"projects"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Project ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Project statistics", Body: ProjectStatsResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+}
@@ -0,0 +1,179 @@
+package taskcontroller
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/service/taskservice"
+)
+
+// DefaultMaxUploadSize bounds the multipart request creating a task with
+// its attachments unless WithMaxUploadSize sets another bound.
+const DefaultMaxUploadSize = 32 << 20
+
+// AttachmentResponse describes an input or artifact of a task.
+type AttachmentResponse struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	// URL downloads an artifact; inputs cannot be downloaded.
+	URL string `json:"url,omitempty"`
+}
+
+// WithMaxUploadSize bounds the size of the multipart request creating a
+// task with its attachments.
+func WithMaxUploadSize(size int64) Option {
+	return func(c *Controller) {
+		c.maxUploadSize = size
+	}
+}
+
+// bindMultipart reads a task creation request sent as multipart/form-data:
+// the "task" field carries the JSON request, the "attachments" files are
+// the inputs of the task. The returned function closes the files.
+func (c *Controller) bindMultipart(ctx *gin.Context, req *CreateTaskRequest) ([]taskservice.Upload, func(), bool) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxUploadSize)
+	form, err := ctx.MultipartForm()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error:   apierror.PayloadTooLarge,
+			Message: i18n.Sprintf(ctx.Request.Context(), "Request body must be at most %d bytes", tooLarge.Limit),
+		})
+		return nil, nil, false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Invalid multipart form"),
+		})
+		return nil, nil, false
+	}
+
+	values := form.Value["task"]
+	if len(values) != 1 {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Request body has invalid fields"),
+			Fields: []controllers.FieldError{{
+				Field:   "task",
+				Rule:    "required",
+				Message: i18n.Sprintf(ctx.Request.Context(), "%s is required", "task"),
+			}},
+		})
+		return nil, nil, false
+	}
+	if err := binding.JSON.BindBody([]byte(values[0]), req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return nil, nil, false
+	}
+
+	var files []multipart.File
+	closeFiles := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+	uploads := make([]taskservice.Upload, 0, len(form.File["attachments"]))
+	names := make(map[string]bool)
+	for _, header := range form.File["attachments"] {
+		name := filepath.Base(header.Filename)
+		if name == "." || name == string(filepath.Separator) || names[name] {
+			closeFiles()
+			message := i18n.Sprintf(ctx.Request.Context(), "Attachment names must be unique and non-empty, got %q", header.Filename)
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   apierror.ValidationError,
+				Message: i18n.T(ctx.Request.Context(), "Request body has invalid fields"),
+				Fields:  []controllers.FieldError{{Field: "attachments", Rule: "unique", Message: message}},
+			})
+			return nil, nil, false
+		}
+		names[name] = true
+
+		file, err := header.Open()
+		if err != nil {
+			closeFiles()
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   apierror.InternalError,
+				Message: "Failed to read attachment",
+			})
+			return nil, nil, false
+		}
+		files = append(files, file)
+		uploads = append(uploads, taskservice.Upload{
+			Name:        name,
+			ContentType: header.Header.Get("Content-Type"),
+			Size:        header.Size,
+			Content:     file,
+		})
+	}
+	return uploads, closeFiles, true
+}
+
+// GetTaskArtifact serves GET /task/{id}/artifacts/{name}.
+func (c *Controller) GetTaskArtifact(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+
+	artifact, content, err := c.taskService.OpenArtifact(ctx.Request.Context(), taskID, ctx.Param("name"))
+	if errors.Is(err, taskservice.ErrArtifactNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   apierror.ArtifactNotFound,
+			Message: i18n.T(ctx.Request.Context(), "Artifact not found"),
+		})
+		return
+	}
+	if err != nil {
+		c.taskError(ctx, err, "Failed to retrieve artifact")
+		return
+	}
+	defer content.Close()
+
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.DataFromReader(http.StatusOK, artifact.Size, contentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Name}),
+	})
+}
+
+func mapAttachmentsToResponse(attachments []taskmodel.Attachment, url func(name string) string) []AttachmentResponse {
+	if len(attachments) == 0 {
+		return nil
+	}
+	response := make([]AttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		response[i] = AttachmentResponse{
+			Name:        attachment.Name,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		}
+		if url != nil {
+			response[i].URL = url(attachment.Name)
+		}
+	}
+	return response
+}
@@ -2,200 +2,769 @@ package taskcontroller
 
 import (
 	"context"
+	"errors"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/auth"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/estimate"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/projectmodel"
 	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/service/taskservice"
+	"github.com/nzb3/workmate_test/internal/tasklog"
 )
 
 type TaskService interface {
-	CreateTask(ctx context.Context, name string) (*taskmodel.Task, error)
+	CreateTask(ctx context.Context, name string, opts ...taskmodel.Option) (*taskmodel.Task, error)
+	CreateTaskWithInputs(ctx context.Context, name string, uploads []taskservice.Upload, opts ...taskmodel.Option) (*taskmodel.Task, error)
+	OpenArtifact(ctx context.Context, taskID uuid.UUID, name string) (taskmodel.Attachment, io.ReadCloser, error)
 	GetTask(ctx context.Context, taskID uuid.UUID) (*taskmodel.Task, error)
 	DeleteTask(ctx context.Context, taskID uuid.UUID) error
-	ListTasks(ctx context.Context) ([]*taskmodel.Task, error)
+	CancelTask(ctx context.Context, taskID uuid.UUID, reason string) (*taskmodel.Task, error)
+	SetTaskPriority(ctx context.Context, taskID uuid.UUID, priority taskmodel.Priority) (*taskmodel.Task, error)
+	TaskEvents(ctx context.Context, taskID uuid.UUID) ([]taskmodel.Event, error)
+	TaskLogs(ctx context.Context, taskID uuid.UUID, offset, limit int) ([]tasklog.Line, int, error)
+	FollowTaskLogs(ctx context.Context, taskID uuid.UUID, offset int, send func([]tasklog.Line) error) error
+	ListTasks(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error)
+	LatencyStats(ctx context.Context, window time.Duration, taskType string) (*taskservice.LatencyStats, error)
+	Throughput(ctx context.Context, period, bucket time.Duration) ([]taskservice.ThroughputBucket, error)
+	DurationEstimates() map[string]estimate.Estimate
+	DispatchOrder(ctx context.Context) ([]taskservice.DispatchEntry, error)
+	DispatchRules() []string
+}
+
+const (
+	defaultLatencyWindow    = 24 * time.Hour
+	defaultTimeseriesPeriod = 24 * time.Hour
+	defaultTimeseriesBucket = time.Hour
+	maxTimeseriesBuckets    = 1000
+)
+
+type ProjectService interface {
+	GetProject(ctx context.Context, projectID uuid.UUID) (*projectmodel.Project, error)
 }
 
 // CreateTaskRequest represents a request to create a new task.
-// @Description Request payload for creating a task.
 type CreateTaskRequest struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name      string     `json:"name" binding:"required"`
+	Type      string     `json:"type" binding:"omitempty,max=50"`
+	ProjectID *uuid.UUID `json:"project_id"`
+	// ExpiresAt fails the task unless it has finished by then.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// MaxRuntimeSeconds replaces the default timeout of the server for the
+	// task, up to the maximum of the server.
+	MaxRuntimeSeconds *int64 `json:"max_runtime_seconds,omitempty" binding:"omitempty,min=1"`
+	// DeleteAfterSeconds deletes the task that long after it has finished.
+	DeleteAfterSeconds *int64 `json:"delete_after_seconds,omitempty" binding:"omitempty,min=1"`
+	// DedupKey detects repeated submissions of the task instead of its
+	// name while the server has a dedup window.
+	DedupKey string `json:"dedup_key,omitempty" binding:"omitempty,max=100"`
+	// Priority orders the task among the tasks waiting for a worker.
+	Priority string `json:"priority,omitempty" binding:"omitempty,oneof=low normal high critical" enums:"low,normal,high,critical"`
+	// SerialKey runs the task one at a time with the other tasks having
+	// the key, in the order they were created.
+	SerialKey string `json:"serial_key,omitempty" binding:"omitempty,max=100"`
+	// Pool runs the task on the workers of a pool configured on the
+	// server instead of the default pool.
+	Pool string `json:"pool,omitempty" binding:"omitempty,max=50"`
+}
+
+// CancelTaskRequest represents the optional body of a request to cancel a
+// task.
+type CancelTaskRequest struct {
+	Reason string `json:"reason" binding:"max=500" example:"Submitted with the wrong input"`
+}
+
+// SetTaskPriorityRequest represents a request to change the priority of a
+// queued task.
+type SetTaskPriorityRequest struct {
+	Priority string `json:"priority" binding:"required,oneof=low normal high critical" enums:"low,normal,high,critical"`
 }
 
 // TaskResponse represents a response with task information.
-// @Description Task information including status and processing time.
 type TaskResponse struct {
-	ID             uuid.UUID            `json:"id"`
-	Name           string               `json:"name"`
-	Status         taskmodel.TaskStatus `json:"status"`
-	CreatedAt      time.Time            `json:"created_at"`
-	ProcessingTime time.Duration        `json:"processing_time" swaggertype:"integer"`
+	ID        uuid.UUID            `json:"id"`
+	Name      string               `json:"name"`
+	Type      string               `json:"type"`
+	Owner     string               `json:"owner,omitempty"`
+	CreatedBy string               `json:"created_by,omitempty"`
+	ProjectID *uuid.UUID           `json:"project_id,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+	Status    taskmodel.TaskStatus `json:"status"`
+	CreatedAt time.Time            `json:"created_at"`
+	// ProcessingTime is in nanoseconds and kept for compatibility; use
+	// ProcessingTimeMs instead.
+	ProcessingTime      time.Duration `json:"processing_time"`
+	ProcessingTimeMs    int64         `json:"processing_time_ms"`
+	ProcessingTimeHuman string        `json:"processing_time_human,omitempty" example:"2m31s"`
+	ExpiresAt           *time.Time    `json:"expires_at,omitempty"`
+	MaxRuntimeSeconds   int64         `json:"max_runtime_seconds,omitempty"`
+	DeleteAfterSeconds  int64         `json:"delete_after_seconds,omitempty"`
+	DedupKey            string        `json:"dedup_key,omitempty"`
+	Priority            string        `json:"priority" enums:"low,normal,high,critical"`
+	SerialKey           string        `json:"serial_key,omitempty"`
+	Pool                string        `json:"pool"`
+	// Slow is set once the task has been processing for longer than the
+	// slow threshold of its type.
+	Slow bool `json:"slow,omitempty"`
+	// Preemptions counts how many times the task gave its worker up to a
+	// critical task while running.
+	Preemptions int `json:"preemptions,omitempty"`
+	// FailureReason explains why a FAILED task failed, when that is known.
+	FailureReason string `json:"failure_reason,omitempty"`
+	// CancelReason is the reason the user gave for cancelling a CANCELLED task.
+	CancelReason string `json:"cancel_reason,omitempty"`
+	// Inputs are the files uploaded together with the task.
+	Inputs []AttachmentResponse `json:"inputs,omitempty"`
+	// Artifacts are the files the task produced once it completed.
+	Artifacts []AttachmentResponse `json:"artifacts,omitempty"`
+	// Source names the instance holding the task in federated listings.
+	Source string `json:"source,omitempty"`
 }
 
 // TaskListResponse represents a response with a list of tasks.
-// @Description List of tasks.
+// List of tasks. Federated listings also carry the total number of tasks and the peers that did not answer.
 type TaskListResponse struct {
-	Tasks []TaskResponse `json:"tasks"`
+	Tasks       []TaskResponse `json:"tasks"`
+	Total       *int           `json:"total,omitempty"`
+	Unavailable []string       `json:"unavailable,omitempty"`
+}
+
+// TaskEventResponse represents a single change of a task.
+// Task event; started_at and finished_at are set by the started and final events, attempt by the events that start or end an attempt.
+type TaskEventResponse struct {
+	Version    int                 `json:"version"`
+	Type       taskmodel.EventType `json:"type" enums:"created,queued,started,progress_updated,priority_changed,slow,preempted,attempt_started,attempt_finished,completed,failed,cancelled"`
+	At         time.Time           `json:"at"`
+	StartedAt  *time.Time          `json:"started_at,omitempty"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	// Priority is set by the priority_changed events.
+	Priority string `json:"priority,omitempty" enums:"low,normal,high,critical"`
+	// ProcessingTime is in nanoseconds and kept for compatibility; use
+	// ProcessingTimeMs instead.
+	ProcessingTime      time.Duration        `json:"processing_time"`
+	ProcessingTimeMs    int64                `json:"processing_time_ms"`
+	ProcessingTimeHuman string               `json:"processing_time_human,omitempty" example:"2m31s"`
+	Attempt             *TaskAttemptResponse `json:"attempt,omitempty"`
+}
+
+// TaskAttemptResponse represents one execution attempt of a task.
+// Execution attempt; finished_at is not set while the attempt runs, error only for failed attempts.
+type TaskAttemptResponse struct {
+	Number     int                      `json:"number"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt *time.Time               `json:"finished_at,omitempty"`
+	Outcome    taskmodel.AttemptOutcome `json:"outcome" enums:"RUNNING,SUCCEEDED,FAILED,CANCELLED,PREEMPTED"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// TaskAttemptsResponse represents the execution attempts of a task.
+// Attempts of the task, oldest first.
+type TaskAttemptsResponse struct {
+	Attempts []TaskAttemptResponse `json:"attempts"`
+}
+
+// TaskEventsResponse represents the history of a task.
+// Events of the task, oldest first.
+type TaskEventsResponse struct {
+	Events []TaskEventResponse `json:"events"`
 }
 
 // ErrorResponse represents an error response.
-// @Description Error response with error code and message.
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Error   apierror.Code `json:"error"`
+	Message string        `json:"message,omitempty"`
+	// Fields lists the invalid fields of a request body.
+	Fields []controllers.FieldError `json:"fields,omitempty"`
+	// ConflictingTaskID is the active task holding the name a task_name_conflict asked for.
+	ConflictingTaskID *uuid.UUID `json:"conflicting_task_id,omitempty"`
+}
+
+// LatencyStatsResponse represents processing-time percentiles.
+// Processing-time percentiles in seconds of tasks completed within the window.
+type LatencyStatsResponse struct {
+	WindowSeconds float64 `json:"window_seconds"`
+	Type          string  `json:"type,omitempty"`
+	Count         int     `json:"count"`
+	P50Seconds    float64 `json:"p50_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+	P99Seconds    float64 `json:"p99_seconds"`
 }
 
+// DurationEstimateResponse represents the expected duration of a task type.
+// Mean and percentiles in seconds of the recent completed tasks of the type.
+type DurationEstimateResponse struct {
+	Type        string  `json:"type"`
+	Count       int     `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	P50Seconds  float64 `json:"p50_seconds"`
+	P90Seconds  float64 `json:"p90_seconds"`
+	P99Seconds  float64 `json:"p99_seconds"`
+}
+
+// DurationEstimatesResponse represents the expected durations of task types.
+// Expected durations per task type, ordered by type.
+type DurationEstimatesResponse struct {
+	Types []DurationEstimateResponse `json:"types"`
+}
+
+// TimeseriesBucketResponse represents task counts within one bucket.
+// Tasks created, completed and failed within the bucket starting at start.
+type TimeseriesBucketResponse struct {
+	Start     time.Time `json:"start"`
+	Created   int       `json:"created"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+}
+
+// TimeseriesResponse represents task throughput over time.
+// Task counts per bucket, oldest first.
+type TimeseriesResponse struct {
+	BucketSeconds float64                    `json:"bucket_seconds"`
+	PeriodSeconds float64                    `json:"period_seconds"`
+	Buckets       []TimeseriesBucketResponse `json:"buckets"`
+}
+
+// drainRetryAfter is how long clients rejected by a draining instance wait
+// before retrying, by when a load balancer has moved them to another
+// instance or the restarted process took over.
+const drainRetryAfter = 5 * time.Second
+
 type Controller struct {
-	taskService TaskService
+	taskService    TaskService
+	projectService ProjectService
+	// templates is set when tasks can be created from templates.
+	templates TemplateService
+	// nameRules restrict the names of created tasks.
+	nameRules NameRules
+	// maxUploadSize bounds multipart requests creating tasks.
+	maxUploadSize int64
+	// shutdown is closed once the server shuts down, ending log streams.
+	shutdown <-chan struct{}
+	// source and peers are set in federation mode.
+	source string
+	peers  Peers
 }
 
-func NewController(service TaskService) *Controller {
-	return &Controller{
-		taskService: service,
+func NewController(taskService TaskService, projectService ProjectService, opts ...Option) *Controller {
+	c := &Controller{
+		taskService:    taskService,
+		projectService: projectService,
+		nameRules:      DefaultNameRules,
+		maxUploadSize:  DefaultMaxUploadSize,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
 	tasks := router.Group("/tasks")
 	{
 		tasks.GET("", c.ListTasks)
+		tasks.GET("/stats/latency", c.GetLatencyStats)
+		tasks.GET("/stats/timeseries", c.GetTimeseries)
+		tasks.GET("/stats/durations", c.GetDurationEstimates)
+		tasks.GET("/queue-order", c.GetDispatchOrder)
 	}
 	task := router.Group("/task")
 	{
 		task.POST("/create", c.CreateTask)
+		if c.templates != nil {
+			task.POST("/from-template/:id", c.CreateTaskFromTemplate)
+		}
 		task.GET("/:id", c.GetTask)
 		task.DELETE("/:id", c.DeleteTask)
+		task.POST("/:id/cancel", c.CancelTask)
+		task.PATCH("/:id/priority", c.SetTaskPriority)
+		task.GET("/:id/events", c.GetTaskEvents)
+		task.GET("/:id/attempts", c.GetTaskAttempts)
+		task.GET("/:id/logs", c.GetTaskLogs)
+		task.GET("/:id/logs/stream", c.StreamTaskLogs)
+		task.GET("/:id/artifacts/:name", c.GetTaskArtifact)
 	}
 }
 
-// CreateTask godoc
-// @Summary      Create a new task
-// @Description  Creates a new task with the specified name
-// @Tags         tasks
-// @Accept       json
-// @Produce      json
-// @Param        request body CreateTaskRequest true "Task info"
-// @Success      202 {object} TaskResponse "Task accepted for processing"
-// @Failure      400 {object} ErrorResponse "Invalid input"
-// @Failure      500 {object} ErrorResponse "Internal error"
-// @Header       202 {string} Location "Location of the created task"
-// @Router       /task/create [post]
+// CreateTask serves POST /task/create. The request is a JSON body, or a
+// multipart form uploading the inputs of the task together with it.
 func (c *Controller) CreateTask(ctx *gin.Context) {
 	var req CreateTaskRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	var uploads []taskservice.Upload
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		var closeFiles func()
+		var ok bool
+		if uploads, closeFiles, ok = c.bindMultipart(ctx, &req); !ok {
+			return
+		}
+		defer closeFiles()
+	} else if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Message: err.Error(),
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
 		})
 		return
 	}
 
-	task, err := c.taskService.CreateTask(ctx.Request.Context(), req.Name)
+	opts := []taskmodel.Option{taskmodel.WithType(req.Type)}
+	if req.ProjectID != nil {
+		_, err := c.projectService.GetProject(ctx.Request.Context(), *req.ProjectID)
+		if errors.Is(err, projectmodel.ErrProjectNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   apierror.ProjectNotFound,
+				Message: i18n.T(ctx.Request.Context(), "Project not found"),
+			})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   apierror.InternalError,
+				Message: "Failed to retrieve project",
+			})
+			return
+		}
+		opts = append(opts, taskmodel.WithProject(*req.ProjectID))
+	}
+	if req.ExpiresAt != nil {
+		opts = append(opts, taskmodel.WithExpiresAt(*req.ExpiresAt))
+	}
+	if req.MaxRuntimeSeconds != nil {
+		opts = append(opts, taskmodel.WithMaxRuntime(time.Duration(*req.MaxRuntimeSeconds)*time.Second))
+	}
+	if req.DeleteAfterSeconds != nil {
+		opts = append(opts, taskmodel.WithDeleteAfter(time.Duration(*req.DeleteAfterSeconds)*time.Second))
+	}
+	if req.DedupKey != "" {
+		opts = append(opts, taskmodel.WithDedupKey(req.DedupKey))
+	}
+	if req.Priority != "" {
+		priority, _ := taskmodel.ParsePriority(req.Priority)
+		opts = append(opts, taskmodel.WithPriority(priority))
+	}
+	if req.SerialKey != "" {
+		opts = append(opts, taskmodel.WithSerialKey(req.SerialKey))
+	}
+	if req.Pool != "" {
+		opts = append(opts, taskmodel.WithPool(req.Pool))
+	}
+	if ctx.Query("unique") == "true" {
+		opts = append(opts, taskmodel.WithUniqueName())
+	}
+
+	c.createTask(ctx, req.Name, uploads, opts)
+}
+
+// createTask creates the task with uploads as its inputs and responds with
+// it, with the task it repeats, or with the reason it was not created.
+func (c *Controller) createTask(ctx *gin.Context, name string, uploads []taskservice.Upload, opts []taskmodel.Option) {
+	if !c.validateName(ctx, name) {
+		return
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx.Request.Context()); ok {
+		opts = append(opts, taskmodel.WithCreatedBy(principal.ID))
+	}
+
+	var task *taskmodel.Task
+	var err error
+	if len(uploads) > 0 {
+		task, err = c.taskService.CreateTaskWithInputs(ctx.Request.Context(), name, uploads, opts...)
+	} else {
+		task, err = c.taskService.CreateTask(ctx.Request.Context(), name, opts...)
+	}
+	if errors.Is(err, taskservice.ErrExpiryInPast) {
+		message := i18n.T(ctx.Request.Context(), "expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Request body has invalid fields"),
+			Fields:  []controllers.FieldError{{Field: "expires_at", Rule: "future", Message: message}},
+		})
+		return
+	}
+	var tooLong *taskservice.RuntimeTooLongError
+	if errors.As(err, &tooLong) {
+		limit := strconv.FormatInt(int64(tooLong.Max/time.Second), 10)
+		message := i18n.Sprintf(ctx.Request.Context(), "%s must be at most %s", "max_runtime_seconds", limit)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Request body has invalid fields"),
+			Fields:  []controllers.FieldError{{Field: "max_runtime_seconds", Rule: "max", Param: limit, Message: message}},
+		})
+		return
+	}
+	var unknownPool *taskservice.UnknownPoolError
+	if errors.As(err, &unknownPool) {
+		message := i18n.Sprintf(ctx.Request.Context(), "%s must be one of: %s", "pool", strings.Join(unknownPool.Pools, ", "))
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Request body has invalid fields"),
+			Fields:  []controllers.FieldError{{Field: "pool", Rule: "oneof", Param: strings.Join(unknownPool.Pools, " "), Message: message}},
+		})
+		return
+	}
+	var duplicate *taskservice.DuplicateTaskError
+	if errors.As(err, &duplicate) {
+		ctx.Header("Location", "/api/v1/task/"+duplicate.Task.ID.String())
+		ctx.JSON(http.StatusOK, c.mapTaskToResponse(ctx.Request.Context(), duplicate.Task))
+		return
+	}
+	var conflict *taskservice.NameConflictError
+	if errors.As(err, &conflict) {
+		ctx.JSON(http.StatusConflict, ErrorResponse{
+			Error:             apierror.TaskNameConflict,
+			Message:           i18n.Sprintf(ctx.Request.Context(), "Active task %s already has the name %q", conflict.Task.ID, conflict.Task.Name),
+			ConflictingTaskID: &conflict.Task.ID,
+		})
+		return
+	}
+	var overloaded *taskservice.OverloadedError
+	if errors.As(err, &overloaded) {
+		ctx.Header("Retry-After", apierror.RetryAfter(overloaded.RetryAfter))
+		ctx.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   apierror.Overloaded,
+			Message: "The service is overloaded, " + overloaded.Reason + "; retry later",
+		})
+		return
+	}
+	if errors.Is(err, taskservice.ErrDraining) {
+		ctx.Header("Retry-After", apierror.RetryAfter(drainRetryAfter))
+		ctx.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   apierror.ServiceDraining,
+			Message: "The service is draining before a restart and does not accept new tasks, retry against another instance",
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
+			Error:   apierror.InternalError,
 			Message: "Failed to create task",
 		})
 		return
 	}
 
-	response := c.mapTaskToResponse(task)
+	response := c.mapTaskToResponse(ctx.Request.Context(), task)
 	ctx.Header("Location", "/api/v1/task/"+task.ID.String())
 	ctx.JSON(http.StatusAccepted, response)
 }
 
-// GetTask godoc
-// @Summary      Get task info
-// @Description  Returns information about a task by its ID
-// @Tags         tasks
-// @Accept       json
-// @Produce      json
-// @Param        id path string true "Task ID (UUID)"
-// @Success      200 {object} TaskResponse "Task found"
-// @Failure      400 {object} ErrorResponse "Invalid ID format"
-// @Failure      404 {object} ErrorResponse "Task not found"
-// @Router       /task/{id} [get]
+// GetTask serves GET /task/{id}.
 func (c *Controller) GetTask(ctx *gin.Context) {
 	taskIDStr := ctx.Param("id")
 	if taskIDStr == "" {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "validation_error",
-			Message: "Missing task id",
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Missing task id"),
 		})
 		return
 	}
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_id",
-			Message: "Invalid task ID format",
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
 		})
 		return
 	}
 
 	task, err := c.taskService.GetTask(ctx.Request.Context(), taskID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "task_not_found",
-			Message: "Task not found",
-		})
+		c.taskError(ctx, err, "Failed to retrieve task")
 		return
 	}
 
-	response := c.mapTaskToResponse(task)
+	response := c.mapTaskToResponse(ctx.Request.Context(), task)
 	ctx.JSON(http.StatusOK, response)
 }
 
-// DeleteTask godoc
-// @Summary      Delete a task
-// @Description  Deletes a task by its ID
-// @Tags         tasks
-// @Accept       json
-// @Produce      json
-// @Param        id path string true "Task ID (UUID)"
-// @Success      204 "Task deleted"
-// @Failure      400 {object} ErrorResponse "Invalid ID format"
-// @Failure      404 {object} ErrorResponse "Task not found"
-// @Router       /task/{id} [delete]
+// DeleteTask serves DELETE /task/{id}.
 func (c *Controller) DeleteTask(ctx *gin.Context) {
 	taskIDStr := ctx.Param("id")
 	taskID, err := uuid.Parse(taskIDStr)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_id",
-			Message: "Invalid task ID format",
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
 		})
 		return
 	}
 
 	err = c.taskService.DeleteTask(ctx.Request.Context(), taskID)
 	if err != nil {
+		c.taskError(ctx, err, "Failed to delete task")
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// CancelTask serves POST /task/{id}/cancel.
+func (c *Controller) CancelTask(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+
+	// The body is optional: without one the task is cancelled without a
+	// reason.
+	var req CancelTaskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+
+	task, err := c.taskService.CancelTask(ctx.Request.Context(), taskID, req.Reason)
+	if errors.Is(err, taskservice.ErrTaskFinished) {
+		ctx.JSON(http.StatusConflict, ErrorResponse{
+			Error:   apierror.TaskFinished,
+			Message: i18n.T(ctx.Request.Context(), "Task has already finished"),
+		})
+		return
+	}
+	if err != nil {
+		c.taskError(ctx, err, "Failed to cancel task")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.mapTaskToResponse(ctx.Request.Context(), task))
+}
+
+// SetTaskPriority serves PATCH /task/{id}/priority.
+func (c *Controller) SetTaskPriority(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+
+	var req SetTaskPriorityRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return
+	}
+	priority, _ := taskmodel.ParsePriority(req.Priority)
+
+	task, err := c.taskService.SetTaskPriority(ctx.Request.Context(), taskID, priority)
+	if errors.Is(err, taskservice.ErrTaskNotWaiting) {
+		ctx.JSON(http.StatusConflict, ErrorResponse{
+			Error:   apierror.TaskNotWaiting,
+			Message: i18n.T(ctx.Request.Context(), "Task has already started"),
+		})
+		return
+	}
+	if err != nil {
+		c.taskError(ctx, err, "Failed to change task priority")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.mapTaskToResponse(ctx.Request.Context(), task))
+}
+
+// GetTaskEvents serves GET /task/{id}/events.
+func (c *Controller) GetTaskEvents(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+
+	events, err := c.taskService.TaskEvents(ctx.Request.Context(), taskID)
+	if err != nil {
+		c.taskError(ctx, err, "Failed to retrieve task events")
+		return
+	}
+
+	response := TaskEventsResponse{Events: make([]TaskEventResponse, 0, len(events))}
+	for _, event := range events {
+		item := TaskEventResponse{
+			Version:             event.Version,
+			Type:                event.Type,
+			At:                  i18n.In(ctx.Request.Context(), event.At),
+			ProcessingTime:      event.ProcessingTime,
+			ProcessingTimeMs:    event.ProcessingTime.Milliseconds(),
+			ProcessingTimeHuman: controllers.HumanDuration(event.ProcessingTime),
+		}
+		if !event.StartedAt.IsZero() {
+			startedAt := i18n.In(ctx.Request.Context(), event.StartedAt)
+			item.StartedAt = &startedAt
+		}
+		if !event.FinishedAt.IsZero() {
+			finishedAt := i18n.In(ctx.Request.Context(), event.FinishedAt)
+			item.FinishedAt = &finishedAt
+		}
+		if event.Type == taskmodel.EventPriorityChanged {
+			item.Priority = event.Priority.String()
+		}
+		if event.Attempt != nil {
+			attempt := mapAttemptToResponse(ctx.Request.Context(), *event.Attempt)
+			item.Attempt = &attempt
+		}
+		response.Events = append(response.Events, item)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTaskAttempts serves GET /task/{id}/attempts.
+func (c *Controller) GetTaskAttempts(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+
+	task, err := c.taskService.GetTask(ctx.Request.Context(), taskID)
+	if err != nil {
+		c.taskError(ctx, err, "Failed to retrieve task")
+		return
+	}
+
+	response := TaskAttemptsResponse{Attempts: make([]TaskAttemptResponse, len(task.Attempts))}
+	for i, attempt := range task.Attempts {
+		response.Attempts[i] = mapAttemptToResponse(ctx.Request.Context(), attempt)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func mapAttemptToResponse(ctx context.Context, attempt taskmodel.Attempt) TaskAttemptResponse {
+	response := TaskAttemptResponse{
+		Number:    attempt.Number,
+		StartedAt: i18n.In(ctx, attempt.StartedAt),
+		Outcome:   attempt.Outcome,
+		Error:     attempt.Error,
+	}
+	if !attempt.FinishedAt.IsZero() {
+		finishedAt := i18n.In(ctx, attempt.FinishedAt)
+		response.FinishedAt = &finishedAt
+	}
+	return response
+}
+
+// taskError responds to a failed request for a single task: a missing task
+// is reported as such, any other failure as an internal error.
+func (c *Controller) taskError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, taskmodel.ErrTaskNotFound) {
 		ctx.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "task_not_found",
-			Message: "Task not found",
+			Error:   apierror.TaskNotFound,
+			Message: i18n.T(ctx.Request.Context(), "Task not found"),
 		})
 		return
 	}
 
-	ctx.Status(http.StatusNoContent)
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   apierror.InternalError,
+		Message: message,
+	})
 }
 
-// ListTasks godoc
-// @Summary      List all tasks
-// @Description  Returns a list of all tasks
-// @Tags         tasks
-// @Accept       json
-// @Produce      json
-// @Success      200 {object} TaskListResponse "List of tasks"
-// @Failure      500 {object} ErrorResponse "Internal error"
-// @Router       /tasks [get]
+// ListTasks serves GET /tasks.
 func (c *Controller) ListTasks(ctx *gin.Context) {
-	tasks, err := c.taskService.ListTasks(ctx.Request.Context())
+	principal, authenticated := auth.PrincipalFromContext(ctx.Request.Context())
+	owner := ctx.Query("owner")
+
+	var filter taskmodel.Filter
+	switch {
+	case owner == "me":
+		if !authenticated {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   apierror.Unauthorized,
+				Message: i18n.T(ctx.Request.Context(), "owner=me requires an authenticated caller"),
+			})
+			return
+		}
+		filter.Owner = principal.ID
+	case owner != "":
+		if authenticated && !principal.Admin && owner != principal.ID {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   apierror.Forbidden,
+				Message: "Only admins can list tasks of other owners",
+			})
+			return
+		}
+		filter.Owner = owner
+	case ctx.Query("all") == "true":
+		if authenticated && !principal.Admin {
+			ctx.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   apierror.Forbidden,
+				Message: "Only admins can list all tasks",
+			})
+			return
+		}
+	case authenticated:
+		filter.Owner = principal.ID
+	}
+
+	switch createdBy := ctx.Query("created_by"); {
+	case createdBy == "me":
+		if !authenticated {
+			ctx.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   apierror.Unauthorized,
+				Message: i18n.T(ctx.Request.Context(), "created_by=me requires an authenticated caller"),
+			})
+			return
+		}
+		filter.CreatedBy = principal.ID
+	case createdBy != "":
+		filter.CreatedBy = createdBy
+	}
+
+	if projectID := ctx.Query("project_id"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   apierror.InvalidID,
+				Message: i18n.T(ctx.Request.Context(), "Invalid project ID format"),
+			})
+			return
+		}
+		filter.ProjectID = id
+	}
+
+	if ctx.Query("federated") == "true" {
+		c.listFederated(ctx, filter)
+		return
+	}
+
+	tasks, err := c.taskService.ListTasks(ctx.Request.Context(), filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
+			Error:   apierror.InternalError,
 			Message: "Failed to retrieve tasks",
 		})
 		return
@@ -206,18 +775,162 @@ func (c *Controller) ListTasks(ctx *gin.Context) {
 	}
 
 	for i, task := range tasks {
-		response.Tasks[i] = c.mapTaskToResponse(task)
+		response.Tasks[i] = c.mapTaskToResponse(ctx.Request.Context(), task)
 	}
 
 	ctx.JSON(http.StatusOK, response)
 }
 
-func (c *Controller) mapTaskToResponse(task *taskmodel.Task) TaskResponse {
-	return TaskResponse{
-		ID:             task.ID,
-		Name:           task.Name,
-		Status:         task.Status,
-		CreatedAt:      task.CreatedAt,
-		ProcessingTime: task.ProcessingTime,
+func (c *Controller) mapTaskToResponse(ctx context.Context, task *taskmodel.Task) TaskResponse {
+	var projectID *uuid.UUID
+	if task.ProjectID != uuid.Nil {
+		projectID = &task.ProjectID
+	}
+
+	response := TaskResponse{
+		ID:                  task.ID,
+		Name:                task.Name,
+		Type:                task.Type,
+		Owner:               task.Owner,
+		CreatedBy:           task.CreatedBy,
+		ProjectID:           projectID,
+		RequestID:           task.RequestID,
+		Status:              task.Status,
+		CreatedAt:           i18n.In(ctx, task.CreatedAt),
+		ProcessingTime:      task.ProcessingTime,
+		ProcessingTimeMs:    task.ProcessingTime.Milliseconds(),
+		ProcessingTimeHuman: controllers.HumanDuration(task.ProcessingTime),
+		MaxRuntimeSeconds:   int64(task.MaxRuntime / time.Second),
+		DeleteAfterSeconds:  int64(task.DeleteAfter / time.Second),
+		DedupKey:            task.DedupKey,
+		Priority:            task.Priority.String(),
+		SerialKey:           task.SerialKey,
+		Pool:                task.Pool,
+		Slow:                task.Slow,
+		Preemptions:         task.Preemptions,
+		FailureReason:       task.FailureReason,
+		CancelReason:        task.CancelReason,
+		Inputs:              mapAttachmentsToResponse(task.Inputs, nil),
+		Artifacts: mapAttachmentsToResponse(task.Artifacts, func(name string) string {
+			return "/api/v1/task/" + task.ID.String() + "/artifacts/" + url.PathEscape(name)
+		}),
+	}
+	if !task.ExpiresAt.IsZero() {
+		expiresAt := i18n.In(ctx, task.ExpiresAt)
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+// GetLatencyStats serves GET /tasks/stats/latency.
+func (c *Controller) GetLatencyStats(ctx *gin.Context) {
+	window, ok := durationQuery(ctx, "window", defaultLatencyWindow)
+	if !ok {
+		return
+	}
+
+	taskType := ctx.Query("type")
+	stats, err := c.taskService.LatencyStats(ctx.Request.Context(), window, taskType)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to compute latency statistics",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, LatencyStatsResponse{
+		WindowSeconds: stats.Window.Seconds(),
+		Type:          taskType,
+		Count:         stats.Count,
+		P50Seconds:    stats.P50.Seconds(),
+		P90Seconds:    stats.P90.Seconds(),
+		P99Seconds:    stats.P99.Seconds(),
+	})
+}
+
+// GetDurationEstimates serves GET /tasks/stats/durations.
+func (c *Controller) GetDurationEstimates(ctx *gin.Context) {
+	estimates := c.taskService.DurationEstimates()
+	response := DurationEstimatesResponse{
+		Types: make([]DurationEstimateResponse, 0, len(estimates)),
+	}
+	for _, taskType := range slices.Sorted(maps.Keys(estimates)) {
+		expected := estimates[taskType]
+		response.Types = append(response.Types, DurationEstimateResponse{
+			Type:        taskType,
+			Count:       expected.Count,
+			MeanSeconds: expected.Mean.Seconds(),
+			P50Seconds:  expected.P50.Seconds(),
+			P90Seconds:  expected.P90.Seconds(),
+			P99Seconds:  expected.P99.Seconds(),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTimeseries serves GET /tasks/stats/timeseries.
+func (c *Controller) GetTimeseries(ctx *gin.Context) {
+	bucket, ok := durationQuery(ctx, "bucket", defaultTimeseriesBucket)
+	if !ok {
+		return
 	}
+	period, ok := durationQuery(ctx, "period", defaultTimeseriesPeriod)
+	if !ok {
+		return
+	}
+
+	if period/bucket > maxTimeseriesBuckets {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.T(ctx.Request.Context(), "Too many buckets, use a larger bucket or a shorter period"),
+		})
+		return
+	}
+
+	buckets, err := c.taskService.Throughput(ctx.Request.Context(), period, bucket)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to compute task throughput",
+		})
+		return
+	}
+
+	response := TimeseriesResponse{
+		BucketSeconds: bucket.Seconds(),
+		PeriodSeconds: period.Seconds(),
+		Buckets:       make([]TimeseriesBucketResponse, len(buckets)),
+	}
+	for i, b := range buckets {
+		response.Buckets[i] = TimeseriesBucketResponse{
+			Start:     i18n.In(ctx.Request.Context(), b.Start),
+			Created:   b.Created,
+			Completed: b.Completed,
+			Failed:    b.Failed,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// durationQuery parses a positive duration query parameter, responding 400
+// and returning false when it is invalid.
+func durationQuery(ctx *gin.Context, name string, defaultValue time.Duration) (time.Duration, bool) {
+	value := ctx.Query(name)
+	if value == "" {
+		return defaultValue, true
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: i18n.Sprintf(ctx.Request.Context(), "Parameter %s must be a positive duration, e.g. 1h or 30m", name),
+		})
+		return 0, false
+	}
+
+	return parsed, true
 }
@@ -0,0 +1,140 @@
+package taskcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/federation"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+const (
+	defaultFederatedLimit = 100
+	maxFederatedLimit     = 1000
+)
+
+// Peers queries the API of other instances.
+type Peers interface {
+	Get(ctx context.Context, path string, query url.Values) []federation.Response
+}
+
+// Option customizes a Controller.
+type Option func(*Controller)
+
+// WithFederation lets GET /tasks?federated=true aggregate the tasks of
+// peers; the tasks of this instance are tagged with source.
+func WithFederation(source string, peers Peers) Option {
+	return func(c *Controller) {
+		c.source = source
+		c.peers = peers
+	}
+}
+
+// listFederated responds with the tasks matching filter on this instance
+// and on every peer, newest first, paginated by the limit and offset query
+// parameters. Peers that fail are reported instead of failing the request.
+func (c *Controller) listFederated(ctx *gin.Context, filter taskmodel.Filter) {
+	limit, ok := intQuery(ctx, "limit", defaultFederatedLimit, maxFederatedLimit)
+	if !ok {
+		return
+	}
+	offset, ok := intQuery(ctx, "offset", 0, -1)
+	if !ok {
+		return
+	}
+
+	tasks, err := c.taskService.ListTasks(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to retrieve tasks",
+		})
+		return
+	}
+	merged := make([]TaskResponse, len(tasks))
+	for i, task := range tasks {
+		merged[i] = c.mapTaskToResponse(ctx.Request.Context(), task)
+		merged[i].Source = c.source
+	}
+
+	// Peers get the filter resolved here, as the caller's identity is not
+	// forwarded to them.
+	query := url.Values{}
+	if filter.Owner != "" {
+		query.Set("owner", filter.Owner)
+	} else {
+		query.Set("all", "true")
+	}
+	if filter.CreatedBy != "" {
+		query.Set("created_by", filter.CreatedBy)
+	}
+	if filter.ProjectID != uuid.Nil {
+		query.Set("project_id", filter.ProjectID.String())
+	}
+
+	unavailable := []string{}
+	if c.peers != nil {
+		for _, response := range c.peers.Get(ctx.Request.Context(), "/api/v1/tasks", query) {
+			var list TaskListResponse
+			if response.Err == nil {
+				response.Err = json.Unmarshal(response.Body, &list)
+			}
+			if response.Err != nil {
+				unavailable = append(unavailable, response.Peer)
+				continue
+			}
+			for _, task := range list.Tasks {
+				task.CreatedAt = i18n.In(ctx.Request.Context(), task.CreatedAt)
+				task.Source = response.Peer
+				merged = append(merged, task)
+			}
+		}
+	}
+
+	slices.SortFunc(merged, func(a, b TaskResponse) int {
+		if order := b.CreatedAt.Compare(a.CreatedAt); order != 0 {
+			return order
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+
+	total := len(merged)
+	page := merged[min(offset, total):min(offset+limit, total)]
+	ctx.JSON(http.StatusOK, TaskListResponse{
+		Tasks:       page,
+		Total:       &total,
+		Unavailable: unavailable,
+	})
+}
+
+// intQuery parses a non-negative integer query parameter of at most max
+// (unbounded when negative), responding 400 and returning false when it is
+// invalid.
+func intQuery(ctx *gin.Context, name string, fallback, max int) (int, bool) {
+	raw := ctx.Query(name)
+	if raw == "" {
+		return fallback, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 || (max >= 0 && value > max) {
+		message := "Parameter " + name + " must be a non-negative integer"
+		if max >= 0 {
+			message += " not greater than " + strconv.Itoa(max)
+		}
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+		})
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,208 @@
+package taskcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/tasklog"
+)
+
+const (
+	defaultLogLimit = 100
+	maxLogLimit     = 1000
+)
+
+// TaskLogLineResponse represents one line logged about a task.
+// Log line; seq numbers the lines of the task from 0.
+type TaskLogLineResponse struct {
+	Seq     int               `json:"seq"`
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level" enums:"DEBUG,INFO,WARN,ERROR"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// TaskLogsResponse represents a page of the log of a task.
+// Log lines of the task, oldest first. Total counts every line logged, including the ones no longer kept.
+type TaskLogsResponse struct {
+	Lines []TaskLogLineResponse `json:"lines"`
+	Total int                   `json:"total"`
+	// NextOffset requests the page following this one.
+	NextOffset int `json:"next_offset"`
+}
+
+// TaskLogEndEvent is the data of the end event closing a log stream.
+// Status of the task once it has finished; empty when the task was deleted.
+type TaskLogEndEvent struct {
+	Status taskmodel.TaskStatus `json:"status,omitempty"`
+}
+
+// TaskLogShutdownEvent is the data of the shutdown event closing a log
+// stream because the server shuts down or restarts; the client reconnects,
+// with Last-Event-ID, to resume.
+type TaskLogShutdownEvent struct{}
+
+// WithShutdown ends the open log streams with a shutdown event once
+// shutdown is closed, so that they do not hold up the shutdown of the
+// server until their tasks finish.
+func WithShutdown(shutdown <-chan struct{}) Option {
+	return func(c *Controller) {
+		c.shutdown = shutdown
+	}
+}
+
+// GetTaskLogs serves GET /task/{id}/logs.
+func (c *Controller) GetTaskLogs(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+	limit, ok := intQuery(ctx, "limit", defaultLogLimit, maxLogLimit)
+	if !ok {
+		return
+	}
+	offset, ok := intQuery(ctx, "offset", 0, -1)
+	if !ok {
+		return
+	}
+
+	lines, total, err := c.taskService.TaskLogs(ctx.Request.Context(), taskID, offset, limit)
+	if err != nil {
+		c.taskError(ctx, err, "Failed to retrieve task logs")
+		return
+	}
+
+	response := TaskLogsResponse{
+		Lines:      make([]TaskLogLineResponse, len(lines)),
+		Total:      total,
+		NextOffset: offset,
+	}
+	for i, line := range lines {
+		response.Lines[i] = mapLogLineToResponse(ctx, line)
+	}
+	if len(lines) > 0 {
+		response.NextOffset = lines[len(lines)-1].Seq + 1
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// StreamTaskLogs serves GET /task/{id}/logs/stream as server-sent events:
+// a log event per line, with the line number as event ID, and an end event
+// once the task has finished, or a shutdown event once the server shuts
+// down. A reconnecting client resumes after the line in its Last-Event-ID
+// header.
+func (c *Controller) StreamTaskLogs(ctx *gin.Context) {
+	taskID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid task ID format"),
+		})
+		return
+	}
+	offset, ok := intQuery(ctx, "offset", 0, -1)
+	if !ok {
+		return
+	}
+	if lastID, err := strconv.Atoi(ctx.GetHeader("Last-Event-ID")); err == nil && lastID >= 0 {
+		offset = lastID + 1
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.shutdown:
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	err = c.taskService.FollowTaskLogs(streamCtx, taskID, offset, func(lines []tasklog.Line) error {
+		startEventStream(ctx)
+		for _, line := range lines {
+			if err := writeEvent(ctx, "log", strconv.Itoa(line.Seq), mapLogLineToResponse(ctx, line)); err != nil {
+				return err
+			}
+		}
+		ctx.Writer.Flush()
+		return nil
+	})
+	if err != nil && ctx.Request.Context().Err() == nil && streamCtx.Err() != nil {
+		// The server shuts down while the task is still running.
+		startEventStream(ctx)
+		_ = writeEvent(ctx, "shutdown", "", TaskLogShutdownEvent{})
+		ctx.Writer.Flush()
+		return
+	}
+	if err != nil {
+		if !ctx.Writer.Written() && ctx.Request.Context().Err() == nil {
+			c.taskError(ctx, err, "Failed to retrieve task logs")
+		}
+		return
+	}
+
+	var end TaskLogEndEvent
+	if task, err := c.taskService.GetTask(ctx.Request.Context(), taskID); err == nil {
+		end.Status = task.Status
+	}
+	startEventStream(ctx)
+	_ = writeEvent(ctx, "end", "", end)
+	ctx.Writer.Flush()
+}
+
+// startEventStream responds with a stream of server-sent events unless the
+// response has started already.
+func startEventStream(ctx *gin.Context) {
+	if ctx.Writer.Written() {
+		return
+	}
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	// Proxies such as nginx would buffer the events otherwise.
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+}
+
+// writeEvent writes a server-sent event with data encoded as JSON.
+func writeEvent(ctx *gin.Context, event, id string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(ctx.Writer, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(ctx.Writer, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
+
+func mapLogLineToResponse(ctx *gin.Context, line tasklog.Line) TaskLogLineResponse {
+	response := TaskLogLineResponse{
+		Seq:     line.Seq,
+		Time:    i18n.In(ctx.Request.Context(), line.Time),
+		Level:   line.Level,
+		Message: line.Message,
+	}
+	if len(line.Attrs) > 0 {
+		response.Attrs = line.Attrs
+	}
+	return response
+}
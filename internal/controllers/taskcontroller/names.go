@@ -0,0 +1,111 @@
+package taskcontroller
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// NameRules restrict the names of created tasks, whether they come with the
+// request or from a template. Rules with a pattern or charset are made with
+// NewNameRules.
+type NameRules struct {
+	// MinLength and MaxLength bound the name in characters.
+	MinLength int
+	MaxLength int
+	// Pattern, when set, is a regexp matching the whole name.
+	Pattern string
+	// Charset, when set, lists the characters a name may consist of as the
+	// body of a regexp character class, e.g. "a-z0-9-".
+	Charset string
+	// ReservedPrefixes are kept for names the operators choose.
+	ReservedPrefixes []string
+
+	pattern *regexp.Regexp
+	charset *regexp.Regexp
+}
+
+// DefaultNameRules allows names of 1 to 100 characters.
+var DefaultNameRules = NameRules{MinLength: 1, MaxLength: 100}
+
+// NewNameRules compiles the pattern and charset of the rules; empty ones
+// allow any name.
+func NewNameRules(minLength, maxLength int, pattern, charset string, reservedPrefixes []string) (NameRules, error) {
+	rules := NameRules{
+		MinLength:        minLength,
+		MaxLength:        maxLength,
+		Pattern:          pattern,
+		Charset:          charset,
+		ReservedPrefixes: reservedPrefixes,
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return NameRules{}, fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+		rules.pattern = re
+	}
+	if charset != "" {
+		re, err := regexp.Compile(`^[` + charset + `]*$`)
+		if err != nil {
+			return NameRules{}, fmt.Errorf("invalid name charset %q: %w", charset, err)
+		}
+		rules.charset = re
+	}
+	return rules, nil
+}
+
+// WithNameRules replaces DefaultNameRules.
+func WithNameRules(rules NameRules) Option {
+	return func(c *Controller) {
+		c.nameRules = rules
+	}
+}
+
+// validateName responds with the rule name breaks, if any.
+func (c *Controller) validateName(ctx *gin.Context, name string) bool {
+	rules := c.nameRules
+	requestCtx := ctx.Request.Context()
+
+	var field controllers.FieldError
+	length := utf8.RuneCountInString(name)
+	switch {
+	case length < rules.MinLength:
+		param := strconv.Itoa(rules.MinLength)
+		field = controllers.FieldError{Rule: "min", Param: param, Message: i18n.Sprintf(requestCtx, "%s must be at least %s characters long", "name", param)}
+	case rules.MaxLength > 0 && length > rules.MaxLength:
+		param := strconv.Itoa(rules.MaxLength)
+		field = controllers.FieldError{Rule: "max", Param: param, Message: i18n.Sprintf(requestCtx, "%s must be at most %s characters long", "name", param)}
+	case rules.charset != nil && !rules.charset.MatchString(name):
+		field = controllers.FieldError{Rule: "charset", Param: rules.Charset, Message: i18n.Sprintf(requestCtx, "%s may only contain the characters %s", "name", rules.Charset)}
+	case rules.pattern != nil && !rules.pattern.MatchString(name):
+		field = controllers.FieldError{Rule: "pattern", Param: rules.Pattern, Message: i18n.Sprintf(requestCtx, "%s must match %s", "name", rules.Pattern)}
+	default:
+		for _, prefix := range rules.ReservedPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				field = controllers.FieldError{Rule: "reserved_prefix", Param: prefix, Message: i18n.Sprintf(requestCtx, "%s must not start with the reserved prefix %q", "name", prefix)}
+				break
+			}
+		}
+	}
+	if field.Rule == "" {
+		return true
+	}
+
+	field.Field = "name"
+	ctx.JSON(http.StatusBadRequest, ErrorResponse{
+		Error:   apierror.ValidationError,
+		Message: i18n.T(requestCtx, "Request body has invalid fields"),
+		Fields:  []controllers.FieldError{field},
+	})
+	return false
+}
@@ -0,0 +1,245 @@
+package taskcontroller
+
+import (
+	"net/http"
+
+	"github.com/nzb3/workmate_test/internal/openapi"
+)
+
+// DescribeRoutes documents the handlers of the controller in spec.
+func (c *Controller) DescribeRoutes(spec *openapi.Spec) {
+	spec.Describe(c.CreateTask, openapi.Operation{
+		Summary:     "Create a new task",
+		Description: "Creates a new task with the specified name, which must satisfy the name rules of the server. To upload input files with the task, send multipart/form-data with the JSON request in the task field and the files in attachments fields. While the server has a dedup window, submitting a task with the dedup_key (or name) of a task the caller created within the window returns that task with 200 instead of creating another. With unique=true, or unique names enforced by the server, the task is rejected with 409 while another active task of the caller has its name",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "unique", In: openapi.InQuery, Type: "boolean", Description: "Reject the task while another active task of the caller has its name"},
+		},
+		Request: CreateTaskRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Repeated submission, the task created before", Body: TaskResponse{}, Headers: map[string]string{"Location": "Location of the existing task"}},
+			{Status: http.StatusAccepted, Description: "Task accepted for processing", Body: TaskResponse{}, Headers: map[string]string{"Location": "Location of the created task"}},
+			{Status: http.StatusBadRequest, Description: "Invalid input", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusConflict, Description: "Another active task has the unique name", Body: ErrorResponse{}},
+			{Status: http.StatusRequestEntityTooLarge, Description: "Multipart request exceeds the upload limit", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+			{Status: http.StatusServiceUnavailable, Description: "Service is draining, in maintenance or overloaded", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.CreateTaskFromTemplate, openapi.Operation{
+		Summary:     "Create a task from a template",
+		Description: "Creates a task with the definition of a task template, naming it after the name pattern of the template",
+		Tags:        []string{"tasks", "templates"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Template ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusAccepted, Description: "Task accepted for processing", Body: TaskResponse{}, Headers: map[string]string{"Location": "Location of the created task"}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format or the template no longer fits the limits of the server", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Template not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+			{Status: http.StatusServiceUnavailable, Description: "Service is draining, in maintenance or overloaded", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTask, openapi.Operation{
+		Summary:     "Get task info",
+		Description: "Returns information about a task by its ID",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task found", Body: TaskResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.DeleteTask, openapi.Operation{
+		Summary:     "Delete a task",
+		Description: "Deletes a task by its ID",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusNoContent, Description: "Task deleted"},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.CancelTask, openapi.Operation{
+		Summary:     "Cancel a task",
+		Description: "Stops a task that has not finished and keeps it with status CANCELLED, unlike deleting it. The body, giving the reason for the cancellation, is optional",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+		},
+		Request: CancelTaskRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task cancelled", Body: TaskResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format or request body", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusConflict, Description: "Task has already finished", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.SetTaskPriority, openapi.Operation{
+		Summary:     "Change the priority of a queued task",
+		Description: "Moves a task waiting for a worker within the queue: tasks of higher priority start first, tasks of equal priority in creation order",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+		},
+		Request: SetTaskPriorityRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task with its new priority", Body: TaskResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format or priority", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusConflict, Description: "Task has already started", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTaskEvents, openapi.Operation{
+		Summary:     "Get task history",
+		Description: "Returns the events of the task, oldest first. Consecutive progress updates are compacted into the latest one",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task history", Body: TaskEventsResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTaskAttempts, openapi.Operation{
+		Summary:     "Get task attempts",
+		Description: "Returns every execution attempt of the task with its start and end times, outcome and error, oldest first",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task attempts", Body: TaskAttemptsResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTaskLogs, openapi.Operation{
+		Summary:     "Get task logs",
+		Description: "Returns the lines logged while creating and executing the task, oldest first, including debug lines. Only the last lines of a task are kept, in memory of the instance that executed it",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+			{Name: "limit", In: openapi.InQuery, Type: "integer", Default: 100, Maximum: 1000, Description: "Page size"},
+			{Name: "offset", In: openapi.InQuery, Type: "integer", Default: 0, Description: "Sequence number of the first line; next_offset of the previous page"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task logs", Body: TaskLogsResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format or page", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.StreamTaskLogs, openapi.Operation{
+		Summary:     "Stream task logs",
+		Description: "Streams the lines logged about the task as server-sent events (text/event-stream) as they are logged: a log event per line with the TaskLogLineResponse as data and the line number as ID, then an end event with the final status of the task, after which the stream closes. When the server shuts down or restarts first, the stream closes with a shutdown event instead and the client reconnects. A Last-Event-ID header resumes after that line",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+			{Name: "offset", In: openapi.InQuery, Type: "integer", Default: 0, Description: "Sequence number of the first line"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Stream of log events closed by an end or shutdown event"},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format or offset", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTaskArtifact, openapi.Operation{
+		Summary:     "Download a task artifact",
+		Description: "Returns the content of a file the task produced once it completed, such as result.json",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Task ID (UUID)"},
+			{Name: "name", In: openapi.InPath, Description: "Artifact name"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Artifact content"},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Task or artifact not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.ListTasks, openapi.Operation{
+		Summary:     "List tasks",
+		Description: "Returns a list of tasks. Authenticated callers see only their own tasks unless an admin asks for all of them",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "owner", In: openapi.InQuery, Description: "Owner identity, \"me\" for the caller's own tasks"},
+			{Name: "all", In: openapi.InQuery, Type: "boolean", Description: "List tasks of every owner (admins only)"},
+			{Name: "created_by", In: openapi.InQuery, Description: "Only tasks created by the identity, \"me\" for the ones the caller created"},
+			{Name: "project_id", In: openapi.InQuery, Description: "Only tasks of the project (UUID)"},
+			{Name: "federated", In: openapi.InQuery, Type: "boolean", Description: "Also list the tasks of the peer instances, newest first, tagged with their source"},
+			{Name: "limit", In: openapi.InQuery, Type: "integer", Default: 100, Maximum: 1000, Description: "Page size of a federated listing"},
+			{Name: "offset", In: openapi.InQuery, Type: "integer", Default: 0, Description: "Tasks of a federated listing to skip"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "List of tasks", Body: TaskListResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid project ID or page", Body: ErrorResponse{}},
+			{Status: http.StatusUnauthorized, Description: "owner=me or created_by=me without authentication", Body: ErrorResponse{}},
+			{Status: http.StatusForbidden, Description: "Listing other owners' tasks is not allowed", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetLatencyStats, openapi.Operation{
+		Summary:     "Get processing-time percentiles",
+		Description: "Returns p50/p90/p99 processing times of tasks completed within the window, to estimate how long new tasks will take",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "window", In: openapi.InQuery, Default: "24h", Description: "Window as a Go duration, e.g. 1h or 30m"},
+			{Name: "type", In: openapi.InQuery, Description: "Only tasks of the type"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Processing-time percentiles", Body: LatencyStatsResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid window", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetDurationEstimates, openapi.Operation{
+		Summary:     "Get expected durations per task type",
+		Description: "Returns the mean and percentiles of the processing times of the recent completed tasks of every type; queue ETAs use the median",
+		Tags:        []string{"tasks"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Expected durations", Body: DurationEstimatesResponse{}},
+		},
+	})
+	spec.Describe(c.GetDispatchOrder, openapi.Operation{
+		Summary:     "Preview the dispatch order",
+		Description: "Returns the tasks waiting for a worker of this instance in exactly the order the workers will take them up, and the rules that order them. Callers other than admins only see their own tasks, with their positions among all waiting tasks. Tasks still in an external queue are not included",
+		Tags:        []string{"tasks"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Waiting tasks in dispatch order", Body: DispatchOrderResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTimeseries, openapi.Operation{
+		Summary:     "Get task throughput time series",
+		Description: "Returns the number of created, completed and failed tasks per time bucket over the period",
+		Tags:        []string{"tasks"},
+		Params: []openapi.Param{
+			{Name: "bucket", In: openapi.InQuery, Default: "1h", Description: "Bucket size as a Go duration"},
+			{Name: "period", In: openapi.InQuery, Default: "24h", Description: "Covered period as a Go duration"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Task counts per bucket", Body: TimeseriesResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid bucket or period", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+}
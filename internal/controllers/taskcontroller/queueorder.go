@@ -0,0 +1,72 @@
+package taskcontroller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/auth"
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// DispatchEntryResponse represents a task waiting for a worker.
+// Waiting task with its position in the dispatch order of its worker pool; sequence is the order it was queued in, which breaks ties between tasks of equal priority and orders the tasks sharing a serial key.
+type DispatchEntryResponse struct {
+	Position  int       `json:"position"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Pool      string    `json:"pool"`
+	Owner     string    `json:"owner,omitempty"`
+	Priority  string    `json:"priority" enums:"low,normal,high,critical"`
+	SerialKey string    `json:"serial_key,omitempty"`
+	Sequence  uint64    `json:"sequence"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DispatchOrderResponse represents the order waiting tasks get a worker in.
+// Waiting tasks in dispatch order and the rules that order them, the first rule deciding first. Callers other than admins only see their own tasks, with their positions among all waiting tasks of their pool.
+type DispatchOrderResponse struct {
+	Rules []string                `json:"rules"`
+	Tasks []DispatchEntryResponse `json:"tasks"`
+}
+
+// GetDispatchOrder serves GET /tasks/queue-order.
+func (c *Controller) GetDispatchOrder(ctx *gin.Context) {
+	order, err := c.taskService.DispatchOrder(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to inspect the dispatch order",
+		})
+		return
+	}
+
+	principal, authenticated := auth.PrincipalFromContext(ctx.Request.Context())
+	response := DispatchOrderResponse{
+		Rules: c.taskService.DispatchRules(),
+		Tasks: make([]DispatchEntryResponse, 0, len(order)),
+	}
+	for _, entry := range order {
+		if authenticated && !principal.Admin && entry.Task.Owner != principal.ID {
+			continue
+		}
+		response.Tasks = append(response.Tasks, DispatchEntryResponse{
+			Position:  entry.Position,
+			ID:        entry.Task.ID,
+			Name:      entry.Task.Name,
+			Type:      entry.Task.Type,
+			Pool:      entry.Task.Pool,
+			Owner:     entry.Task.Owner,
+			Priority:  entry.Task.Priority.String(),
+			SerialKey: entry.Task.SerialKey,
+			Sequence:  entry.Sequence,
+			CreatedAt: i18n.In(ctx.Request.Context(), entry.Task.CreatedAt),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
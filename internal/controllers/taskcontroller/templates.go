@@ -0,0 +1,71 @@
+package taskcontroller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/models/templatemodel"
+)
+
+// TemplateService numbers the tasks created from task templates.
+type TemplateService interface {
+	Instantiate(ctx context.Context, templateID uuid.UUID) (*templatemodel.Template, string, error)
+}
+
+// WithTemplates enables POST /task/from-template/{id}.
+func WithTemplates(templates TemplateService) Option {
+	return func(c *Controller) {
+		c.templates = templates
+	}
+}
+
+// CreateTaskFromTemplate serves POST /task/from-template/{id}.
+func (c *Controller) CreateTaskFromTemplate(ctx *gin.Context) {
+	templateID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid template ID format"),
+		})
+		return
+	}
+
+	template, name, err := c.templates.Instantiate(ctx.Request.Context(), templateID)
+	if errors.Is(err, templatemodel.ErrTemplateNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   apierror.TemplateNotFound,
+			Message: i18n.T(ctx.Request.Context(), "Template not found"),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to retrieve template",
+		})
+		return
+	}
+
+	opts := []taskmodel.Option{
+		taskmodel.WithType(template.Type),
+		taskmodel.WithPriority(template.Priority),
+	}
+	if template.ProjectID != uuid.Nil {
+		opts = append(opts, taskmodel.WithProject(template.ProjectID))
+	}
+	if template.MaxRuntime > 0 {
+		opts = append(opts, taskmodel.WithMaxRuntime(template.MaxRuntime))
+	}
+	if template.DeleteAfter > 0 {
+		opts = append(opts, taskmodel.WithDeleteAfter(template.DeleteAfter))
+	}
+
+	c.createTask(ctx, name, nil, opts)
+}
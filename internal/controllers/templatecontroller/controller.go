@@ -0,0 +1,287 @@
+package templatecontroller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/controllers"
+	"github.com/nzb3/workmate_test/internal/i18n"
+	"github.com/nzb3/workmate_test/internal/models/projectmodel"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/models/templatemodel"
+)
+
+type TemplateService interface {
+	CreateTemplate(ctx context.Context, opts ...templatemodel.Option) (*templatemodel.Template, error)
+	GetTemplate(ctx context.Context, templateID uuid.UUID) (*templatemodel.Template, error)
+	UpdateTemplate(ctx context.Context, templateID uuid.UUID, opts ...templatemodel.Option) (*templatemodel.Template, error)
+	DeleteTemplate(ctx context.Context, templateID uuid.UUID) error
+	ListTemplates(ctx context.Context) ([]*templatemodel.Template, error)
+}
+
+type ProjectService interface {
+	GetProject(ctx context.Context, projectID uuid.UUID) (*projectmodel.Project, error)
+}
+
+// TemplateRequest represents a request to create or update a task template.
+type TemplateRequest struct {
+	// NamePattern is the name of the created tasks; {date}, {time} and {seq}
+	// are replaced with the UTC date and time of creation and the number of
+	// the task created from the template.
+	NamePattern        string     `json:"name_pattern" binding:"required,min=1,max=100" example:"Nightly report {date}"`
+	Type               string     `json:"type" binding:"omitempty,max=50"`
+	ProjectID          *uuid.UUID `json:"project_id"`
+	MaxRuntimeSeconds  *int64     `json:"max_runtime_seconds,omitempty" binding:"omitempty,min=1"`
+	DeleteAfterSeconds *int64     `json:"delete_after_seconds,omitempty" binding:"omitempty,min=1"`
+	// Priority is given to the created tasks.
+	Priority string `json:"priority,omitempty" binding:"omitempty,oneof=low normal high critical" enums:"low,normal,high,critical"`
+}
+
+// TemplateResponse represents a response with task template information.
+type TemplateResponse struct {
+	ID                 uuid.UUID  `json:"id"`
+	NamePattern        string     `json:"name_pattern"`
+	Type               string     `json:"type,omitempty"`
+	ProjectID          *uuid.UUID `json:"project_id,omitempty"`
+	MaxRuntimeSeconds  int64      `json:"max_runtime_seconds,omitempty"`
+	DeleteAfterSeconds int64      `json:"delete_after_seconds,omitempty"`
+	Priority           string     `json:"priority" enums:"low,normal,high,critical"`
+	// Instances is the number of tasks created from the template.
+	Instances int       `json:"instances"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TemplateListResponse represents a response with a list of task templates.
+type TemplateListResponse struct {
+	Templates []TemplateResponse `json:"templates"`
+}
+
+// ErrorResponse represents an error response.
+type ErrorResponse struct {
+	Error   apierror.Code `json:"error"`
+	Message string        `json:"message,omitempty"`
+	// Fields lists the invalid fields of a request body.
+	Fields []controllers.FieldError `json:"fields,omitempty"`
+}
+
+type Controller struct {
+	templateService TemplateService
+	projectService  ProjectService
+}
+
+func NewController(templateService TemplateService, projectService ProjectService) *Controller {
+	return &Controller{
+		templateService: templateService,
+		projectService:  projectService,
+	}
+}
+
+func (c *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	templates := router.Group("/templates")
+	{
+		templates.GET("", c.ListTemplates)
+	}
+	template := router.Group("/template")
+	{
+		template.POST("/create", c.CreateTemplate)
+		template.GET("/:id", c.GetTemplate)
+		template.PUT("/:id", c.UpdateTemplate)
+		template.DELETE("/:id", c.DeleteTemplate)
+	}
+}
+
+// CreateTemplate serves POST /template/create.
+func (c *Controller) CreateTemplate(ctx *gin.Context) {
+	opts, ok := c.bindTemplate(ctx)
+	if !ok {
+		return
+	}
+
+	template, err := c.templateService.CreateTemplate(ctx.Request.Context(), opts...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to create template",
+		})
+		return
+	}
+
+	ctx.Header("Location", "/api/v1/template/"+template.ID.String())
+	ctx.JSON(http.StatusCreated, mapTemplateToResponse(ctx.Request.Context(), template))
+}
+
+// GetTemplate serves GET /template/{id}.
+func (c *Controller) GetTemplate(ctx *gin.Context) {
+	templateID, ok := c.parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+
+	template, err := c.templateService.GetTemplate(ctx.Request.Context(), templateID)
+	if err != nil {
+		c.templateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapTemplateToResponse(ctx.Request.Context(), template))
+}
+
+// UpdateTemplate serves PUT /template/{id}.
+func (c *Controller) UpdateTemplate(ctx *gin.Context) {
+	templateID, ok := c.parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+	opts, ok := c.bindTemplate(ctx)
+	if !ok {
+		return
+	}
+
+	template, err := c.templateService.UpdateTemplate(ctx.Request.Context(), templateID, opts...)
+	if err != nil {
+		c.templateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapTemplateToResponse(ctx.Request.Context(), template))
+}
+
+// DeleteTemplate serves DELETE /template/{id}.
+func (c *Controller) DeleteTemplate(ctx *gin.Context) {
+	templateID, ok := c.parseTemplateID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.templateService.DeleteTemplate(ctx.Request.Context(), templateID); err != nil {
+		c.templateError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListTemplates serves GET /templates.
+func (c *Controller) ListTemplates(ctx *gin.Context) {
+	templates, err := c.templateService.ListTemplates(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   apierror.InternalError,
+			Message: "Failed to retrieve templates",
+		})
+		return
+	}
+
+	response := TemplateListResponse{
+		Templates: make([]TemplateResponse, len(templates)),
+	}
+	for i, template := range templates {
+		response.Templates[i] = mapTemplateToResponse(ctx.Request.Context(), template)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// bindTemplate reads the template definition from the request body,
+// responding with an error when it is invalid or names a missing project.
+func (c *Controller) bindTemplate(ctx *gin.Context) ([]templatemodel.Option, bool) {
+	var req TemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		message, fields := controllers.DescribeBindingError(ctx.Request.Context(), err)
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.ValidationError,
+			Message: message,
+			Fields:  fields,
+		})
+		return nil, false
+	}
+
+	opts := []templatemodel.Option{
+		templatemodel.WithNamePattern(req.NamePattern),
+		templatemodel.WithType(req.Type),
+	}
+	if req.ProjectID != nil {
+		_, err := c.projectService.GetProject(ctx.Request.Context(), *req.ProjectID)
+		if errors.Is(err, projectmodel.ErrProjectNotFound) {
+			ctx.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   apierror.ProjectNotFound,
+				Message: i18n.T(ctx.Request.Context(), "Project not found"),
+			})
+			return nil, false
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   apierror.InternalError,
+				Message: "Failed to retrieve project",
+			})
+			return nil, false
+		}
+		opts = append(opts, templatemodel.WithProject(*req.ProjectID))
+	}
+	if req.MaxRuntimeSeconds != nil {
+		opts = append(opts, templatemodel.WithMaxRuntime(time.Duration(*req.MaxRuntimeSeconds)*time.Second))
+	}
+	if req.DeleteAfterSeconds != nil {
+		opts = append(opts, templatemodel.WithDeleteAfter(time.Duration(*req.DeleteAfterSeconds)*time.Second))
+	}
+	if req.Priority != "" {
+		priority, _ := taskmodel.ParsePriority(req.Priority)
+		opts = append(opts, templatemodel.WithPriority(priority))
+	}
+	return opts, true
+}
+
+func (c *Controller) parseTemplateID(ctx *gin.Context) (uuid.UUID, bool) {
+	templateID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   apierror.InvalidID,
+			Message: i18n.T(ctx.Request.Context(), "Invalid template ID format"),
+		})
+		return uuid.Nil, false
+	}
+
+	return templateID, true
+}
+
+// templateError responds to a failed request for a single template: a
+// missing template is reported as such, any other failure as an internal
+// error.
+func (c *Controller) templateError(ctx *gin.Context, err error) {
+	if errors.Is(err, templatemodel.ErrTemplateNotFound) {
+		ctx.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   apierror.TemplateNotFound,
+			Message: i18n.T(ctx.Request.Context(), "Template not found"),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   apierror.InternalError,
+		Message: "Failed to process template request",
+	})
+}
+
+func mapTemplateToResponse(ctx context.Context, template *templatemodel.Template) TemplateResponse {
+	response := TemplateResponse{
+		ID:                 template.ID,
+		NamePattern:        template.NamePattern,
+		Type:               template.Type,
+		MaxRuntimeSeconds:  int64(template.MaxRuntime / time.Second),
+		DeleteAfterSeconds: int64(template.DeleteAfter / time.Second),
+		Priority:           template.Priority.String(),
+		Instances:          template.Instances,
+		CreatedAt:          i18n.In(ctx, template.CreatedAt),
+	}
+	if template.ProjectID != uuid.Nil {
+		projectID := template.ProjectID
+		response.ProjectID = &projectID
+	}
+	return response
+}
@@ -0,0 +1,75 @@
+package templatecontroller
+
+import (
+	"net/http"
+
+	"github.com/nzb3/workmate_test/internal/openapi"
+)
+
+// DescribeRoutes documents the handlers of the controller in spec.
+func (c *Controller) DescribeRoutes(spec *openapi.Spec) {
+	spec.Describe(c.CreateTemplate, openapi.Operation{
+		Summary:     "Create a task template",
+		Description: "Creates a predefined task definition that tasks can be created from",
+		Tags:        []string{"templates"},
+		Request:     TemplateRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Description: "Template created", Body: TemplateResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid input", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Project not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.GetTemplate, openapi.Operation{
+		Summary:     "Get template info",
+		Description: "Returns a task template by its ID",
+		Tags:        []string{"templates"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Template ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Template found", Body: TemplateResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Template not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.UpdateTemplate, openapi.Operation{
+		Summary:     "Update a task template",
+		Description: "Replaces the definition of a task template",
+		Tags:        []string{"templates"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Template ID (UUID)"},
+		},
+		Request: TemplateRequest{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Template updated", Body: TemplateResponse{}},
+			{Status: http.StatusBadRequest, Description: "Invalid input", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Template or project not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.DeleteTemplate, openapi.Operation{
+		Summary:     "Delete a task template",
+		Description: "Deletes a task template; tasks created from it are kept",
+		Tags:        []string{"templates"},
+		Params: []openapi.Param{
+			{Name: "id", In: openapi.InPath, Description: "Template ID (UUID)"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusNoContent, Description: "Template deleted"},
+			{Status: http.StatusBadRequest, Description: "Invalid ID format", Body: ErrorResponse{}},
+			{Status: http.StatusNotFound, Description: "Template not found", Body: ErrorResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal server error", Body: ErrorResponse{}},
+		},
+	})
+	spec.Describe(c.ListTemplates, openapi.Operation{
+		Summary:     "List task templates",
+		Description: "Returns a list of all task templates",
+		Tags:        []string{"templates"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "List of templates", Body: TemplateListResponse{}},
+			{Status: http.StatusInternalServerError, Description: "Internal error", Body: ErrorResponse{}},
+		},
+	})
+}
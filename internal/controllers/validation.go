@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// FieldError describes why one field of a request body is invalid.
+type FieldError struct {
+	Field   string `json:"field" example:"name"`
+	Rule    string `json:"rule" example:"max"`
+	Param   string `json:"param,omitempty" example:"100"`
+	Message string `json:"message" example:"name must be at most 100 characters long"`
+}
+
+// Validation errors name fields as they appear in the JSON body.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// DescribeBindingError turns an error of ShouldBindJSON into a message and
+// the invalid fields in the language of ctx; a body that is not valid JSON
+// has no fields.
+func DescribeBindingError(ctx context.Context, err error) (string, []FieldError) {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, len(validationErrors))
+		for i, fieldErr := range validationErrors {
+			fields[i] = FieldError{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Param:   fieldErr.Param(),
+				Message: fieldMessage(ctx, fieldErr),
+			}
+		}
+		return i18n.T(ctx, "Request body has invalid fields"), fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return i18n.T(ctx, "Request body has invalid fields"), []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: i18n.Sprintf(ctx, "%s must be of type %s", typeErr.Field, typeErr.Type),
+		}}
+	}
+
+	return i18n.Sprintf(ctx, "Request body is not valid JSON: %v", err), nil
+}
+
+func fieldMessage(ctx context.Context, err validator.FieldError) string {
+	field, param := err.Field(), err.Param()
+	isString := err.Kind() == reflect.String
+
+	switch err.Tag() {
+	case "required":
+		return i18n.Sprintf(ctx, "%s is required", field)
+	case "min":
+		if isString {
+			return i18n.Sprintf(ctx, "%s must be at least %s characters long", field, param)
+		}
+		return i18n.Sprintf(ctx, "%s must be at least %s", field, param)
+	case "max":
+		if isString {
+			return i18n.Sprintf(ctx, "%s must be at most %s characters long", field, param)
+		}
+		return i18n.Sprintf(ctx, "%s must be at most %s", field, param)
+	case "oneof":
+		return i18n.Sprintf(ctx, "%s must be one of: %s", field, strings.ReplaceAll(param, " ", ", "))
+	default:
+		return i18n.Sprintf(ctx, "%s does not satisfy the %s rule", field, err.Tag())
+	}
+}
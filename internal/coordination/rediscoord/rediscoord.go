@@ -0,0 +1,149 @@
+// Package rediscoord coordinates instances through Redis: task execution
+// locks are keys with an expiry that their holder keeps extending, and
+// cancellations are fanned out over a pub/sub channel.
+package rediscoord
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// lockTTL is how long the lock of a crashed executor outlives it.
+	lockTTL = 15 * time.Second
+	// lockRefresh is how often a held lock is extended.
+	lockRefresh = lockTTL / 3
+)
+
+// The scripts change a lock only while it still holds the token of the
+// caller, so an expired lock taken over by another executor is left alone.
+var (
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Coordinator is a taskservice.Coordinator backed by Redis.
+type Coordinator struct {
+	client *redis.Client
+	prefix string
+	logger *slog.Logger
+}
+
+// New connects to the Redis server at url; prefix is prepended to every
+// key and channel name.
+func New(ctx context.Context, url, prefix string, logger *slog.Logger) (*Coordinator, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &Coordinator{client: client, prefix: prefix, logger: logger}, nil
+}
+
+// Lock takes the lock of the task with a random token and extends it until
+// unlock is called.
+func (c *Coordinator) Lock(ctx context.Context, taskID uuid.UUID) (func(), bool, error) {
+	key := c.prefix + "task-lock:" + taskID.String()
+	token := uuid.NewString()
+	ok, err := c.client.SetNX(ctx, key, token, lockTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := refreshScript.Run(context.Background(), c.client, []string{key}, token, lockTTL.Milliseconds()).Err(); err != nil {
+					c.logger.Warn("Failed to extend a task lock", "task_id", taskID, "error", err)
+				}
+			}
+		}
+	}()
+
+	unlock := func() {
+		close(done)
+		<-stopped
+		if err := unlockScript.Run(context.Background(), c.client, []string{key}, token).Err(); err != nil {
+			c.logger.Warn("Failed to release a task lock, it expires on its own", "task_id", taskID, "error", err)
+		}
+	}
+	return unlock, true, nil
+}
+
+func (c *Coordinator) cancellations() string {
+	return c.prefix + "task-cancellations"
+}
+
+// Cancel publishes the task ID to every subscribed instance.
+func (c *Coordinator) Cancel(ctx context.Context, taskID uuid.UUID) error {
+	return c.client.Publish(ctx, c.cancellations(), taskID.String()).Err()
+}
+
+// Cancellations subscribes to the cancellation channel. The subscription
+// reconnects by itself; cancellations published while it is down are
+// missed.
+func (c *Coordinator) Cancellations(ctx context.Context, handle func(taskID uuid.UUID)) error {
+	subscription := c.client.Subscribe(ctx, c.cancellations())
+	defer subscription.Close()
+	if _, err := subscription.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to subscribe to cancellations: %w", err)
+	}
+
+	messages := subscription.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			taskID, err := uuid.Parse(message.Payload)
+			if err != nil {
+				c.logger.WarnContext(ctx, "Ignoring a malformed cancellation", "payload", message.Payload)
+				continue
+			}
+			handle(taskID)
+		}
+	}
+}
+
+// Ping checks the connection to Redis.
+func (c *Coordinator) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+func (c *Coordinator) Close() error {
+	return c.client.Close()
+}
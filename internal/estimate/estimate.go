@@ -0,0 +1,207 @@
+package estimate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/timing"
+)
+
+// DefaultWindow is the number of recent durations kept per task type
+// unless NewModel is given another one.
+const DefaultWindow = 100
+
+// Estimate summarizes the recent processing times of one task type.
+type Estimate struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+}
+
+// Model keeps the processing times of the last window completed tasks of
+// every type and estimates how long the next one will take. When it has a
+// path, it is stored there so that estimates survive restarts.
+type Model struct {
+	window int
+	path   string
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+	dirty   bool
+}
+
+// NewModel creates an empty model; an empty path keeps it in memory only.
+// Failures to save it in the background are logged to logger.
+func NewModel(window int, path string, logger *slog.Logger) *Model {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Model{
+		window:  window,
+		path:    path,
+		logger:  logger,
+		samples: make(map[string][]time.Duration),
+	}
+}
+
+// file is the stored form of the model.
+type file struct {
+	// Types holds the durations in nanoseconds, oldest first.
+	Types map[string][]time.Duration `json:"types"`
+}
+
+// Load replaces the durations with the stored ones. A missing file leaves
+// the model empty.
+func (m *Model) Load() error {
+	if m.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read duration estimates: %w", err)
+	}
+
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to decode duration estimates: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.samples = make(map[string][]time.Duration, len(stored.Types))
+	for taskType, durations := range stored.Types {
+		if len(durations) > m.window {
+			durations = durations[len(durations)-m.window:]
+		}
+		m.samples[taskType] = durations
+	}
+	m.dirty = false
+	return nil
+}
+
+// Save stores the durations if they changed since the last Load or Save.
+// The file is replaced atomically, so a crash never leaves it half written.
+func (m *Model) Save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	if !m.dirty {
+		m.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(file{Types: m.samples})
+	m.dirty = false
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode duration estimates: %w", err)
+	}
+
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		m.markDirty()
+		return fmt.Errorf("failed to write duration estimates: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		m.markDirty()
+		return fmt.Errorf("failed to write duration estimates: %w", err)
+	}
+	return nil
+}
+
+func (m *Model) markDirty() {
+	m.mu.Lock()
+	m.dirty = true
+	m.mu.Unlock()
+}
+
+// Run saves the model every interval until ctx is cancelled.
+func (m *Model) Run(ctx context.Context, interval time.Duration) {
+	if m.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Save(); err != nil {
+				m.logger.WarnContext(ctx, "Saving duration estimates failed", "error", err)
+			}
+		}
+	}
+}
+
+// Observe records the processing time of a completed task.
+func (m *Model) Observe(taskType string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	durations := append(m.samples[taskType], d)
+	if len(durations) > m.window {
+		durations = slices.Clone(durations[len(durations)-m.window:])
+	}
+	m.samples[taskType] = durations
+	m.dirty = true
+}
+
+// Estimate summarizes the recent durations of the task type; it reports
+// false when no task of the type has completed yet.
+func (m *Model) Estimate(taskType string) (Estimate, bool) {
+	m.mu.Lock()
+	durations := slices.Clone(m.samples[taskType])
+	m.mu.Unlock()
+
+	if len(durations) == 0 {
+		return Estimate{}, false
+	}
+	return summarize(durations), true
+}
+
+// Estimates summarizes the recent durations of every task type.
+func (m *Model) Estimates() map[string]Estimate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	estimates := make(map[string]Estimate, len(m.samples))
+	for taskType, durations := range m.samples {
+		estimates[taskType] = summarize(slices.Clone(durations))
+	}
+	return estimates
+}
+
+// summarize sorts durations in place.
+func summarize(durations []time.Duration) Estimate {
+	slices.Sort(durations)
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return Estimate{
+		Count: len(durations),
+		Mean:  total / time.Duration(len(durations)),
+		P50:   timing.Percentile(durations, 50),
+		P90:   timing.Percentile(durations, 90),
+		P99:   timing.Percentile(durations, 99),
+	}
+}
@@ -0,0 +1,61 @@
+package features
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"regexp"
+	"sync"
+)
+
+// ErrInvalidName is returned for flag names outside [a-z0-9_.-].
+var ErrInvalidName = errors.New("feature flag name must consist of lowercase letters, digits, '_', '-' and '.'")
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// ValidName reports whether name can be used as a feature flag name.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Flags gates risky functionality per environment. Initial values come from
+// the config and can be toggled at runtime; unknown flags are disabled.
+type Flags struct {
+	mu     sync.RWMutex
+	flags  map[string]bool
+	logger *slog.Logger
+}
+
+func New(initial map[string]bool, logger *slog.Logger) *Flags {
+	flags := make(map[string]bool, len(initial))
+	maps.Copy(flags, initial)
+	return &Flags{flags: flags, logger: logger}
+}
+
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+// Set enables or disables a flag, declaring it if it is not known yet.
+func (f *Flags) Set(name string, enabled bool) error {
+	if !ValidName(name) {
+		return ErrInvalidName
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if previous, ok := f.flags[name]; !ok || previous != enabled {
+		f.logger.Info("Feature flag switched", "flag", name, "enabled", enabled)
+	}
+	f.flags[name] = enabled
+	return nil
+}
+
+// All returns a snapshot of every known flag.
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return maps.Clone(f.flags)
+}
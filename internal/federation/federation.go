@@ -0,0 +1,91 @@
+// Package federation queries the API of peer instances, e.g. the
+// deployments of other regions, so that one instance can answer for all.
+package federation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/requestid"
+)
+
+// maxResponseSize bounds the body read from a peer.
+const maxResponseSize = 32 << 20
+
+// Peer is another instance, addressed by the base URL of its API.
+type Peer struct {
+	Name string
+	URL  string
+}
+
+// Response is the answer of one peer; Err is set when it did not answer
+// with 200.
+type Response struct {
+	Peer string
+	Body []byte
+	Err  error
+}
+
+// Client sends the same request to every peer.
+type Client struct {
+	peers  []Peer
+	client *http.Client
+}
+
+// NewClient queries peers, giving each of them timeout to answer.
+func NewClient(peers []Peer, timeout time.Duration) *Client {
+	return &Client{
+		peers:  peers,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Get requests path with query from every peer concurrently and returns
+// the responses in the order of the peers. The requests carry no caller
+// credentials: the query has to say whose data to return.
+func (c *Client) Get(ctx context.Context, path string, query url.Values) []Response {
+	responses := make([]Response, len(c.peers))
+	var wg sync.WaitGroup
+	for i, peer := range c.peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := c.get(ctx, peer, path, query)
+			responses[i] = Response{Peer: peer.Name, Body: body, Err: err}
+		}()
+	}
+	wg.Wait()
+	return responses
+}
+
+func (c *Client) get(ctx context.Context, peer Peer, path string, query url.Values) ([]byte, error) {
+	target := strings.TrimSuffix(peer.URL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
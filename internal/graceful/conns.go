@@ -0,0 +1,52 @@
+package graceful
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Conns tracks connections that were accepted but have not sent a request
+// yet. http.Server.Shutdown closes such connections as soon as their request
+// arrives, which would fail requests that raced with a restart; waiting for
+// them before the shutdown lets them be served.
+type Conns struct {
+	fresh sync.Map // [net.Conn]struct{}
+	count atomic.Int64
+}
+
+// Track installs a connection state hook on server; it must be called
+// before the server starts serving.
+func (c *Conns) Track(server *http.Server) {
+	next := server.ConnState
+	server.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			c.fresh.Store(conn, struct{}{})
+			c.count.Add(1)
+		} else if _, ok := c.fresh.LoadAndDelete(conn); ok {
+			c.count.Add(-1)
+		}
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+// WaitFresh waits until every accepted connection has started its first
+// request or was closed. The listener must be closed beforehand.
+func (c *Conns) WaitFresh(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+
+	for c.count.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
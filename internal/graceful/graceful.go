@@ -0,0 +1,282 @@
+// Package graceful restarts the server binary without closing its listening
+// sockets: the running process starts a new copy of itself that inherits the
+// listeners, waits until the copy reports readiness and only then stops
+// accepting connections and drains. Connections waiting in the accept queue
+// are picked up by the new process, so none are refused during a deploy.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// listenFDsEnv maps listener addresses to inherited file descriptors,
+	// e.g. "tcp::8080=3,unix:/run/workmate.sock=4".
+	listenFDsEnv = "WORKMATE_LISTEN_FDS"
+	readyFDEnv   = "WORKMATE_READY_FD"
+)
+
+// Listener is a TCP or Unix socket listener that can be handed over to a new
+// process. Closing it more than once is not an error, so it can be closed
+// ahead of http.Server.Shutdown.
+type Listener struct {
+	net.Listener
+	key      string
+	once     sync.Once
+	closeErr error
+}
+
+var (
+	inheritOnce sync.Once
+	inherited   map[string]uintptr
+	inheritErr  error
+)
+
+// Listen returns the listener for network and addr inherited from the parent
+// process during a restart, or a new one. A stale Unix socket file left by a
+// previous run is removed before listening.
+func Listen(network, addr string) (*Listener, error) {
+	key := network + ":" + addr
+
+	inheritOnce.Do(func() {
+		inherited, inheritErr = parseListenFDs(os.Getenv(listenFDsEnv))
+		// Descriptors must not leak into processes started later on.
+		os.Unsetenv(listenFDsEnv)
+	})
+	if inheritErr != nil {
+		return nil, inheritErr
+	}
+
+	if fd, ok := inherited[key]; ok {
+		file := os.NewFile(fd, key)
+		defer file.Close()
+
+		listener, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to use inherited listener %s: %w", key, err)
+		}
+		// The socket file is now owned by this process.
+		if unix, ok := listener.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(true)
+		}
+		return &Listener{Listener: listener, key: key}, nil
+	}
+
+	if network == "unix" {
+		if err := removeStaleSocket(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{Listener: listener, key: key}, nil
+}
+
+func (l *Listener) Close() error {
+	l.once.Do(func() {
+		l.closeErr = l.Listener.Close()
+	})
+	return l.closeErr
+}
+
+// File returns a duplicate of the listening socket for passing to another process.
+func (l *Listener) File() (*os.File, error) {
+	filer, ok := l.Listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("listener %T cannot be passed to another process", l.Listener)
+	}
+	return filer.File()
+}
+
+// KeepSocketFile stops Close from removing the Unix socket file, which the
+// process the listener was handed over to keeps serving on.
+func (l *Listener) KeepSocketFile() {
+	if unix, ok := l.Listener.(*net.UnixListener); ok {
+		unix.SetUnlinkOnClose(false)
+	}
+}
+
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+func parseListenFDs(value string) (map[string]uintptr, error) {
+	fds := make(map[string]uintptr)
+	if value == "" {
+		return fds, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		key, fdValue, ok := strings.Cut(item, "=")
+		fd, err := strconv.Atoi(fdValue)
+		if !ok || err != nil || fd < 3 {
+			return nil, fmt.Errorf("invalid %s entry %q", listenFDsEnv, item)
+		}
+		fds[key] = uintptr(fd)
+	}
+	return fds, nil
+}
+
+// NotifyReady tells the process that started this one during a restart, and
+// systemd when running under it, that this process is serving traffic. It is
+// a no-op otherwise.
+func NotifyReady() error {
+	if err := notifySystemd(); err != nil {
+		return err
+	}
+
+	fd, ok, err := inheritedFD(readyFDEnv)
+	if err != nil || !ok {
+		return err
+	}
+
+	file := os.NewFile(fd, "ready")
+	defer file.Close()
+
+	if _, err := file.Write([]byte{1}); err != nil {
+		return fmt.Errorf("failed to notify parent process: %w", err)
+	}
+	return nil
+}
+
+// Restart starts a new copy of the running binary with the same arguments
+// and environment that inherits listeners, and waits until it reports
+// readiness. On error the new process is killed and the caller keeps serving.
+func Restart(ctx context.Context, listeners []*Listener, timeout time.Duration, logger *slog.Logger) error {
+	// ExtraFiles[i] becomes file descriptor 3+i in the child.
+	var (
+		files []*os.File
+		fds   []string
+	)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, listener := range listeners {
+		file, err := listener.File()
+		if err != nil {
+			return fmt.Errorf("failed to get listener file: %w", err)
+		}
+		fds = append(fds, fmt.Sprintf("%s=%d", listener.key, 3+len(files)))
+		files = append(files, file)
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", err)
+	}
+	defer readyReader.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		readyWriter.Close()
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, readyWriter)
+	cmd.Env = append(os.Environ(),
+		listenFDsEnv+"="+strings.Join(fds, ","),
+		fmt.Sprintf("%s=%d", readyFDEnv, 3+len(files)),
+	)
+
+	err = cmd.Start()
+	// The child holds its own copy of the write end; closing ours makes the
+	// read below fail as soon as the child exits without reporting readiness.
+	readyWriter.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+	logger.InfoContext(ctx, "Started new process, waiting for it to become ready", "pid", cmd.Process.Pid)
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := readyReader.Read(make([]byte, 1))
+		if errors.Is(err, io.EOF) {
+			err = errors.New("new process exited before becoming ready")
+		}
+		ready <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+	case <-timer.C:
+		err = fmt.Errorf("new process did not become ready within %s", timeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
+	// The new process outlives this one; nothing waits for it here.
+	_ = cmd.Process.Release()
+	return nil
+}
+
+func inheritedFD(env string) (uintptr, bool, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return 0, false, nil
+	}
+	os.Unsetenv(env)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 3 {
+		return 0, false, fmt.Errorf("invalid %s %q", env, value)
+	}
+	return uintptr(fd), true, nil
+}
+
+// notifySystemd implements the sd_notify protocol so that systemd units with
+// Type=notify and NotifyAccess=all follow the main process across restarts.
+func notifySystemd() error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd: %w", err)
+	}
+	defer conn.Close()
+
+	message := fmt.Sprintf("READY=1\nMAINPID=%d", os.Getpid())
+	if _, err := conn.Write([]byte(message)); err != nil {
+		return fmt.Errorf("failed to notify systemd: %w", err)
+	}
+	return nil
+}
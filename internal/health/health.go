@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const checkTimeout = 5 * time.Second
+
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateReady    State = "ready"
+	StateDraining State = "draining"
+)
+
+// Check reports an error when a dependency is not usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker tracks the serving state of the process and the dependency
+// checks that must pass before it accepts traffic.
+type Checker struct {
+	state   atomic.Value // State
+	started time.Time
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+func NewChecker() *Checker {
+	c := &Checker{started: time.Now()}
+	c.state.Store(StateStarting)
+	return c
+}
+
+// AddCheck registers a dependency check run on every readiness probe.
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Uptime returns the time elapsed since the checker, and so the process, started.
+func (c *Checker) Uptime() time.Duration {
+	return time.Since(c.started)
+}
+
+func (c *Checker) State() State {
+	return c.state.Load().(State)
+}
+
+// MarkReady is called once startup has finished.
+func (c *Checker) MarkReady() {
+	c.state.Store(StateReady)
+}
+
+// MarkDraining makes readiness fail so load balancers stop routing new
+// requests while in-flight ones complete.
+func (c *Checker) MarkDraining() {
+	c.state.Store(StateDraining)
+}
+
+// Result is the outcome of a single dependency check.
+type Result struct {
+	Name    string
+	Err     error
+	Latency time.Duration
+}
+
+type Status struct {
+	Ready  bool
+	State  State
+	Checks []Result
+}
+
+// Healthy reports whether every check passed, regardless of the serving state.
+func (s Status) Healthy() bool {
+	for _, result := range s.Checks {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Ready runs every check and reports whether the process can serve traffic.
+func (c *Checker) Ready(ctx context.Context) Status {
+	status := Status{
+		State:  c.State(),
+		Checks: c.Run(ctx),
+	}
+	status.Ready = status.State == StateReady && status.Healthy()
+
+	return status
+}
+
+// Run executes the registered checks concurrently, each bounded by checkTimeout.
+func (c *Checker) Run(ctx context.Context) []Result {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.check(checkCtx)
+			results[i] = Result{Name: check.name, Err: err, Latency: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,112 @@
+// Package i18n translates the messages shown to end users. Messages are
+// written in English in the code and double as the keys of the catalogs
+// embedded from locales/<language>.json; a message missing from a catalog
+// is shown in English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is the language of the messages in the code.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	catalogs = loadCatalogs()
+	matcher  = language.NewMatcher(tags())
+)
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	catalogs := map[string]map[string]string{DefaultLanguage: {}}
+	for _, entry := range entries {
+		data, err := locales.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("invalid message catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return catalogs
+}
+
+// Languages returns the supported languages, the default one first.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		if lang != DefaultLanguage {
+			languages = append(languages, lang)
+		}
+	}
+	sort.Strings(languages)
+	return append([]string{DefaultLanguage}, languages...)
+}
+
+func tags() []language.Tag {
+	languages := Languages()
+	tags := make([]language.Tag, len(languages))
+	for i, lang := range languages {
+		tags[i] = language.MustParse(lang)
+	}
+	return tags
+}
+
+// Match picks the supported language that fits an Accept-Language header
+// best, or DefaultLanguage when none does.
+func Match(acceptLanguage string) string {
+	accepted, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(accepted) == 0 {
+		return DefaultLanguage
+	}
+	_, index, confidence := matcher.Match(accepted...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+	return Languages()[index]
+}
+
+type languageKey struct{}
+
+// WithLanguage returns a copy of ctx in which messages are translated to lang.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// Language returns the language of ctx, DefaultLanguage if none is set.
+func Language(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey{}).(string); ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// T translates message to the language of ctx.
+func T(ctx context.Context, message string) string {
+	if translated, ok := catalogs[Language(ctx)][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf translates format to the language of ctx and formats it.
+func Sprintf(ctx context.Context, format string, args ...any) string {
+	return fmt.Sprintf(T(ctx, format), args...)
+}
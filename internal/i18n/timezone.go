@@ -0,0 +1,32 @@
+package i18n
+
+import (
+	"context"
+	"time"
+
+	// Minimal container images have no zoneinfo of their own.
+	_ "time/tzdata"
+)
+
+type locationKey struct{}
+
+// WithLocation returns a copy of ctx in which timestamps are shown in loc.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// Location returns the time zone of ctx, UTC if none is set.
+func Location(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok {
+		return loc
+	}
+	return time.UTC
+}
+
+// In converts t to the time zone of ctx; the zero time stays zero.
+func In(ctx context.Context, t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(Location(ctx))
+}
@@ -0,0 +1,167 @@
+// Package loadgen creates synthetic tasks for development environments:
+// sample tasks at startup and a steady stream of tasks at a given rate, to
+// exercise dashboards and autoscaling.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// MaxRate bounds the tasks created per second.
+const MaxRate = 1000
+
+// TaskType is the type of generated tasks unless another one is given.
+const TaskType = "loadgen"
+
+// sampleTypes are cycled through by Seed.
+var sampleTypes = []string{"report", "export", "import", "cleanup"}
+
+// ErrInvalidRate is returned for a rate outside (0, MaxRate].
+var ErrInvalidRate = fmt.Errorf("rate must be positive and at most %d tasks per second", MaxRate)
+
+// Creator creates tasks.
+type Creator interface {
+	CreateTask(ctx context.Context, name string, opts ...taskmodel.Option) (*taskmodel.Task, error)
+}
+
+// Seed creates n sample tasks and returns how many were created.
+func Seed(ctx context.Context, creator Creator, n int) (int, error) {
+	for i := range n {
+		taskType := sampleTypes[i%len(sampleTypes)]
+		if _, err := creator.CreateTask(ctx, fmt.Sprintf("Sample %s %d", taskType, i+1), taskmodel.WithType(taskType)); err != nil {
+			return i, err
+		}
+	}
+	return n, nil
+}
+
+// Status describes the current or last run of the generator.
+type Status struct {
+	Running bool
+	// Rate is the number of tasks created per second.
+	Rate      float64
+	TaskType  string
+	StartedAt time.Time
+	// Until is when the run stops; zero runs until stopped.
+	Until   time.Time
+	Created int
+	Failed  int
+}
+
+// Generator creates tasks at a steady rate until its duration has passed,
+// it is stopped, or the context it was created with is done.
+type Generator struct {
+	ctx     context.Context
+	creator Creator
+	logger  *slog.Logger
+
+	// control serializes Start and Stop.
+	control sync.Mutex
+	mu      sync.Mutex
+	status  Status
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func New(ctx context.Context, creator Creator, logger *slog.Logger) *Generator {
+	return &Generator{ctx: ctx, creator: creator, logger: logger}
+}
+
+// Start replaces the current run with one creating rate tasks of taskType
+// per second for duration, or until stopped when duration is zero.
+func (g *Generator) Start(rate float64, duration time.Duration, taskType string) (Status, error) {
+	if rate <= 0 || rate > MaxRate {
+		return Status{}, ErrInvalidRate
+	}
+	if duration < 0 {
+		return Status{}, errors.New("duration must not be negative")
+	}
+	if taskType == "" {
+		taskType = TaskType
+	}
+
+	g.control.Lock()
+	defer g.control.Unlock()
+	g.stop()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	g.status = Status{Running: true, Rate: rate, TaskType: taskType, StartedAt: now}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if duration > 0 {
+		g.status.Until = now.Add(duration)
+		ctx, cancel = context.WithDeadline(g.ctx, g.status.Until)
+	} else {
+		ctx, cancel = context.WithCancel(g.ctx)
+	}
+	g.cancel = cancel
+	g.done = make(chan struct{})
+	go g.run(ctx, g.done, time.Duration(float64(time.Second)/rate), taskType)
+
+	g.logger.Info("Load generation started", "rate", rate, "duration", duration, "task_type", taskType)
+	return g.status, nil
+}
+
+// Stop ends the current run and waits for it; it reports whether one was
+// running.
+func (g *Generator) Stop() bool {
+	g.control.Lock()
+	defer g.control.Unlock()
+	return g.stop()
+}
+
+func (g *Generator) stop() bool {
+	g.mu.Lock()
+	cancel, done := g.cancel, g.done
+	g.mu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	<-done
+	return true
+}
+
+func (g *Generator) Status() Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.status
+}
+
+func (g *Generator) run(ctx context.Context, done chan struct{}, interval time.Duration, taskType string) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 1; ; n++ {
+		select {
+		case <-ctx.Done():
+			g.mu.Lock()
+			g.status.Running = false
+			g.cancel = nil
+			status := g.status
+			g.mu.Unlock()
+			g.logger.Info("Load generation stopped", "created", status.Created, "failed", status.Failed)
+			return
+		case <-ticker.C:
+		}
+
+		_, err := g.creator.CreateTask(ctx, fmt.Sprintf("Load %d", n), taskmodel.WithType(taskType))
+		g.mu.Lock()
+		if err != nil {
+			g.status.Failed++
+		} else {
+			g.status.Created++
+		}
+		g.mu.Unlock()
+	}
+}
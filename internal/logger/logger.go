@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New creates a logger writing to w in the given format with all output
+// redacted. Records below level are dropped; level can be changed at runtime.
+// Records logged with the context of a span carry its trace_id and span_id.
+// Records are also passed to bridges, e.g. the OpenTelemetry log bridge.
+func New(w io.Writer, format string, level *slog.LevelVar, redactor *Redactor, bridges ...slog.Handler) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	handler = &traceHandler{next: handler}
+	if len(bridges) > 0 {
+		handler = &teeHandler{handlers: append([]slog.Handler{handler}, bridges...), level: level}
+	}
+
+	return slog.New(&redactingHandler{next: handler, redactor: redactor})
+}
+
+// ParseLevel parses one of debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// LevelName returns the lowercase name accepted by ParseLevel.
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// redactingHandler masks sensitive values in messages and attributes before
+// passing records on.
+type redactingHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr)
+	}
+
+	return &redactingHandler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *redactingHandler) redactAttr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+
+	if h.redactor.IsSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, redactedValue)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.Redact(attr.Value.String()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.redactAttr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	default:
+		return attr
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Redactor masks sensitive values in log output.
+type Redactor struct {
+	keys     map[string]struct{}
+	keyValue *regexp.Regexp
+	bearer   *regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+func NewRedactor(keys []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		keys:   make(map[string]struct{}, len(keys)),
+		bearer: regexp.MustCompile(`(?i)(bearer|basic)\s+[a-z0-9._~+/=-]+`),
+	}
+
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+
+	if len(quoted) > 0 {
+		r.keyValue = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)(["']?\s*[=:]\s*)("[^"]*"|[^\s&,;]+)`)
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// IsSensitiveKey reports whether values stored under key must be masked.
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	_, ok := r.keys[strings.ToLower(key)]
+	return ok
+}
+
+// Redact returns s with all sensitive key/value pairs, credentials and configured patterns masked.
+func (r *Redactor) Redact(s string) string {
+	s = r.bearer.ReplaceAllString(s, redactedValue)
+	if r.keyValue != nil {
+		s = r.keyValue.ReplaceAllString(s, "${1}${2}"+redactedValue)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactedValue)
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package maintenance
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Mode is the read-only maintenance switch: while enabled, mutating API
+// requests are rejected so storage can be migrated safely.
+type Mode struct {
+	enabled atomic.Bool
+	// until is the unix time in nanoseconds the maintenance is expected to
+	// end at, zero when unknown.
+	until  atomic.Int64
+	logger *slog.Logger
+}
+
+func New(enabled bool, logger *slog.Logger) *Mode {
+	m := &Mode{logger: logger}
+	m.enabled.Store(enabled)
+	return m
+}
+
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled switches maintenance mode; until is when it is expected to
+// end, zero when unknown, and is forgotten when it is switched off.
+func (m *Mode) SetEnabled(enabled bool, until time.Time) {
+	if !enabled || until.IsZero() {
+		m.until.Store(0)
+	} else {
+		m.until.Store(until.UnixNano())
+	}
+	if m.enabled.Swap(enabled) != enabled {
+		m.logger.Info("Maintenance mode switched", "enabled", enabled)
+	}
+}
+
+// Until returns when the maintenance is expected to end, or the zero time
+// when it is not known.
+func (m *Mode) Until() time.Time {
+	until := m.until.Load()
+	if until == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, until)
+}
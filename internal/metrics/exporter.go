@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exporter pushes the collected metrics to a backend that does not scrape
+// the /metrics endpoint.
+type Exporter interface {
+	// Run pushes metrics periodically until ctx is cancelled.
+	Run(ctx context.Context)
+	// Shutdown pushes the final values and releases the exporter.
+	Shutdown(ctx context.Context) error
+}
+
+// Gatherer gives exporters access to the same instrumentation that is
+// served to Prometheus.
+func (m *Metrics) Gatherer() prometheus.Gatherer {
+	return m.registry
+}
@@ -0,0 +1,253 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+const namespace = "workmate"
+
+// WorkerPool reports the state of the task executors.
+type WorkerPool interface {
+	QueuedTasks() int
+	RunningTasks() int
+	WorkerCapacity() int
+	ExecutorGoroutines() int
+}
+
+// TaskCounter reports the number of stored tasks per status.
+type TaskCounter interface {
+	CountByStatus(ctx context.Context) (map[taskmodel.TaskStatus]int, error)
+}
+
+// Metrics collects HTTP and task metrics and exposes them in the Prometheus format.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	httpSlowRequests *prometheus.CounterVec
+
+	tasksCreated   prometheus.Counter
+	tasksFinished  *prometheus.CounterVec
+	tasksSlow      *prometheus.CounterVec
+	tasksPreempted *prometheus.CounterVec
+
+	taskProcessingTime *prometheus.HistogramVec
+	taskQueueWait      *prometheus.HistogramVec
+}
+
+var (
+	processingTimeBuckets = []float64{1, 5, 15, 30, 60, 120, 180, 240, 300, 360, 600, 1800}
+	queueWaitBuckets      = []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 15, 30, 60, 300, 900}
+)
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpSlowRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_slow_requests_total",
+			Help:      "Number of HTTP requests slower than the slow request threshold, by method and route.",
+		}, []string{"method", "route"}),
+		tasksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_created_total",
+			Help:      "Number of created tasks.",
+		}),
+		tasksFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_finished_total",
+			Help:      "Number of finished tasks by final status.",
+		}, []string{"status"}),
+		tasksSlow: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_slow_total",
+			Help:      "Number of tasks that ran longer than the slow threshold of their type, by task type.",
+		}, []string{"type"}),
+		tasksPreempted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_preempted_total",
+			Help:      "Number of times running tasks gave their worker up to a critical task, by task type.",
+		}, []string{"type"}),
+		taskProcessingTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "task_processing_seconds",
+			Help:      "Time from the start of execution until the task finished, by task type and final status.",
+			Buckets:   processingTimeBuckets,
+		}, []string{"type", "status"}),
+		taskQueueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "task_queue_wait_seconds",
+			Help:      "Time from task creation until the start of execution, by task type and final status.",
+			Buckets:   queueWaitBuckets,
+		}, []string{"type", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpSlowRequests,
+		m.tasksCreated,
+		m.tasksFinished,
+		m.tasksSlow,
+		m.tasksPreempted,
+		m.taskProcessingTime,
+		m.taskQueueWait,
+	)
+
+	return m
+}
+
+// RegisterWorkerPool exports queue depth, in-flight executors and worker
+// utilization of the pool, read at scrape time.
+func (m *Metrics) RegisterWorkerPool(pool WorkerPool) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "tasks_queued",
+			Help:      "Number of tasks waiting for a free worker.",
+		}, func() float64 {
+			return float64(pool.QueuedTasks())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "tasks_active",
+			Help:      "Number of tasks currently being executed.",
+		}, func() float64 {
+			return float64(pool.RunningTasks())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "executor_goroutines",
+			Help:      "Number of executor goroutines, queued and running.",
+		}, func() float64 {
+			return float64(pool.ExecutorGoroutines())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workers_capacity",
+			Help:      "Maximum number of concurrently executing tasks.",
+		}, func() float64 {
+			return float64(pool.WorkerCapacity())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workers_utilization_ratio",
+			Help:      "Share of busy workers, from 0 to 1.",
+		}, func() float64 {
+			capacity := pool.WorkerCapacity()
+			if capacity == 0 {
+				return 0
+			}
+			return float64(pool.RunningTasks()) / float64(capacity)
+		}),
+	)
+}
+
+// RegisterTaskCounter exports the number of stored tasks per status, read
+// from the repository index at scrape time.
+func (m *Metrics) RegisterTaskCounter(counter TaskCounter) {
+	m.registry.MustRegister(&taskStatusCollector{
+		counter: counter,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "tasks"),
+			"Number of stored tasks by status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+type taskStatusCollector struct {
+	counter TaskCounter
+	desc    *prometheus.Desc
+}
+
+func (c *taskStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *taskStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.counter.CountByStatus(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	// Known statuses are always exported so dashboards see zeros, not gaps.
+	for _, status := range taskmodel.Statuses() {
+		if _, ok := counts[status]; !ok {
+			counts[status] = 0
+		}
+	}
+
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), string(status))
+	}
+}
+
+// Handler serves the collected metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware records the count and latency of every request, labeled by route template.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := ctx.Request.Method
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) SlowRequest(method, route string) {
+	m.httpSlowRequests.WithLabelValues(method, route).Inc()
+}
+
+func (m *Metrics) TaskCreated() {
+	m.tasksCreated.Inc()
+}
+
+func (m *Metrics) TaskSlow(taskType string) {
+	m.tasksSlow.WithLabelValues(taskType).Inc()
+}
+
+func (m *Metrics) TaskPreempted(taskType string) {
+	m.tasksPreempted.WithLabelValues(taskType).Inc()
+}
+
+func (m *Metrics) TaskFinished(taskType string, status taskmodel.TaskStatus, queueWait, processingTime time.Duration) {
+	m.tasksFinished.WithLabelValues(string(status)).Inc()
+	m.taskProcessingTime.WithLabelValues(taskType, string(status)).Observe(processingTime.Seconds())
+	m.taskQueueWait.WithLabelValues(taskType, string(status)).Observe(queueWait.Seconds())
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prometheusbridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// OTLPExporter pushes metrics over OTLP/HTTP. The endpoint is configured by
+// the standard OTEL_EXPORTER_OTLP_* environment variables.
+type OTLPExporter struct {
+	provider *sdkmetric.MeterProvider
+}
+
+func NewOTLPExporter(ctx context.Context, gatherer prometheus.Gatherer, serviceName string, interval time.Duration) (*OTLPExporter, error) {
+	exporter, err := otlpmetrichttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metric resource: %w", err)
+	}
+
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithInterval(interval),
+		sdkmetric.WithProducer(prometheusbridge.NewMetricProducer(prometheusbridge.WithGatherer(gatherer))),
+	)
+
+	return &OTLPExporter{
+		provider: sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithResource(res),
+		),
+	}, nil
+}
+
+// Run only waits for ctx: the periodic reader pushes on its own.
+func (e *OTLPExporter) Run(ctx context.Context) {
+	<-ctx.Done()
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return e.provider.Shutdown(ctx)
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// maxStatsDPacket keeps datagrams below the common Ethernet MTU.
+const maxStatsDPacket = 1432
+
+// StatsDExporter pushes metrics over UDP in the StatsD line format with
+// DogStatsD tags carrying the Prometheus labels. Counters and histogram
+// counts and sums are sent as deltas since the previous push, gauges as
+// current values.
+type StatsDExporter struct {
+	gatherer prometheus.Gatherer
+	conn     net.Conn
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	previous map[string]float64 // last cumulative value by series
+}
+
+func NewStatsDExporter(gatherer prometheus.Gatherer, addr string, interval time.Duration, logger *slog.Logger) (*StatsDExporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", addr, err)
+	}
+
+	return &StatsDExporter{
+		gatherer: gatherer,
+		conn:     conn,
+		interval: interval,
+		logger:   logger,
+		previous: make(map[string]float64),
+	}, nil
+}
+
+func (e *StatsDExporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.push(); err != nil {
+				e.logger.WarnContext(ctx, "Failed to push metrics to StatsD", "error", err)
+			}
+		}
+	}
+}
+
+func (e *StatsDExporter) Shutdown(ctx context.Context) error {
+	err := e.push()
+	if closeErr := e.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (e *StatsDExporter) push() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := e.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, line := range e.lines(family, metric) {
+				if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+				if packet.Len() > 0 {
+					packet.WriteByte('\n')
+				}
+				packet.WriteString(line)
+			}
+		}
+	}
+
+	return flush()
+}
+
+func (e *StatsDExporter) lines(family *dto.MetricFamily, metric *dto.Metric) []string {
+	name := family.GetName()
+	tags := statsDTags(metric.GetLabel())
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return e.counter(name, tags, metric.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		return []string{statsDLine(name, metric.GetGauge().GetValue(), "g", tags)}
+	case dto.MetricType_UNTYPED:
+		return []string{statsDLine(name, metric.GetUntyped().GetValue(), "g", tags)}
+	case dto.MetricType_HISTOGRAM:
+		histogram := metric.GetHistogram()
+		return append(
+			e.counter(name+".count", tags, float64(histogram.GetSampleCount())),
+			e.counter(name+".sum", tags, histogram.GetSampleSum())...,
+		)
+	case dto.MetricType_SUMMARY:
+		summary := metric.GetSummary()
+		return append(
+			e.counter(name+".count", tags, float64(summary.GetSampleCount())),
+			e.counter(name+".sum", tags, summary.GetSampleSum())...,
+		)
+	default:
+		return nil
+	}
+}
+
+// counter converts a cumulative value into the delta since the last push.
+func (e *StatsDExporter) counter(name, tags string, value float64) []string {
+	key := name + tags
+	delta := value - e.previous[key]
+	if delta < 0 {
+		// The counter was reset.
+		delta = value
+	}
+	e.previous[key] = value
+
+	if delta == 0 {
+		return nil
+	}
+	return []string{statsDLine(name, delta, "c", tags)}
+}
+
+func statsDLine(name string, value float64, kind, tags string) string {
+	return name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind + tags
+}
+
+func statsDTags(labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	tags := make([]string, len(labels))
+	for i, label := range labels {
+		tags[i] = label.GetName() + ":" + statsDTagValue(label.GetValue())
+	}
+	sort.Strings(tags)
+
+	return "|#" + strings.Join(tags, ",")
+}
+
+// statsDTagReplacer strips the characters that delimit StatsD tags.
+var statsDTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+func statsDTagValue(value string) string {
+	return statsDTagReplacer.Replace(value)
+}
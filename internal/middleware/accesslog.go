@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/auth"
+	"github.com/nzb3/workmate_test/internal/requestid"
+)
+
+// AccessLog writes one structured log record per request.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.RequestURI()),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("size", ctx.Writer.Size()),
+			slog.String("client", ctx.ClientIP()),
+		}
+
+		if requestID := requestid.FromContext(ctx.Request.Context()); requestID != "" {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+		if principal, ok := auth.PrincipalFromContext(ctx.Request.Context()); ok {
+			attrs = append(attrs, slog.String("user", principal.ID))
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", ctx.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		logger.LogAttrs(ctx.Request.Context(), level, "HTTP request", attrs...)
+	}
+}
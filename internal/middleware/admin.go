@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/auth"
+)
+
+// AdminAuth allows the request if it comes from an admin principal or carries
+// the configured admin token as a bearer token. Without a token only admin
+// principals are accepted.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if principal, ok := auth.PrincipalFromContext(ctx.Request.Context()); ok && principal.Admin {
+			ctx.Next()
+			return
+		}
+
+		if token == "" {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   apierror.AdminDisabled,
+				"message": "Admin API is disabled",
+			})
+			return
+		}
+
+		provided, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   apierror.Unauthorized,
+				"message": "Invalid or missing admin token",
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/auth"
+)
+
+const (
+	IdentityFromCommonName = "cn"
+	IdentityFromSubject    = "subject"
+)
+
+// ClientCertIdentity maps the subject of the verified client certificate to
+// the request principal. source selects the common name or the full subject DN;
+// identities listed in admins are granted admin rights.
+func ClientCertIdentity(source string, admins []string) gin.HandlerFunc {
+	adminSet := make(map[string]struct{}, len(admins))
+	for _, id := range admins {
+		adminSet[id] = struct{}{}
+	}
+
+	return func(ctx *gin.Context) {
+		tlsState := ctx.Request.TLS
+		if tlsState == nil || len(tlsState.VerifiedChains) == 0 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   apierror.Unauthorized,
+				"message": "Client certificate is required",
+			})
+			return
+		}
+
+		subject := tlsState.VerifiedChains[0][0].Subject
+		id := subject.CommonName
+		if source == IdentityFromSubject {
+			id = subject.String()
+		}
+
+		_, admin := adminSet[id]
+		principal := auth.Principal{ID: id, Admin: admin}
+
+		ctx.Request = ctx.Request.WithContext(auth.WithPrincipal(ctx.Request.Context(), principal))
+		ctx.Next()
+	}
+}
@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// Content codings supported by Compress.
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+)
+
+// uncompressedTypes are already compressed or streamed, where buffering
+// for compression would hold events back.
+var uncompressedTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/octet-stream",
+	"text/event-stream",
+}
+
+// Compress compresses responses of at least minSize bytes with the first of
+// encodings, in order of preference, that the client accepts. Responses
+// that already have a Content-Encoding or an uncompressedTypes content type
+// are sent as they are, and so is everything written before the handler
+// flushes, which marks a streamed response.
+func Compress(encodings []string, minSize int) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(ctx.GetHeader("Accept-Encoding"), encodings)
+		if encoding == "" || ctx.Request.Method == http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: ctx.Writer, encoding: encoding, minSize: minSize}
+		ctx.Writer = writer
+		defer func() {
+			writer.close()
+			ctx.Writer = writer.ResponseWriter
+		}()
+		ctx.Next()
+	}
+}
+
+// negotiateEncoding picks the first of encodings accepted by the
+// Accept-Encoding header, or "" when none is.
+func negotiateEncoding(header string, encodings []string) string {
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		ok := true
+		if key, value, found := strings.Cut(strings.TrimSpace(params), "="); found && strings.TrimSpace(key) == "q" {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			ok = err == nil && q > 0
+		}
+		if name == "*" {
+			wildcard = ok
+			continue
+		}
+		accepted[name] = ok
+	}
+
+	for _, encoding := range encodings {
+		if ok, listed := accepted[encoding]; ok || (!listed && wildcard) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	for _, prefix := range uncompressedTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// compressWriter holds the response back until minSize bytes are written,
+// the handler flushes or the handler returns, and then decides whether to
+// compress it.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	status      int
+	wroteHeader bool
+	buffer      []byte
+	decided     bool
+	compressor  io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.wroteHeader = true
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.wroteHeader || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	if !w.decided {
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends what has been written so far; a flushed response is
+// streamed, so it is decided on right away.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if flusher, ok := w.compressor.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide sends the header, compressed or not, and the buffered body.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if len(w.buffer) >= w.minSize && len(w.buffer) > 0 &&
+		header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) &&
+		status != http.StatusNoContent && status != http.StatusNotModified {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+		switch w.encoding {
+		case EncodingBrotli:
+			w.compressor = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+		default:
+			w.compressor = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	if w.compressor != nil {
+		_, err := w.compressor.Write(buffer)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+// close sends a response the handler left undecided and finishes the
+// compressed stream.
+func (w *compressWriter) close() {
+	if !w.decided && (w.wroteHeader || w.status != 0) {
+		_ = w.decide()
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Close()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is recorded for requests whose client went away
+// before the response, as nginx does.
+const StatusClientClosedRequest = 499
+
+// ClientDisconnect records requests abandoned by their client with status
+// 499 instead of the error the handler reports when its cancelled context
+// makes storage calls fail, so access logs and metrics don't count them as
+// server errors. Nothing is sent since nobody is reading.
+func ClientDisconnect() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := ctx.Request.Context()
+		ctx.Writer = &disconnectWriter{ResponseWriter: ctx.Writer, ctx: reqCtx}
+		ctx.Next()
+	}
+}
+
+type disconnectWriter struct {
+	gin.ResponseWriter
+	ctx  context.Context
+	gone bool
+}
+
+func (w *disconnectWriter) WriteHeader(code int) {
+	if !w.Written() && errors.Is(w.ctx.Err(), context.Canceled) {
+		w.gone = true
+		code = StatusClientClosedRequest
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *disconnectWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.WriteHeader(w.Status())
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *disconnectWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	if w.gone {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *disconnectWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	if w.gone {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
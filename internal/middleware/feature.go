@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/features"
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// Feature hides the routes it guards behind the named flag: while the flag
+// is disabled they respond 404 as if they did not exist.
+func Feature(flags *features.Flags, name string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !flags.Enabled(name) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error":   apierror.NotFound,
+				"message": i18n.T(ctx.Request.Context(), "Resource not found"),
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// Language picks the language of user-facing messages from Accept-Language,
+// stores it in the request context and names it in Content-Language.
+func Language() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lang := i18n.Match(ctx.GetHeader("Accept-Language"))
+
+		ctx.Request = ctx.Request.WithContext(i18n.WithLanguage(ctx.Request.Context(), lang))
+		ctx.Writer.Header().Add("Vary", "Accept-Language")
+		ctx.Header("Content-Language", lang)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/maintenance"
+)
+
+// maintenanceRetryAfter is sent as Retry-After when the end of the
+// maintenance is not known.
+const maintenanceRetryAfter = time.Minute
+
+// Maintenance rejects mutating requests with 503 while maintenance mode is
+// enabled; reads keep working. Retry-After points to the expected end of the
+// maintenance when it is known.
+func Maintenance(mode *maintenance.Mode) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			ctx.Next()
+			return
+		}
+
+		if mode.Enabled() {
+			retryAfter := maintenanceRetryAfter
+			if until := mode.Until(); !until.IsZero() {
+				retryAfter = time.Until(until)
+			}
+			ctx.Header("Retry-After", apierror.RetryAfter(retryAfter))
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   apierror.Maintenance,
+				"message": "The service is in read-only maintenance mode, changes are temporarily unavailable",
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
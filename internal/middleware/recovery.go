@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/panicreport"
+	"github.com/nzb3/workmate_test/internal/requestid"
+)
+
+// Recovery turns handler panics into 500 responses and reports them with
+// their stack trace.
+func Recovery(reporter panicreport.Reporter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+
+			stack := debug.Stack()
+			log.Printf("Panic recovered in %s %s: %v\n%s", ctx.Request.Method, ctx.Request.URL.Path, recovered, stack)
+
+			reporter.Report(ctx.Request.Context(), panicreport.NewReport(recovered, stack, map[string]string{
+				"source":     "http",
+				"method":     ctx.Request.Method,
+				"route":      ctx.FullPath(),
+				"request_id": requestid.FromContext(ctx.Request.Context()),
+			}))
+
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":   apierror.InternalError,
+				"message": "Internal server error",
+			})
+		}()
+
+		ctx.Next()
+	}
+}
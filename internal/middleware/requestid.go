@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/requestid"
+)
+
+// RequestID propagates the caller's X-Request-ID or generates one, stores it
+// in the request context and returns it on the response.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := requestid.Resolve(ctx.GetHeader(requestid.Header))
+
+		ctx.Request = ctx.Request.WithContext(requestid.WithRequestID(ctx.Request.Context(), id))
+		ctx.Header(requestid.Header, id)
+		ctx.Next()
+	}
+}
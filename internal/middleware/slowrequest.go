@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/auth"
+	"github.com/nzb3/workmate_test/internal/requestid"
+	"github.com/nzb3/workmate_test/internal/timing"
+)
+
+// SlowRequestCounter counts requests exceeding the latency threshold.
+type SlowRequestCounter interface {
+	SlowRequest(method, route string)
+}
+
+// SlowRequests logs and counts every request slower than threshold with the
+// time spent in instrumented operations, such as repository calls, and the
+// remainder spent elsewhere in the handler chain.
+func SlowRequests(threshold time.Duration, logger *slog.Logger, counter SlowRequestCounter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx, breakdown := timing.WithBreakdown(ctx.Request.Context())
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		start := time.Now()
+		ctx.Next()
+		latency := time.Since(start)
+
+		if latency < threshold {
+			return
+		}
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		counter.SlowRequest(ctx.Request.Method, route)
+
+		operations := breakdown.Operations()
+		names := make([]string, 0, len(operations))
+		for name := range operations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var tracked time.Duration
+		breakdownAttrs := make([]any, 0, len(operations)+1)
+		for _, name := range names {
+			operation := operations[name]
+			tracked += operation.Total
+			breakdownAttrs = append(breakdownAttrs, slog.Group(name,
+				slog.Int("count", operation.Count),
+				slog.Float64("ms", milliseconds(operation.Total)),
+			))
+		}
+		breakdownAttrs = append(breakdownAttrs, slog.Float64("other_ms", milliseconds(latency-tracked)))
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", route),
+			slog.String("path", ctx.Request.URL.RequestURI()),
+			slog.Int("status", ctx.Writer.Status()),
+			slog.Float64("latency_ms", milliseconds(latency)),
+			slog.Float64("threshold_ms", milliseconds(threshold)),
+			slog.String("client", ctx.ClientIP()),
+			slog.Group("breakdown", breakdownAttrs...),
+		}
+		if requestID := requestid.FromContext(ctx.Request.Context()); requestID != "" {
+			attrs = append(attrs, slog.String("request_id", requestID))
+		}
+		if principal, ok := auth.PrincipalFromContext(ctx.Request.Context()); ok {
+			attrs = append(attrs, slog.String("user", principal.ID))
+		}
+
+		logger.LogAttrs(ctx.Request.Context(), slog.LevelWarn, "Slow HTTP request", attrs...)
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
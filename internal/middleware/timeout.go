@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+)
+
+// Timeout cancels the request context after the timeout of the route,
+// routes[method+" "+route template] or else fallback, and answers 504 if the
+// handler has not responded by then. A zero timeout disables it.
+//
+// The handler keeps running until it notices the cancelled context, so
+// services and repositories must respect ctx for the timeout to free them.
+func Timeout(fallback time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		timeout, ok := routes[ctx.Request.Method+" "+ctx.FullPath()]
+		if !ok {
+			timeout = fallback
+		}
+		if timeout <= 0 {
+			ctx.Next()
+			return
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Writer = &timeoutWriter{ResponseWriter: ctx.Writer, ctx: reqCtx}
+
+		ctx.Next()
+
+		if !ctx.Writer.Written() && errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+			ctx.Writer.WriteHeaderNow()
+		}
+	}
+}
+
+// timeoutWriter replaces whatever the handler responds after the deadline,
+// typically an internal error caused by the cancelled context, with 504.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if !w.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+		body, _ := json.Marshal(gin.H{
+			"error":   apierror.Timeout,
+			"message": "The request took too long to process",
+		})
+		_, _ = w.ResponseWriter.Write(body)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.WriteHeader(w.Status())
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	if w.timedOut {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	if w.timedOut {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/apierror"
+	"github.com/nzb3/workmate_test/internal/i18n"
+)
+
+// Time zone selection: the query parameter takes precedence over the header.
+const (
+	TimezoneQuery  = "tz"
+	TimezoneHeader = "Accept-Timezone"
+)
+
+// Timezone picks the IANA time zone, e.g. Europe/Berlin, timestamps are
+// shown in from the tz query parameter or the Accept-Timezone header and
+// stores it in the request context; timestamps are in UTC without either.
+// An unknown zone is rejected with 400.
+func Timezone() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := strings.TrimSpace(ctx.Query(TimezoneQuery))
+		if name == "" {
+			name = strings.TrimSpace(ctx.GetHeader(TimezoneHeader))
+		}
+		if name == "" {
+			ctx.Next()
+			return
+		}
+
+		loc, err := time.LoadLocation(name)
+		if err != nil || name == "Local" {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   apierror.ValidationError,
+				"message": i18n.Sprintf(ctx.Request.Context(), "Unknown time zone %q, expected an IANA name such as Europe/Berlin", name),
+			})
+			return
+		}
+
+		ctx.Request = ctx.Request.WithContext(i18n.WithLocation(ctx.Request.Context(), loc))
+		ctx.Writer.Header().Add("Vary", TimezoneHeader)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,10 @@
+package projectmodel
+
+import "errors"
+
+// Repositories wrap these errors so that callers can tell a missing project
+// from a storage failure with errors.Is.
+var (
+	ErrProjectNotFound      = errors.New("project not found")
+	ErrProjectAlreadyExists = errors.New("project already exists")
+)
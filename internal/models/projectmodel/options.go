@@ -0,0 +1,15 @@
+package projectmodel
+
+type Option func(*Project)
+
+func WithName(name string) Option {
+	return func(p *Project) {
+		p.Name = name
+	}
+}
+
+func WithDescription(description string) Option {
+	return func(p *Project) {
+		p.Description = description
+	}
+}
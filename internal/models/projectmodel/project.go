@@ -0,0 +1,25 @@
+package projectmodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Project struct {
+	ID          uuid.UUID
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+func NewProject(opts ...Option) *Project {
+	project := new(Project)
+	project.ID = uuid.New()
+
+	for _, opt := range opts {
+		opt(project)
+	}
+
+	return project
+}
@@ -0,0 +1,22 @@
+package taskmodel
+
+// Attachment is a file stored with a task: an input uploaded together with
+// the task or an artifact the task produced.
+type Attachment struct {
+	// Name identifies the file among the inputs or the artifacts of the task.
+	Name        string
+	ContentType string
+	Size        int64
+	// Key locates the content in the blob store.
+	Key string
+}
+
+// Artifact returns the artifact of the task called name.
+func (t *Task) Artifact(name string) (Attachment, bool) {
+	for _, artifact := range t.Artifacts {
+		if artifact.Name == name {
+			return artifact, true
+		}
+	}
+	return Attachment{}, false
+}
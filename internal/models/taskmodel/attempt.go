@@ -0,0 +1,50 @@
+package taskmodel
+
+import "time"
+
+// AttemptOutcome is how an execution attempt of a task ended.
+type AttemptOutcome string
+
+const (
+	AttemptRunning   AttemptOutcome = "RUNNING"
+	AttemptSucceeded AttemptOutcome = "SUCCEEDED"
+	AttemptFailed    AttemptOutcome = "FAILED"
+	// AttemptCancelled ends an attempt stopped by a timeout, expiry,
+	// deletion or shutdown.
+	AttemptCancelled AttemptOutcome = "CANCELLED"
+	// AttemptPreempted ends an attempt whose worker was taken by a
+	// critical task; the task resumes the work in its next attempt.
+	AttemptPreempted AttemptOutcome = "PREEMPTED"
+)
+
+// Attempt is one execution of a task; a task is executed again after a
+// failed attempt while its retry policy allows.
+type Attempt struct {
+	// Number counts the attempts of the task from 1.
+	Number    int
+	StartedAt time.Time
+	// FinishedAt is zero while the attempt runs.
+	FinishedAt time.Time
+	Outcome    AttemptOutcome
+	// Error is why a failed attempt failed.
+	Error string
+}
+
+// recordAttempt stores attempt, replacing the attempt with the same number.
+func (t *Task) recordAttempt(attempt Attempt) {
+	for i := range t.Attempts {
+		if t.Attempts[i].Number == attempt.Number {
+			t.Attempts[i] = attempt
+			return
+		}
+	}
+	t.Attempts = append(t.Attempts, attempt)
+}
+
+// lastAttempt returns the latest attempt of the task.
+func (t *Task) lastAttempt() (Attempt, bool) {
+	if len(t.Attempts) == 0 {
+		return Attempt{}, false
+	}
+	return t.Attempts[len(t.Attempts)-1], true
+}
@@ -0,0 +1,10 @@
+package taskmodel
+
+import "errors"
+
+// Repositories wrap these errors so that callers can tell a missing task
+// from a storage failure with errors.Is.
+var (
+	ErrTaskNotFound      = errors.New("task not found")
+	ErrTaskAlreadyExists = errors.New("task already exists")
+)
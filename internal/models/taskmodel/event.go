@@ -0,0 +1,179 @@
+package taskmodel
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType names a change in the life of a task.
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	// EventQueued is recorded when a pending task is queued for a worker.
+	EventQueued          EventType = "queued"
+	EventStarted         EventType = "started"
+	EventProgressUpdated EventType = "progress_updated"
+	// EventPriorityChanged is recorded when a waiting task is given
+	// another priority.
+	EventPriorityChanged EventType = "priority_changed"
+	// EventSlow is recorded when a task has been processing for longer
+	// than the slow threshold of its type.
+	EventSlow EventType = "slow"
+	// EventPreempted is recorded when a running task gave its worker up
+	// to a critical task and was queued again.
+	EventPreempted EventType = "preempted"
+	// EventAttemptStarted and EventAttemptFinished carry an execution
+	// attempt; the final attempt is carried by the final event instead.
+	EventAttemptStarted  EventType = "attempt_started"
+	EventAttemptFinished EventType = "attempt_finished"
+	EventCompleted       EventType = "completed"
+	EventFailed          EventType = "failed"
+	EventCancelled       EventType = "cancelled"
+	// EventDeleted is published when a task is erased. It is not part of
+	// the history, which is erased together with the task.
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a single change of a task. Applying the events of a task in
+// order to an empty task reproduces its current state.
+type Event struct {
+	TaskID uuid.UUID
+	// Version numbers the events of a task, starting with 1 for EventCreated.
+	Version int
+	Type    EventType
+	At      time.Time
+	// Task is the initial state of the task, carried by EventCreated only.
+	Task *Task
+	// StartedAt is carried by EventStarted.
+	StartedAt time.Time
+	// FinishedAt is carried by EventCompleted, EventFailed and EventCancelled.
+	FinishedAt     time.Time
+	ProcessingTime time.Duration
+	// FailureReason is carried by EventFailed.
+	FailureReason string
+	// CancelReason is carried by EventCancelled.
+	CancelReason string
+	// Artifacts is carried by EventCompleted.
+	Artifacts []Attachment
+	// Priority is carried by EventPriorityChanged.
+	Priority Priority
+	// Attempt is the attempt started or finished by the change, carried by
+	// the attempt events and by final events that end an attempt.
+	Attempt *Attempt
+}
+
+// ChangeEvent returns the event that turns previous into current. The
+// version is left for the event store to assign.
+func ChangeEvent(previous, current *Task, at time.Time) Event {
+	event := Event{
+		TaskID:         current.ID,
+		At:             at,
+		ProcessingTime: current.ProcessingTime,
+	}
+
+	if attempt, ok := current.lastAttempt(); ok {
+		if last, ok := previous.lastAttempt(); !ok || last != attempt {
+			event.Attempt = &attempt
+		}
+	}
+
+	switch {
+	case current.Status == StatusDone:
+		event.Type = EventCompleted
+		event.FinishedAt = current.FinishedAt
+		event.Artifacts = slices.Clone(current.Artifacts)
+	case current.Status == StatusFailed:
+		event.Type = EventFailed
+		event.FinishedAt = current.FinishedAt
+		event.FailureReason = current.FailureReason
+	case current.Status == StatusCancelled:
+		event.Type = EventCancelled
+		event.FinishedAt = current.FinishedAt
+		event.CancelReason = current.CancelReason
+	case previous.StartedAt.IsZero() && !current.StartedAt.IsZero(),
+		previous.Status != StatusProcessing && current.Status == StatusProcessing:
+		event.Type = EventStarted
+		event.StartedAt = current.StartedAt
+	case previous.Status == StatusProcessing && current.Status == StatusQueued:
+		event.Type = EventPreempted
+	case previous.Status != StatusQueued && current.Status == StatusQueued:
+		event.Type = EventQueued
+	case !previous.Slow && current.Slow:
+		event.Type = EventSlow
+	case previous.Priority != current.Priority:
+		event.Type = EventPriorityChanged
+		event.Priority = current.Priority
+	case event.Attempt != nil && event.Attempt.Outcome == AttemptRunning:
+		event.Type = EventAttemptStarted
+	case event.Attempt != nil:
+		event.Type = EventAttemptFinished
+	default:
+		event.Type = EventProgressUpdated
+	}
+
+	return event
+}
+
+// Apply folds event into the task. Status changes go through the state
+// machine, so an event stream with an illegal transition fails to replay.
+func (t *Task) Apply(event Event) error {
+	switch event.Type {
+	case EventCreated:
+		if event.Task == nil {
+			return fmt.Errorf("event %d of task %s: created event without a task", event.Version, event.TaskID)
+		}
+		*t = *event.Task.Clone()
+		return nil
+	case EventQueued:
+		if err := t.Transition(StatusQueued); err != nil {
+			return fmt.Errorf("event %d of task %s: %w", event.Version, event.TaskID, err)
+		}
+	case EventStarted:
+		// Tasks created before the waiting statuses existed are PROCESSING
+		// from the start.
+		if t.IsWaiting() {
+			if err := t.Transition(StatusProcessing); err != nil {
+				return fmt.Errorf("event %d of task %s: %w", event.Version, event.TaskID, err)
+			}
+		}
+		t.StartedAt = event.StartedAt
+	case EventPreempted:
+		if err := t.Transition(StatusQueued); err != nil {
+			return fmt.Errorf("event %d of task %s: %w", event.Version, event.TaskID, err)
+		}
+		t.StartedAt = time.Time{}
+		t.Preemptions++
+	case EventPriorityChanged:
+		t.Priority = event.Priority
+	case EventSlow:
+		t.Slow = true
+	case EventProgressUpdated, EventAttemptStarted, EventAttemptFinished:
+	case EventCompleted, EventFailed, EventCancelled:
+		status := StatusDone
+		switch event.Type {
+		case EventFailed:
+			status = StatusFailed
+		case EventCancelled:
+			status = StatusCancelled
+		}
+		if err := t.Transition(status); err != nil {
+			return fmt.Errorf("event %d of task %s: %w", event.Version, event.TaskID, err)
+		}
+		t.FinishedAt = event.FinishedAt
+		t.FailureReason = event.FailureReason
+		t.CancelReason = event.CancelReason
+		t.Artifacts = slices.Clone(event.Artifacts)
+	default:
+		return fmt.Errorf("event %d of task %s: unknown event type %q", event.Version, event.TaskID, event.Type)
+	}
+
+	if event.Attempt != nil {
+		t.recordAttempt(*event.Attempt)
+	}
+	t.ProcessingTime = event.ProcessingTime
+	return nil
+}
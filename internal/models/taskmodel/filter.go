@@ -0,0 +1,48 @@
+package taskmodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Filter selects tasks by their attributes. Zero-valued fields match everything.
+type Filter struct {
+	Owner       string
+	CreatedBy   string
+	ProjectID   uuid.UUID
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Statuses    []TaskStatus
+}
+
+func (f Filter) IsEmpty() bool {
+	return f.Owner == "" && f.CreatedBy == "" && f.ProjectID == uuid.Nil && f.CreatedFrom.IsZero() && f.CreatedTo.IsZero() && len(f.Statuses) == 0
+}
+
+func (f Filter) Match(task *Task) bool {
+	if f.Owner != "" && task.Owner != f.Owner {
+		return false
+	}
+	if f.CreatedBy != "" && task.CreatedBy != f.CreatedBy {
+		return false
+	}
+	if f.ProjectID != uuid.Nil && task.ProjectID != f.ProjectID {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && task.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !task.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, status := range f.Statuses {
+		if task.Status == status {
+			return true
+		}
+	}
+	return false
+}
@@ -1,5 +1,11 @@
 package taskmodel
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type Option func(*Task)
 
 func WithName(name string) Option {
@@ -7,3 +13,109 @@ func WithName(name string) Option {
 		t.Name = name
 	}
 }
+
+func WithType(taskType string) Option {
+	return func(t *Task) {
+		if taskType != "" {
+			t.Type = taskType
+		}
+	}
+}
+
+func WithProject(projectID uuid.UUID) Option {
+	return func(t *Task) {
+		t.ProjectID = projectID
+	}
+}
+
+func WithRequestID(requestID string) Option {
+	return func(t *Task) {
+		t.RequestID = requestID
+	}
+}
+
+func WithTraceContext(carrier map[string]string) Option {
+	return func(t *Task) {
+		t.TraceContext = carrier
+	}
+}
+
+// WithExpiresAt fails the task unless it has finished by expiresAt.
+func WithExpiresAt(expiresAt time.Time) Option {
+	return func(t *Task) {
+		t.ExpiresAt = expiresAt
+	}
+}
+
+// WithInputs attaches the uploaded files inputs to the task.
+func WithInputs(inputs []Attachment) Option {
+	return func(t *Task) {
+		t.Inputs = inputs
+	}
+}
+
+// WithMaxRuntime cancels the task after maxRuntime instead of the default
+// timeout.
+func WithMaxRuntime(maxRuntime time.Duration) Option {
+	return func(t *Task) {
+		t.MaxRuntime = maxRuntime
+	}
+}
+
+// WithDeleteAfter deletes the task deleteAfter after it has finished.
+func WithDeleteAfter(deleteAfter time.Duration) Option {
+	return func(t *Task) {
+		t.DeleteAfter = deleteAfter
+	}
+}
+
+// WithPriority orders the task among the tasks waiting for a worker.
+func WithPriority(priority Priority) Option {
+	return func(t *Task) {
+		t.Priority = priority
+	}
+}
+
+// WithPool runs the task on the workers of the named pool.
+func WithPool(pool string) Option {
+	return func(t *Task) {
+		t.Pool = pool
+	}
+}
+
+// WithSerialKey runs the task one at a time with the other tasks having
+// key, in the order they were created.
+func WithSerialKey(key string) Option {
+	return func(t *Task) {
+		t.SerialKey = key
+	}
+}
+
+// WithDedupKey detects repeated submissions of the task by key instead of
+// its name.
+func WithDedupKey(key string) Option {
+	return func(t *Task) {
+		t.DedupKey = key
+	}
+}
+
+// WithUniqueName creates the task only while no other active task of its
+// owner has its name.
+func WithUniqueName() Option {
+	return func(t *Task) {
+		t.UniqueName = true
+	}
+}
+
+// WithCreatedBy records the authenticated caller creating the task.
+func WithCreatedBy(principal string) Option {
+	return func(t *Task) {
+		t.CreatedBy = principal
+	}
+}
+
+func WithOwner(owner string) Option {
+	return func(t *Task) {
+		t.Owner = owner
+	}
+}
@@ -0,0 +1,53 @@
+package taskmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority orders the tasks waiting for a worker: a waiting task of higher
+// priority starts first, tasks of equal priority start in queue order.
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	// PriorityNormal is the zero value, assigned to tasks created without
+	// a priority.
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:      "low",
+	PriorityNormal:   "normal",
+	PriorityHigh:     "high",
+	PriorityCritical: "critical",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority returns the priority named by s in any case; the empty
+// string is PriorityNormal.
+func ParsePriority(s string) (Priority, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "" {
+		return PriorityNormal, nil
+	}
+	for priority, priorityName := range priorityNames {
+		if priorityName == name {
+			return priority, nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("unknown task priority %q", s)
+}
+
+// Priorities returns every priority, lowest first.
+func Priorities() []Priority {
+	return []Priority{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical}
+}
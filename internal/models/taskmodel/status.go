@@ -0,0 +1,50 @@
+package taskmodel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransition is returned for a status change the task state
+// machine does not allow, such as a failed task turning DONE.
+var ErrInvalidTransition = errors.New("invalid task status transition")
+
+// transitions lists the statuses each status may change to. A new task has
+// no status yet; final statuses have no way out. Waiting tasks may fail
+// without running, e.g. when they expire, but complete only once running.
+// A running task preempted by a critical one is queued again.
+var transitions = map[TaskStatus][]TaskStatus{
+	"":               {StatusPending, StatusQueued, StatusProcessing},
+	StatusPending:    {StatusQueued, StatusProcessing, StatusFailed, StatusCancelled},
+	StatusQueued:     {StatusProcessing, StatusFailed, StatusCancelled},
+	StatusProcessing: {StatusQueued, StatusDone, StatusFailed, StatusCancelled},
+}
+
+// IsFinal reports whether no further transition is allowed from s.
+func (s TaskStatus) IsFinal() bool {
+	return s != "" && len(transitions[s]) == 0
+}
+
+// CanTransitionTo reports whether a task in status s may move to next.
+// Staying in a non-final status is allowed, e.g. to record progress.
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	if s == next {
+		return !s.IsFinal()
+	}
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the task to next or returns an error wrapping
+// ErrInvalidTransition, leaving the task unchanged.
+func (t *Task) Transition(next TaskStatus) error {
+	if !t.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, t.Status, next)
+	}
+	t.Status = next
+	return nil
+}
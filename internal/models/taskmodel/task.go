@@ -1,29 +1,156 @@
 package taskmodel
 
 import (
-	"github.com/google/uuid"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// DefaultType is assigned to tasks created without an explicit type.
+const DefaultType = "default"
+
+// DefaultPool runs the tasks created without an explicit worker pool.
+const DefaultPool = "default"
+
 type TaskStatus string
 
 const (
-	StatusDone       TaskStatus = "DONE"
+	// StatusPending is a stored task not handed to an executor yet.
+	StatusPending TaskStatus = "PENDING"
+	// StatusQueued is a task waiting for a free worker.
+	StatusQueued     TaskStatus = "QUEUED"
 	StatusProcessing TaskStatus = "PROCESSING"
+	StatusDone       TaskStatus = "DONE"
 	StatusFailed     TaskStatus = "FAILED"
+	// StatusCancelled is a task its user cancelled before it finished.
+	StatusCancelled TaskStatus = "CANCELLED"
 )
 
+func (s TaskStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusQueued, StatusProcessing, StatusDone, StatusFailed, StatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsWaiting reports whether a task in the status has not started yet.
+func (s TaskStatus) IsWaiting() bool {
+	return s == StatusPending || s == StatusQueued
+}
+
+// IsActive reports whether a task in the status is still to finish.
+func (s TaskStatus) IsActive() bool {
+	return s.IsWaiting() || s == StatusProcessing
+}
+
+// ParseStatus returns the status named by s in any case.
+func ParseStatus(s string) (TaskStatus, error) {
+	status := TaskStatus(strings.ToUpper(strings.TrimSpace(s)))
+	if !status.IsValid() {
+		return "", fmt.Errorf("unknown task status %q", s)
+	}
+	return status, nil
+}
+
+// UnmarshalText accepts the known statuses in any case, and the empty
+// status of a task not created yet.
+func (s *TaskStatus) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = ""
+		return nil
+	}
+	status, err := ParseStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// Statuses returns every known task status in lifecycle order.
+func Statuses() []TaskStatus {
+	return []TaskStatus{StatusPending, StatusQueued, StatusProcessing, StatusDone, StatusFailed, StatusCancelled}
+}
+
+// ActiveStatuses returns the statuses of tasks still to finish.
+func ActiveStatuses() []TaskStatus {
+	return []TaskStatus{StatusPending, StatusQueued, StatusProcessing}
+}
+
 type Task struct {
-	ID             uuid.UUID
-	Name           string
-	Status         TaskStatus
-	CreatedAt      time.Time
+	ID    uuid.UUID
+	Name  string
+	Type  string
+	Owner string
+	// CreatedBy is the authenticated caller that created the task, empty
+	// for tasks created without authentication.
+	CreatedBy string
+	ProjectID uuid.UUID
+	RequestID string
+	Status    TaskStatus
+	CreatedAt time.Time
+	// StartedAt is when a worker picked the task up, zero while it is queued.
+	StartedAt time.Time
+	// FinishedAt is when the task reached a final status, zero while it runs.
+	FinishedAt     time.Time
 	ProcessingTime time.Duration
+	// ExpiresAt is when the task is failed unless it has finished by then;
+	// zero means never.
+	ExpiresAt time.Time
+	// MaxRuntime cancels the task if it has not finished that long after
+	// it was created, instead of the default timeout of the service; zero
+	// uses the default.
+	MaxRuntime time.Duration
+	// DeleteAfter is how long the task is kept once it has finished; zero
+	// keeps it until retention removes it.
+	DeleteAfter time.Duration
+	// FailureReason explains why the task failed, when that is known.
+	FailureReason string
+	// CancelReason is what the user gave as the reason for cancelling a
+	// CANCELLED task, if anything.
+	CancelReason string
+	// DedupKey identifies repeated submissions of the task instead of its
+	// name; see SubmissionKey.
+	DedupKey string
+	// UniqueName asked for the task to be created only while no other
+	// active task of its owner has its name.
+	UniqueName bool
+	// Priority orders the task among the tasks waiting for a worker.
+	Priority Priority
+	// Pool names the worker pool the task runs on.
+	Pool string
+	// SerialKey makes the task run only once the tasks with the same key
+	// created before it have finished, one at a time; empty runs it as
+	// soon as a worker is free.
+	SerialKey string
+	// Preemptions counts how many times the task gave its worker up to a
+	// critical task while running.
+	Preemptions int
+	// Slow is set once the task has been processing for longer than the
+	// slow threshold of its type.
+	Slow bool
+	// Attempts are the executions of the task, oldest first.
+	Attempts []Attempt
+	// Inputs are the files uploaded together with the task.
+	Inputs []Attachment
+	// Artifacts are the files the task produced, stored when it completes.
+	Artifacts []Attachment
+	// TraceContext holds the propagation headers (traceparent, baggage) of
+	// the request that created the task, so its execution joins the same trace.
+	TraceContext map[string]string
 }
 
 func NewTask(opts ...Option) *Task {
 	task := new(Task)
 	task.ID = uuid.New()
+	task.Type = DefaultType
+	task.Pool = DefaultPool
 
 	for _, opt := range opts {
 		opt(task)
@@ -32,6 +159,25 @@ func NewTask(opts ...Option) *Task {
 	return task
 }
 
+// Clone returns a copy of the task that shares no memory with it.
+func (t *Task) Clone() *Task {
+	clone := *t
+	clone.TraceContext = maps.Clone(t.TraceContext)
+	clone.Attempts = slices.Clone(t.Attempts)
+	clone.Inputs = slices.Clone(t.Inputs)
+	clone.Artifacts = slices.Clone(t.Artifacts)
+	return &clone
+}
+
+// SubmissionKey returns the key repeated submissions of the task are detected
+// by: its DedupKey, or its name when it has none.
+func (t *Task) SubmissionKey() string {
+	if t.DedupKey != "" {
+		return t.DedupKey
+	}
+	return t.Name
+}
+
 func (t *Task) IsDone() bool {
 	return t.Status == StatusDone
 }
@@ -44,6 +190,22 @@ func (t *Task) IsProcessing() bool {
 	return t.Status == StatusProcessing
 }
 
+func (t *Task) IsCancelled() bool {
+	return t.Status == StatusCancelled
+}
+
+// IsWaiting reports whether the task is PENDING or QUEUED.
+func (t *Task) IsWaiting() bool {
+	return t.Status.IsWaiting()
+}
+
+// IsActive reports whether the task is waiting or processing.
+func (t *Task) IsActive() bool {
+	return t.Status.IsActive()
+}
+
+// SetStatus assigns status without checking the state machine; use
+// Transition for status changes.
 func (t *Task) SetStatus(status TaskStatus) {
 	t.Status = status
 }
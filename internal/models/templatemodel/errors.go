@@ -0,0 +1,10 @@
+package templatemodel
+
+import "errors"
+
+// Repositories wrap these errors so that callers can tell a missing template
+// from a storage failure with errors.Is.
+var (
+	ErrTemplateNotFound      = errors.New("template not found")
+	ErrTemplateAlreadyExists = errors.New("template already exists")
+)
@@ -0,0 +1,47 @@
+package templatemodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+type Option func(*Template)
+
+func WithNamePattern(pattern string) Option {
+	return func(t *Template) {
+		t.NamePattern = pattern
+	}
+}
+
+func WithType(taskType string) Option {
+	return func(t *Template) {
+		t.Type = taskType
+	}
+}
+
+func WithProject(projectID uuid.UUID) Option {
+	return func(t *Template) {
+		t.ProjectID = projectID
+	}
+}
+
+func WithMaxRuntime(maxRuntime time.Duration) Option {
+	return func(t *Template) {
+		t.MaxRuntime = maxRuntime
+	}
+}
+
+func WithDeleteAfter(deleteAfter time.Duration) Option {
+	return func(t *Template) {
+		t.DeleteAfter = deleteAfter
+	}
+}
+
+func WithPriority(priority taskmodel.Priority) Option {
+	return func(t *Template) {
+		t.Priority = priority
+	}
+}
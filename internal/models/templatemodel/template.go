@@ -0,0 +1,52 @@
+package templatemodel
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// Template is a predefined shape of task that clients instantiate instead
+// of repeating the same create request.
+type Template struct {
+	ID uuid.UUID
+	// NamePattern is the name of the created tasks, in which {date}, {time}
+	// and {seq} are replaced; see TaskName.
+	NamePattern string
+	Type        string
+	ProjectID   uuid.UUID
+	MaxRuntime  time.Duration
+	DeleteAfter time.Duration
+	// Priority is given to the created tasks.
+	Priority taskmodel.Priority
+	// Instances counts the tasks created from the template.
+	Instances int
+	CreatedAt time.Time
+}
+
+func NewTemplate(opts ...Option) *Template {
+	template := new(Template)
+	template.ID = uuid.New()
+
+	for _, opt := range opts {
+		opt(template)
+	}
+
+	return template
+}
+
+// TaskName expands the name pattern for the seq-th task created at now:
+// {date} becomes the UTC date (2006-01-02), {time} the UTC time (15:04:05)
+// and {seq} the number of the task.
+func (t *Template) TaskName(now time.Time, seq int) string {
+	now = now.UTC()
+	return strings.NewReplacer(
+		"{date}", now.Format(time.DateOnly),
+		"{time}", now.Format(time.TimeOnly),
+		"{seq}", strconv.Itoa(seq),
+	).Replace(t.NamePattern)
+}
@@ -0,0 +1,248 @@
+// Package openapi builds the OpenAPI 3 document of the API at runtime. The
+// paths are the routes registered on the engine and the schemas are
+// derived from the request and response types, including their validation
+// rules, so the document cannot drift from the code. Controllers describe
+// their handlers with Describe; routes nobody described are still listed.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of the generated document.
+const Version = "3.0.3"
+
+// Parameter locations.
+const (
+	InPath  = "path"
+	InQuery = "query"
+)
+
+// Info describes the API as a whole.
+type Info struct {
+	Title       string
+	Description string
+	Version     string
+	// AdminDescription describes the admin token of operations with Admin set.
+	AdminDescription string
+}
+
+// Operation documents a handler.
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []Param
+	// Request is a value of the JSON request body type, nil without a body.
+	Request   any
+	Responses []Response
+	// Admin operations require the admin token.
+	Admin bool
+}
+
+// Param is a path or query parameter. Path parameters of the route that
+// are not listed are documented as plain strings.
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	// Type is "string", "integer" or "boolean"; "string" when empty.
+	Type     string
+	Required bool
+	Default  any
+	Maximum  int
+}
+
+// Response is one documented outcome of an operation.
+type Response struct {
+	Status      int
+	Description string
+	// Body is a value of the JSON response body type, nil without a body.
+	Body any
+	// Headers maps response header names to their descriptions.
+	Headers map[string]string
+}
+
+// Spec collects the operations of the handlers and renders the document
+// for the routes of an engine.
+type Spec struct {
+	info       Info
+	operations map[string]Operation
+	enums      map[reflect.Type][]any
+}
+
+func New(info Info) *Spec {
+	return &Spec{
+		info:       info,
+		operations: make(map[string]Operation),
+		enums:      make(map[reflect.Type][]any),
+	}
+}
+
+// Enum lists the values of the type of value, e.g. the constants of a
+// string type, wherever the type appears in a schema.
+func (s *Spec) Enum(value any, values ...any) {
+	s.enums[reflect.TypeOf(value)] = values
+}
+
+// Describe documents handler wherever it is registered.
+func (s *Spec) Describe(handler gin.HandlerFunc, operation Operation) {
+	s.operations[handlerName(handler)] = operation
+}
+
+// handlerName identifies a handler the way gin.RouteInfo.Handler does.
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
+
+// Handler serves the document of the routes registered on engine.
+func (s *Spec) Handler(engine *gin.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, s.Document(engine.Routes()))
+	}
+}
+
+// Document renders the OpenAPI document of routes. Wildcard routes, which
+// OpenAPI cannot express, are left out.
+func (s *Spec) Document(routes gin.RoutesInfo) map[string]any {
+	schemas := newSchemas(s.enums)
+	paths := make(map[string]map[string]any)
+	for _, route := range routes {
+		if strings.Contains(route.Path, "*") {
+			continue
+		}
+		path, pathParams := convertPath(route.Path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+		operation, ok := s.operations[route.Handler]
+		if !ok {
+			operation = Operation{Responses: []Response{{Status: http.StatusOK, Description: "Undocumented"}}}
+		}
+		paths[path][strings.ToLower(route.Method)] = s.operation(operation, pathParams, schemas)
+	}
+
+	info := map[string]any{"title": s.info.Title, "version": s.info.Version}
+	if s.info.Description != "" {
+		info["description"] = s.info.Description
+	}
+	return map[string]any{
+		"openapi": Version,
+		"info":    info,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"AdminToken": map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "Authorization",
+					"description": s.info.AdminDescription,
+				},
+			},
+		},
+	}
+}
+
+// convertPath turns /task/:id into /task/{id} and returns the parameter names.
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func (s *Spec) operation(operation Operation, pathParams []string, schemas *schemas) map[string]any {
+	result := map[string]any{}
+	if operation.Summary != "" {
+		result["summary"] = operation.Summary
+	}
+	if operation.Description != "" {
+		result["description"] = operation.Description
+	}
+	if len(operation.Tags) > 0 {
+		result["tags"] = operation.Tags
+	}
+	if operation.Admin {
+		result["security"] = []map[string][]string{{"AdminToken": {}}}
+	}
+
+	described := make(map[string]bool)
+	var params []map[string]any
+	for _, param := range operation.Params {
+		described[param.In+":"+param.Name] = true
+		params = append(params, parameter(param))
+	}
+	for _, name := range pathParams {
+		if !described[InPath+":"+name] {
+			params = append(params, parameter(Param{Name: name, In: InPath}))
+		}
+	}
+	if len(params) > 0 {
+		result["parameters"] = params
+	}
+
+	if operation.Request != nil {
+		result["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(operation.Request))},
+			},
+		}
+	}
+
+	responses := make(map[string]any)
+	for _, response := range operation.Responses {
+		rendered := map[string]any{"description": response.Description}
+		if response.Body != nil {
+			rendered["content"] = map[string]any{
+				"application/json": map[string]any{"schema": schemas.of(reflect.TypeOf(response.Body))},
+			}
+		}
+		if len(response.Headers) > 0 {
+			headers := make(map[string]any)
+			for name, description := range response.Headers {
+				headers[name] = map[string]any{"description": description, "schema": map[string]any{"type": "string"}}
+			}
+			rendered["headers"] = headers
+		}
+		responses[strconv.Itoa(response.Status)] = rendered
+	}
+	result["responses"] = responses
+	return result
+}
+
+func parameter(param Param) map[string]any {
+	schema := map[string]any{"type": "string"}
+	if param.Type != "" {
+		schema["type"] = param.Type
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+	if param.Maximum != 0 {
+		schema["maximum"] = param.Maximum
+	}
+
+	rendered := map[string]any{
+		"name":     param.Name,
+		"in":       param.In,
+		"required": param.Required || param.In == InPath,
+		"schema":   schema,
+	}
+	if param.Description != "" {
+		rendered["description"] = param.Description
+	}
+	return rendered
+}
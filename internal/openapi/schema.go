@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	uuidType     = reflect.TypeOf(uuid.UUID{})
+)
+
+// schemas renders Go types as JSON schemas; structs become components
+// named after their package and type, e.g. "taskcontroller.TaskResponse".
+type schemas struct {
+	components map[string]any
+	enums      map[reflect.Type][]any
+}
+
+func newSchemas(enums map[reflect.Type][]any) *schemas {
+	return &schemas{components: make(map[string]any), enums: enums}
+}
+
+func (s *schemas) of(t reflect.Type) map[string]any {
+	if values, ok := s.enums[t]; ok {
+		schema := s.kind(t)
+		schema["enum"] = values
+		return schema
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "Nanoseconds"}
+	}
+	return s.kind(t)
+}
+
+func (s *schemas) kind(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.Struct:
+		name := t.String()
+		if _, ok := s.components[name]; !ok {
+			// The placeholder ends the recursion of self-referencing types.
+			s.components[name] = nil
+			s.components[name] = s.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object renders the JSON fields of a struct. A field is required when its
+// binding rules say so or, without binding rules, when it is always encoded.
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	s.fields(t, properties, &required)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+func (s *schemas) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.fields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := s.of(field.Type)
+		binding, hasBinding := field.Tag.Lookup("binding")
+		if _, isRef := schema["$ref"]; !isRef {
+			applyRules(schema, strings.Split(binding, ","))
+			if enums := field.Tag.Get("enums"); enums != "" {
+				schema["enum"] = strings.Split(enums, ",")
+			}
+			if example, ok := field.Tag.Lookup("example"); ok {
+				schema["example"] = exampleValue(example, schema["type"])
+			}
+		}
+		properties[name] = schema
+
+		optional := strings.Contains(options, "omitempty") || field.Type.Kind() == reflect.Pointer
+		if hasBinding {
+			optional = !hasRule(binding, "required")
+		}
+		if !optional {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applyRules documents the length and range rules of a binding tag.
+func applyRules(schema map[string]any, rules []string) {
+	for _, rule := range rules {
+		key, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			continue
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case schema["type"] == "string" && key == "min":
+			schema["minLength"] = limit
+		case schema["type"] == "string" && key == "max":
+			schema["maxLength"] = limit
+		case schema["type"] == "array" && key == "min":
+			schema["minItems"] = limit
+		case schema["type"] == "array" && key == "max":
+			schema["maxItems"] = limit
+		case key == "min":
+			schema["minimum"] = limit
+		case key == "max":
+			schema["maximum"] = limit
+		}
+	}
+}
+
+func hasRule(binding, name string) bool {
+	for _, rule := range strings.Split(binding, ",") {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+func exampleValue(example string, schemaType any) any {
+	if schemaType == "string" {
+		return example
+	}
+	var value any
+	if json.Unmarshal([]byte(example), &value) == nil {
+		return value
+	}
+	return example
+}
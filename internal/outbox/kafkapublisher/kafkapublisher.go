@@ -0,0 +1,62 @@
+// Package kafkapublisher publishes task events to a Kafka topic. Messages
+// are keyed by task ID, so the events of a task stay in one partition and
+// are consumed in order.
+package kafkapublisher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/nzb3/workmate_test/internal/outbox"
+)
+
+// Publisher is an outbox.Publisher writing to a Kafka topic.
+type Publisher struct {
+	writer *kafka.Writer
+}
+
+// New creates a publisher for topic. Connections are opened on the first
+// publish.
+func New(brokers []string, topic string) *Publisher {
+	return &Publisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// The relay publishes one event at a time and waits for it,
+			// so there is nothing to batch.
+			BatchSize: 1,
+		},
+	}
+}
+
+// Publish writes the event and returns once all in-sync replicas have it.
+func (p *Publisher) Publish(ctx context.Context, message outbox.Message) error {
+	value, err := outbox.Encode(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", message.ID(), err)
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(message.Event.TaskID.String()),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "schema-version", Value: []byte(strconv.Itoa(outbox.SchemaVersion))},
+			{Key: "event-type", Value: []byte(message.Event.Type)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", message.ID(), err)
+	}
+	return nil
+}
+
+// Close flushes and closes the connections.
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,146 @@
+// Package outbox relays task events to external systems. Events are
+// recorded in an outbox by the task repository while the change they
+// describe is stored, and published from there asynchronously, so a
+// failing or slow publisher neither loses events nor blocks writes.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// DefaultInterval is how often the relay looks for pending messages.
+const DefaultInterval = time.Second
+
+// batchSize bounds the messages published per pass of the relay.
+const batchSize = 100
+
+// Message is a task event waiting in the outbox.
+type Message struct {
+	// Seq orders the messages of the outbox.
+	Seq   uint64
+	Event taskmodel.Event
+}
+
+// ID identifies the event to consumers. Messages are delivered at least
+// once, so consumers drop the ones whose ID they have already seen.
+func (m Message) ID() string {
+	return fmt.Sprintf("%s/%d", m.Event.TaskID, m.Event.Version)
+}
+
+// Store holds the messages that have not been published yet.
+type Store interface {
+	// PendingEvents returns up to limit messages in outbox order.
+	PendingEvents(ctx context.Context, limit int) ([]Message, error)
+	// AckEvents removes the messages up to and including seq.
+	AckEvents(ctx context.Context, seq uint64) error
+}
+
+// Publisher delivers a message to an external system.
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+}
+
+// Publishers publishes every message to each of its publishers in turn.
+// A message one of them fails is retried on all of them; consumers drop
+// the copies by the message ID.
+type Publishers []Publisher
+
+func (p Publishers) Publish(ctx context.Context, message Message) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the publishers that hold connections.
+func (p Publishers) Close() error {
+	var errs []error
+	for _, publisher := range p {
+		if closer, ok := publisher.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Relay publishes the messages of a store in order. A message is removed
+// only after it was published; a failed one is retried on the next pass
+// together with the messages behind it.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+func NewRelay(store Store, publisher Publisher, interval time.Duration, logger *slog.Logger) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Run publishes pending messages every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+				r.logger.WarnContext(ctx, "Failed to publish task events, retrying", "error", err)
+			}
+		}
+	}
+}
+
+// Flush publishes the pending messages until the outbox is empty or a
+// message fails. It returns the number of published messages.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		messages, err := r.store.PendingEvents(ctx, batchSize)
+		if err != nil {
+			return published, fmt.Errorf("failed to read outbox: %w", err)
+		}
+		if len(messages) == 0 {
+			return published, nil
+		}
+
+		sent, publishErr := r.publish(ctx, messages)
+		published += sent
+		if sent > 0 {
+			if err := r.store.AckEvents(ctx, messages[sent-1].Seq); err != nil {
+				return published, fmt.Errorf("failed to acknowledge events: %w", err)
+			}
+		}
+		if publishErr != nil {
+			return published, publishErr
+		}
+	}
+}
+
+// publish sends messages in order and returns how many were sent before
+// the first failure.
+func (r *Relay) publish(ctx context.Context, messages []Message) (int, error) {
+	for i, message := range messages {
+		if err := r.publisher.Publish(ctx, message); err != nil {
+			return i, fmt.Errorf("failed to publish event %s: %w", message.ID(), err)
+		}
+	}
+	return len(messages), nil
+}
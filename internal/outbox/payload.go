@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// SchemaVersion is the version of the JSON payload. It is raised whenever
+// a field is removed or changes its meaning; new optional fields keep it.
+const SchemaVersion = 1
+
+// Payload is the JSON value of a published event.
+type Payload struct {
+	SchemaVersion int `json:"schema_version"`
+	// ID is the outbox message ID; consumers drop IDs they have seen.
+	ID         string              `json:"id"`
+	TaskID     uuid.UUID           `json:"task_id"`
+	Version    int                 `json:"version"`
+	Type       taskmodel.EventType `json:"type"`
+	At         time.Time           `json:"at"`
+	Task       *TaskPayload        `json:"task,omitempty"`
+	StartedAt  *time.Time          `json:"started_at,omitempty"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	// ProcessingTime is in nanoseconds and kept for compatibility; use
+	// ProcessingTimeMs instead.
+	ProcessingTime   time.Duration `json:"processing_time"`
+	ProcessingTimeMs int64         `json:"processing_time_ms"`
+	// FailureReason is carried by failed events when the reason is known.
+	FailureReason string `json:"failure_reason,omitempty"`
+	// CancelReason is carried by cancelled events when the user gave one.
+	CancelReason string `json:"cancel_reason,omitempty"`
+	// Attempt is carried by the events that start or end an attempt.
+	Attempt *AttemptPayload `json:"attempt,omitempty"`
+}
+
+// AttemptPayload is an execution attempt of a task.
+type AttemptPayload struct {
+	Number     int                      `json:"number"`
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt *time.Time               `json:"finished_at,omitempty"`
+	Outcome    taskmodel.AttemptOutcome `json:"outcome"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// TaskPayload is the initial state of a task, carried by the created event.
+type TaskPayload struct {
+	Name      string               `json:"name"`
+	Type      string               `json:"type"`
+	Owner     string               `json:"owner,omitempty"`
+	CreatedBy string               `json:"created_by,omitempty"`
+	ProjectID *uuid.UUID           `json:"project_id,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+	Status    taskmodel.TaskStatus `json:"status"`
+	CreatedAt time.Time            `json:"created_at"`
+	ExpiresAt *time.Time           `json:"expires_at,omitempty"`
+}
+
+// Encode returns the JSON payload of message.
+func Encode(message Message) ([]byte, error) {
+	return json.Marshal(NewPayload(message))
+}
+
+// NewPayload returns the payload of message.
+func NewPayload(message Message) Payload {
+	event := message.Event
+	payload := Payload{
+		SchemaVersion:    SchemaVersion,
+		ID:               message.ID(),
+		TaskID:           event.TaskID,
+		Version:          event.Version,
+		Type:             event.Type,
+		At:               event.At,
+		ProcessingTime:   event.ProcessingTime,
+		ProcessingTimeMs: event.ProcessingTime.Milliseconds(),
+		FailureReason:    event.FailureReason,
+		CancelReason:     event.CancelReason,
+	}
+	if task := event.Task; task != nil {
+		payload.Task = &TaskPayload{
+			Name:      task.Name,
+			Type:      task.Type,
+			Owner:     task.Owner,
+			CreatedBy: task.CreatedBy,
+			RequestID: task.RequestID,
+			Status:    task.Status,
+			CreatedAt: task.CreatedAt,
+		}
+		if task.ProjectID != uuid.Nil {
+			payload.Task.ProjectID = &task.ProjectID
+		}
+		if !task.ExpiresAt.IsZero() {
+			payload.Task.ExpiresAt = &task.ExpiresAt
+		}
+	}
+	if !event.StartedAt.IsZero() {
+		payload.StartedAt = &event.StartedAt
+	}
+	if !event.FinishedAt.IsZero() {
+		payload.FinishedAt = &event.FinishedAt
+	}
+	if attempt := event.Attempt; attempt != nil {
+		payload.Attempt = &AttemptPayload{
+			Number:    attempt.Number,
+			StartedAt: attempt.StartedAt,
+			Outcome:   attempt.Outcome,
+			Error:     attempt.Error,
+		}
+		if !attempt.FinishedAt.IsZero() {
+			payload.Attempt.FinishedAt = &attempt.FinishedAt
+		}
+	}
+	return payload
+}
@@ -0,0 +1,249 @@
+// Package webhookpublisher posts task events to an HTTP endpoint. The body
+// is the JSON payload of the event unless a Go template renders it, and
+// requests only go to allowed hosts, optionally through a proxy. Requests
+// continue the trace and carry the request ID of the request that created
+// the task.
+package webhookpublisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/outbox"
+	"github.com/nzb3/workmate_test/internal/requestid"
+)
+
+var tracer = otel.Tracer("github.com/nzb3/workmate_test/internal/outbox/webhookpublisher")
+
+// maxRedirects bounds the redirects followed for one event.
+const maxRedirects = 5
+
+// Config describes the webhook.
+type Config struct {
+	URL string
+	// Template renders the request body; the JSON payload of the event is
+	// sent when it is empty.
+	Template string
+	// Headers are added to every request; they may override Content-Type.
+	Headers map[string]string
+	// AllowedHosts restricts the hosts requests and redirects may go to;
+	// "*.example.com" allows the subdomains of example.com. Any host is
+	// allowed when it is empty.
+	AllowedHosts []string
+	// Proxy is the URL of the outbound proxy; the HTTP_PROXY and
+	// HTTPS_PROXY environment variables are used when it is empty.
+	Proxy   string
+	Timeout time.Duration
+}
+
+// Tasks looks up the current state of a task.
+type Tasks interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*taskmodel.Task, error)
+}
+
+// Data is what the body template is executed with: the fields of the
+// event payload, e.g. {{.Type}} or {{.TaskID}}, and the task.
+type Data struct {
+	outbox.Payload
+	// Current is the task as stored when the event is delivered, which may
+	// be ahead of the event; nil once the task has been deleted.
+	Current *taskmodel.Task
+}
+
+// templateFuncs are available in body templates.
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. {{json .Current.Name}} for a quoted string.
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// ErrHostNotAllowed is returned for requests to hosts missing from the
+// allowlist.
+var ErrHostNotAllowed = errors.New("webhook host is not allowed")
+
+// Publisher is an outbox.Publisher posting to a webhook.
+type Publisher struct {
+	url      string
+	template *template.Template
+	headers  map[string]string
+	allowed  []string
+	tasks    Tasks
+	client   *http.Client
+}
+
+// New validates cfg and creates a publisher; tasks resolve the current
+// state of the task for templates.
+func New(cfg Config, tasks Tasks) (*Publisher, error) {
+	p := &Publisher{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		allowed: cfg.AllowedHosts,
+		tasks:   tasks,
+	}
+
+	target, err := url.Parse(cfg.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", cfg.URL)
+	}
+	if !p.hostAllowed(target.Hostname()) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, target.Hostname())
+	}
+
+	if cfg.Template != "" {
+		if p.template, err = template.New("webhook").Funcs(templateFuncs).Parse(cfg.Template); err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %w", err)
+		}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid webhook proxy %q", cfg.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+
+	p.client = &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if !p.hostAllowed(req.URL.Hostname()) {
+				return fmt.Errorf("%w: redirect to %s", ErrHostNotAllowed, req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+	return p, nil
+}
+
+func (p *Publisher) hostAllowed(host string) bool {
+	if len(p.allowed) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range p.allowed {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish posts the event and succeeds on a 2xx response. The request is
+// sent in a client span continuing the trace stored on the task, whose
+// context it propagates together with the request ID of the task.
+func (p *Publisher) Publish(ctx context.Context, message outbox.Message) (err error) {
+	current, err := p.current(ctx, message)
+	if err != nil {
+		return fmt.Errorf("failed to get task of event %s: %w", message.ID(), err)
+	}
+	body, err := p.body(message, current)
+	if err != nil {
+		return fmt.Errorf("failed to render event %s: %w", message.ID(), err)
+	}
+
+	// The created event carries the task; later ones rely on the stored
+	// task, so the deleted event starts a trace of its own.
+	origin := message.Event.Task
+	if origin == nil {
+		origin = current
+	}
+	if origin != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(origin.TraceContext))
+	}
+	ctx, span := tracer.Start(ctx, "webhook.publish",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("task.id", message.Event.TaskID.String()),
+			attribute.String("event.type", string(message.Event.Type)),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", message.ID())
+	req.Header.Set("X-Event-Type", string(message.Event.Type))
+	req.Header.Set("X-Schema-Version", strconv.Itoa(outbox.SchemaVersion))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if origin != nil && origin.RequestID != "" {
+		req.Header.Set(requestid.Header, origin.RequestID)
+	}
+	for key, value := range p.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// current returns the task of the event as stored now, nil once it has
+// been deleted.
+func (p *Publisher) current(ctx context.Context, message outbox.Message) (*taskmodel.Task, error) {
+	task, err := p.tasks.GetByID(ctx, message.Event.TaskID)
+	if errors.Is(err, taskmodel.ErrTaskNotFound) {
+		return nil, nil
+	}
+	return task, err
+}
+
+func (p *Publisher) body(message outbox.Message, current *taskmodel.Task) ([]byte, error) {
+	if p.template == nil {
+		return outbox.Encode(message)
+	}
+
+	data := Data{Payload: outbox.NewPayload(message), Current: current}
+	var body bytes.Buffer
+	if err := p.template.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
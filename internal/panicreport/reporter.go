@@ -0,0 +1,59 @@
+package panicreport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const sendTimeout = 10 * time.Second
+
+// Report describes a recovered panic.
+type Report struct {
+	Message string
+	Stack   []byte
+	Time    time.Time
+	Tags    map[string]string
+}
+
+func NewReport(recovered any, stack []byte, tags map[string]string) Report {
+	return Report{
+		Message: fmt.Sprint(recovered),
+		Stack:   stack,
+		Time:    time.Now().UTC(),
+		Tags:    tags,
+	}
+}
+
+// Reporter delivers panic reports to an error-reporting sink.
+type Reporter interface {
+	Report(ctx context.Context, report Report)
+}
+
+type sender interface {
+	send(ctx context.Context, report Report) error
+}
+
+// asyncReporter sends reports in the background so a slow sink never delays
+// the recovering request or executor.
+type asyncReporter struct {
+	name   string
+	sender sender
+}
+
+func (r *asyncReporter) Report(ctx context.Context, report Report) {
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+		defer cancel()
+
+		if err := r.sender.send(sendCtx, report); err != nil {
+			log.Printf("Failed to send panic report to %s: %v", r.name, err)
+		}
+	}()
+}
+
+// Nop discards reports; the panic is still logged by the caller.
+type Nop struct{}
+
+func (Nop) Report(context.Context, Report) {}
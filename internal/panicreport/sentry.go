@@ -0,0 +1,119 @@
+package panicreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// NewSentryReporter sends reports to the Sentry store API of the project
+// identified by dsn ("https://<key>@<host>/<project>").
+func NewSentryReporter(dsn string, environment string) (Reporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing public key")
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	prefix := ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, prefix, projectID)
+
+	return &asyncReporter{
+		name: "Sentry",
+		sender: &sentrySender{
+			endpoint:    endpoint,
+			auth:        "Sentry sentry_version=7, sentry_client=workmate/1.0, sentry_key=" + parsed.User.Username(),
+			environment: environment,
+			client:      &http.Client{},
+		},
+	}, nil
+}
+
+type sentrySender struct {
+	endpoint    string
+	auth        string
+	environment string
+	client      *http.Client
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message"`
+	Exception   sentryExceptions  `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (s *sentrySender) send(ctx context.Context, report Report) error {
+	event := sentryEvent{
+		EventID:     strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Timestamp:   report.Time.Format("2006-01-02T15:04:05.000Z"),
+		Level:       "fatal",
+		Platform:    "go",
+		Logger:      "panic",
+		Environment: s.environment,
+		Message:     report.Message,
+		Exception: sentryExceptions{Values: []sentryException{{
+			Type:  "panic",
+			Value: report.Message,
+		}}},
+		Tags:  report.Tags,
+		Extra: map[string]string{"stacktrace": string(report.Stack)},
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package panicreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewWebhookReporter posts reports as JSON to url.
+func NewWebhookReporter(url string) Reporter {
+	return &asyncReporter{
+		name: "webhook",
+		sender: &webhookSender{
+			url:    url,
+			client: &http.Client{},
+		},
+	}
+}
+
+type webhookSender struct {
+	url    string
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Message string            `json:"message"`
+	Stack   string            `json:"stack"`
+	Time    time.Time         `json:"time"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+func (s *webhookSender) send(ctx context.Context, report Report) error {
+	body, err := json.Marshal(webhookPayload{
+		Message: report.Message,
+		Stack:   string(report.Stack),
+		Time:    report.Time,
+		Tags:    report.Tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Package natsqueue dispatches tasks through a NATS JetStream work queue.
+// Every instance consumes from one durable consumer, so each task is
+// executed by one of them; a task whose executor disappears without an
+// acknowledgement is delivered again after the ack wait.
+package natsqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// ackWait is how long a delivered task may go without a progress
+	// signal before JetStream delivers it again.
+	ackWait = 30 * time.Second
+	// progressInterval is how often a running task tells JetStream it is
+	// still being worked on.
+	progressInterval = ackWait / 3
+	// fetchWait bounds a single wait for the next task.
+	fetchWait = 5 * time.Second
+	// redeliveryDelay is how long a task whose delivery failed, e.g.
+	// because another executor holds its lock, waits before the next one.
+	redeliveryDelay = 5 * time.Second
+)
+
+// Config names the JetStream resources used for dispatching.
+type Config struct {
+	URL      string
+	Stream   string
+	Subject  string
+	Consumer string
+}
+
+// Queue is a taskservice.Dispatcher backed by JetStream.
+type Queue struct {
+	conn     *nats.Conn
+	js       jetstream.JetStream
+	consumer jetstream.Consumer
+	subject  string
+	logger   *slog.Logger
+}
+
+// New connects to NATS and creates the work-queue stream and the durable
+// consumer unless they exist.
+func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Queue, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("workmate"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      cfg.Stream,
+		Subjects:  []string{cfg.Subject},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:   cfg.Consumer,
+		AckPolicy: jetstream.AckExplicitPolicy,
+		AckWait:   ackWait,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create consumer %s: %w", cfg.Consumer, err)
+	}
+
+	return &Queue{
+		conn:     conn,
+		js:       js,
+		consumer: consumer,
+		subject:  cfg.Subject,
+		logger:   logger,
+	}, nil
+}
+
+// Dispatch publishes the task ID. The ID doubles as the message ID, so a
+// retried publish of the same task is dropped by JetStream.
+func (q *Queue) Dispatch(ctx context.Context, taskID uuid.UUID) error {
+	id := taskID.String()
+	if _, err := q.js.Publish(ctx, q.subject, []byte(id), jetstream.WithMsgID(id)); err != nil {
+		return fmt.Errorf("failed to publish task %s: %w", id, err)
+	}
+	return nil
+}
+
+// Consume fetches a task whenever one of the workers is free and runs
+// handle for it in its own goroutine. The message is acknowledged when
+// handle succeeds and negatively acknowledged when it fails, so JetStream
+// delivers it again after redeliveryDelay. Handlers still running when ctx is done acknowledge
+// their messages once they finish.
+func (q *Queue) Consume(ctx context.Context, workers int, handle func(ctx context.Context, taskID uuid.UUID) error) error {
+	slots := make(chan struct{}, workers)
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		msg, err := q.consumer.Next(jetstream.FetchMaxWait(fetchWait))
+		if err != nil {
+			<-slots
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, nats.ErrTimeout) {
+				continue
+			}
+			q.logger.WarnContext(ctx, "Failed to fetch a task from NATS", "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(fetchWait):
+			}
+			continue
+		}
+
+		go func() {
+			defer func() { <-slots }()
+			q.deliver(ctx, msg, handle)
+		}()
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, msg jetstream.Msg, handle func(ctx context.Context, taskID uuid.UUID) error) {
+	taskID, err := uuid.Parse(string(msg.Data()))
+	if err != nil {
+		q.logger.ErrorContext(ctx, "Dropping a malformed task message", "data", string(msg.Data()), "error", err)
+		_ = msg.Term()
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = msg.InProgress()
+			}
+		}
+	}()
+
+	if err := handle(context.WithoutCancel(ctx), taskID); err != nil {
+		q.logger.WarnContext(ctx, "Task delivery failed, it will be delivered again", "task_id", taskID, "error", err)
+		_ = msg.NakWithDelay(redeliveryDelay)
+		return
+	}
+	if err := msg.Ack(); err != nil {
+		q.logger.WarnContext(ctx, "Failed to acknowledge a task", "task_id", taskID, "error", err)
+	}
+}
+
+// Ping checks that the connection to NATS is up.
+func (q *Queue) Ping(ctx context.Context) error {
+	if !q.conn.IsConnected() {
+		return fmt.Errorf("not connected to NATS: %s", q.conn.Status())
+	}
+	return ctx.Err()
+}
+
+// Close drains the connection, flushing pending acknowledgements.
+func (q *Queue) Close() error {
+	return q.conn.Drain()
+}
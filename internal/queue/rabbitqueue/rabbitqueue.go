@@ -0,0 +1,204 @@
+// Package rabbitqueue dispatches tasks through a durable RabbitMQ queue.
+// Every instance consumes from the same queue with manual acknowledgements,
+// so each task is executed by one of them; the deliveries of an instance
+// that disappears are returned to the queue by the broker. Deliveries that
+// cannot be processed end up in a dead-letter queue.
+package rabbitqueue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// deliveryLimit is how many times a task is delivered before it is
+	// moved to the dead-letter queue.
+	deliveryLimit = 5
+	// redeliveryDelay is how long a task whose delivery failed, e.g.
+	// because another executor holds its lock, is held before it is
+	// returned to the queue.
+	redeliveryDelay = 5 * time.Second
+)
+
+// Config names the RabbitMQ resources used for dispatching. The
+// dead-letter exchange and queue are named after Queue.
+type Config struct {
+	URL   string
+	Queue string
+}
+
+// Queue is a taskservice.Dispatcher backed by RabbitMQ.
+type Queue struct {
+	conn *amqp.Connection
+	// publisher is in confirm mode: Dispatch returns once the broker has
+	// stored the task.
+	publisher *amqp.Channel
+	queue     string
+	logger    *slog.Logger
+}
+
+// New connects to RabbitMQ and declares the task queue, a quorum queue
+// that dead-letters rejected deliveries, together with its dead-letter
+// exchange and queue.
+func New(ctx context.Context, cfg Config, logger *slog.Logger) (*Queue, error) {
+	conn, err := amqp.DialConfig(cfg.URL, amqp.Config{
+		Properties: amqp.Table{"connection_name": "workmate"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	publisher, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	if err := declare(publisher, cfg.Queue); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := publisher.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	return &Queue{
+		conn:      conn,
+		publisher: publisher,
+		queue:     cfg.Queue,
+		logger:    logger,
+	}, nil
+}
+
+func declare(ch *amqp.Channel, queue string) error {
+	deadLetters := queue + ".dlx"
+	if err := ch.ExchangeDeclare(deadLetters, amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", deadLetters, err)
+	}
+	if _, err := ch.QueueDeclare(queue+".dead", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare queue %s.dead: %w", queue, err)
+	}
+	if err := ch.QueueBind(queue+".dead", "", deadLetters, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s.dead: %w", queue, err)
+	}
+
+	_, err := ch.QueueDeclare(queue, true, false, false, false, amqp.Table{
+		amqp.QueueTypeArg:        amqp.QueueTypeQuorum,
+		"x-dead-letter-exchange": deadLetters,
+		"x-delivery-limit":       deliveryLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+	}
+	return nil
+}
+
+// encode makes the persistent message carrying the task ID.
+func encode(taskID uuid.UUID) amqp.Publishing {
+	id := taskID.String()
+	return amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "text/plain",
+		MessageId:    id,
+		Body:         []byte(id),
+	}
+}
+
+// decode returns the task ID carried by a delivery.
+func decode(delivery amqp.Delivery) (uuid.UUID, error) {
+	return uuid.Parse(string(delivery.Body))
+}
+
+// Dispatch publishes the task ID as a persistent message and waits for the
+// broker to confirm it.
+func (q *Queue) Dispatch(ctx context.Context, taskID uuid.UUID) error {
+	id := taskID.String()
+	confirmation, err := q.publisher.PublishWithDeferredConfirmWithContext(ctx, "", q.queue, false, false, encode(taskID))
+	if err != nil {
+		return fmt.Errorf("failed to publish task %s: %w", id, err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to confirm task %s: %w", id, err)
+	}
+	if !acked {
+		return fmt.Errorf("broker rejected task %s", id)
+	}
+	return nil
+}
+
+// Consume receives up to workers unacknowledged tasks at a time (the
+// prefetch count) and runs handle for each in its own goroutine. The
+// delivery is acknowledged when handle succeeds and returned to the queue
+// redeliveryDelay after it fails; after deliveryLimit attempts it is dead-lettered. When
+// ctx is done Consume stops receiving and waits for the running handlers.
+func (q *Queue) Consume(ctx context.Context, workers int, handle func(ctx context.Context, taskID uuid.UUID) error) error {
+	ch, err := q.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(workers, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+	deliveries, err := ch.ConsumeWithContext(ctx, q.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume queue %s: %w", q.queue, err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.deliver(ctx, delivery, handle)
+		}()
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("RabbitMQ closed the consumer of queue %s", q.queue)
+}
+
+func (q *Queue) deliver(ctx context.Context, delivery amqp.Delivery, handle func(ctx context.Context, taskID uuid.UUID) error) {
+	taskID, err := decode(delivery)
+	if err != nil {
+		q.logger.ErrorContext(ctx, "Dead-lettering a malformed task message", "data", string(delivery.Body), "error", err)
+		_ = delivery.Reject(false)
+		return
+	}
+
+	if err := handle(context.WithoutCancel(ctx), taskID); err != nil {
+		q.logger.WarnContext(ctx, "Task delivery failed, it will be delivered again", "task_id", taskID, "error", err)
+		select {
+		case <-time.After(redeliveryDelay):
+		case <-ctx.Done():
+		}
+		_ = delivery.Nack(false, true)
+		return
+	}
+	if err := delivery.Ack(false); err != nil {
+		q.logger.WarnContext(ctx, "Failed to acknowledge a task", "task_id", taskID, "error", err)
+	}
+}
+
+// Ping checks that the connection to RabbitMQ is open.
+func (q *Queue) Ping(ctx context.Context) error {
+	if q.conn.IsClosed() {
+		return fmt.Errorf("connection to RabbitMQ is closed")
+	}
+	return ctx.Err()
+}
+
+// Close closes the connection; unacknowledged deliveries return to the queue.
+func (q *Queue) Close() error {
+	return q.conn.Close()
+}
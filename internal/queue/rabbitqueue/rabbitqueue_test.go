@@ -0,0 +1,99 @@
+package rabbitqueue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acknowledger records how a delivery was settled.
+type acknowledger struct {
+	acked, nacked, rejected bool
+	requeue                 bool
+}
+
+func (a *acknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *acknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.nacked, a.requeue = true, requeue
+	return nil
+}
+
+func (a *acknowledger) Reject(tag uint64, requeue bool) error {
+	a.rejected, a.requeue = true, requeue
+	return nil
+}
+
+// delivery turns a published message into the delivery a consumer receives.
+func delivery(message amqp.Publishing, ack *acknowledger) amqp.Delivery {
+	return amqp.Delivery{
+		Acknowledger: ack,
+		DeliveryMode: message.DeliveryMode,
+		ContentType:  message.ContentType,
+		MessageId:    message.MessageId,
+		Body:         message.Body,
+		DeliveryTag:  1,
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	taskID := uuid.New()
+	message := encode(taskID)
+	assert.Equal(t, amqp.Persistent, message.DeliveryMode)
+	assert.Equal(t, taskID.String(), message.MessageId)
+
+	decoded, err := decode(delivery(message, nil))
+	require.NoError(t, err)
+	assert.Equal(t, taskID, decoded)
+
+	_, err = decode(amqp.Delivery{Body: []byte("not a task")})
+	assert.Error(t, err)
+}
+
+func TestDeliver(t *testing.T) {
+	q := &Queue{queue: "tasks", logger: slog.Default()}
+	taskID := uuid.New()
+
+	t.Run("ack", func(t *testing.T) {
+		ack := &acknowledger{}
+		var handled uuid.UUID
+		q.deliver(context.Background(), delivery(encode(taskID), ack), func(ctx context.Context, id uuid.UUID) error {
+			handled = id
+			return nil
+		})
+		assert.Equal(t, taskID, handled)
+		assert.Equal(t, &acknowledger{acked: true}, ack)
+	})
+
+	t.Run("nack", func(t *testing.T) {
+		// A stopping consumer returns the delivery without waiting out the
+		// redelivery delay.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ack := &acknowledger{}
+		q.deliver(ctx, delivery(encode(taskID), ack), func(ctx context.Context, id uuid.UUID) error {
+			// The handler runs to completion even though the consumer stops.
+			assert.NoError(t, ctx.Err())
+			return errors.New("task is locked by another executor")
+		})
+		assert.Equal(t, &acknowledger{nacked: true, requeue: true}, ack)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		ack := &acknowledger{}
+		q.deliver(context.Background(), delivery(amqp.Publishing{Body: []byte("not a task")}, ack), func(ctx context.Context, id uuid.UUID) error {
+			t.Fatal("malformed messages must not be handled")
+			return nil
+		})
+		assert.Equal(t, &acknowledger{rejected: true}, ack)
+	})
+}
@@ -0,0 +1,165 @@
+package projectrepository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/projectmodel"
+)
+
+type InMemoryProjectRepository struct {
+	store     sync.Map     // [uuid.UUID]*projectmodel.Project
+	lastWrite atomic.Int64 // unix nanoseconds
+}
+
+func NewInMemoryProjectRepository() *InMemoryProjectRepository {
+	return &InMemoryProjectRepository{}
+}
+
+func (r *InMemoryProjectRepository) Create(ctx context.Context, project *projectmodel.Project) (err error) {
+	_, span := startSpan(ctx, "Create")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if project == nil {
+		return fmt.Errorf("project cannot be nil")
+	}
+
+	if _, exists := r.store.Load(project.ID); exists {
+		return fmt.Errorf("%w: %s", projectmodel.ErrProjectAlreadyExists, project.ID)
+	}
+
+	project.CreatedAt = time.Now()
+
+	r.store.Store(project.ID, r.copyProject(project))
+	r.markWritten()
+
+	return nil
+}
+
+func (r *InMemoryProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *projectmodel.Project, err error) {
+	_, span := startSpan(ctx, "GetByID")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	value, exists := r.store.Load(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", projectmodel.ErrProjectNotFound, id)
+	}
+
+	project, ok := value.(*projectmodel.Project)
+	if !ok {
+		return nil, fmt.Errorf("invalid project data for ID %s", id.String())
+	}
+
+	return r.copyProject(project), nil
+}
+
+func (r *InMemoryProjectRepository) Update(ctx context.Context, project *projectmodel.Project) (err error) {
+	_, span := startSpan(ctx, "Update")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if project == nil {
+		return fmt.Errorf("project cannot be nil")
+	}
+
+	if _, exists := r.store.Load(project.ID); !exists {
+		return fmt.Errorf("%w: %s", projectmodel.ErrProjectNotFound, project.ID)
+	}
+
+	r.store.Store(project.ID, r.copyProject(project))
+	r.markWritten()
+
+	return nil
+}
+
+func (r *InMemoryProjectRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	_, span := startSpan(ctx, "Delete")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, exists := r.store.Load(id); !exists {
+		return fmt.Errorf("%w: %s", projectmodel.ErrProjectNotFound, id)
+	}
+
+	r.store.Delete(id)
+	r.markWritten()
+	return nil
+}
+
+func (r *InMemoryProjectRepository) GetAll(ctx context.Context) (_ []*projectmodel.Project, err error) {
+	_, span := startSpan(ctx, "GetAll")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var projects []*projectmodel.Project
+
+	r.store.Range(func(key, value interface{}) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if project, ok := value.(*projectmodel.Project); ok {
+			projects = append(projects, r.copyProject(project))
+		}
+		return true
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (r *InMemoryProjectRepository) copyProject(original *projectmodel.Project) *projectmodel.Project {
+	if original == nil {
+		return nil
+	}
+
+	return &projectmodel.Project{
+		ID:          original.ID,
+		Name:        original.Name,
+		Description: original.Description,
+		CreatedAt:   original.CreatedAt,
+	}
+}
+
+// LastWrite returns the time of the last successful write, or the zero
+// time if nothing has been written yet.
+func (r *InMemoryProjectRepository) LastWrite() time.Time {
+	nanos := r.lastWrite.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func (r *InMemoryProjectRepository) markWritten() {
+	r.lastWrite.Store(time.Now().UnixNano())
+}
+
+// Ping performs a read against the store to check that it is reachable.
+func (r *InMemoryProjectRepository) Ping(ctx context.Context) error {
+	r.store.Load(uuid.Nil)
+	return ctx.Err()
+}
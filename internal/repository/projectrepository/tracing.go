@@ -0,0 +1,34 @@
+package projectrepository
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nzb3/workmate_test/internal/timing"
+)
+
+var tracer = otel.Tracer("github.com/nzb3/workmate_test/internal/repository/projectrepository")
+
+// operation is an instrumented repository call: a span plus its share of
+// the request timing breakdown.
+type operation struct {
+	span trace.Span
+	stop func()
+}
+
+func startSpan(ctx context.Context, name string) (context.Context, *operation) {
+	ctx, span := tracer.Start(ctx, "projectrepository."+name)
+	return ctx, &operation{span: span, stop: timing.Track(ctx, "projectrepository."+name)}
+}
+
+func endSpan(op *operation, err error) {
+	op.stop()
+	if err != nil {
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+	op.span.End()
+}
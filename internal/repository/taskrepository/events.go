@@ -0,0 +1,99 @@
+package taskrepository
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/outbox"
+)
+
+// taskStream is the append-only event history of one task. Its mutex also
+// serializes the writes of the task, so events are appended in the order
+// their changes are stored.
+type taskStream struct {
+	mu     sync.Mutex
+	events []taskmodel.Event
+}
+
+// append adds event as the next version and returns it. Consecutive
+// progress updates are compacted into the latest one so long-running tasks
+// keep short streams.
+func (s *taskStream) append(event taskmodel.Event) taskmodel.Event {
+	event.Version = s.nextVersion()
+	if last := len(s.events) - 1; last >= 0 && event.Type == taskmodel.EventProgressUpdated && s.events[last].Type == taskmodel.EventProgressUpdated {
+		s.events[last] = event
+		return event
+	}
+	s.events = append(s.events, event)
+	return event
+}
+
+func (s *taskStream) nextVersion() int {
+	if len(s.events) == 0 {
+		return 1
+	}
+	return s.events[len(s.events)-1].Version + 1
+}
+
+func (s *taskStream) history() []taskmodel.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := slices.Clone(s.events)
+	for i, event := range events {
+		// The initial task of EventCreated is shared with the stream.
+		if event.Task != nil {
+			events[i].Task = event.Task.Clone()
+		}
+	}
+	return events
+}
+
+// subscribers receives the events of every task as they are stored.
+type subscribers struct {
+	mu       sync.RWMutex
+	handlers []func(taskmodel.Event)
+}
+
+func (s *subscribers) add(handler func(taskmodel.Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+func (s *subscribers) publish(event taskmodel.Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, handler := range s.handlers {
+		handler(event)
+	}
+}
+
+// replay rebuilds the task from its events.
+func (s *taskStream) replay() (*taskmodel.Task, error) {
+	task := new(taskmodel.Task)
+	for _, event := range s.events {
+		if err := task.Apply(event); err != nil {
+			return nil, err
+		}
+	}
+	return task, nil
+}
+
+// outboxQueue keeps the events awaiting publishing, in the order they were stored.
+type outboxQueue struct {
+	mu       sync.Mutex
+	enabled  bool
+	seq      uint64
+	messages []outbox.Message
+}
+
+func (q *outboxQueue) record(event taskmodel.Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.enabled {
+		return
+	}
+	q.seq++
+	q.messages = append(q.messages, outbox.Message{Seq: q.seq, Event: event})
+}
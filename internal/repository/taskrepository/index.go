@@ -0,0 +1,46 @@
+package taskrepository
+
+import (
+	"sync"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// statusIndex counts stored tasks by status so counts do not need a scan
+// of the whole store.
+type statusIndex struct {
+	mu     sync.Mutex
+	counts map[taskmodel.TaskStatus]int
+}
+
+func newStatusIndex() *statusIndex {
+	return &statusIndex{counts: make(map[taskmodel.TaskStatus]int)}
+}
+
+// move records that a stored task changed from previous to current;
+// either may be nil for creation and deletion.
+func (i *statusIndex) move(previous, current *taskmodel.Task) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if previous != nil {
+		i.counts[previous.Status]--
+		if i.counts[previous.Status] <= 0 {
+			delete(i.counts, previous.Status)
+		}
+	}
+	if current != nil {
+		i.counts[current.Status]++
+	}
+}
+
+func (i *statusIndex) snapshot() map[taskmodel.TaskStatus]int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	counts := make(map[taskmodel.TaskStatus]int, len(i.counts))
+	for status, count := range i.counts {
+		counts[status] = count
+	}
+	return counts
+}
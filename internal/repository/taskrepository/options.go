@@ -0,0 +1,13 @@
+package taskrepository
+
+import "github.com/nzb3/workmate_test/internal/clock"
+
+type Option func(*InMemoryTaskRepository)
+
+// WithClock timestamps tasks and their events with c instead of the wall
+// clock.
+func WithClock(c clock.Clock) Option {
+	return func(r *InMemoryTaskRepository) {
+		r.clock = c
+	}
+}
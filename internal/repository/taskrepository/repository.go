@@ -1,44 +1,97 @@
 package taskrepository
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/nzb3/workmate_test/internal/clock"
 	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/outbox"
 )
 
+// InMemoryTaskRepository stores every task as a stream of events. The
+// stored task is the snapshot of its stream, kept up to date by every
+// write, so reads never replay events.
 type InMemoryTaskRepository struct {
-	store sync.Map // [uuid.UUID]*taskmodel.Task
+	store     sync.Map // [uuid.UUID]*taskmodel.Task
+	streams   sync.Map // [uuid.UUID]*taskStream
+	listeners subscribers
+	outbox    outboxQueue
+	byStatus  *statusIndex
+	lastWrite atomic.Int64 // unix nanoseconds
+	clock     clock.Clock
 }
 
-func NewInMemoryTaskRepository() *InMemoryTaskRepository {
-	return &InMemoryTaskRepository{}
+func NewInMemoryTaskRepository(opts ...Option) *InMemoryTaskRepository {
+	r := &InMemoryTaskRepository{
+		byStatus: newStatusIndex(),
+		clock:    clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-func (r *InMemoryTaskRepository) Create(task *taskmodel.Task) error {
+func (r *InMemoryTaskRepository) Create(ctx context.Context, task *taskmodel.Task) (err error) {
+	_, span := startSpan(ctx, "Create")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if task == nil {
 		return fmt.Errorf("task cannot be nil")
 	}
 
-	if _, exists := r.store.Load(task.ID); exists {
-		return fmt.Errorf("task with ID %s already exists", task.ID.String())
+	stream := &taskStream{}
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if _, exists := r.streams.LoadOrStore(task.ID, stream); exists {
+		return fmt.Errorf("%w: %s", taskmodel.ErrTaskAlreadyExists, task.ID)
 	}
 
-	task.CreatedAt = time.Now()
+	// The service stamps tasks with its own clock; tasks created without
+	// a creation time get the time they are stored.
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = r.clock.Now()
+	}
 
 	taskCopy := r.copyTask(task)
+	event := stream.append(taskmodel.Event{
+		TaskID: task.ID,
+		Type:   taskmodel.EventCreated,
+		At:     task.CreatedAt,
+		Task:   r.copyTask(task),
+	})
 	r.store.Store(task.ID, taskCopy)
+	r.byStatus.move(nil, taskCopy)
+	r.markWritten()
+	r.emit(event)
 
 	return nil
 }
 
-func (r *InMemoryTaskRepository) GetByID(id uuid.UUID) (*taskmodel.Task, error) {
+func (r *InMemoryTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *taskmodel.Task, err error) {
+	_, span := startSpan(ctx, "GetByID")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	value, exists := r.store.Load(id)
 	if !exists {
-		return nil, fmt.Errorf("task with ID %s not found", id.String())
+		return nil, fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, id)
 	}
 
 	task, ok := value.(*taskmodel.Task)
@@ -49,39 +102,229 @@ func (r *InMemoryTaskRepository) GetByID(id uuid.UUID) (*taskmodel.Task, error)
 	return r.copyTask(task), nil
 }
 
-func (r *InMemoryTaskRepository) Update(task *taskmodel.Task) error {
+func (r *InMemoryTaskRepository) Update(ctx context.Context, task *taskmodel.Task) (err error) {
+	_, span := startSpan(ctx, "Update")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if task == nil {
 		return fmt.Errorf("task cannot be nil")
 	}
 
-	if _, exists := r.store.Load(task.ID); !exists {
-		return fmt.Errorf("task with ID %s not found", task.ID.String())
+	stream, ok := r.lockStream(task.ID)
+	if !ok {
+		return fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, task.ID)
+	}
+	defer stream.mu.Unlock()
+
+	stored := asTask(r.load(task.ID))
+	if !stored.Status.CanTransitionTo(task.Status) {
+		return fmt.Errorf("%w: task %s: %s -> %s", taskmodel.ErrInvalidTransition, task.ID, stored.Status, task.Status)
 	}
 
 	taskCopy := r.copyTask(task)
+	event := stream.append(taskmodel.ChangeEvent(stored, taskCopy, r.clock.Now()))
 	r.store.Store(task.ID, taskCopy)
+	r.byStatus.move(stored, taskCopy)
+	r.markWritten()
+	r.emit(event)
+
+	return nil
+}
+
+func (r *InMemoryTaskRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	_, span := startSpan(ctx, "Delete")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	stream, ok := r.lockStream(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, id)
+	}
+	defer stream.mu.Unlock()
 
+	r.remove(id, stream)
+	r.markWritten()
 	return nil
 }
 
-func (r *InMemoryTaskRepository) Delete(id uuid.UUID) error {
-	if _, exists := r.store.Load(id); !exists {
-		return fmt.Errorf("task with ID %s not found", id.String())
+// Events returns the event history of the task, oldest first.
+func (r *InMemoryTaskRepository) Events(ctx context.Context, id uuid.UUID) (_ []taskmodel.Event, err error) {
+	_, span := startSpan(ctx, "Events")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	value, exists := r.streams.Load(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, id)
+	}
+	return value.(*taskStream).history(), nil
+}
+
+// Subscribe registers handler to receive every stored event of every task,
+// followed by EventDeleted when a task is erased. Events of a task arrive
+// in order; the handler runs while the task is locked and must not call
+// back into the repository.
+func (r *InMemoryTaskRepository) Subscribe(handler func(taskmodel.Event)) {
+	r.listeners.add(handler)
+}
+
+// Rebuild replays the event stream of every task and replaces the stored
+// tasks that no longer match it. It returns the number of repaired tasks.
+func (r *InMemoryTaskRepository) Rebuild(ctx context.Context) (repaired int, err error) {
+	_, span := startSpan(ctx, "Rebuild")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.streams.Range(func(key, value any) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		id := key.(uuid.UUID)
+		stream, ok := r.lockStream(id)
+		if !ok {
+			return true
+		}
+		defer stream.mu.Unlock()
+
+		task, replayErr := stream.replay()
+		if replayErr != nil {
+			err = fmt.Errorf("failed to replay task %s: %w", id, replayErr)
+			return false
+		}
+
+		stored := asTask(r.load(id))
+		if stored == nil || !reflect.DeepEqual(stored, task) {
+			r.store.Store(id, task)
+			r.byStatus.move(stored, task)
+			repaired++
+		}
+		return true
+	})
+	if err != nil {
+		return repaired, err
+	}
+
+	if repaired > 0 {
+		r.markWritten()
+	}
+	return repaired, nil
+}
+
+// lockStream returns the locked event stream of the task, or false if the
+// task does not exist.
+func (r *InMemoryTaskRepository) lockStream(id uuid.UUID) (*taskStream, bool) {
+	value, exists := r.streams.Load(id)
+	if !exists {
+		return nil, false
+	}
+
+	stream := value.(*taskStream)
+	stream.mu.Lock()
+	// The task may have been deleted while waiting for the lock.
+	if current, _ := r.streams.Load(id); current != stream {
+		stream.mu.Unlock()
+		return nil, false
 	}
+	return stream, true
+}
+
+func (r *InMemoryTaskRepository) load(id uuid.UUID) any {
+	value, _ := r.store.Load(id)
+	return value
+}
+
+// remove erases the task and its events; the caller holds the stream lock.
+func (r *InMemoryTaskRepository) remove(id uuid.UUID, stream *taskStream) {
+	r.streams.CompareAndDelete(id, stream)
+	if previous, loaded := r.store.LoadAndDelete(id); loaded {
+		r.byStatus.move(asTask(previous), nil)
+	}
+	r.emit(taskmodel.Event{
+		TaskID:  id,
+		Version: stream.nextVersion(),
+		Type:    taskmodel.EventDeleted,
+		At:      r.clock.Now(),
+	})
+}
+
+// emit records a stored event in the outbox and hands it to the
+// subscribers; the caller holds the stream lock, so both see the events of
+// a task in the order they were stored.
+func (r *InMemoryTaskRepository) emit(event taskmodel.Event) {
+	r.outbox.record(event)
+	r.listeners.publish(event)
+}
 
-	r.store.Delete(id)
+// EnableOutbox starts recording every stored event in the outbox, to be
+// published by an outbox.Relay. Events stored earlier are not recorded.
+func (r *InMemoryTaskRepository) EnableOutbox() {
+	r.outbox.mu.Lock()
+	defer r.outbox.mu.Unlock()
+	r.outbox.enabled = true
+}
+
+// PendingEvents returns up to limit events of the outbox, oldest first.
+func (r *InMemoryTaskRepository) PendingEvents(ctx context.Context, limit int) ([]outbox.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.outbox.mu.Lock()
+	defer r.outbox.mu.Unlock()
+	return slices.Clone(r.outbox.messages[:min(limit, len(r.outbox.messages))]), nil
+}
+
+// AckEvents removes the outbox events up to and including seq.
+func (r *InMemoryTaskRepository) AckEvents(ctx context.Context, seq uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.outbox.mu.Lock()
+	defer r.outbox.mu.Unlock()
+	acked, _ := slices.BinarySearchFunc(r.outbox.messages, seq+1, func(message outbox.Message, target uint64) int {
+		return cmp.Compare(message.Seq, target)
+	})
+	r.outbox.messages = slices.Delete(r.outbox.messages, 0, acked)
 	return nil
 }
 
-func (r *InMemoryTaskRepository) GetAll() ([]*taskmodel.Task, error) {
+func (r *InMemoryTaskRepository) GetAll(ctx context.Context) (_ []*taskmodel.Task, err error) {
+	_, span := startSpan(ctx, "GetAll")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var tasks []*taskmodel.Task
 
 	r.store.Range(func(key, value interface{}) bool {
+		if ctx.Err() != nil {
+			return false
+		}
 		if task, ok := value.(*taskmodel.Task); ok {
 			tasks = append(tasks, r.copyTask(task))
 		}
 		return true
 	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return tasks, nil
 }
@@ -90,14 +333,7 @@ func (r *InMemoryTaskRepository) copyTask(original *taskmodel.Task) *taskmodel.T
 	if original == nil {
 		return nil
 	}
-
-	return &taskmodel.Task{
-		ID:             original.ID,
-		Name:           original.Name,
-		Status:         original.Status,
-		CreatedAt:      original.CreatedAt,
-		ProcessingTime: original.ProcessingTime,
-	}
+	return original.Clone()
 }
 
 func (r *InMemoryTaskRepository) GetTaskCount() int {
@@ -109,22 +345,76 @@ func (r *InMemoryTaskRepository) GetTaskCount() int {
 	return count
 }
 
-func (r *InMemoryTaskRepository) GetTasksByStatus(status taskmodel.TaskStatus) ([]*taskmodel.Task, error) {
+func (r *InMemoryTaskRepository) GetTasksByStatus(ctx context.Context, status taskmodel.TaskStatus) (_ []*taskmodel.Task, err error) {
+	_, span := startSpan(ctx, "GetTasksByStatus")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var tasks []*taskmodel.Task
 
 	r.store.Range(func(key, value interface{}) bool {
+		if ctx.Err() != nil {
+			return false
+		}
 		if task, ok := value.(*taskmodel.Task); ok && task.Status == status {
 			tasks = append(tasks, r.copyTask(task))
 		}
 		return true
 	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	return tasks, nil
 }
 
+// CountByStatus returns the number of stored tasks per status.
+func (r *InMemoryTaskRepository) CountByStatus(ctx context.Context) (_ map[taskmodel.TaskStatus]int, err error) {
+	_, span := startSpan(ctx, "CountByStatus")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.byStatus.snapshot(), nil
+}
+
 func (r *InMemoryTaskRepository) Clear() {
-	r.store.Range(func(key, value interface{}) bool {
-		r.store.Delete(key)
+	r.streams.Range(func(key, value interface{}) bool {
+		if stream, ok := r.lockStream(key.(uuid.UUID)); ok {
+			r.remove(key.(uuid.UUID), stream)
+			stream.mu.Unlock()
+		}
 		return true
 	})
+	r.markWritten()
+}
+
+func asTask(value any) *taskmodel.Task {
+	task, _ := value.(*taskmodel.Task)
+	return task
+}
+
+// LastWrite returns the time of the last successful write, or the zero
+// time if nothing has been written yet.
+func (r *InMemoryTaskRepository) LastWrite() time.Time {
+	nanos := r.lastWrite.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func (r *InMemoryTaskRepository) markWritten() {
+	r.lastWrite.Store(r.clock.Now().UnixNano())
+}
+
+// Ping performs a read against the store to check that it is reachable.
+func (r *InMemoryTaskRepository) Ping(ctx context.Context) error {
+	r.store.Load(uuid.Nil)
+	return ctx.Err()
 }
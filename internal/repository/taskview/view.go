@@ -0,0 +1,137 @@
+package taskview
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// View is the read model of the tasks: a denormalized copy kept up to date
+// from the events of the task repository and indexed by owner and project,
+// so listings and statistics never read the primary store.
+type View struct {
+	mu        sync.RWMutex
+	tasks     map[uuid.UUID]*taskmodel.Task
+	byOwner   map[string]map[uuid.UUID]struct{}
+	byProject map[uuid.UUID]map[uuid.UUID]struct{}
+}
+
+func New() *View {
+	return &View{
+		tasks:     make(map[uuid.UUID]*taskmodel.Task),
+		byOwner:   make(map[string]map[uuid.UUID]struct{}),
+		byProject: make(map[uuid.UUID]map[uuid.UUID]struct{}),
+	}
+}
+
+// Apply updates the view with an event of the task repository. Events of
+// tasks the view does not know, other than their creation, are ignored.
+func (v *View) Apply(event taskmodel.Event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch event.Type {
+	case taskmodel.EventCreated:
+		if event.Task != nil {
+			v.insert(event.Task.Clone())
+		}
+	case taskmodel.EventDeleted:
+		v.delete(event.TaskID)
+	default:
+		if task, ok := v.tasks[event.TaskID]; ok {
+			// Apply only fails for an illegal transition, which the
+			// repository never stores.
+			_ = task.Apply(event)
+		}
+	}
+}
+
+// Seed adds tasks that the view does not know yet, for tasks stored before
+// the view was subscribed to the repository.
+func (v *View) Seed(tasks []*taskmodel.Task) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, task := range tasks {
+		if _, ok := v.tasks[task.ID]; !ok {
+			v.insert(task.Clone())
+		}
+	}
+}
+
+// List returns copies of the tasks matching the filter.
+func (v *View) List(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var tasks []*taskmodel.Task
+	add := func(task *taskmodel.Task) {
+		if filter.Match(task) {
+			tasks = append(tasks, task.Clone())
+		}
+	}
+
+	switch {
+	case filter.Owner != "":
+		for id := range v.byOwner[filter.Owner] {
+			add(v.tasks[id])
+		}
+	case filter.ProjectID != uuid.Nil:
+		for id := range v.byProject[filter.ProjectID] {
+			add(v.tasks[id])
+		}
+	default:
+		for _, task := range v.tasks {
+			add(task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (v *View) insert(task *taskmodel.Task) {
+	v.tasks[task.ID] = task
+	if task.Owner != "" {
+		addToIndex(v.byOwner, task.Owner, task.ID)
+	}
+	if task.ProjectID != uuid.Nil {
+		addToIndex(v.byProject, task.ProjectID, task.ID)
+	}
+}
+
+func (v *View) delete(id uuid.UUID) {
+	task, ok := v.tasks[id]
+	if !ok {
+		return
+	}
+	delete(v.tasks, id)
+	removeFromIndex(v.byOwner, task.Owner, id)
+	removeFromIndex(v.byProject, task.ProjectID, id)
+}
+
+func addToIndex[K comparable](index map[K]map[uuid.UUID]struct{}, key K, id uuid.UUID) {
+	ids, ok := index[key]
+	if !ok {
+		ids = make(map[uuid.UUID]struct{})
+		index[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func removeFromIndex[K comparable](index map[K]map[uuid.UUID]struct{}, key K, id uuid.UUID) {
+	ids, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, key)
+	}
+}
@@ -0,0 +1,161 @@
+package templaterepository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/templatemodel"
+)
+
+type InMemoryTemplateRepository struct {
+	store     sync.Map     // [uuid.UUID]*templatemodel.Template
+	lastWrite atomic.Int64 // unix nanoseconds
+}
+
+func NewInMemoryTemplateRepository() *InMemoryTemplateRepository {
+	return &InMemoryTemplateRepository{}
+}
+
+func (r *InMemoryTemplateRepository) Create(ctx context.Context, template *templatemodel.Template) (err error) {
+	_, span := startSpan(ctx, "Create")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if template == nil {
+		return fmt.Errorf("template cannot be nil")
+	}
+
+	if _, exists := r.store.Load(template.ID); exists {
+		return fmt.Errorf("%w: %s", templatemodel.ErrTemplateAlreadyExists, template.ID)
+	}
+
+	template.CreatedAt = time.Now()
+
+	r.store.Store(template.ID, r.copyTemplate(template))
+	r.markWritten()
+
+	return nil
+}
+
+func (r *InMemoryTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *templatemodel.Template, err error) {
+	_, span := startSpan(ctx, "GetByID")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	value, exists := r.store.Load(id)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", templatemodel.ErrTemplateNotFound, id)
+	}
+
+	template, ok := value.(*templatemodel.Template)
+	if !ok {
+		return nil, fmt.Errorf("invalid template data for ID %s", id.String())
+	}
+
+	return r.copyTemplate(template), nil
+}
+
+func (r *InMemoryTemplateRepository) Update(ctx context.Context, template *templatemodel.Template) (err error) {
+	_, span := startSpan(ctx, "Update")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if template == nil {
+		return fmt.Errorf("template cannot be nil")
+	}
+
+	if _, exists := r.store.Load(template.ID); !exists {
+		return fmt.Errorf("%w: %s", templatemodel.ErrTemplateNotFound, template.ID)
+	}
+
+	r.store.Store(template.ID, r.copyTemplate(template))
+	r.markWritten()
+
+	return nil
+}
+
+func (r *InMemoryTemplateRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	_, span := startSpan(ctx, "Delete")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, exists := r.store.Load(id); !exists {
+		return fmt.Errorf("%w: %s", templatemodel.ErrTemplateNotFound, id)
+	}
+
+	r.store.Delete(id)
+	r.markWritten()
+	return nil
+}
+
+func (r *InMemoryTemplateRepository) GetAll(ctx context.Context) (_ []*templatemodel.Template, err error) {
+	_, span := startSpan(ctx, "GetAll")
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var templates []*templatemodel.Template
+
+	r.store.Range(func(key, value interface{}) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if template, ok := value.(*templatemodel.Template); ok {
+			templates = append(templates, r.copyTemplate(template))
+		}
+		return true
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *InMemoryTemplateRepository) copyTemplate(original *templatemodel.Template) *templatemodel.Template {
+	if original == nil {
+		return nil
+	}
+
+	clone := *original
+	return &clone
+}
+
+// LastWrite returns the time of the last successful write, or the zero
+// time if nothing has been written yet.
+func (r *InMemoryTemplateRepository) LastWrite() time.Time {
+	nanos := r.lastWrite.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func (r *InMemoryTemplateRepository) markWritten() {
+	r.lastWrite.Store(time.Now().UnixNano())
+}
+
+// Ping performs a read against the store to check that it is reachable.
+func (r *InMemoryTemplateRepository) Ping(ctx context.Context) error {
+	r.store.Load(uuid.Nil)
+	return ctx.Err()
+}
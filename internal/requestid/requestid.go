@@ -0,0 +1,34 @@
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID in both directions.
+const Header = "X-Request-ID"
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Resolve returns the client-provided ID when it is safe to log and echo back,
+// otherwise a freshly generated one.
+func Resolve(provided string) string {
+	if validID.MatchString(provided) {
+		return provided
+	}
+	return uuid.NewString()
+}
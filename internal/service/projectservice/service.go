@@ -0,0 +1,139 @@
+package projectservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/projectmodel"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+var ErrProjectNotEmpty = errors.New("project still has tasks")
+
+type Repository interface {
+	Create(ctx context.Context, project *projectmodel.Project) error
+	GetByID(ctx context.Context, id uuid.UUID) (*projectmodel.Project, error)
+	Update(ctx context.Context, project *projectmodel.Project) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetAll(ctx context.Context) ([]*projectmodel.Project, error)
+}
+
+type TaskService interface {
+	ListTasks(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error)
+}
+
+// Stats summarizes the tasks of a project.
+type Stats struct {
+	Total    int
+	ByStatus map[taskmodel.TaskStatus]int
+}
+
+type Service struct {
+	repo  Repository
+	tasks TaskService
+}
+
+func NewService(repo Repository, tasks TaskService) *Service {
+	return &Service{
+		repo:  repo,
+		tasks: tasks,
+	}
+}
+
+func (s *Service) CreateProject(ctx context.Context, name, description string) (*projectmodel.Project, error) {
+	project := projectmodel.NewProject(
+		projectmodel.WithName(name),
+		projectmodel.WithDescription(description),
+	)
+
+	if err := s.repo.Create(ctx, project); err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return project, nil
+}
+
+func (s *Service) GetProject(ctx context.Context, projectID uuid.UUID) (*projectmodel.Project, error) {
+	project, err := s.repo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return project, nil
+}
+
+func (s *Service) UpdateProject(ctx context.Context, projectID uuid.UUID, name, description string) (*projectmodel.Project, error) {
+	project, err := s.repo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	project.Name = name
+	project.Description = description
+
+	if err := s.repo.Update(ctx, project); err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return project, nil
+}
+
+// DeleteProject removes an empty project. Projects that still group tasks
+// cannot be deleted and ErrProjectNotEmpty is returned.
+func (s *Service) DeleteProject(ctx context.Context, projectID uuid.UUID) error {
+	tasks, err := s.ProjectTasks(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		return ErrProjectNotEmpty
+	}
+
+	if err := s.repo.Delete(ctx, projectID); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ListProjects(ctx context.Context) ([]*projectmodel.Project, error) {
+	projects, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (s *Service) ProjectTasks(ctx context.Context, projectID uuid.UUID) ([]*taskmodel.Task, error) {
+	if _, err := s.repo.GetByID(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	tasks, err := s.tasks.ListTasks(ctx, taskmodel.Filter{ProjectID: projectID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Service) ProjectStats(ctx context.Context, projectID uuid.UUID) (*Stats, error) {
+	tasks, err := s.ProjectTasks(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		Total:    len(tasks),
+		ByStatus: make(map[taskmodel.TaskStatus]int),
+	}
+	for _, task := range tasks {
+		stats.ByStatus[task.Status]++
+	}
+
+	return stats, nil
+}
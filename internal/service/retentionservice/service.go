@@ -0,0 +1,120 @@
+package retentionservice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// Rule keeps tasks with the given status for at most MaxAge after creation.
+type Rule struct {
+	Status taskmodel.TaskStatus
+	MaxAge time.Duration
+}
+
+// Candidate is a task that the rule would delete on the next run.
+type Candidate struct {
+	Task      *taskmodel.Task
+	Rule      Rule
+	ExpiredAt time.Time
+}
+
+type TaskService interface {
+	ListTasks(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error)
+	PurgeTasks(ctx context.Context, filter taskmodel.Filter) (int, error)
+}
+
+type Service struct {
+	tasks    TaskService
+	rules    []Rule
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func NewService(tasks TaskService, rules []Rule, interval time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		tasks:    tasks,
+		rules:    rules,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (s *Service) Rules() []Rule {
+	return s.rules
+}
+
+// Run evaluates the rules every interval until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	if len(s.rules) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Apply(ctx); err != nil {
+				s.logger.ErrorContext(ctx, "Retention run failed", "error", err)
+			}
+		}
+	}
+}
+
+// Apply deletes every task that outlived its rule and returns the number of deleted tasks.
+func (s *Service) Apply(ctx context.Context) (int, error) {
+	now := time.Now()
+	total := 0
+
+	for _, rule := range s.rules {
+		purged, err := s.tasks.PurgeTasks(ctx, rule.filter(now))
+		total += purged
+		if err != nil {
+			return total, fmt.Errorf("failed to apply retention rule for %s: %w", rule.Status, err)
+		}
+	}
+
+	if total > 0 {
+		s.logger.InfoContext(ctx, "Retention removed tasks", "count", total)
+	}
+	return total, nil
+}
+
+// DryRun returns the tasks Apply would delete right now without deleting them.
+func (s *Service) DryRun(ctx context.Context) ([]Candidate, error) {
+	tasks, err := s.tasks.ListTasks(ctx, taskmodel.Filter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	now := time.Now()
+	var candidates []Candidate
+	for _, task := range tasks {
+		for _, rule := range s.rules {
+			if rule.filter(now).Match(task) {
+				candidates = append(candidates, Candidate{
+					Task:      task,
+					Rule:      rule,
+					ExpiredAt: task.CreatedAt.Add(rule.MaxAge),
+				})
+				break
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+func (r Rule) filter(now time.Time) taskmodel.Filter {
+	return taskmodel.Filter{
+		CreatedTo: now.Add(-r.MaxAge),
+		Statuses:  []taskmodel.TaskStatus{r.Status},
+	}
+}
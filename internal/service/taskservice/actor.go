@@ -0,0 +1,177 @@
+package taskservice
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// TaskContext is the actor owning the runtime state of one task. The state
+// is only ever touched by the actor goroutine, which applies the commands
+// sent to it one at a time; the executor, API handlers and read paths
+// communicate with it instead of sharing memory.
+type TaskContext struct {
+	ID uuid.UUID
+	// Done is closed once the task reached a final status.
+	Done chan struct{}
+
+	cancel   context.CancelFunc
+	commands chan func(*taskState)
+	// stopped is closed when the actor exits; the state is read-only from then on.
+	stopped chan struct{}
+
+	// queueSeq orders tasks waiting for a worker by creation.
+	queueSeq uint64
+	// pool is the pool of workers the task runs on.
+	pool string
+	// serialKey is the serial key of the task; see taskmodel.Task.SerialKey.
+	serialKey string
+
+	state taskState
+}
+
+// taskState is the state owned by the actor goroutine of a task.
+type taskState struct {
+	// task is the latest version of the task, written to the repository.
+	task taskmodel.Task
+	// status is the status of the execution: waiting until a worker picked
+	// the task up, then PROCESSING until it finished.
+	status taskmodel.TaskStatus
+	// expected is the planned execution time, known once the task started.
+	expected time.Duration
+	// lastBeat is the last time the executor of the task made progress.
+	lastBeat time.Time
+	// removed is set once the task was deleted: its state is no longer stored.
+	removed bool
+	// cancelled is set once the user cancelled the task, which then
+	// finishes CANCELLED rather than FAILED.
+	cancelled bool
+	finished  bool
+}
+
+func newTaskContext(task taskmodel.Task, cancel context.CancelFunc, queueSeq uint64) *TaskContext {
+	return &TaskContext{
+		ID:        task.ID,
+		Done:      make(chan struct{}),
+		cancel:    cancel,
+		commands:  make(chan func(*taskState)),
+		stopped:   make(chan struct{}),
+		queueSeq:  queueSeq,
+		pool:      task.Pool,
+		serialKey: task.SerialKey,
+		state:     taskState{task: task, status: task.Status},
+	}
+}
+
+// run applies commands until the task is finished.
+func (tc *TaskContext) run() {
+	defer close(tc.stopped)
+	for command := range tc.commands {
+		command(&tc.state)
+		if tc.state.finished {
+			return
+		}
+	}
+}
+
+// do runs command on the actor goroutine and waits for it. It reports false,
+// without running the command, once the actor has stopped. A panic of the
+// command is raised again in the calling goroutine.
+func (tc *TaskContext) do(command func(*taskState)) bool {
+	applied := make(chan any, 1)
+	select {
+	case tc.commands <- func(state *taskState) {
+		defer func() { applied <- recover() }()
+		command(state)
+	}:
+		if recovered := <-applied; recovered != nil {
+			panic(recovered)
+		}
+		return true
+	case <-tc.stopped:
+		return false
+	}
+}
+
+// view runs read on the actor goroutine, or directly once the actor has
+// stopped and the state can no longer change. read must not modify the state.
+func (tc *TaskContext) view(read func(*taskState)) {
+	if !tc.do(read) {
+		read(&tc.state)
+	}
+}
+
+// Cancel stops the execution of the task; the task still stores its final status.
+func (tc *TaskContext) Cancel() {
+	tc.cancel()
+}
+
+// remove cancels the task and makes sure none of its later state changes
+// are stored, so a deleted task is not written back by its executor.
+func (tc *TaskContext) remove() {
+	tc.do(func(state *taskState) {
+		state.removed = true
+	})
+	tc.cancel()
+}
+
+// cancelByUser cancels the task on behalf of its user, who may give a
+// reason for it.
+func (tc *TaskContext) cancelByUser(reason string) {
+	tc.do(func(state *taskState) {
+		state.cancelled = true
+		state.task.CancelReason = reason
+	})
+	tc.cancel()
+}
+
+func (tc *TaskContext) cancelledByUser() (cancelled bool) {
+	tc.view(func(state *taskState) { cancelled = state.cancelled })
+	return cancelled
+}
+
+func (tc *TaskContext) IsFinished() bool {
+	select {
+	case <-tc.Done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Status returns the status of the task as known to its executor.
+func (tc *TaskContext) Status() (status taskmodel.TaskStatus) {
+	tc.view(func(state *taskState) { status = state.status })
+	return status
+}
+
+// Priority returns the priority the task waits for a worker with.
+func (tc *TaskContext) Priority() (priority taskmodel.Priority) {
+	tc.view(func(state *taskState) { priority = state.task.Priority })
+	return priority
+}
+
+// StartedAt returns when a worker picked the task up, or zero while it is queued.
+func (tc *TaskContext) StartedAt() (started time.Time) {
+	tc.view(func(state *taskState) { started = state.task.StartedAt })
+	return started
+}
+
+// ExpectedFinish returns when a started task should complete, or zero while it is queued.
+func (tc *TaskContext) ExpectedFinish() (finish time.Time) {
+	tc.view(func(state *taskState) {
+		if !state.task.StartedAt.IsZero() {
+			finish = state.task.StartedAt.Add(state.expected)
+		}
+	})
+	return finish
+}
+
+// LastHeartbeat returns when the executor last made progress, or zero while the task is queued.
+func (tc *TaskContext) LastHeartbeat() (beat time.Time) {
+	tc.view(func(state *taskState) { beat = state.lastBeat })
+	return beat
+}
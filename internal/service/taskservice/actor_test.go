@@ -0,0 +1,131 @@
+package taskservice
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+func startActor(t *testing.T) (*TaskContext, context.Context) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	task := taskmodel.NewTask(taskmodel.WithName("Actor"))
+	task.Status = taskmodel.StatusQueued
+	tc := newTaskContext(*task, cancel, 1)
+	go tc.run()
+	return tc, ctx
+}
+
+// finish stops the actor the way the executor does once the task reached
+// a final status.
+func finish(tc *TaskContext, status taskmodel.TaskStatus) {
+	tc.do(func(state *taskState) {
+		state.status = status
+		state.finished = true
+	})
+}
+
+func TestActorAppliesCommandsInOrder(t *testing.T) {
+	tc, _ := startActor(t)
+	defer finish(tc, taskmodel.StatusDone)
+
+	var applied []int
+	for i := range 100 {
+		require.True(t, tc.do(func(*taskState) { applied = append(applied, i) }))
+	}
+	for i, n := range applied {
+		require.Equal(t, i, n)
+	}
+
+	// Commands of concurrent callers are applied one at a time.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				tc.do(func(state *taskState) { state.task.ProcessingTime++ })
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total time.Duration
+	tc.view(func(state *taskState) { total = state.task.ProcessingTime })
+	assert.Equal(t, time.Duration(1000), total)
+}
+
+func TestActorViewAfterStop(t *testing.T) {
+	tc, _ := startActor(t)
+	finish(tc, taskmodel.StatusDone)
+	<-tc.stopped
+
+	assert.False(t, tc.do(func(state *taskState) { state.status = taskmodel.StatusFailed }))
+	assert.Equal(t, taskmodel.StatusDone, tc.Status())
+	assert.False(t, tc.cancelledByUser())
+
+	// Cancelling a stopped task neither blocks nor changes its state.
+	tc.cancelByUser("too late")
+	assert.Equal(t, taskmodel.StatusDone, tc.Status())
+	assert.False(t, tc.cancelledByUser())
+}
+
+func TestActorCancelDuringExecution(t *testing.T) {
+	tc, ctx := startActor(t)
+
+	executed := make(chan struct{})
+	go func() {
+		defer close(executed)
+		tc.do(func(state *taskState) { state.status = taskmodel.StatusProcessing })
+		for ctx.Err() == nil {
+			tc.do(func(state *taskState) { state.lastBeat = time.Now() })
+		}
+		tc.do(func(state *taskState) {
+			state.status = taskmodel.StatusFailed
+			if state.cancelled {
+				state.status = taskmodel.StatusCancelled
+			}
+			state.finished = true
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tc.cancelByUser("no longer needed")
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-executed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("executor did not stop after the task was cancelled")
+	}
+	<-tc.stopped
+	assert.Equal(t, taskmodel.StatusCancelled, tc.Status())
+	assert.True(t, tc.cancelledByUser())
+	var reason string
+	tc.view(func(state *taskState) { reason = state.task.CancelReason })
+	assert.Equal(t, "no longer needed", reason)
+}
+
+func TestActorRaisesCommandPanics(t *testing.T) {
+	tc, _ := startActor(t)
+	defer finish(tc, taskmodel.StatusDone)
+
+	assert.PanicsWithValue(t, "broken", func() {
+		tc.do(func(*taskState) { panic("broken") })
+	})
+	// The actor keeps serving commands after a panic.
+	assert.Equal(t, taskmodel.StatusQueued, tc.Status())
+}
@@ -0,0 +1,167 @@
+package taskservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// DuplicateTaskError is returned by CreateTask for a task submitted again
+// within the dedup window: its owner created a task with the same
+// submission key shortly before, which is Task.
+type DuplicateTaskError struct {
+	Task *taskmodel.Task
+}
+
+func (e *DuplicateTaskError) Error() string {
+	return fmt.Sprintf("task %s with the same submission key was created within the dedup window", e.Task.ID)
+}
+
+// NameConflictError is returned by CreateTask for a task asking for a
+// unique name while Task, another active task of its owner, has it.
+type NameConflictError struct {
+	Task *taskmodel.Task
+}
+
+func (e *NameConflictError) Error() string {
+	return fmt.Sprintf("active task %s already has the name %q", e.Task.ID, e.Task.Name)
+}
+
+// dedupIndex remembers the tasks created within the dedup window by owner
+// and submission key. order lists the remembered submissions oldest first,
+// so that the expired ones are forgotten without scanning recent.
+type dedupIndex struct {
+	window time.Duration
+	recent map[dedupKey]dedupEntry
+	order  []dedupKey
+}
+
+type dedupKey struct {
+	owner string
+	key   string
+}
+
+type dedupEntry struct {
+	taskID    uuid.UUID
+	createdAt time.Time
+	// listed is how many times the key is in dedupIndex.order.
+	listed int
+}
+
+// admit checks a new task against the existing ones before it is created:
+// a repeated submission is answered with a DuplicateTaskError, a unique
+// name taken by an active task with a NameConflictError. Until the
+// returned function is called with whether the admitted task was stored,
+// no other task is admitted, so that concurrent submissions cannot both
+// pass the checks.
+func (s *Service) admit(ctx context.Context, task *taskmodel.Task) (func(created bool), error) {
+	unique := task.UniqueName || s.uniqueNames
+	if s.dedup.window <= 0 && !unique {
+		return func(bool) {}, nil
+	}
+
+	s.admission.Lock()
+	done := func(created bool) {
+		if created && s.dedup.window > 0 {
+			s.rememberSubmission(task)
+		}
+		s.admission.Unlock()
+	}
+
+	if s.dedup.window > 0 {
+		duplicate, err := s.findDuplicate(ctx, task)
+		if err != nil {
+			done(false)
+			return nil, err
+		}
+		if duplicate != nil {
+			done(false)
+			s.logger.InfoContext(ctx, "Duplicate task submission", "task_id", duplicate.ID, "actor", actor(ctx), "request_id", task.RequestID)
+			return nil, &DuplicateTaskError{Task: duplicate}
+		}
+	}
+	if unique {
+		conflict, err := s.findActiveNamesake(ctx, task)
+		if err != nil {
+			done(false)
+			return nil, err
+		}
+		if conflict != nil {
+			done(false)
+			return nil, &NameConflictError{Task: conflict}
+		}
+	}
+	return done, nil
+}
+
+// findActiveNamesake returns an active task of the owner of task with its
+// name, or nil. s.admission must be held.
+func (s *Service) findActiveNamesake(ctx context.Context, task *taskmodel.Task) (*taskmodel.Task, error) {
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	filter := taskmodel.Filter{Statuses: taskmodel.ActiveStatuses()}
+	for _, existing := range tasks {
+		if existing.Name == task.Name && existing.Owner == task.Owner && filter.Match(existing) {
+			s.updateTaskProcessingTime(existing)
+			return existing, nil
+		}
+	}
+	return nil, nil
+}
+
+// findDuplicate returns the task created within the window that task
+// repeats, or nil. s.admission must be held.
+func (s *Service) findDuplicate(ctx context.Context, task *taskmodel.Task) (*taskmodel.Task, error) {
+	entry, ok := s.dedup.recent[dedupKey{owner: task.Owner, key: task.SubmissionKey()}]
+	if !ok || task.CreatedAt.Sub(entry.createdAt) >= s.dedup.window {
+		return nil, nil
+	}
+
+	// A deleted task no longer counts as a duplicate.
+	existing, err := s.repo.GetByID(ctx, entry.taskID)
+	if err != nil {
+		if errors.Is(err, taskmodel.ErrTaskNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	s.updateTaskProcessingTime(existing)
+	return existing, nil
+}
+
+// rememberSubmission records the created task for findDuplicate and
+// forgets the tasks created before the window. s.admission must be held.
+func (s *Service) rememberSubmission(task *taskmodel.Task) {
+	for len(s.dedup.order) > 0 {
+		key := s.dedup.order[0]
+		entry := s.dedup.recent[key]
+		if entry.listed == 1 && task.CreatedAt.Sub(entry.createdAt) < s.dedup.window {
+			break
+		}
+		// A key submitted again is listed more than once; recent holds
+		// its latest submission, so the earlier listings are just dropped.
+		if entry.listed > 1 {
+			entry.listed--
+			s.dedup.recent[key] = entry
+		} else {
+			delete(s.dedup.recent, key)
+		}
+		s.dedup.order = s.dedup.order[1:]
+	}
+
+	key := dedupKey{owner: task.Owner, key: task.SubmissionKey()}
+	s.dedup.recent[key] = dedupEntry{
+		taskID:    task.ID,
+		createdAt: task.CreatedAt,
+		listed:    s.dedup.recent[key].listed + 1,
+	}
+	s.dedup.order = append(s.dedup.order, key)
+}
@@ -0,0 +1,155 @@
+package taskservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// ResultArtifact is the artifact every completed task produces: a JSON
+// summary of the task and the inputs it was given.
+const ResultArtifact = "result.json"
+
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// BlobStore keeps the inputs and artifacts of tasks.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Upload is a file uploaded together with a new task.
+type Upload struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Content     io.Reader
+}
+
+// CreateTaskWithInputs stores uploads in the blob store and creates a task
+// with them as its inputs. The stored uploads are removed again when the
+// task cannot be created.
+func (s *Service) CreateTaskWithInputs(ctx context.Context, name string, uploads []Upload, opts ...taskmodel.Option) (*taskmodel.Task, error) {
+	if s.draining.Load() {
+		return nil, ErrDraining
+	}
+
+	inputs := make([]taskmodel.Attachment, 0, len(uploads))
+	for _, upload := range uploads {
+		input := taskmodel.Attachment{
+			Name:        upload.Name,
+			ContentType: upload.ContentType,
+			Size:        upload.Size,
+			Key:         "inputs/" + uuid.NewString(),
+		}
+		if err := s.blobs.Put(ctx, input.Key, upload.Content, upload.Size); err != nil {
+			s.deleteAttachments(ctx, inputs)
+			return nil, fmt.Errorf("failed to store input %s: %w", upload.Name, err)
+		}
+		inputs = append(inputs, input)
+	}
+
+	task, err := s.CreateTask(ctx, name, append(opts, taskmodel.WithInputs(inputs))...)
+	if err != nil {
+		s.deleteAttachments(ctx, inputs)
+		return nil, err
+	}
+	return task, nil
+}
+
+// OpenArtifact opens the artifact of the task called name; the caller
+// closes it.
+func (s *Service) OpenArtifact(ctx context.Context, taskID uuid.UUID, name string) (taskmodel.Attachment, io.ReadCloser, error) {
+	task, err := s.repo.GetByID(ctx, taskID)
+	if err != nil {
+		return taskmodel.Attachment{}, nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	artifact, ok := task.Artifact(name)
+	if !ok {
+		return taskmodel.Attachment{}, nil, ErrArtifactNotFound
+	}
+	content, err := s.blobs.Get(ctx, artifact.Key)
+	if err != nil {
+		return taskmodel.Attachment{}, nil, fmt.Errorf("failed to read artifact %s: %w", name, err)
+	}
+	return artifact, content, nil
+}
+
+type inputSummary struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+type resultSummary struct {
+	TaskID   uuid.UUID      `json:"task_id"`
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	Attempts int            `json:"attempts"`
+	Inputs   []inputSummary `json:"inputs"`
+}
+
+// storeResult stores the ResultArtifact of the task that just completed
+// and records it on the task, to be stored with the final status.
+func (s *Service) storeResult(ctx context.Context, taskContext *TaskContext) error {
+	var task taskmodel.Task
+	var removed bool
+	taskContext.view(func(state *taskState) {
+		task = *state.task.Clone()
+		removed = state.removed
+	})
+	if removed {
+		return nil
+	}
+
+	summary := resultSummary{
+		TaskID:   task.ID,
+		Name:     task.Name,
+		Type:     task.Type,
+		Attempts: len(task.Attempts),
+		Inputs:   make([]inputSummary, len(task.Inputs)),
+	}
+	for i, input := range task.Inputs {
+		summary.Inputs[i] = inputSummary{Name: input.Name, ContentType: input.ContentType, Size: input.Size}
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	artifact := taskmodel.Attachment{
+		Name:        ResultArtifact,
+		ContentType: "application/json",
+		Size:        int64(len(data)),
+		Key:         "tasks/" + task.ID.String() + "/artifacts/" + ResultArtifact,
+	}
+	if err := s.blobs.Put(ctx, artifact.Key, bytes.NewReader(data), artifact.Size); err != nil {
+		return err
+	}
+
+	taskContext.do(func(state *taskState) {
+		state.task.Artifacts = append(state.task.Artifacts, artifact)
+	})
+	return nil
+}
+
+// deleteAttachments removes attachments from the blob store. Failures are
+// only logged, as the tasks they belonged to are gone already.
+func (s *Service) deleteAttachments(ctx context.Context, attachments ...[]taskmodel.Attachment) {
+	for _, list := range attachments {
+		for _, attachment := range list {
+			if err := s.blobs.Delete(ctx, attachment.Key); err != nil {
+				s.logger.WarnContext(ctx, "Failed to delete task attachment", "key", attachment.Key, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package taskservice
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrTaskLocked is returned for a delivered task whose execution lock is
+// held by another executor; the queue delivers it again later.
+var ErrTaskLocked = errors.New("task is locked by another executor")
+
+// Coordinator coordinates the instances executing the tasks of a shared
+// repository and queue.
+type Coordinator interface {
+	// Lock takes the execution lock of the task and keeps it until unlock
+	// is called. It reports false when another executor holds the lock.
+	Lock(ctx context.Context, taskID uuid.UUID) (unlock func(), ok bool, err error)
+	// Cancel asks every instance to stop its executor of the task.
+	Cancel(ctx context.Context, taskID uuid.UUID) error
+	// Cancellations calls handle for every task cancelled by any instance
+	// until ctx is done.
+	Cancellations(ctx context.Context, handle func(taskID uuid.UUID)) error
+}
+
+// WatchCancellations stops the executors of tasks deleted through other
+// instances until ctx is done. It returns immediately when the service has
+// no coordinator.
+func (s *Service) WatchCancellations(ctx context.Context) error {
+	if s.coordinator == nil {
+		return nil
+	}
+	return s.coordinator.Cancellations(ctx, s.removeExecution)
+}
+
+// removeExecution stops the executor of a task erased from the repository.
+func (s *Service) removeExecution(taskID uuid.UUID) {
+	if taskContext, ok := s.loadTaskContext(taskID); ok {
+		taskContext.remove()
+		s.contexts.Delete(taskID)
+	}
+}
+
+// cancelElsewhere stops the executor of an erased task on the other
+// instances. The task is already gone, so a failure only leaves that
+// executor running until it fails to store its progress.
+func (s *Service) cancelElsewhere(ctx context.Context, taskID uuid.UUID) {
+	if s.coordinator == nil {
+		return
+	}
+	if err := s.coordinator.Cancel(ctx, taskID); err != nil {
+		s.logger.WarnContext(ctx, "Failed to cancel the task on other instances", "task_id", taskID, "error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package taskservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// Dispatcher hands created tasks to executors through an external queue,
+// so that any instance sharing the repository may execute them. Without a
+// dispatcher tasks are executed by the instance that created them.
+type Dispatcher interface {
+	// Dispatch queues the task for execution.
+	Dispatch(ctx context.Context, taskID uuid.UUID) error
+	// Consume calls handle for queued tasks until ctx is done, at most
+	// workers at a time. A task is removed from the queue once handle
+	// returns nil and delivered again otherwise, also to another instance.
+	Consume(ctx context.Context, workers int, handle func(ctx context.Context, taskID uuid.UUID) error) error
+}
+
+// dispatch queues a stored PENDING task and marks it QUEUED. A task that
+// cannot be queued would never run, so it is failed.
+func (s *Service) dispatch(ctx context.Context, task *taskmodel.Task) error {
+	if err := s.dispatcher.Dispatch(ctx, task.ID); err != nil {
+		s.finalizeTask(ctx, task, taskmodel.StatusFailed, 0)
+		return fmt.Errorf("failed to queue task: %w", err)
+	}
+
+	// An executor may have taken the task from the queue already, moving
+	// it past QUEUED.
+	queued := task.Clone()
+	if err := queued.Transition(taskmodel.StatusQueued); err != nil {
+		return nil
+	}
+	err := s.repo.Update(ctx, queued)
+	if errors.Is(err, taskmodel.ErrInvalidTransition) {
+		return nil
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to store queued task", "task_id", task.ID, "error", err)
+		return nil
+	}
+	*task = *queued
+	return nil
+}
+
+// Consume executes the tasks delivered by the dispatcher until ctx is
+// done. It returns immediately when the service has no dispatcher.
+func (s *Service) Consume(ctx context.Context) error {
+	if s.dispatcher == nil {
+		return nil
+	}
+	return s.dispatcher.Consume(ctx, s.WorkerCapacity(), s.executeDispatched)
+}
+
+// executeDispatched runs a delivered task to completion. Deliveries of
+// tasks that were deleted, have finished or already run here are dropped;
+// a task locked by another executor is delivered again later.
+func (s *Service) executeDispatched(ctx context.Context, taskID uuid.UUID) error {
+	if s.coordinator != nil {
+		unlock, ok, err := s.coordinator.Lock(ctx, taskID)
+		if err != nil {
+			return fmt.Errorf("failed to lock task: %w", err)
+		}
+		if !ok {
+			return ErrTaskLocked
+		}
+		defer unlock()
+	}
+
+	taskCtx, task, taskContext, err := s.takeDispatched(ctx, taskID)
+	if err != nil || taskContext == nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "Task received from the queue", "task_id", task.ID, "request_id", task.RequestID)
+	s.executeTask(taskCtx, *task, taskContext)
+	return nil
+}
+
+// takeDispatched registers the executor of a delivered task, or returns a
+// nil TaskContext when the delivery is to be dropped.
+func (s *Service) takeDispatched(ctx context.Context, taskID uuid.UUID) (context.Context, *taskmodel.Task, *TaskContext, error) {
+	s.deliveries.Lock()
+	defer s.deliveries.Unlock()
+
+	if _, running := s.contexts.Load(taskID); running {
+		return nil, nil, nil, nil
+	}
+	task, err := s.repo.GetByID(ctx, taskID)
+	if errors.Is(err, taskmodel.ErrTaskNotFound) {
+		return nil, nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if !task.IsActive() {
+		return nil, nil, nil, nil
+	}
+
+	taskCtx, taskContext := s.newExecution(task)
+	return taskCtx, task, taskContext, nil
+}
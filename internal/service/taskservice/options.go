@@ -0,0 +1,252 @@
+package taskservice
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/clock"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/panicreport"
+	"github.com/nzb3/workmate_test/internal/tasklog"
+)
+
+const (
+	// DefaultWorkers is the number of concurrently executing tasks unless
+	// WithWorkers says otherwise.
+	DefaultWorkers = 100
+	// DefaultTimeout is the time after which an unfinished task is
+	// cancelled unless WithTimeout says otherwise.
+	DefaultTimeout = 6 * time.Minute
+	// DefaultMaxRuntime is the longest MaxRuntime a task may ask for unless
+	// WithMaxRuntime says otherwise.
+	DefaultMaxRuntime = time.Hour
+	// DefaultShutdownTimeout is how long Shutdown waits for the cancelled
+	// tasks to stop unless WithShutdownTimeout says otherwise.
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// RetryPolicy decides how often a failing task is executed again. Tasks
+// cancelled by a timeout, deletion or shutdown are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; 1 disables retries.
+	MaxAttempts int
+	// Backoff is the pause before the second attempt, doubled for every
+	// following one.
+	Backoff time.Duration
+}
+
+// NoRetry executes every task once.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// backoff returns the pause before the given attempt, counting from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return p.Backoff << (attempt - 2)
+}
+
+// Option customizes a Service.
+type Option func(*Service)
+
+// WithWorkers bounds the number of concurrently executing tasks of the
+// default pool.
+func WithWorkers(workers int) Option {
+	return func(s *Service) {
+		s.workers.capacity[taskmodel.DefaultPool] = workers
+	}
+}
+
+// WithPools adds pools of workers by name with their number of workers,
+// which tasks asking for one of them run on instead of the default pool.
+func WithPools(pools map[string]int) Option {
+	return func(s *Service) {
+		for name, workers := range pools {
+			s.workers.capacity[name] = workers
+		}
+	}
+}
+
+// WithTimeout cancels tasks still running after timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = timeout
+	}
+}
+
+// WithShutdownTimeout bounds how long Shutdown waits for the cancelled
+// tasks to stop.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithMaxRuntime bounds the MaxRuntime tasks may ask for instead of the
+// timeout.
+func WithMaxRuntime(maxRuntime time.Duration) Option {
+	return func(s *Service) {
+		s.maxRuntime = maxRuntime
+	}
+}
+
+// WithDedupWindow answers the creation of a task with the task its owner
+// created with the same submission key less than window before, see
+// DuplicateTaskError. Zero creates every submitted task.
+func WithDedupWindow(window time.Duration) Option {
+	return func(s *Service) {
+		s.dedup = dedupIndex{window: window, recent: make(map[dedupKey]dedupEntry)}
+	}
+}
+
+// WithUniqueNames creates tasks only while no other active task of their
+// owner has their name, see NameConflictError.
+func WithUniqueNames(unique bool) Option {
+	return func(s *Service) {
+		s.uniqueNames = unique
+	}
+}
+
+// WithSlowThresholds flags tasks as slow once they have been processing
+// for longer than the threshold of their type in byType, or threshold for
+// other types; a zero threshold flags none. A task flagged as slow records
+// EventSlow and is counted by Metrics.TaskSlow.
+func WithSlowThresholds(threshold time.Duration, byType map[string]time.Duration) Option {
+	return func(s *Service) {
+		s.slowThreshold = threshold
+		s.slowThresholds = byType
+	}
+}
+
+// WithPreemption lets a critical task that finds every worker of its pool
+// busy take the worker of the running task of lowest priority, which is
+// queued again and resumes its work once it gets a worker; critical tasks
+// are never preempted. A preemption records EventPreempted and is counted
+// by Metrics.TaskPreempted.
+func WithPreemption(enabled bool) Option {
+	return func(s *Service) {
+		s.workers.preemption = enabled
+	}
+}
+
+// WithRetryPolicy executes failing tasks again according to policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *Service) {
+		s.retry = policy
+	}
+}
+
+// WithShedding rejects new tasks of a priority below high with an
+// OverloadedError while the executors are behind the thresholds of policy.
+func WithShedding(policy ShedPolicy) Option {
+	return func(s *Service) {
+		s.shedding = policy
+	}
+}
+
+// WithClock measures task execution with c instead of the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = c
+	}
+}
+
+// WithLogger logs task lifecycle events to logger instead of slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithMetrics records created and finished tasks in metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(s *Service) {
+		s.metrics = metrics
+	}
+}
+
+// WithDurationModel learns task durations in model instead of a private
+// in-memory one.
+func WithDurationModel(model DurationModel) Option {
+	return func(s *Service) {
+		s.durations = model
+	}
+}
+
+// WithBlobStore keeps the inputs and artifacts of tasks in store instead
+// of memory.
+func WithBlobStore(store BlobStore) Option {
+	return func(s *Service) {
+		s.blobs = store
+	}
+}
+
+// WithLogLines keeps the last lines lines logged about every task instead
+// of tasklog.DefaultCapacity.
+func WithLogLines(lines int) Option {
+	return func(s *Service) {
+		s.logs = tasklog.NewStore(lines)
+	}
+}
+
+// WithReadModel serves listings and statistics from reads instead of the
+// repository.
+func WithReadModel(reads ReadModel) Option {
+	return func(s *Service) {
+		s.reads = reads
+	}
+}
+
+// WithDispatcher queues created tasks in dispatcher and executes the tasks
+// it delivers; see Consume.
+func WithDispatcher(dispatcher Dispatcher) Option {
+	return func(s *Service) {
+		s.dispatcher = dispatcher
+	}
+}
+
+// WithCoordinator locks dispatched tasks while they execute and stops
+// executors on other instances when a task is deleted; see
+// WatchCancellations.
+func WithCoordinator(coordinator Coordinator) Option {
+	return func(s *Service) {
+		s.coordinator = coordinator
+	}
+}
+
+// WithPanicReporter reports panics raised while executing tasks to reporter.
+func WithPanicReporter(reporter panicreport.Reporter) Option {
+	return func(s *Service) {
+		s.reporter = reporter
+	}
+}
+
+// nopMetrics discards metrics when none are configured.
+type nopMetrics struct{}
+
+func (nopMetrics) TaskCreated() {}
+
+func (nopMetrics) TaskFinished(string, taskmodel.TaskStatus, time.Duration, time.Duration) {}
+
+func (nopMetrics) TaskSlow(string) {}
+
+func (nopMetrics) TaskPreempted(string) {}
+
+// repositoryReads lists tasks straight from the repository when no read
+// model is configured.
+type repositoryReads struct {
+	repo Repository
+}
+
+func (r repositoryReads) List(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error) {
+	tasks, err := r.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*taskmodel.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if filter.Match(task) {
+			matched = append(matched, task)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,210 @@
+package taskservice
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/estimate"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// fallbackWorkEstimate is the expected execution time used for queue
+// estimates before any task has completed.
+const fallbackWorkEstimate = 4*time.Minute + 30*time.Second
+
+// QueuedTask is a task waiting for a free worker.
+type QueuedTask struct {
+	Task *taskmodel.Task
+	// Position counts within the pool of the task.
+	Position int
+	Wait     time.Duration
+	// EstimatedStart assumes running tasks finish as planned and queued
+	// ones take the recent median processing time of their type.
+	EstimatedStart time.Time
+	// EstimatedFinish is EstimatedStart plus that median.
+	EstimatedFinish time.Time
+}
+
+// Queue returns the tasks waiting for a worker in the order they will be
+// dispatched: the highest priority first, in creation order within a
+// priority. Every pool of workers takes up its own tasks.
+func (s *Service) Queue(ctx context.Context) ([]QueuedTask, error) {
+	now := s.clock.Now()
+
+	// Every pool has its slots, the times its workers become free.
+	var waiting []*TaskContext
+	slots := make(map[string]*timeHeap, len(s.workers.capacity))
+	for pool := range s.workers.capacity {
+		slots[pool] = &timeHeap{}
+	}
+	s.contexts.Range(func(key, value any) bool {
+		taskContext, ok := value.(*TaskContext)
+		if !ok || taskContext.IsFinished() {
+			return true
+		}
+		if finish := taskContext.ExpectedFinish(); !finish.IsZero() {
+			*slots[taskContext.pool] = append(*slots[taskContext.pool], finish)
+		} else {
+			waiting = append(waiting, taskContext)
+		}
+		return true
+	})
+
+	for pool, capacity := range s.workers.capacity {
+		for len(*slots[pool]) < capacity {
+			*slots[pool] = append(*slots[pool], now)
+		}
+		heap.Init(slots[pool])
+	}
+
+	priorities := make(map[*TaskContext]taskmodel.Priority, len(waiting))
+	for _, taskContext := range waiting {
+		priorities[taskContext] = taskContext.Priority()
+	}
+	sort.Slice(waiting, func(i, j int) bool {
+		if pi, pj := priorities[waiting[i]], priorities[waiting[j]]; pi != pj {
+			return pi > pj
+		}
+		return waiting[i].queueSeq < waiting[j].queueSeq
+	})
+
+	// Types without completed tasks take the median of all types.
+	fallback := fallbackWorkEstimate
+	if stats, err := s.LatencyStats(ctx, 24*time.Hour, ""); err == nil && stats.Count > 0 {
+		fallback = stats.P50
+	}
+
+	positions := make(map[string]int)
+	queue := make([]QueuedTask, 0, len(waiting))
+	for _, taskContext := range waiting {
+		free := slots[taskContext.pool]
+		if free.Len() == 0 {
+			continue
+		}
+
+		task, err := s.repo.GetByID(ctx, taskContext.ID)
+		if err != nil {
+			// Deleted while waiting.
+			continue
+		}
+
+		start := heap.Pop(free).(time.Time)
+		if start.Before(now) {
+			start = now
+		}
+		finish := start.Add(s.expectedDuration(task.Type, fallback))
+		heap.Push(free, finish)
+
+		positions[taskContext.pool]++
+		queue = append(queue, QueuedTask{
+			Task:            task,
+			Position:        positions[taskContext.pool],
+			Wait:            now.Sub(task.CreatedAt),
+			EstimatedStart:  start,
+			EstimatedFinish: finish,
+		})
+	}
+
+	return queue, nil
+}
+
+// Dispatch order rules, in the order they are applied.
+const (
+	// DispatchRuleSerialKey holds a task back until the tasks with its
+	// serial key queued before it have finished.
+	DispatchRuleSerialKey = "serial_key"
+	// DispatchRulePriority starts the waiting task of highest priority first.
+	DispatchRulePriority = "priority"
+	// DispatchRuleQueueOrder starts tasks of equal priority in the order
+	// they were queued.
+	DispatchRuleQueueOrder = "queue_order"
+)
+
+// DispatchRules returns the rules DispatchOrder is sorted by, the first
+// rule deciding first.
+func (s *Service) DispatchRules() []string {
+	return []string{DispatchRuleSerialKey, DispatchRulePriority, DispatchRuleQueueOrder}
+}
+
+// DispatchEntry is a task waiting for a worker of this instance.
+type DispatchEntry struct {
+	Task *taskmodel.Task
+	// Position is 1 for the task that gets the next free worker of its
+	// pool.
+	Position int
+	// Sequence numbers the tasks in the order they were queued, breaking
+	// ties between tasks of equal priority and ordering the tasks sharing
+	// a serial key.
+	Sequence uint64
+}
+
+// DispatchOrder returns the tasks waiting for a worker in exactly the
+// order the workers will take them up, read from the queue of the workers
+// itself; see DispatchRules. Tasks held back by a running task of their
+// serial key are placed as if those running tasks finished before any
+// other task started. Tasks of different pools do not compete for
+// workers, so positions count within a pool. Tasks still in an external
+// queue are not included.
+func (s *Service) DispatchOrder(ctx context.Context) ([]DispatchEntry, error) {
+	waiting := s.workers.order()
+
+	positions := make(map[string]int)
+	order := make([]DispatchEntry, 0, len(waiting))
+	for _, w := range waiting {
+		task, err := s.repo.GetByID(ctx, w.taskID)
+		if errors.Is(err, taskmodel.ErrTaskNotFound) {
+			// Deleted while waiting.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		// Report the priority the task is ordered by, which a concurrent
+		// change may not have reached yet.
+		task.Priority = w.priority
+		positions[w.pool]++
+		order = append(order, DispatchEntry{
+			Task:     task,
+			Position: positions[w.pool],
+			Sequence: w.seq,
+		})
+	}
+	return order, nil
+}
+
+// expectedDuration is the median processing time of the task type, or
+// fallback when no task of the type has completed yet.
+func (s *Service) expectedDuration(taskType string, fallback time.Duration) time.Duration {
+	if estimate, ok := s.durations.Estimate(taskType); ok {
+		return estimate.P50
+	}
+	return fallback
+}
+
+// DurationEstimates summarizes the recent processing times of every task
+// type.
+func (s *Service) DurationEstimates() map[string]estimate.Estimate {
+	return s.durations.Estimates()
+}
+
+// timeHeap is a min-heap of the times workers become free.
+type timeHeap []time.Time
+
+func (h timeHeap) Len() int           { return len(h) }
+func (h timeHeap) Less(i, j int) bool { return h[i].Before(h[j]) }
+func (h timeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *timeHeap) Push(x any) {
+	*h = append(*h, x.(time.Time))
+}
+
+func (h *timeHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
@@ -4,98 +4,325 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/nzb3/workmate_test/internal/auth"
+	"github.com/nzb3/workmate_test/internal/blobstore"
+	"github.com/nzb3/workmate_test/internal/clock"
+	"github.com/nzb3/workmate_test/internal/estimate"
 	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/panicreport"
+	"github.com/nzb3/workmate_test/internal/requestid"
+	"github.com/nzb3/workmate_test/internal/tasklog"
 )
 
-const defaultTimeToProcessTask = 6 * time.Minute
+const (
+	// maxHeartbeatAge is how long running tasks may go without progress
+	// before the workers are reported unhealthy.
+	maxHeartbeatAge = 10 * time.Second
+	// followPollInterval is how often FollowTaskLogs checks whether a task
+	// without an executor here has finished.
+	followPollInterval = time.Second
+	// followBatch bounds the lines FollowTaskLogs sends at once.
+	followBatch = 100
+)
+
+// ErrDraining is returned by CreateTask while the service is drained.
+var ErrDraining = errors.New("service is draining, new tasks are not accepted")
+
+// ErrTaskFinished is returned by CancelTask for a task that has finished
+// already.
+var ErrTaskFinished = errors.New("task has already finished")
+
+// ErrTaskNotWaiting is returned by SetTaskPriority for a task that has
+// started or finished already.
+var ErrTaskNotWaiting = errors.New("task is no longer waiting for a worker")
+
+// ErrExpiryInPast is returned by CreateTask for a task that would expire
+// before it is created.
+var ErrExpiryInPast = errors.New("task expiry is not in the future")
+
+// UnknownPoolError is returned by CreateTask for a task asking for a pool
+// of workers the service does not have.
+type UnknownPoolError struct {
+	Pool string
+	// Pools are the pools the service has.
+	Pools []string
+}
+
+func (e *UnknownPoolError) Error() string {
+	return fmt.Sprintf("unknown worker pool %q", e.Pool)
+}
+
+// RuntimeTooLongError is returned by CreateTask for a task asking for a
+// longer MaxRuntime than the service allows.
+type RuntimeTooLongError struct {
+	Max time.Duration
+}
+
+func (e *RuntimeTooLongError) Error() string {
+	return fmt.Sprintf("task max runtime exceeds the maximum of %s", e.Max)
+}
+
+// errExpired is the cause the execution of an expired task is cancelled with.
+var errExpired = errors.New("task expired")
+
+// errPreempted is the cause the run of a task on a worker is cancelled with
+// when a critical task takes the worker.
+var errPreempted = errors.New("worker taken by a critical task")
+
+var tracer = otel.Tracer("github.com/nzb3/workmate_test/internal/service/taskservice")
 
 type Repository interface {
-	Create(task *taskmodel.Task) error
-	GetByID(id uuid.UUID) (*taskmodel.Task, error)
-	Update(task *taskmodel.Task) error
-	Delete(id uuid.UUID) error
-	GetAll() ([]*taskmodel.Task, error)
+	Create(ctx context.Context, task *taskmodel.Task) error
+	GetByID(ctx context.Context, id uuid.UUID) (*taskmodel.Task, error)
+	Update(ctx context.Context, task *taskmodel.Task) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetAll(ctx context.Context) ([]*taskmodel.Task, error)
+	// Events returns the event history of a task, oldest first.
+	Events(ctx context.Context, id uuid.UUID) ([]taskmodel.Event, error)
+	// Rebuild replays the events of every task to repair the stored state.
+	Rebuild(ctx context.Context) (int, error)
 }
 
-type TaskContext struct {
-	ID      uuid.UUID
-	Cancel  context.CancelFunc
-	Started time.Time
-	Done    chan struct{}
-	Status  taskmodel.TaskStatus
-	mu      sync.RWMutex
+// ReadModel serves task listings and statistics, keeping heavy reads off
+// the repository the executors write to.
+type ReadModel interface {
+	List(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error)
 }
 
-func (tc *TaskContext) IsFinished() bool {
-	tc.mu.RLock()
-	defer tc.mu.RUnlock()
-	select {
-	case <-tc.Done:
-		return true
-	default:
-		return false
-	}
+type Metrics interface {
+	TaskCreated()
+	TaskFinished(taskType string, status taskmodel.TaskStatus, queueWait, processingTime time.Duration)
+	// TaskSlow counts tasks that exceeded the slow threshold of their type.
+	TaskSlow(taskType string)
+	// TaskPreempted counts running tasks preempted by a critical task.
+	TaskPreempted(taskType string)
 }
 
-func (tc *TaskContext) markFinished(status taskmodel.TaskStatus) {
-	tc.mu.Lock()
-	defer tc.mu.Unlock()
-	tc.Status = status
-	select {
-	case <-tc.Done:
-	default:
-		close(tc.Done)
-	}
+// DurationModel learns how long completed tasks of every type took, for
+// the ETAs of queued tasks.
+type DurationModel interface {
+	Observe(taskType string, d time.Duration)
+	Estimate(taskType string) (estimate.Estimate, bool)
+	Estimates() map[string]estimate.Estimate
 }
 
 type Service struct {
-	repo     Repository
-	contexts sync.Map //[uuid.UUID]*TaskContext
-	wg       sync.WaitGroup
+	repo        Repository
+	reads       ReadModel
+	dispatcher  Dispatcher
+	coordinator Coordinator
+	metrics     Metrics
+	durations   DurationModel
+	blobs       BlobStore
+	// logs keeps the lines logged about every task, see TaskLogs.
+	logs     *tasklog.Store
+	reporter panicreport.Reporter
+	// clock measures task execution; heartbeats use the wall clock as they
+	// track the executor goroutines themselves.
+	clock  clock.Clock
+	logger *slog.Logger
+	// timeout cancels tasks that have not finished in time.
+	timeout time.Duration
+	// shutdownTimeout bounds how long Shutdown waits for cancelled tasks.
+	shutdownTimeout time.Duration
+	// maxRuntime bounds the timeout a task may ask for instead.
+	maxRuntime time.Duration
+	retry      RetryPolicy
+	// dedup detects repeated submissions when its window is set.
+	dedup dedupIndex
+	// slowThreshold flags tasks processing for longer as slow, unless
+	// slowThresholds has a threshold for their type; zero flags none.
+	slowThreshold  time.Duration
+	slowThresholds map[string]time.Duration
+	// uniqueNames creates tasks only while no other active task of their
+	// owner has their name, as if every task asked for WithUniqueName.
+	uniqueNames bool
+	// admission serializes checking new tasks against the existing ones
+	// with creating them, see admit.
+	admission sync.Mutex
+	contexts  sync.Map //[uuid.UUID]*TaskContext
+	wg        sync.WaitGroup
+	// deliveries serializes taking up dispatched tasks, so that concurrent
+	// deliveries of one task start a single executor.
+	deliveries sync.Mutex
+
+	// workers bounds the number of concurrently executing tasks;
+	// tasks beyond the limit wait for a free worker by priority.
+	workers  *workerPool
+	queued   atomic.Int64
+	running  atomic.Int64
+	queueSeq atomic.Uint64
+	// waits tracks the tasks waiting for a worker for QueueWait.
+	waits waitQueue
+	// shedding rejects tasks of low priority while the executors are far
+	// behind.
+	shedding ShedPolicy
+	// executors counts executor goroutines, both queued and running.
+	executors atomic.Int64
+	closed    atomic.Bool
+	// draining rejects new tasks while already accepted ones keep running.
+	draining atomic.Bool
+	// heartbeat is the unix time in nanoseconds of the last executor progress.
+	heartbeat atomic.Int64
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{
-		repo: repo,
+// NewService creates a service storing tasks in repo. Without options it
+// executes DefaultWorkers tasks at a time, cancels them after DefaultTimeout
+// or the MaxRuntime of up to DefaultMaxRuntime they ask for, and does not
+// retry them.
+func NewService(repo Repository, opts ...Option) *Service {
+	s := &Service{
+		repo:            repo,
+		metrics:         nopMetrics{},
+		durations:       estimate.NewModel(estimate.DefaultWindow, "", slog.Default()),
+		blobs:           blobstore.NewMemory(),
+		logs:            tasklog.NewStore(tasklog.DefaultCapacity),
+		reporter:        panicreport.Nop{},
+		clock:           clock.Real{},
+		logger:          slog.Default(),
+		timeout:         DefaultTimeout,
+		maxRuntime:      DefaultMaxRuntime,
+		shutdownTimeout: DefaultShutdownTimeout,
+		retry:           NoRetry,
+		workers:         newWorkerPool(DefaultWorkers),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.logger = slog.New(tasklog.NewHandler(s.logger.Handler(), s.logs))
+	if s.reads == nil {
+		s.reads = repositoryReads{repo: repo}
 	}
+	return s
 }
 
-func (s *Service) CreateTask(ctx context.Context, name string) (*taskmodel.Task, error) {
-	task := taskmodel.NewTask(taskmodel.WithName(name))
-	task.SetStatus(taskmodel.StatusProcessing)
-	task.CreatedAt = time.Now()
+func (s *Service) CreateTask(ctx context.Context, name string, opts ...taskmodel.Option) (*taskmodel.Task, error) {
+	if s.draining.Load() {
+		return nil, ErrDraining
+	}
 
-	if err := s.repo.Create(task); err != nil {
-		return nil, fmt.Errorf("failed to create task: %w", err)
+	opts = append([]taskmodel.Option{taskmodel.WithName(name)}, opts...)
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		opts = append(opts, taskmodel.WithOwner(principal.ID))
 	}
-	taskCtx, cancel := context.WithTimeout(context.Background(), defaultTimeToProcessTask)
-	taskContext := &TaskContext{
-		ID:      task.ID,
-		Cancel:  cancel,
-		Started: time.Now(),
-		Done:    make(chan struct{}),
-		Status:  taskmodel.StatusProcessing,
+
+	if id := requestid.FromContext(ctx); id != "" {
+		opts = append(opts, taskmodel.WithRequestID(id))
 	}
 
-	s.contexts.Store(task.ID, taskContext)
-	s.wg.Add(1)
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		opts = append(opts, taskmodel.WithTraceContext(carrier))
+	}
+
+	task := taskmodel.NewTask(opts...)
+	initial := taskmodel.StatusQueued
+	if s.dispatcher != nil {
+		// The task is queued once the dispatcher accepted it.
+		initial = taskmodel.StatusPending
+	}
+	if err := task.Transition(initial); err != nil {
+		return nil, err
+	}
+	task.CreatedAt = s.clock.Now()
+	if !task.ExpiresAt.IsZero() && !task.ExpiresAt.After(task.CreatedAt) {
+		return nil, ErrExpiryInPast
+	}
+	if task.MaxRuntime > s.maxRuntime {
+		return nil, &RuntimeTooLongError{Max: s.maxRuntime}
+	}
+	if !s.workers.has(task.Pool) {
+		return nil, &UnknownPoolError{Pool: task.Pool, Pools: s.workers.pools()}
+	}
+	if err := s.shed(task); err != nil {
+		return nil, err
+	}
+
+	admitted, err := s.admit(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Create(ctx, task)
+	admitted(err == nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	s.metrics.TaskCreated()
+	s.logs.Open(task.ID)
+	s.logger.InfoContext(ctx, "Task created", "task_id", task.ID, "actor", actor(ctx), "request_id", task.RequestID)
+
+	if s.dispatcher != nil {
+		if err := s.dispatch(ctx, task); err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
 
+	taskCtx, taskContext := s.newExecution(task)
 	go s.executeTask(taskCtx, *task, taskContext)
 
 	return task, nil
 }
 
+// newExecution registers the executor of the task about to run here and
+// returns the context bounding its execution.
+func (s *Service) newExecution(task *taskmodel.Task) (context.Context, *TaskContext) {
+	timeout := s.timeout
+	if task.MaxRuntime > 0 {
+		timeout = task.MaxRuntime
+	}
+	taskCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	if !task.ExpiresAt.IsZero() {
+		taskCtx, cancel = s.withExpiry(taskCtx, cancel, task.ExpiresAt)
+	}
+	taskContext := newTaskContext(*task, cancel, s.queueSeq.Add(1))
+	if !s.workers.has(taskContext.pool) {
+		// Delivered by an instance with other pools.
+		s.logger.Warn("Unknown worker pool, running the task on the default pool", "task_id", task.ID, "pool", task.Pool)
+		taskContext.pool = taskmodel.DefaultPool
+	}
+	s.workers.register(taskContext)
+	go taskContext.run()
+	s.logs.Open(task.ID)
+
+	s.contexts.Store(task.ID, taskContext)
+	s.wg.Add(1)
+	s.executors.Add(1)
+	return taskCtx, taskContext
+}
+
+// withExpiry cancels ctx with errExpired once expiresAt has passed, whether
+// or not the task is running by then; cancel is called with the returned
+// CancelFunc.
+func (s *Service) withExpiry(ctx context.Context, cancel context.CancelFunc, expiresAt time.Time) (context.Context, context.CancelFunc) {
+	ctx, expire := context.WithCancelCause(ctx)
+	timer := s.clock.AfterFunc(expiresAt.Sub(s.clock.Now()), func() { expire(errExpired) })
+	return ctx, func() {
+		timer.Stop()
+		expire(context.Canceled)
+		cancel()
+	}
+}
+
 func (s *Service) GetTask(ctx context.Context, taskID uuid.UUID) (*taskmodel.Task, error) {
-	task, err := s.repo.GetByID(taskID)
+	task, err := s.repo.GetByID(ctx, taskID)
 	if err != nil {
-		return nil, fmt.Errorf("task not found: %w", err)
+		return nil, fmt.Errorf("failed to get task: %w", err)
 	}
 
 	s.updateTaskProcessingTime(task)
@@ -103,36 +330,291 @@ func (s *Service) GetTask(ctx context.Context, taskID uuid.UUID) (*taskmodel.Tas
 }
 
 func (s *Service) DeleteTask(ctx context.Context, taskID uuid.UUID) error {
-	_, err := s.repo.GetByID(taskID)
+	task, err := s.repo.GetByID(ctx, taskID)
 	if err != nil {
-		return fmt.Errorf("task not found: %w", err)
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	s.removeExecution(taskID)
+	if err := s.repo.Delete(ctx, taskID); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
 	}
+	s.cancelElsewhere(ctx, taskID)
+	s.deleteAttachments(ctx, task.Inputs, task.Artifacts)
+
+	s.logger.InfoContext(ctx, "Task deleted", "task_id", taskID, "actor", actor(ctx))
+	s.logs.Delete(taskID)
+	return nil
+}
 
+// CancelTask stops a task that has not finished and keeps it with status
+// CANCELLED, unlike DeleteTask, recording reason, which may be empty. It
+// returns ErrTaskFinished for a task that has finished already.
+func (s *Service) CancelTask(ctx context.Context, taskID uuid.UUID, reason string) (*taskmodel.Task, error) {
 	if taskContext, ok := s.loadTaskContext(taskID); ok {
-		taskContext.Cancel()
-		s.contexts.Delete(taskID)
+		s.logger.InfoContext(ctx, "Task cancelled", "task_id", taskID, "actor", actor(ctx), "reason", reason)
+		taskContext.cancelByUser(reason)
+		select {
+		case <-taskContext.Done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	} else {
+		task, err := s.repo.GetByID(ctx, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		if !task.IsActive() {
+			return nil, ErrTaskFinished
+		}
+		// The task waits in the queue or runs on another instance, which
+		// must not store it any more.
+		if err := task.Transition(taskmodel.StatusCancelled); err != nil {
+			return nil, ErrTaskFinished
+		}
+		task.FinishedAt = s.clock.Now()
+		task.CancelReason = reason
+		err = s.repo.Update(ctx, task)
+		if errors.Is(err, taskmodel.ErrInvalidTransition) {
+			return nil, ErrTaskFinished
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel task: %w", err)
+		}
+		s.cancelElsewhere(ctx, taskID)
+		s.logger.InfoContext(ctx, "Task cancelled", "task_id", taskID, "actor", actor(ctx), "reason", reason)
+		if task.DeleteAfter > 0 {
+			s.scheduleDeletion(context.WithoutCancel(ctx), task.ID, task.DeleteAfter)
+		}
 	}
 
-	if err := s.repo.Delete(taskID); err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+	task, err := s.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
 	}
+	if !task.IsCancelled() {
+		return nil, ErrTaskFinished
+	}
+	return task, nil
+}
 
-	return nil
+// SetTaskPriority changes the priority of a task waiting for a worker,
+// moving it within the queue. It returns ErrTaskNotWaiting for a task that
+// has started or finished already.
+func (s *Service) SetTaskPriority(ctx context.Context, taskID uuid.UUID, priority taskmodel.Priority) (*taskmodel.Task, error) {
+	if taskContext, ok := s.loadTaskContext(taskID); ok {
+		var err error
+		applied := taskContext.do(func(state *taskState) {
+			if !state.status.IsWaiting() {
+				err = ErrTaskNotWaiting
+				return
+			}
+			previous := state.task.Priority
+			state.task.Priority = priority
+			if state.removed {
+				return
+			}
+			if err = s.repo.Update(ctx, &state.task); err != nil {
+				state.task.Priority = previous
+			}
+		})
+		if !applied {
+			return nil, ErrTaskNotWaiting
+		}
+		if errors.Is(err, taskmodel.ErrInvalidTransition) {
+			return nil, ErrTaskNotWaiting
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update task priority: %w", err)
+		}
+		s.workers.reprioritize(taskID, priority)
+	} else {
+		// The task waits for another instance, which takes the priority up
+		// once it receives the task.
+		task, err := s.repo.GetByID(ctx, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		if !task.IsWaiting() {
+			return nil, ErrTaskNotWaiting
+		}
+		task.Priority = priority
+		err = s.repo.Update(ctx, task)
+		if errors.Is(err, taskmodel.ErrInvalidTransition) {
+			return nil, ErrTaskNotWaiting
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update task priority: %w", err)
+		}
+	}
+	s.logger.InfoContext(ctx, "Task priority changed", "task_id", taskID, "priority", priority.String(), "actor", actor(ctx))
+
+	return s.GetTask(ctx, taskID)
 }
 
-func (s *Service) ListTasks(ctx context.Context) ([]*taskmodel.Task, error) {
-	tasks, err := s.repo.GetAll()
+func (s *Service) ListTasks(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error) {
+	tasks, err := s.reads.List(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tasks: %w", err)
 	}
 
 	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		s.updateTaskProcessingTime(task)
 	}
 
 	return tasks, nil
 }
 
+// TaskLogs returns up to limit lines logged about the task starting with
+// line offset, oldest first, and the number of lines logged in total. Only
+// the last lines of a task are kept, and only by the instance that logged
+// them.
+func (s *Service) TaskLogs(ctx context.Context, taskID uuid.UUID, offset, limit int) ([]tasklog.Line, int, error) {
+	if _, err := s.repo.GetByID(ctx, taskID); err != nil {
+		return nil, 0, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	lines, total := s.logs.Lines(taskID, offset, limit)
+	return lines, total, nil
+}
+
+// FollowTaskLogs passes the lines logged about the task starting with line
+// offset to send, oldest first and as they are logged, until the task has
+// finished or ctx is done. Tasks executed by another instance are followed
+// until their final status is stored, without lines.
+func (s *Service) FollowTaskLogs(ctx context.Context, taskID uuid.UUID, offset int, send func([]tasklog.Line) error) error {
+	if _, err := s.repo.GetByID(ctx, taskID); err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	poll := time.NewTicker(followPollInterval)
+	defer poll.Stop()
+	stopped := false
+	for {
+		changed, finished := s.logs.Follow(taskID)
+		for {
+			lines, _ := s.logs.Lines(taskID, offset, followBatch)
+			if len(lines) == 0 {
+				break
+			}
+			if err := send(lines); err != nil {
+				return err
+			}
+			offset = lines[len(lines)-1].Seq + 1
+		}
+		if finished || stopped {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-poll.C:
+			if _, running := s.contexts.Load(taskID); running {
+				continue
+			}
+			task, err := s.repo.GetByID(ctx, taskID)
+			if errors.Is(err, taskmodel.ErrTaskNotFound) {
+				return nil
+			}
+			stopped = err == nil && !task.IsActive()
+		}
+	}
+}
+
+// TaskEvents returns the history of a task as recorded by the repository.
+func (s *Service) TaskEvents(ctx context.Context, taskID uuid.UUID) ([]taskmodel.Event, error) {
+	events, err := s.repo.Events(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task events: %w", err)
+	}
+	return events, nil
+}
+
+// RebuildTasks replays the event history of every task and repairs the
+// tasks whose stored state diverged from it. It returns the number of
+// repaired tasks.
+func (s *Service) RebuildTasks(ctx context.Context) (int, error) {
+	repaired, err := s.repo.Rebuild(ctx)
+	if err != nil {
+		return repaired, fmt.Errorf("failed to rebuild tasks: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Tasks rebuilt from events", "repaired", repaired, "actor", actor(ctx))
+	return repaired, nil
+}
+
+// Recover fails tasks left active by a previous run of the service: they
+// have no executor in this process and would otherwise never finish. It
+// returns the number of recovered tasks.
+func (s *Service) Recover(ctx context.Context) (int, error) {
+	// The queue delivers the interrupted tasks again instead.
+	if s.dispatcher != nil {
+		return 0, nil
+	}
+
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	recovered := 0
+	for _, task := range tasks {
+		if !task.IsActive() {
+			continue
+		}
+		if _, running := s.contexts.Load(task.ID); running {
+			continue
+		}
+
+		s.logger.WarnContext(ctx, "Task was interrupted by a restart, marking it failed", "task_id", task.ID)
+		s.finalizeTask(ctx, task, taskmodel.StatusFailed, task.ProcessingTime)
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+// PurgeTasks permanently erases every task matching the filter, cancelling
+// the ones still being executed. It returns the number of erased tasks.
+func (s *Service) PurgeTasks(ctx context.Context, filter taskmodel.Filter) (int, error) {
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	purged := 0
+	for _, task := range tasks {
+		if !filter.Match(task) {
+			continue
+		}
+
+		s.removeExecution(task.ID)
+		if err := s.repo.Delete(ctx, task.ID); err != nil {
+			return purged, fmt.Errorf("failed to purge task %s: %w", task.ID, err)
+		}
+		s.cancelElsewhere(ctx, task.ID)
+		s.deleteAttachments(ctx, task.Inputs, task.Artifacts)
+		s.logs.Delete(task.ID)
+		purged++
+	}
+
+	s.logger.InfoContext(ctx, "Tasks purged", "count", purged, "actor", actor(ctx))
+	return purged, nil
+}
+
+// actor names the caller for audit log lines.
+func actor(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.ID
+	}
+	return "anonymous"
+}
+
 func (s *Service) loadTaskContext(taskID uuid.UUID) (*TaskContext, bool) {
 	if value, exists := s.contexts.Load(taskID); exists {
 		if tc, ok := value.(*TaskContext); ok {
@@ -148,99 +630,590 @@ func (s *Service) updateTaskProcessingTime(task *taskmodel.Task) {
 	}
 
 	if taskContext, exists := s.loadTaskContext(task.ID); exists && !taskContext.IsFinished() {
-		task.ProcessingTime = time.Since(taskContext.Started)
+		if started := taskContext.StartedAt(); !started.IsZero() {
+			task.ProcessingTime = s.clock.Since(started)
+		}
 	}
 }
 
+// executeTask runs the task as a child span of the request that created it,
+// restored from the trace context stored on the task.
 func (s *Service) executeTask(ctx context.Context, task taskmodel.Task, taskContext *TaskContext) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(task.TraceContext))
+	ctx, span := tracer.Start(ctx, "task.execute",
+		trace.WithAttributes(
+			attribute.String("task.id", task.ID.String()),
+			attribute.String("task.type", task.Type),
+		),
+	)
+
 	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.recoverTask(ctx, &task, taskContext, recovered)
+			span.RecordError(fmt.Errorf("panic: %v", recovered))
+		}
+
+		s.executors.Add(-1)
 		s.wg.Done()
 		if !taskContext.IsFinished() {
-			taskContext.markFinished(taskmodel.StatusFailed)
+			s.finishTask(ctx, taskContext, taskmodel.StatusFailed)
 		}
 		s.contexts.Delete(task.ID)
-		log.Printf("Task %s execution finished with status: %s", task.ID, taskContext.Status)
+
+		status := taskContext.Status()
+		queueWait, processingTime := s.clock.Since(task.CreatedAt), time.Duration(0)
+		if started := taskContext.StartedAt(); !started.IsZero() {
+			queueWait, processingTime = started.Sub(task.CreatedAt), s.clock.Since(started)
+		}
+		s.metrics.TaskFinished(task.Type, status, queueWait, processingTime)
+		if status == taskmodel.StatusDone {
+			s.durations.Observe(task.Type, processingTime)
+		}
+		s.logger.InfoContext(ctx, "Task execution finished", "task_id", task.ID, "status", status)
+		s.logs.Finish(task.ID)
+
+		span.SetAttributes(attribute.String("task.status", string(status)))
+		if status != taskmodel.StatusDone {
+			span.SetStatus(codes.Error, "task finished with status "+string(status))
+		}
+		span.End()
 	}()
 
-	log.Printf("Starting task execution: %s (ID: %s)", task.Name, task.ID)
+	runCtx, err := s.acquireWorker(ctx, taskContext)
+	if err != nil {
+		s.logger.InfoContext(ctx, "Task was cancelled while waiting for a worker", "task_id", task.ID)
+		s.finishCancelled(ctx, taskContext, task.ExpiresAt)
+		return
+	}
+	holding := true
+	defer func() {
+		if holding {
+			s.releaseWorker(taskContext)
+		}
+	}()
 
+	// remaining is the work left of the attempt, less than workDuration
+	// once the task resumes after a preemption.
 	workDuration := time.Duration(3+rand.Intn(3)) * time.Minute
-	log.Printf("Task %s will take %v to complete", task.ID, workDuration)
+	remaining := workDuration
+	if err := s.startTask(ctx, taskContext, workDuration); err != nil {
+		s.logger.WarnContext(ctx, "Failed to store task start", "task_id", task.ID, "error", err)
+	}
+	s.logger.InfoContext(ctx, "Starting task execution",
+		"task_id", task.ID,
+		"name", task.Name,
+		"request_id", task.RequestID,
+		"work_duration", workDuration.String(),
+	)
 
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+	// resume queues the preempted task again and continues it once it got a
+	// worker back; it reports false when the task was cancelled meanwhile.
+	resume := func() bool {
+		s.preemptTask(ctx, &task, taskContext)
 
-	start := time.Now()
+		holding = false
+		if runCtx, err = s.acquireWorker(ctx, taskContext); err != nil {
+			s.logger.InfoContext(ctx, "Task was cancelled while waiting for a worker", "task_id", task.ID)
+			s.finishCancelled(ctx, taskContext, task.ExpiresAt)
+			return false
+		}
+		holding = true
+		if err := s.startTask(ctx, taskContext, remaining); err != nil {
+			s.logger.WarnContext(ctx, "Failed to store task start", "task_id", task.ID, "error", err)
+		}
+		s.logger.InfoContext(ctx, "Resuming task execution", "task_id", task.ID, "remaining", remaining.String())
+		return true
+	}
+
+	// Preempted attempts do not count against the retry policy.
+	failures := 0
+	for attempt := 1; ; attempt++ {
+		s.beginAttempt(ctx, taskContext, attempt)
+		started := s.clock.Now()
+		err := s.runAttempt(runCtx, &task, taskContext, remaining)
+		if err == nil {
+			if err = s.storeResult(ctx, taskContext); err != nil {
+				err = fmt.Errorf("failed to store task result: %w", err)
+			}
+		}
+		if err == nil {
+			s.logger.InfoContext(ctx, "Task completed successfully", "task_id", task.ID, "attempt", attempt)
+			s.endAttempt(ctx, taskContext, taskmodel.AttemptSucceeded, nil)
+			s.finishTask(ctx, taskContext, taskmodel.StatusDone)
+			return
+		}
+
+		if ctx.Err() != nil {
+			s.logger.InfoContext(ctx, "Task was cancelled", "task_id", task.ID, "attempt", attempt)
+			s.endAttempt(ctx, taskContext, taskmodel.AttemptCancelled, nil)
+			s.finishCancelled(ctx, taskContext, task.ExpiresAt)
+			return
+		}
+
+		if errors.Is(context.Cause(runCtx), errPreempted) {
+			s.endAttempt(ctx, taskContext, taskmodel.AttemptPreempted, nil)
+			remaining = max(remaining-s.clock.Since(started), 0)
+			s.logger.InfoContext(ctx, "Task preempted by a critical task", "task_id", task.ID, "attempt", attempt, "remaining", remaining.String())
+			if !resume() {
+				return
+			}
+			continue
+		}
+
+		failures++
+		s.endAttempt(ctx, taskContext, taskmodel.AttemptFailed, err)
+		if failures >= s.retry.MaxAttempts {
+			s.logger.ErrorContext(ctx, "Task failed", "task_id", task.ID, "attempt", attempt, "error", err)
+			s.finishTask(ctx, taskContext, taskmodel.StatusFailed)
+			return
+		}
+		// A retry does the whole work again.
+		remaining = workDuration
+
+		backoff := s.retry.backoff(failures + 1)
+		s.logger.WarnContext(ctx, "Task attempt failed, retrying",
+			"task_id", task.ID,
+			"attempt", attempt,
+			"backoff", backoff.String(),
+			"error", err,
+		)
+		elapsed := make(chan struct{})
+		timer := s.clock.AfterFunc(backoff, func() { close(elapsed) })
+		select {
+		case <-runCtx.Done():
+			timer.Stop()
+		case <-elapsed:
+		}
 
+		// The run ends during the backoff when the task is cancelled or its
+		// worker is taken; neither starts the next attempt right away.
+		if ctx.Err() != nil {
+			s.logger.InfoContext(ctx, "Task was cancelled while waiting to retry", "task_id", task.ID, "attempt", attempt)
+			s.finishCancelled(ctx, taskContext, task.ExpiresAt)
+			return
+		}
+		if runCtx.Err() != nil {
+			s.logger.InfoContext(ctx, "Task preempted by a critical task while waiting to retry", "task_id", task.ID, "attempt", attempt)
+			if !resume() {
+				return
+			}
+		}
+	}
+}
+
+// runAttempt performs the work of the task, which takes workDuration. It
+// returns ctx.Err() when the task is cancelled and an error when the attempt
+// failed, including by a panic.
+func (s *Service) runAttempt(ctx context.Context, task *taskmodel.Task, taskContext *TaskContext, workDuration time.Duration) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.reportPanic(ctx, task, recovered)
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	ticker := s.clock.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	start := s.clock.Now()
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("Task %s was cancelled", task.ID)
-			s.finalizeTask(&task, taskmodel.StatusFailed, time.Since(start))
-			taskContext.markFinished(taskmodel.StatusFailed)
-			return
+			return ctx.Err()
 
 		case <-ticker.C:
-			elapsed := time.Since(start)
-			task.ProcessingTime = elapsed
+			elapsed := s.clock.Since(start)
+			s.logger.DebugContext(ctx, "Task progress",
+				"task_id", task.ID,
+				"elapsed", elapsed.Round(time.Second).String(),
+				"remaining", (workDuration - elapsed).Round(time.Second).String(),
+			)
 
 			if elapsed >= workDuration {
-				log.Printf("Task %s completed successfully", task.ID)
-				s.finalizeTask(&task, taskmodel.StatusDone, elapsed)
-				taskContext.markFinished(taskmodel.StatusDone)
-				return
+				return nil
 			}
 
-			if err := s.repo.Update(&task); err != nil {
-				log.Printf("Failed to update task %s during execution: %v", task.ID, err)
-				s.finalizeTask(&task, taskmodel.StatusFailed, elapsed)
-				taskContext.markFinished(taskmodel.StatusFailed)
-				return
+			if err := s.reportProgress(ctx, taskContext); err != nil {
+				return fmt.Errorf("failed to update task during execution: %w", err)
 			}
 		}
 	}
 }
 
-func (s *Service) finalizeTask(task *taskmodel.Task, status taskmodel.TaskStatus, processingTime time.Duration) {
-	task.Status = status
-	task.ProcessingTime = processingTime
+// recoverTask reports a panic raised while executing the task and marks the
+// task as failed so it does not stay PROCESSING forever.
+func (s *Service) recoverTask(ctx context.Context, task *taskmodel.Task, taskContext *TaskContext, recovered any) {
+	s.reportPanic(ctx, task, recovered)
+	s.finishTask(ctx, taskContext, taskmodel.StatusFailed)
+}
+
+func (s *Service) reportPanic(ctx context.Context, task *taskmodel.Task, recovered any) {
+	stack := debug.Stack()
+	s.logger.ErrorContext(ctx, "Panic recovered in task", "task_id", task.ID, "panic", recovered, "stack", string(stack))
+
+	s.reporter.Report(ctx, panicreport.NewReport(recovered, stack, map[string]string{
+		"source":     "executor",
+		"task_id":    task.ID.String(),
+		"task_type":  task.Type,
+		"request_id": task.RequestID,
+	}))
+}
+
+// acquireWorker waits for a worker for the task and returns the context
+// the task runs in on it, which is cancelled with errPreempted when a
+// critical task takes the worker.
+func (s *Service) acquireWorker(ctx context.Context, taskContext *TaskContext) (context.Context, error) {
+	queued := s.queued.Add(1)
+	defer s.queued.Add(-1)
+	s.logger.DebugContext(ctx, "Task waiting for a worker", "queued", queued, "running", s.running.Load())
 
-	if err := s.repo.Update(task); err != nil {
-		log.Printf("Failed to finalize task %s: %v", task.ID, err)
+	wait := s.waits.push(s.clock.Now())
+	runCtx, err := s.workers.acquire(ctx, taskContext)
+	s.waits.remove(wait)
+	if err != nil {
+		return nil, err
 	}
+	s.running.Add(1)
+	return runCtx, nil
 }
 
-func (s *Service) Shutdown(ctx context.Context) error {
-	log.Println("Shutting down task service...")
+func (s *Service) releaseWorker(taskContext *TaskContext) {
+	s.running.Add(-1)
+	s.workers.release(taskContext)
+}
 
-	s.contexts.Range(func(key, value interface{}) bool {
-		if taskContext, ok := value.(*TaskContext); ok && !taskContext.IsFinished() {
-			log.Printf("Cancelling task %s", taskContext.ID)
-			taskContext.Cancel()
+// preemptTask queues the preempted task again and gives its worker up to
+// the critical task waiting for it.
+func (s *Service) preemptTask(ctx context.Context, task *taskmodel.Task, taskContext *TaskContext) {
+	taskContext.do(func(state *taskState) {
+		if err := state.task.Transition(taskmodel.StatusQueued); err != nil {
+			return
+		}
+		state.status = taskmodel.StatusQueued
+		state.task.StartedAt = time.Time{}
+		state.task.Preemptions++
+		if state.removed {
+			return
+		}
+		if err := s.repo.Update(ctx, &state.task); err != nil {
+			s.logger.WarnContext(ctx, "Failed to store preempted task", "task_id", state.task.ID, "error", err)
 		}
-		return true
 	})
+	s.running.Add(-1)
+	s.workers.requeue(taskContext)
+	s.metrics.TaskPreempted(task.Type)
+}
+
+// QueuedTasks returns the number of tasks waiting for a free worker.
+func (s *Service) QueuedTasks() int {
+	return int(s.queued.Load())
+}
+
+// RunningTasks returns the number of tasks currently held by a worker.
+func (s *Service) RunningTasks() int {
+	return int(s.running.Load())
+}
+
+// QueueWait returns how long the oldest task still waiting for a worker
+// has waited so far, zero when none waits: a measure of how far behind the
+// executors are.
+func (s *Service) QueueWait() time.Duration {
+	since, ok := s.waits.oldest()
+	if !ok {
+		return 0
+	}
+	return s.clock.Since(since)
+}
 
+// Drain stops accepting new tasks; running and queued tasks still finish.
+func (s *Service) Drain() {
+	s.draining.Store(true)
+	s.logger.Info("Task service is draining, new tasks are rejected")
+}
+
+// Undrain resumes accepting new tasks.
+func (s *Service) Undrain() {
+	s.draining.Store(false)
+	s.logger.Info("Task service accepts new tasks again")
+}
+
+func (s *Service) Draining() bool {
+	return s.draining.Load()
+}
+
+// CheckWorkers reports an error once the service has been shut down, or
+// when tasks are running but no executor has made progress recently.
+func (s *Service) CheckWorkers(ctx context.Context) error {
+	if s.closed.Load() {
+		return errors.New("task service is shut down")
+	}
+
+	if s.RunningTasks() > 0 {
+		if age := s.HeartbeatAge(); age > maxHeartbeatAge {
+			return fmt.Errorf("last worker heartbeat was %s ago", age.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// HeartbeatAge returns the time since an executor last made progress,
+// or zero if no task has run yet.
+func (s *Service) HeartbeatAge() time.Duration {
+	last := s.heartbeat.Load()
+	if last == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, last))
+}
+
+// ExecutorGoroutines returns the number of live executor goroutines,
+// including the ones still waiting for a worker.
+func (s *Service) ExecutorGoroutines() int {
+	return int(s.executors.Load())
+}
+
+// WorkerCapacity returns the maximum number of concurrently executing
+// tasks, of all pools.
+func (s *Service) WorkerCapacity() int {
+	return s.workers.total()
+}
+
+// WorkerPoolStats is the state of a pool of workers.
+type WorkerPoolStats struct {
+	Name     string
+	Capacity int
+	// Running and Queued count the tasks holding and waiting for a worker
+	// of the pool.
+	Running int
+	Queued  int
+}
+
+// WorkerPools returns the state of every pool of workers, by name.
+func (s *Service) WorkerPools() []WorkerPoolStats {
+	busy, waiting := s.workers.usage()
+	pools := make([]WorkerPoolStats, 0, len(s.workers.capacity))
+	for _, name := range s.workers.pools() {
+		pools = append(pools, WorkerPoolStats{
+			Name:     name,
+			Capacity: s.workers.capacity[name],
+			Running:  busy[name],
+			Queued:   waiting[name],
+		})
+	}
+	return pools
+}
+
+// startTask records and stores that a worker picked the task up.
+func (s *Service) startTask(ctx context.Context, taskContext *TaskContext, workDuration time.Duration) (err error) {
+	taskContext.do(func(state *taskState) {
+		if err = state.task.Transition(taskmodel.StatusProcessing); err != nil {
+			return
+		}
+		state.status = taskmodel.StatusProcessing
+		state.task.StartedAt = s.clock.Now()
+		state.expected = workDuration
+		state.lastBeat = time.Now()
+		if !state.removed {
+			err = s.repo.Update(ctx, &state.task)
+		}
+	})
+	s.heartbeat.Store(time.Now().UnixNano())
+	return err
+}
+
+// beginAttempt records and stores that an execution attempt started.
+func (s *Service) beginAttempt(ctx context.Context, taskContext *TaskContext, number int) {
+	taskContext.do(func(state *taskState) {
+		state.task.Attempts = append(state.task.Attempts, taskmodel.Attempt{
+			Number:    number,
+			StartedAt: s.clock.Now(),
+			Outcome:   taskmodel.AttemptRunning,
+		})
+		if state.removed {
+			return
+		}
+		if err := s.repo.Update(ctx, &state.task); err != nil {
+			s.logger.WarnContext(ctx, "Failed to store task attempt", "task_id", state.task.ID, "attempt", number, "error", err)
+		}
+	})
+}
+
+// endAttempt records and stores how the running attempt ended.
+func (s *Service) endAttempt(ctx context.Context, taskContext *TaskContext, outcome taskmodel.AttemptOutcome, err error) {
+	taskContext.do(func(state *taskState) {
+		if len(state.task.Attempts) == 0 {
+			return
+		}
+		attempt := &state.task.Attempts[len(state.task.Attempts)-1]
+		attempt.FinishedAt = s.clock.Now()
+		attempt.Outcome = outcome
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		if state.removed {
+			return
+		}
+		// Cancelled attempts must be stored too.
+		if err := s.repo.Update(context.WithoutCancel(ctx), &state.task); err != nil {
+			s.logger.WarnContext(ctx, "Failed to store task attempt", "task_id", state.task.ID, "attempt", attempt.Number, "error", err)
+		}
+	})
+}
+
+// reportProgress records a heartbeat of the executor and stores the
+// processing time of the task so far.
+func (s *Service) reportProgress(ctx context.Context, taskContext *TaskContext) (err error) {
+	var task taskmodel.Task
+	var slow bool
+	taskContext.do(func(state *taskState) {
+		state.lastBeat = time.Now()
+		state.task.ProcessingTime = s.clock.Since(state.task.StartedAt)
+		if threshold := s.slowThresholdOf(state.task.Type); !state.task.Slow && threshold > 0 && state.task.ProcessingTime > threshold {
+			state.task.Slow, slow = true, true
+		}
+		if !state.removed {
+			err = s.repo.Update(ctx, &state.task)
+		}
+		task = state.task
+	})
+	s.heartbeat.Store(time.Now().UnixNano())
+	if slow {
+		s.metrics.TaskSlow(task.Type)
+		s.logger.WarnContext(ctx, "Task is running longer than its slow threshold",
+			"task_id", task.ID,
+			"type", task.Type,
+			"processing_time", task.ProcessingTime.Round(time.Second).String(),
+			"threshold", s.slowThresholdOf(task.Type).String(),
+		)
+	}
+	return err
+}
+
+// slowThresholdOf returns the slow threshold of the task type, zero when
+// tasks of the type are never flagged.
+func (s *Service) slowThresholdOf(taskType string) time.Duration {
+	if threshold, ok := s.slowThresholds[taskType]; ok {
+		return threshold
+	}
+	return s.slowThreshold
+}
+
+// finishTask moves the task to its final status, stores it unless the task
+// was deleted meanwhile, and stops its actor.
+func (s *Service) finishTask(ctx context.Context, taskContext *TaskContext, status taskmodel.TaskStatus) {
+	taskContext.do(func(state *taskState) {
+		var processingTime time.Duration
+		if !state.task.StartedAt.IsZero() {
+			processingTime = s.clock.Since(state.task.StartedAt)
+		}
+		if !state.removed {
+			s.finalizeTask(ctx, &state.task, status, processingTime)
+		}
+
+		state.status = status
+		state.finished = true
+		close(taskContext.Done)
+	})
+}
+
+// finishCancelled finishes a task whose execution was cancelled: CANCELLED
+// when its user cancelled it, FAILED otherwise, recording why when it
+// expired.
+func (s *Service) finishCancelled(ctx context.Context, taskContext *TaskContext, expiresAt time.Time) {
+	if taskContext.cancelledByUser() {
+		s.finishTask(ctx, taskContext, taskmodel.StatusCancelled)
+		return
+	}
+	if errors.Is(context.Cause(ctx), errExpired) {
+		s.logger.InfoContext(ctx, "Task expired", "task_id", taskContext.ID, "expires_at", expiresAt)
+		taskContext.do(func(state *taskState) {
+			state.task.FailureReason = fmt.Sprintf("task expired at %s before it finished", expiresAt.UTC().Format(time.RFC3339))
+		})
+	}
+	s.finishTask(ctx, taskContext, taskmodel.StatusFailed)
+}
+
+func (s *Service) finalizeTask(ctx context.Context, task *taskmodel.Task, status taskmodel.TaskStatus, processingTime time.Duration) {
+	if err := task.Transition(status); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to finalize task", "task_id", task.ID, "error", err)
+		return
+	}
+	task.ProcessingTime = processingTime
+	task.FinishedAt = s.clock.Now()
+
+	// The final state must be stored even when the task was cancelled.
+	if err := s.repo.Update(context.WithoutCancel(ctx), task); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to finalize task", "task_id", task.ID, "error", err)
+		return
+	}
+	if task.DeleteAfter > 0 {
+		s.scheduleDeletion(context.WithoutCancel(ctx), task.ID, task.DeleteAfter)
+	}
+}
+
+// scheduleDeletion deletes the finished task after d, unless it is gone by
+// then anyway.
+func (s *Service) scheduleDeletion(ctx context.Context, taskID uuid.UUID, d time.Duration) {
+	s.clock.AfterFunc(d, func() {
+		task, err := s.repo.GetByID(ctx, taskID)
+		if err == nil {
+			err = s.repo.Delete(ctx, taskID)
+		}
+		if errors.Is(err, taskmodel.ErrTaskNotFound) {
+			return
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete finished task", "task_id", taskID, "error", err)
+			return
+		}
+		s.deleteAttachments(ctx, task.Inputs, task.Artifacts)
+		s.logger.InfoContext(ctx, "Finished task deleted", "task_id", taskID, "delete_after", d)
+		s.logs.Delete(taskID)
+	})
+}
+
+// Wait blocks until every task executing or waiting for a worker has
+// finished, or until ctx is done.
+func (s *Service) Wait(ctx context.Context) error {
 	done := make(chan struct{})
 	go func() {
 		s.wg.Wait()
 		close(done)
 	}()
 
-	shutdownTimeout := 30 * time.Second
-	shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
-	defer cancel()
-
 	select {
-	case <-shutdownCtx.Done():
-		log.Println("Shutdown timeout reached")
-		return errors.New("shutdown timeout")
+	case <-ctx.Done():
+		return ctx.Err()
 	case <-done:
-		log.Println("All tasks finished, service shutdown complete")
 		return nil
 	}
 }
 
+// Shutdown cancels the unfinished tasks and waits for their executors to
+// stop.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.logger.InfoContext(ctx, "Shutting down task service")
+	s.closed.Store(true)
+
+	s.contexts.Range(func(key, value interface{}) bool {
+		if taskContext, ok := value.(*TaskContext); ok && !taskContext.IsFinished() {
+			s.logger.InfoContext(ctx, "Cancelling task", "task_id", taskContext.ID)
+			taskContext.Cancel()
+		}
+		return true
+	})
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.Wait(shutdownCtx); err != nil {
+		s.logger.WarnContext(ctx, "Task service shutdown timeout reached")
+		return errors.New("shutdown timeout")
+	}
+	s.logger.InfoContext(ctx, "All tasks finished, task service shutdown complete")
+	return nil
+}
+
 func (s *Service) WaitForTask(ctx context.Context, taskID uuid.UUID) error {
 	taskContext, exists := s.loadTaskContext(taskID)
 	if !exists {
@@ -257,10 +1230,7 @@ func (s *Service) WaitForTask(ctx context.Context, taskID uuid.UUID) error {
 
 func (s *Service) GetTaskStatus(taskID uuid.UUID) (taskmodel.TaskStatus, bool) {
 	if taskContext, exists := s.loadTaskContext(taskID); exists {
-		taskContext.mu.RLock()
-		status := taskContext.Status
-		taskContext.mu.RUnlock()
-		return status, true
+		return taskContext.Status(), true
 	}
 	return "", false
 }
@@ -0,0 +1,84 @@
+package taskservice
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// ShedPolicy decides when the executors are too far behind to accept new
+// tasks of a priority below high. A zero threshold disables its check.
+type ShedPolicy struct {
+	// MaxQueued is the number of tasks waiting for a worker at which new
+	// tasks are rejected.
+	MaxQueued int
+	// MaxWait is the wait of the oldest task still waiting for a worker at
+	// which new tasks are rejected.
+	MaxWait time.Duration
+	// RetryAfter is what rejected clients are told to wait before retrying.
+	RetryAfter time.Duration
+}
+
+// OverloadedError is returned by CreateTask for a task of a priority below
+// high while the executors are too far behind according to the ShedPolicy.
+type OverloadedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *OverloadedError) Error() string {
+	return "service is overloaded, " + e.Reason
+}
+
+// waitQueue remembers since when the tasks waiting for a worker wait, in
+// the order they started to, so that the longest wait is at the front.
+type waitQueue struct {
+	mu    sync.Mutex
+	since list.List
+}
+
+func (q *waitQueue) push(at time.Time) *list.Element {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.since.PushBack(at)
+}
+
+func (q *waitQueue) remove(e *list.Element) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.since.Remove(e)
+}
+
+// oldest returns since when the longest waiting task waits, false when no
+// task waits.
+func (q *waitQueue) oldest() (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	front := q.since.Front()
+	if front == nil {
+		return time.Time{}, false
+	}
+	return front.Value.(time.Time), true
+}
+
+// shed returns an OverloadedError for a task of a priority below high
+// while the executors are too far behind.
+func (s *Service) shed(task *taskmodel.Task) error {
+	if task.Priority >= taskmodel.PriorityHigh {
+		return nil
+	}
+
+	var reason string
+	if queued := s.QueuedTasks(); s.shedding.MaxQueued > 0 && queued >= s.shedding.MaxQueued {
+		reason = fmt.Sprintf("%d tasks are waiting for a worker", queued)
+	} else if wait := s.QueueWait(); s.shedding.MaxWait > 0 && wait >= s.shedding.MaxWait {
+		reason = fmt.Sprintf("tasks wait %s for a worker", wait.Round(time.Second))
+	}
+	if reason == "" {
+		return nil
+	}
+	return &OverloadedError{Reason: reason, RetryAfter: s.shedding.RetryAfter}
+}
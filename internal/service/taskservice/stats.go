@@ -0,0 +1,101 @@
+package taskservice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/timing"
+)
+
+// LatencyStats summarizes the processing time of tasks completed within a window.
+type LatencyStats struct {
+	Window time.Duration
+	Count  int
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+}
+
+// LatencyStats computes processing-time percentiles of the tasks that
+// finished successfully within the last window. An empty taskType covers
+// every type.
+func (s *Service) LatencyStats(ctx context.Context, window time.Duration, taskType string) (*LatencyStats, error) {
+	tasks, err := s.reads.List(ctx, taskmodel.Filter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	since := s.clock.Now().Add(-window)
+	durations := make([]time.Duration, 0, len(tasks))
+	for _, task := range tasks {
+		if !task.IsDone() || task.FinishedAt.Before(since) {
+			continue
+		}
+		if taskType != "" && task.Type != taskType {
+			continue
+		}
+		durations = append(durations, task.ProcessingTime)
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	return &LatencyStats{
+		Window: window,
+		Count:  len(durations),
+		P50:    timing.Percentile(durations, 50),
+		P90:    timing.Percentile(durations, 90),
+		P99:    timing.Percentile(durations, 99),
+	}, nil
+}
+
+// ThroughputBucket counts task events within [Start, Start+bucket).
+type ThroughputBucket struct {
+	Start     time.Time
+	Created   int
+	Completed int
+	Failed    int
+}
+
+// Throughput counts created, completed and failed tasks per bucket over the
+// last period. Buckets are aligned to the bucket size, the last one contains now.
+func (s *Service) Throughput(ctx context.Context, period, bucket time.Duration) ([]ThroughputBucket, error) {
+	tasks, err := s.reads.List(ctx, taskmodel.Filter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	count := int((period + bucket - 1) / bucket)
+	end := s.clock.Now().Truncate(bucket).Add(bucket)
+	start := end.Add(-time.Duration(count) * bucket)
+
+	buckets := make([]ThroughputBucket, count)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * bucket)
+	}
+
+	index := func(t time.Time) (int, bool) {
+		if t.IsZero() || t.Before(start) || !t.Before(end) {
+			return 0, false
+		}
+		return int(t.Sub(start) / bucket), true
+	}
+
+	for _, task := range tasks {
+		if i, ok := index(task.CreatedAt); ok {
+			buckets[i].Created++
+		}
+		if i, ok := index(task.FinishedAt); ok {
+			switch {
+			case task.IsDone():
+				buckets[i].Completed++
+			case task.IsFailed():
+				buckets[i].Failed++
+			}
+		}
+	}
+
+	return buckets, nil
+}
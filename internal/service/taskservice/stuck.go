@@ -0,0 +1,71 @@
+package taskservice
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+const (
+	StuckReasonRunningTooLong = "running_too_long"
+	StuckReasonStaleHeartbeat = "stale_heartbeat"
+)
+
+// StuckTask is a running task that takes longer than expected or whose
+// executor stopped making progress.
+type StuckTask struct {
+	Task          *taskmodel.Task
+	StartedAt     time.Time
+	Running       time.Duration
+	Expected      time.Duration
+	LastHeartbeat time.Time
+	Reasons       []string
+}
+
+// StuckTasks returns running tasks executing for longer than threshold or
+// without a heartbeat for longer than maxHeartbeatAge, longest running first.
+func (s *Service) StuckTasks(ctx context.Context, threshold time.Duration) ([]StuckTask, error) {
+	now := s.clock.Now()
+
+	var stuck []StuckTask
+	s.contexts.Range(func(key, value any) bool {
+		taskContext, ok := value.(*TaskContext)
+		if !ok || taskContext.IsFinished() {
+			return true
+		}
+		started := taskContext.StartedAt()
+		if started.IsZero() {
+			return true
+		}
+
+		candidate := StuckTask{
+			StartedAt:     started,
+			Running:       now.Sub(started),
+			Expected:      taskContext.ExpectedFinish().Sub(started),
+			LastHeartbeat: taskContext.LastHeartbeat(),
+		}
+		if candidate.Running > threshold {
+			candidate.Reasons = append(candidate.Reasons, StuckReasonRunningTooLong)
+		}
+		if time.Since(candidate.LastHeartbeat) > maxHeartbeatAge {
+			candidate.Reasons = append(candidate.Reasons, StuckReasonStaleHeartbeat)
+		}
+		if len(candidate.Reasons) == 0 {
+			return true
+		}
+
+		task, err := s.repo.GetByID(ctx, taskContext.ID)
+		if err != nil {
+			return true
+		}
+		candidate.Task = task
+		stuck = append(stuck, candidate)
+		return true
+	})
+
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].Running > stuck[j].Running })
+
+	return stuck, nil
+}
@@ -0,0 +1,405 @@
+package taskservice
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+)
+
+// workerPool bounds the number of concurrently executing tasks of every
+// named pool of workers. Tasks beyond the limit of their pool wait for a
+// free worker of it, the highest priority first and in queue order within
+// a priority. Tasks sharing a serial key run one at a time in queue order,
+// across pools: only the first of them is eligible for a worker. With
+// preemption, a critical task finding its pool busy takes the worker of the
+// running task of lowest priority, which is queued again.
+type workerPool struct {
+	// capacity is the number of workers by pool and preemption enables
+	// preempting; both are set before the first task is queued.
+	capacity   map[string]int
+	preemption bool
+
+	mu      sync.Mutex
+	busy    map[string]int
+	waiting waiterHeap
+	// running holds the tasks holding a worker, by ID; grants numbers
+	// them in the order they got it.
+	running map[uuid.UUID]*waiter
+	grants  uint64
+	// serial holds the queue sequences of the registered tasks by serial
+	// key, in queue order; the first one is running or next to run.
+	serial map[string][]uint64
+}
+
+// newWorkerPool returns a pool with capacity workers in the default pool.
+func newWorkerPool(capacity int) *workerPool {
+	return &workerPool{
+		capacity: map[string]int{taskmodel.DefaultPool: capacity},
+		busy:     make(map[string]int),
+		running:  make(map[uuid.UUID]*waiter),
+		serial:   make(map[string][]uint64),
+	}
+}
+
+// waiter is a task waiting for a worker; ready is closed once it got one.
+// It stays with the task while the task holds the worker.
+type waiter struct {
+	taskID    uuid.UUID
+	pool      string
+	priority  taskmodel.Priority
+	seq       uint64
+	serialKey string
+	ready     chan struct{}
+	index     int
+
+	// stop ends the context the task runs in on the worker, with
+	// errPreempted once the worker is taken; granted orders the tasks
+	// holding a worker.
+	stop      context.CancelCauseFunc
+	granted   uint64
+	preempted bool
+}
+
+// has reports whether the pool of workers named pool exists.
+func (p *workerPool) has(pool string) bool {
+	_, ok := p.capacity[pool]
+	return ok
+}
+
+// pools returns the names of the pools of workers, sorted.
+func (p *workerPool) pools() []string {
+	pools := make([]string, 0, len(p.capacity))
+	for pool := range p.capacity {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+	return pools
+}
+
+// total returns the number of workers of all pools.
+func (p *workerPool) total() int {
+	total := 0
+	for _, capacity := range p.capacity {
+		total += capacity
+	}
+	return total
+}
+
+// usage returns the number of busy workers and of waiting tasks by pool.
+func (p *workerPool) usage() (busy, waiting map[string]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	busy = make(map[string]int, len(p.busy))
+	for pool, n := range p.busy {
+		busy[pool] = n
+	}
+	waiting = make(map[string]int)
+	for _, w := range p.waiting {
+		waiting[w.pool]++
+	}
+	return busy, waiting
+}
+
+// register reserves the place of a task with a serial key among the tasks
+// sharing it as soon as the task is queued, before its executor asks for a
+// worker, so that the executors of consecutive tasks cannot overtake one
+// another. Every registered task must acquire a worker afterwards.
+func (p *workerPool) register(taskContext *TaskContext) {
+	if taskContext.serialKey == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seqs := p.serial[taskContext.serialKey]
+	i, _ := slices.BinarySearch(seqs, taskContext.queueSeq)
+	p.serial[taskContext.serialKey] = slices.Insert(seqs, i, taskContext.queueSeq)
+}
+
+// unregister removes a task from the tasks sharing its serial key.
+func (p *workerPool) unregister(key string, seq uint64) {
+	if key == "" {
+		return
+	}
+	seqs := p.serial[key]
+	if i, found := slices.BinarySearch(seqs, seq); found {
+		seqs = slices.Delete(seqs, i, i+1)
+	}
+	if len(seqs) == 0 {
+		delete(p.serial, key)
+		return
+	}
+	p.serial[key] = seqs
+}
+
+// eligible reports whether the waiting task may get a worker: it has no
+// serial key or is the first task of its key.
+func (p *workerPool) eligible(w *waiter) bool {
+	return w.serialKey == "" || p.serial[w.serialKey][0] == w.seq
+}
+
+// acquire waits for a worker for the task until ctx is done and returns
+// the context the task runs in on the worker, which ends with the cause
+// errPreempted once a critical task takes the worker. The priority of the
+// task is read while the pool is locked, so a priority changed
+// concurrently is either read or applied by reprioritize.
+func (p *workerPool) acquire(ctx context.Context, taskContext *TaskContext) (context.Context, error) {
+	runCtx, stop := context.WithCancelCause(ctx)
+	p.mu.Lock()
+	w := &waiter{
+		taskID:    taskContext.ID,
+		pool:      taskContext.pool,
+		priority:  taskContext.Priority(),
+		seq:       taskContext.queueSeq,
+		serialKey: taskContext.serialKey,
+		ready:     make(chan struct{}),
+		stop:      stop,
+	}
+	heap.Push(&p.waiting, w)
+	p.grant()
+	p.preempt(w)
+	p.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return runCtx, nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Granted meanwhile: pass the worker on.
+		p.busy[w.pool]--
+		delete(p.running, w.taskID)
+	default:
+		heap.Remove(&p.waiting, w.index)
+	}
+	stop(nil)
+	// The next task of the serial key may have become eligible.
+	p.unregister(w.serialKey, w.seq)
+	p.grant()
+	return nil, ctx.Err()
+}
+
+// release returns the worker of the task, letting the next task with its
+// serial key run.
+func (p *workerPool) release(taskContext *TaskContext) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vacate(taskContext)
+	p.unregister(taskContext.serialKey, taskContext.queueSeq)
+	p.grant()
+}
+
+// requeue returns the worker of a preempted task, which keeps its place
+// among the tasks sharing its serial key and acquires a worker again.
+func (p *workerPool) requeue(taskContext *TaskContext) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vacate(taskContext)
+	p.grant()
+}
+
+// vacate takes the worker from the task holding it.
+func (p *workerPool) vacate(taskContext *TaskContext) {
+	if w, ok := p.running[taskContext.ID]; ok {
+		w.stop(nil)
+		delete(p.running, taskContext.ID)
+	}
+	p.busy[taskContext.pool]--
+}
+
+// grant hands free workers to the first eligible waiting tasks of their
+// pools.
+func (p *workerPool) grant() {
+	for {
+		w := p.next()
+		if w == nil {
+			return
+		}
+		heap.Remove(&p.waiting, w.index)
+		p.busy[w.pool]++
+		p.grants++
+		w.granted = p.grants
+		p.running[w.taskID] = w
+		close(w.ready)
+	}
+}
+
+// preempt takes workers for the critical tasks of the pool of w that wait
+// for a free one, one from each of the running tasks of lowest priority,
+// unless enough are being taken already. The preempted tasks give their
+// workers back once they noticed, which grants them to the critical tasks
+// first.
+func (p *workerPool) preempt(w *waiter) {
+	if !p.preemption || w.priority != taskmodel.PriorityCritical {
+		return
+	}
+	needed := 0
+	for _, waiting := range p.waiting {
+		if waiting.pool == w.pool && waiting.priority == taskmodel.PriorityCritical && p.eligible(waiting) {
+			needed++
+		}
+	}
+	for _, running := range p.running {
+		if running.pool == w.pool && running.preempted {
+			needed--
+		}
+	}
+	for ; needed > 0; needed-- {
+		victim := p.victim(w.pool)
+		if victim == nil {
+			return
+		}
+		victim.preempted = true
+		victim.stop(errPreempted)
+	}
+}
+
+// victim returns the running task of the pool to preempt: the one of
+// lowest priority below critical, the one that got its worker last among
+// those, so that the least work is interrupted. It returns nil when every
+// running task is critical or preempted already.
+func (p *workerPool) victim(pool string) *waiter {
+	var victim *waiter
+	for _, running := range p.running {
+		if running.pool != pool || running.preempted || running.priority == taskmodel.PriorityCritical {
+			continue
+		}
+		if victim == nil || running.priority < victim.priority ||
+			running.priority == victim.priority && running.granted > victim.granted {
+			victim = running
+		}
+	}
+	return victim
+}
+
+// next returns the first eligible waiting task with a free worker in its
+// pool, nil when there is none. The first waiting task usually is; the
+// others are only looked at while it waits for an earlier task of its
+// serial key or for its pool.
+func (p *workerPool) next() *waiter {
+	if p.waiting.Len() == 0 {
+		return nil
+	}
+	if first := p.waiting[0]; p.free(first.pool) && p.eligible(first) {
+		return first
+	}
+	var next *waiter
+	for _, w := range p.waiting {
+		if p.free(w.pool) && p.eligible(w) && (next == nil || w.before(next)) {
+			next = w
+		}
+	}
+	return next
+}
+
+// free reports whether the pool has a free worker.
+func (p *workerPool) free(pool string) bool {
+	return p.busy[pool] < p.capacity[pool]
+}
+
+// reprioritize moves a waiting task to its place for priority; it reports
+// false when the task is not waiting for a worker.
+func (p *workerPool) reprioritize(taskID uuid.UUID, priority taskmodel.Priority) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, w := range p.waiting {
+		if w.taskID == taskID {
+			w.priority = priority
+			heap.Fix(&p.waiting, w.index)
+			p.preempt(w)
+			return true
+		}
+	}
+	return false
+}
+
+// order returns the tasks waiting for a worker in the order they will get
+// one, assuming no other task arrives meanwhile. A task waiting for an
+// earlier task of its serial key to finish follows the tasks that do not
+// have to.
+func (p *workerPool) order() []waiter {
+	p.mu.Lock()
+	waiting := make([]waiter, len(p.waiting))
+	running := make(map[string]bool, len(p.serial))
+	for i, w := range p.waiting {
+		waiting[i] = *w
+	}
+	for key, seqs := range p.serial {
+		running[key] = !slices.ContainsFunc(waiting, func(w waiter) bool { return w.seq == seqs[0] })
+	}
+	p.mu.Unlock()
+
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].before(&waiting[j]) })
+
+	eligible := func(w waiter) bool {
+		if w.serialKey == "" {
+			return true
+		}
+		return !running[w.serialKey] && !slices.ContainsFunc(waiting, func(other waiter) bool {
+			return other.serialKey == w.serialKey && other.seq < w.seq
+		})
+	}
+
+	// Take the waiting tasks up as the workers would. Once every waiting
+	// task waits for a running task of its serial key, one of those
+	// finishes first, in an unknown order; assume all of them do.
+	order := make([]waiter, 0, len(waiting))
+	for len(waiting) > 0 {
+		i := slices.IndexFunc(waiting, eligible)
+		if i < 0 {
+			clear(running)
+			continue
+		}
+		w := waiting[i]
+		order = append(order, w)
+		waiting = slices.Delete(waiting, i, i+1)
+		if w.serialKey != "" {
+			running[w.serialKey] = true
+		}
+	}
+	return order
+}
+
+// before reports whether w gets a worker before other: the higher priority
+// first, then the task queued first.
+func (w *waiter) before(other *waiter) bool {
+	if w.priority != other.priority {
+		return w.priority > other.priority
+	}
+	return w.seq < other.seq
+}
+
+// waiterHeap orders waiting tasks by waiter.before.
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool { return h[i].before(h[j]) }
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,112 @@
+package templateservice
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/clock"
+	"github.com/nzb3/workmate_test/internal/models/templatemodel"
+)
+
+type Repository interface {
+	Create(ctx context.Context, template *templatemodel.Template) error
+	GetByID(ctx context.Context, id uuid.UUID) (*templatemodel.Template, error)
+	Update(ctx context.Context, template *templatemodel.Template) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetAll(ctx context.Context) ([]*templatemodel.Template, error)
+}
+
+type Service struct {
+	repo  Repository
+	clock clock.Clock
+	// instances serializes numbering the tasks created from templates.
+	instances sync.Mutex
+}
+
+func NewService(repo Repository, clk clock.Clock) *Service {
+	return &Service{
+		repo:  repo,
+		clock: clk,
+	}
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, opts ...templatemodel.Option) (*templatemodel.Template, error) {
+	template := templatemodel.NewTemplate(opts...)
+
+	if err := s.repo.Create(ctx, template); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template, nil
+}
+
+func (s *Service) GetTemplate(ctx context.Context, templateID uuid.UUID) (*templatemodel.Template, error) {
+	template, err := s.repo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return template, nil
+}
+
+// UpdateTemplate replaces the definition of a template; the number of
+// tasks created from it is kept.
+func (s *Service) UpdateTemplate(ctx context.Context, templateID uuid.UUID, opts ...templatemodel.Option) (*templatemodel.Template, error) {
+	s.instances.Lock()
+	defer s.instances.Unlock()
+
+	stored, err := s.repo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	template := templatemodel.NewTemplate(opts...)
+	template.ID = stored.ID
+	template.Instances = stored.Instances
+	template.CreatedAt = stored.CreatedAt
+
+	if err := s.repo.Update(ctx, template); err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return template, nil
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, templateID uuid.UUID) error {
+	if err := s.repo.Delete(ctx, templateID); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) ListTemplates(ctx context.Context) ([]*templatemodel.Template, error) {
+	templates, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Instantiate numbers the next task created from the template and returns
+// the template together with the name of that task.
+func (s *Service) Instantiate(ctx context.Context, templateID uuid.UUID) (*templatemodel.Template, string, error) {
+	s.instances.Lock()
+	defer s.instances.Unlock()
+
+	template, err := s.repo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get template: %w", err)
+	}
+
+	template.Instances++
+	if err := s.repo.Update(ctx, template); err != nil {
+		return nil, "", fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return template, template.TaskName(s.clock.Now(), template.Instances), nil
+}
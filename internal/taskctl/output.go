@@ -0,0 +1,88 @@
+package taskctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nzb3/workmate_test/pkg/client"
+)
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes tasks in the selected output format.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (printer, error) {
+	if format != outputTable && format != outputJSON {
+		return printer{}, usageError{fmt.Errorf("unknown output format %q, expected table or json", format)}
+	}
+	return printer{w: w, format: format}, nil
+}
+
+// tasks prints a table with a row per task or a JSON array.
+func (p printer) tasks(tasks []client.Task) error {
+	if p.format == outputJSON {
+		if tasks == nil {
+			tasks = []client.Task{}
+		}
+		return p.json(tasks)
+	}
+
+	withSource := false
+	for _, task := range tasks {
+		withSource = withSource || task.Source != ""
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	header := "ID\tNAME\tTYPE\tSTATUS\tCREATED\tDURATION"
+	if withSource {
+		header += "\tSOURCE"
+	}
+	fmt.Fprintln(tw, header)
+	for _, task := range tasks {
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", task.ID, task.Name, task.Type, task.Status,
+			task.CreatedAt.Local().Format(time.DateTime), task.ProcessingTime.Round(time.Millisecond))
+		if withSource {
+			row += "\t" + task.Source
+		}
+		fmt.Fprintln(tw, row)
+	}
+	return tw.Flush()
+}
+
+// task prints a single task, as a table row or a JSON object.
+func (p printer) task(task *client.Task) error {
+	if p.format == outputJSON {
+		return p.json(task)
+	}
+	return p.tasks([]client.Task{*task})
+}
+
+// status prints a status change observed by watch: a line of text or a
+// JSON object per change.
+func (p printer) status(task *client.Task) error {
+	if p.format == outputJSON {
+		return json.NewEncoder(p.w).Encode(task)
+	}
+	line := fmt.Sprintf("%s  %s  %s", time.Now().Format(time.TimeOnly), task.Status, task.ProcessingTime.Round(time.Millisecond))
+	if task.FailureReason != "" {
+		line += "  " + task.FailureReason
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
+
+func (p printer) json(value any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,119 @@
+package taskctl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// Profile describes how to reach one server.
+type Profile struct {
+	Server string `yaml:"server"`
+	// Token is sent as "Authorization: Bearer <token>"; it is the admin
+	// token of the server.
+	Token string `yaml:"token"`
+	// CA verifies the server certificate; Cert and Key authenticate the
+	// caller when the server requires client certificates.
+	CA   string `yaml:"ca"`
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+// profilesFile is the config file of taskctl:
+//
+//	current: local
+//	profiles:
+//	  local:
+//	    server: http://localhost:8080
+//	  prod:
+//	    server: https://tasks.example.com
+//	    token: ...
+type profilesFile struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// defaultConfigPath is $XDG_CONFIG_HOME/taskctl/config.yaml or its
+// equivalent on the platform.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "taskctl", "config.yaml")
+}
+
+// loadProfiles reads the config file; a missing file has no profiles.
+func loadProfiles(path string) (profilesFile, error) {
+	var file profilesFile
+	if path == "" {
+		return file, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// resolve picks the profile by name, the current one when name is empty.
+func (f profilesFile) resolve(name string) (Profile, error) {
+	if name == "" {
+		name = f.Current
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+	profile, ok := f.Profiles[name]
+	if !ok {
+		return Profile{}, usageError{fmt.Errorf("unknown profile %q", name)}
+	}
+	return profile, nil
+}
+
+// httpClient sends requests with the TLS settings of the profile.
+func (p Profile) httpClient(timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if p.CA == "" && p.Cert == "" {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if p.CA != "" {
+		pem, err := os.ReadFile(p.CA)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no CA certificates", p.CA)
+		}
+	}
+	if p.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(p.Cert, p.Key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	client.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	return client, nil
+}
@@ -0,0 +1,496 @@
+// Package taskctl is the command line client of the task API.
+package taskctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/nzb3/workmate_test/pkg/client"
+)
+
+// Exit codes; scripts can tell a failed task from a failed request.
+const (
+	ExitOK = 0
+	// ExitError: the server rejected the request or it could not be sent.
+	ExitError = 1
+	// ExitUsage: invalid arguments, flags or config file.
+	ExitUsage = 2
+	// ExitNotFound: the task does not exist.
+	ExitNotFound = 3
+	// ExitTaskFailed: the waited for task finished with status FAILED.
+	ExitTaskFailed = 4
+	// ExitUnavailable: the server could not be reached or is unavailable.
+	ExitUnavailable = 5
+)
+
+// usageError marks errors in the way the command was called.
+type usageError struct{ error }
+
+func (e usageError) Unwrap() error { return e.error }
+
+// errTaskFailed is returned by the commands waiting for a task that failed.
+var errTaskFailed = errors.New("task failed")
+
+// Execute runs the command line and exits the process with the exit code
+// matching the outcome.
+func Execute() {
+	os.Exit(Run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Run runs the command line with args and returns its exit code.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	root := newRootCommand()
+	root.SetArgs(args)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+
+	err := root.ExecuteContext(ctx)
+	if err == nil {
+		return ExitOK
+	}
+	fmt.Fprintln(stderr, "Error:", err)
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	var usage usageError
+	var apiErr *client.Error
+	var netErr *url.Error
+	switch {
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, errTaskFailed):
+		return ExitTaskFailed
+	case errors.Is(err, client.ErrNotFound):
+		return ExitNotFound
+	case errors.As(err, &apiErr):
+		if apiErr.StatusCode == http.StatusServiceUnavailable {
+			return ExitUnavailable
+		}
+		return ExitError
+	case errors.As(err, &netErr):
+		return ExitUnavailable
+	default:
+		return ExitError
+	}
+}
+
+// options are the global flags.
+type options struct {
+	configPath string
+	profile    string
+	server     string
+	token      string
+	output     string
+	timeout    time.Duration
+}
+
+// client connects to the server of the selected profile; --server and
+// --token override the profile.
+func (o *options) client() (*client.Client, error) {
+	file, err := loadProfiles(o.configPath)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	profile, err := file.resolve(o.profile)
+	if err != nil {
+		return nil, err
+	}
+	if o.server != "" {
+		profile.Server = o.server
+	}
+	if o.token != "" {
+		profile.Token = o.token
+	}
+	if profile.Server == "" {
+		profile.Server = defaultServer
+	}
+
+	httpClient, err := profile.httpClient(o.timeout)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	opts := []client.Option{client.WithHTTPClient(httpClient)}
+	if profile.Token != "" {
+		opts = append(opts, client.WithHeader("Authorization", "Bearer "+profile.Token))
+	}
+	return client.New(profile.Server, opts...), nil
+}
+
+func (o *options) printer(cmd *cobra.Command) (printer, error) {
+	return newPrinter(cmd.OutOrStdout(), o.output)
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:   "taskctl",
+		Short: "Command line client of the workmate task API",
+		Long: "Command line client of the workmate task API.\n\n" +
+			"The server is taken from --server, TASKCTL_SERVER or the selected profile of the config file.\n\n" +
+			"Exit codes: 0 success, 1 request failed, 2 invalid usage, 3 task not found,\n" +
+			"4 task finished with status FAILED, 5 server unreachable or unavailable.",
+		Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err}
+	})
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", envOr("TASKCTL_CONFIG", defaultConfigPath()), "config file with the profiles (TASKCTL_CONFIG)")
+	flags.StringVarP(&opts.profile, "profile", "p", os.Getenv("TASKCTL_PROFILE"), "profile of the config file to use, the current one by default (TASKCTL_PROFILE)")
+	flags.StringVar(&opts.server, "server", os.Getenv("TASKCTL_SERVER"), "base URL of the server, e.g. "+defaultServer+" (TASKCTL_SERVER)")
+	flags.StringVar(&opts.token, "token", os.Getenv("TASKCTL_TOKEN"), "admin token sent as a bearer token (TASKCTL_TOKEN)")
+	flags.StringVarP(&opts.output, "output", "o", outputTable, "output format: table or json")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of a single request")
+
+	root.AddCommand(
+		newCreateCommand(opts),
+		newGetCommand(opts),
+		newListCommand(opts),
+		newWatchCommand(opts),
+		newDeleteCommand(opts),
+		newCancelCommand(opts),
+		newPriorityCommand(opts),
+	)
+	return root
+}
+
+func newCreateCommand(opts *options) *cobra.Command {
+	var (
+		req       client.CreateRequest
+		project   string
+		expiresIn time.Duration
+		deleteIn  time.Duration
+		runtime   time.Duration
+		wait      bool
+		interval  time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a task",
+		Args:  exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Name = args[0]
+			if project != "" {
+				id, err := parseID("project", project)
+				if err != nil {
+					return err
+				}
+				req.ProjectID = &id
+			}
+			if expiresIn > 0 {
+				expiresAt := time.Now().Add(expiresIn)
+				req.ExpiresAt = &expiresAt
+			}
+			if runtime > 0 {
+				req.MaxRuntimeSeconds = int64(max(runtime/time.Second, 1))
+			}
+			if deleteIn > 0 {
+				req.DeleteAfterSeconds = int64(max(deleteIn/time.Second, 1))
+			}
+			out, err := opts.printer(cmd)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			task, err := c.Create(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			if wait {
+				if task, err = waitFor(cmd.Context(), c, task.ID, interval, nil); err != nil {
+					return err
+				}
+			}
+			if err := out.task(task); err != nil {
+				return err
+			}
+			if task.Status == client.StatusFailed {
+				return errTaskFailed
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&req.Type, "type", "", "task type")
+	cmd.Flags().StringVar(&project, "project", "", "ID of the project the task belongs to")
+	cmd.Flags().DurationVar(&expiresIn, "expires-in", 0, "fail the task unless it has finished within this time")
+	cmd.Flags().DurationVar(&runtime, "max-runtime", 0, "cancel the task if it runs longer than this instead of the server default")
+	cmd.Flags().DurationVar(&deleteIn, "delete-after", 0, "delete the task this long after it has finished")
+	cmd.Flags().StringVar((*string)(&req.Priority), "priority", "", "priority among the queued tasks: low, normal, high or critical")
+	cmd.Flags().StringVar(&req.Pool, "pool", "", "worker pool to run the task on instead of the default pool")
+	cmd.Flags().StringVar(&req.SerialKey, "serial-key", "", "run the task one at a time with the other tasks having this key, in creation order")
+	cmd.Flags().BoolVar(&req.Unique, "unique", false, "fail instead of creating the task while another active task has its name")
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait until the task has finished")
+	cmd.Flags().DurationVar(&interval, "interval", client.DefaultPollInterval, "how often --wait checks the task")
+	return cmd
+}
+
+func newGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a task",
+		Args:  exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID("task", args[0])
+			if err != nil {
+				return err
+			}
+			out, err := opts.printer(cmd)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			task, err := c.Get(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			return out.task(task)
+		},
+	}
+}
+
+func newListCommand(opts *options) *cobra.Command {
+	var (
+		list    client.ListOptions
+		project string
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tasks",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if project != "" {
+				id, err := parseID("project", project)
+				if err != nil {
+					return err
+				}
+				list.ProjectID = id
+			}
+			out, err := opts.printer(cmd)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			tasks, err := c.List(cmd.Context(), list)
+			if err != nil {
+				return err
+			}
+			return out.tasks(tasks)
+		},
+	}
+	cmd.Flags().StringVar(&list.Owner, "owner", "", `tasks of the owner, "me" for the caller's own`)
+	cmd.Flags().StringVar(&list.CreatedBy, "created-by", "", `tasks created by the identity, "me" for the ones the caller created`)
+	cmd.Flags().BoolVar(&list.All, "all", false, "tasks of every owner (admins only)")
+	cmd.Flags().StringVar(&project, "project", "", "tasks of the project")
+	cmd.Flags().BoolVar(&list.Federated, "federated", false, "also list the tasks of the server's peer instances")
+	return cmd
+}
+
+func newWatchCommand(opts *options) *cobra.Command {
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "watch ID",
+		Short: "Print the status changes of a task until it has finished",
+		Long: "Print the status changes of a task until it has finished.\n\n" +
+			"Exits with 0 when the task is DONE and with 4 when it FAILED.",
+		Args: exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID("task", args[0])
+			if err != nil {
+				return err
+			}
+			out, err := opts.printer(cmd)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			task, err := waitFor(cmd.Context(), c, id, interval, out.status)
+			if err != nil {
+				return err
+			}
+			if task.Status == client.StatusFailed {
+				return errTaskFailed
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", client.DefaultPollInterval, "how often the task is checked")
+	return cmd
+}
+
+func newDeleteCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete a task, cancelling it if it is still executing",
+		Args:  exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID("task", args[0])
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+			return c.Delete(cmd.Context(), id)
+		},
+	}
+}
+
+func newCancelCommand(opts *options) *cobra.Command {
+	var reason string
+	cmd := &cobra.Command{
+		Use:   "cancel ID",
+		Short: "Cancel a task that is still executing",
+		Long: "Cancel a task that is still executing.\n\n" +
+			"Unlike delete, cancel keeps the task with status CANCELLED and refuses to touch a\n" +
+			"task that has already finished.",
+		Args: exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID("task", args[0])
+			if err != nil {
+				return err
+			}
+			out, err := opts.printer(cmd)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			task, err := c.Cancel(cmd.Context(), id, reason)
+			var apiErr *client.Error
+			if errors.As(err, &apiErr) && apiErr.Code == "task_finished" {
+				return errors.New("task has already finished")
+			}
+			if err != nil {
+				return err
+			}
+			return out.task(task)
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "why the task is cancelled, kept with the task")
+	return cmd
+}
+
+func newPriorityCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "priority ID PRIORITY",
+		Short: "Change the priority of a queued task",
+		Long: "Change the priority of a task waiting for a worker to low, normal, high or critical.\n\n" +
+			"Queued tasks of higher priority start first; a task that has started keeps its priority.",
+		Args: exactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID("task", args[0])
+			if err != nil {
+				return err
+			}
+			out, err := opts.printer(cmd)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client()
+			if err != nil {
+				return err
+			}
+
+			task, err := c.SetPriority(cmd.Context(), id, client.Priority(args[1]))
+			var apiErr *client.Error
+			if errors.As(err, &apiErr) && apiErr.Code == "task_not_waiting" {
+				return errors.New("task has already started")
+			}
+			if err != nil {
+				return err
+			}
+			return out.task(task)
+		},
+	}
+}
+
+// waitFor polls the task until it has finished, calling changed (when not
+// nil) with the task every time its status changes.
+func waitFor(ctx context.Context, c *client.Client, id uuid.UUID, interval time.Duration, changed func(*client.Task) error) (*client.Task, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last client.Status
+	for {
+		task, err := c.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if task.Status != last && changed != nil {
+			if err := changed(task); err != nil {
+				return nil, err
+			}
+		}
+		last = task.Status
+		if task.Status.IsFinal() {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(n)(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+func parseID(kind, raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, usageError{fmt.Errorf("invalid %s ID %q", kind, raw)}
+	}
+	return id, nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
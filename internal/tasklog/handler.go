@@ -0,0 +1,85 @@
+package tasklog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// TaskIDKey is the attribute naming the task a log record belongs to.
+const TaskIDKey = "task_id"
+
+// Handler passes records on to another handler and keeps those naming a
+// task in a Store. Debug records are kept even when the other handler
+// discards them, so the log of a task includes its progress.
+type Handler struct {
+	next  slog.Handler
+	store *Store
+	// attrs are the attributes added with WithAttrs, prefixed with group.
+	attrs []slog.Attr
+	group string
+}
+
+func NewHandler(next slog.Handler, store *Store) *Handler {
+	return &Handler{next: next, store: store}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelDebug || h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	line := Line{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+		Attrs:   make(map[string]string),
+	}
+	var taskID uuid.UUID
+	collect := func(key string, value slog.Value) {
+		if key == TaskIDKey {
+			taskID, _ = uuid.Parse(fmt.Sprint(value.Any()))
+			return
+		}
+		line.Attrs[key] = value.String()
+	}
+	for _, attr := range h.attrs {
+		collect(attr.Key, attr.Value)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		collect(h.group+attr.Key, attr.Value)
+		return true
+	})
+	if taskID != uuid.Nil {
+		h.store.Append(taskID, line)
+	}
+
+	if !h.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefixed := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		prefixed[i] = slog.Attr{Key: h.group + attr.Key, Value: attr.Value}
+	}
+	return &Handler{
+		next:  h.next.WithAttrs(attrs),
+		store: h.store,
+		attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], prefixed...),
+		group: h.group,
+	}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{
+		next:  h.next.WithGroup(name),
+		store: h.store,
+		attrs: h.attrs,
+		group: h.group + name + ".",
+	}
+}
@@ -0,0 +1,153 @@
+// Package tasklog keeps the log lines written while executing tasks, per
+// task, so that the log of one task can be read without searching the
+// output of the whole server.
+package tasklog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCapacity is the number of lines kept per task unless NewStore is
+// given another one.
+const DefaultCapacity = 1000
+
+// Line is one log line of a task.
+type Line struct {
+	// Seq numbers the lines of a task from 0, counting the dropped ones.
+	Seq     int
+	Time    time.Time
+	Level   string
+	Message string
+	Attrs   map[string]string
+}
+
+// Store keeps the last lines of every open task in a ring buffer. Lines of
+// tasks that were not opened, or were deleted already, are dropped.
+type Store struct {
+	capacity int
+
+	mu   sync.Mutex
+	logs map[uuid.UUID]*ring
+}
+
+// ring holds the last lines of one task; written counts every line ever
+// appended, so lines[written%len(lines)] is the next one to overwrite.
+type ring struct {
+	lines   []Line
+	written int
+	// finished is set once the task will log no more lines while executing.
+	finished bool
+	// changed is closed and replaced whenever a line is appended or the
+	// log finishes, waking up the followers of the log.
+	changed chan struct{}
+}
+
+// notify wakes up the followers of the log.
+func (r *ring) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// NewStore creates an empty store keeping capacity lines per task.
+func NewStore(capacity int) *Store {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Store{
+		capacity: capacity,
+		logs:     make(map[uuid.UUID]*ring),
+	}
+}
+
+// Open starts keeping the lines of the task; opening it again, as another
+// execution starts, keeps the lines it has.
+func (s *Store) Open(taskID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if log, ok := s.logs[taskID]; ok {
+		log.finished = false
+		return
+	}
+	s.logs[taskID] = &ring{changed: make(chan struct{})}
+}
+
+// Append adds a line to the log of the task, numbering it.
+func (s *Store) Append(taskID uuid.UUID, line Line) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, ok := s.logs[taskID]
+	if !ok {
+		return
+	}
+	line.Seq = log.written
+	if len(log.lines) < s.capacity {
+		log.lines = append(log.lines, line)
+	} else {
+		log.lines[log.written%s.capacity] = line
+	}
+	log.written++
+	log.notify()
+}
+
+// Finish marks the log of the task as complete, ending Follow.
+func (s *Store) Finish(taskID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if log, ok := s.logs[taskID]; ok && !log.finished {
+		log.finished = true
+		log.notify()
+	}
+}
+
+// Follow returns a channel closed when the next line is appended to the log
+// of the task or it finishes or is deleted, and whether the log is finished
+// already; a log that is not kept counts as finished.
+func (s *Store) Follow(taskID uuid.UUID) (<-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, ok := s.logs[taskID]
+	if !ok {
+		return nil, true
+	}
+	return log.changed, log.finished
+}
+
+// Lines returns up to limit kept lines of the task starting with line
+// offset, oldest first, and the number of lines written in total. Dropped
+// lines are skipped.
+func (s *Store) Lines(taskID uuid.UUID, offset, limit int) ([]Line, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, ok := s.logs[taskID]
+	if !ok {
+		return nil, 0
+	}
+	first := log.written - len(log.lines)
+	offset = max(offset, first)
+	end := min(offset+limit, log.written)
+
+	lines := make([]Line, 0, max(end-offset, 0))
+	for seq := offset; seq < end; seq++ {
+		lines = append(lines, log.lines[seq%s.capacity])
+	}
+	return lines, log.written
+}
+
+// Delete drops the lines of the task and stops keeping new ones.
+func (s *Store) Delete(taskID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if log, ok := s.logs[taskID]; ok {
+		close(log.changed)
+		delete(s.logs, taskID)
+	}
+}
@@ -0,0 +1,16 @@
+package timing
+
+import "time"
+
+// Percentile returns the nearest-rank p-th percentile of sorted durations,
+// or zero when there are none.
+func Percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
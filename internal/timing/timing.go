@@ -0,0 +1,66 @@
+// Package timing measures where time goes: the breakdown of a request by
+// operation and percentiles of recorded durations.
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type breakdownKey struct{}
+
+// Operation is the time spent in one kind of operation.
+type Operation struct {
+	Count int
+	Total time.Duration
+}
+
+// Breakdown accumulates the time spent in named operations while serving
+// a request, e.g. repository calls.
+type Breakdown struct {
+	mu         sync.Mutex
+	operations map[string]Operation
+}
+
+// WithBreakdown returns a context collecting a new breakdown.
+func WithBreakdown(ctx context.Context) (context.Context, *Breakdown) {
+	breakdown := &Breakdown{operations: make(map[string]Operation)}
+	return context.WithValue(ctx, breakdownKey{}, breakdown), breakdown
+}
+
+// Track starts timing an operation; the returned function stops it. It is
+// a no-op when ctx carries no breakdown.
+func Track(ctx context.Context, name string) func() {
+	breakdown, ok := ctx.Value(breakdownKey{}).(*Breakdown)
+	if !ok {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		breakdown.add(name, time.Since(start))
+	}
+}
+
+func (b *Breakdown) add(name string, elapsed time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	operation := b.operations[name]
+	operation.Count++
+	operation.Total += elapsed
+	b.operations[name] = operation
+}
+
+// Operations returns a snapshot of the recorded operations by name.
+func (b *Breakdown) Operations() map[string]Operation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	operations := make(map[string]Operation, len(b.operations))
+	for name, operation := range b.operations {
+		operations[name] = operation
+	}
+	return operations
+}
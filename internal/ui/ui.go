@@ -0,0 +1,24 @@
+// Package ui serves a single-page web UI for operators: a task list that
+// refreshes itself, with actions to create, cancel and delete tasks. The
+// page talks to the public task API from the browser.
+package ui
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed index.html
+var page []byte
+
+// RegisterRoutes serves the page at /ui.
+func RegisterRoutes(router gin.IRoutes) {
+	router.GET("/ui", Page)
+}
+
+// Page serves the UI.
+func Page(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
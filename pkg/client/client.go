@@ -0,0 +1,736 @@
+// Package client is a Go client of the task API.
+//
+//	c := client.New("http://localhost:8080")
+//	task, err := c.Create(ctx, client.CreateRequest{Name: "report"})
+//	...
+//	task, err = c.WaitForCompletion(ctx, task.ID)
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultRetries is how many times a failed request is retried unless
+	// WithRetries says otherwise.
+	DefaultRetries = 3
+	// DefaultBackoff is the pause before the first retry, doubled for every
+	// following one.
+	DefaultBackoff = 200 * time.Millisecond
+	// DefaultPollInterval is how often WaitForCompletion checks the task.
+	DefaultPollInterval = time.Second
+
+	// pageSize is the page requested while listing federated tasks.
+	pageSize = 1000
+)
+
+type Status string
+
+const (
+	// StatusPending and StatusQueued are tasks waiting to be picked up by
+	// a worker, StatusProcessing the ones running.
+	StatusPending    Status = "PENDING"
+	StatusQueued     Status = "QUEUED"
+	StatusProcessing Status = "PROCESSING"
+	StatusDone       Status = "DONE"
+	StatusFailed     Status = "FAILED"
+	// StatusCancelled is a task cancelled through Cancel.
+	StatusCancelled Status = "CANCELLED"
+)
+
+// IsFinal reports whether a task in the status has finished.
+func (s Status) IsFinal() bool {
+	return s == StatusDone || s == StatusFailed || s == StatusCancelled
+}
+
+// IsWaiting reports whether a task in the status has not started yet.
+func (s Status) IsWaiting() bool {
+	return s == StatusPending || s == StatusQueued
+}
+
+// Priority orders the tasks waiting for a worker: higher priorities start
+// first.
+type Priority string
+
+const (
+	PriorityLow      Priority = "low"
+	PriorityNormal   Priority = "normal"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
+)
+
+type Task struct {
+	ID             uuid.UUID     `json:"id"`
+	Name           string        `json:"name"`
+	Type           string        `json:"type"`
+	Owner          string        `json:"owner,omitempty"`
+	CreatedBy      string        `json:"created_by,omitempty"`
+	ProjectID      *uuid.UUID    `json:"project_id,omitempty"`
+	RequestID      string        `json:"request_id,omitempty"`
+	Status         Status        `json:"status"`
+	CreatedAt      time.Time     `json:"created_at"`
+	ProcessingTime time.Duration `json:"processing_time"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	// MaxRuntimeSeconds is how long the task may run before it is cancelled,
+	// zero when the server default applies.
+	MaxRuntimeSeconds int64 `json:"max_runtime_seconds,omitempty"`
+	// DeleteAfterSeconds is how long the task is kept once it has finished.
+	DeleteAfterSeconds int64    `json:"delete_after_seconds,omitempty"`
+	DedupKey           string   `json:"dedup_key,omitempty"`
+	Priority           Priority `json:"priority,omitempty"`
+	SerialKey          string   `json:"serial_key,omitempty"`
+	// Pool names the worker pool the task runs on.
+	Pool string `json:"pool,omitempty"`
+	// Slow is set once the task has been processing for longer than the
+	// slow threshold of its type on the server.
+	Slow bool `json:"slow,omitempty"`
+	// Preemptions counts how many times the task gave its worker up to a
+	// critical task while running.
+	Preemptions int `json:"preemptions,omitempty"`
+	// FailureReason explains why a FAILED task failed, when that is known.
+	FailureReason string `json:"failure_reason,omitempty"`
+	// CancelReason is the reason given for cancelling a CANCELLED task.
+	CancelReason string `json:"cancel_reason,omitempty"`
+	// Inputs are the files uploaded together with the task.
+	Inputs []Attachment `json:"inputs,omitempty"`
+	// Artifacts are the files the task produced once it completed.
+	Artifacts []Attachment `json:"artifacts,omitempty"`
+	// Source names the instance holding the task in federated listings.
+	Source string `json:"source,omitempty"`
+}
+
+type CreateRequest struct {
+	Name      string     `json:"name"`
+	Type      string     `json:"type,omitempty"`
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	// ExpiresAt fails the task unless it has finished by then.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// MaxRuntimeSeconds replaces the default timeout of the server for the
+	// task, up to the maximum of the server.
+	MaxRuntimeSeconds int64 `json:"max_runtime_seconds,omitempty"`
+	// DeleteAfterSeconds deletes the task that long after it has finished.
+	DeleteAfterSeconds int64 `json:"delete_after_seconds,omitempty"`
+	// DedupKey detects repeated submissions instead of the name while the
+	// server has a dedup window; Create then returns the task created
+	// before.
+	DedupKey string `json:"dedup_key,omitempty"`
+	// Priority orders the task among the tasks waiting for a worker;
+	// empty is PriorityNormal.
+	Priority Priority `json:"priority,omitempty"`
+	// SerialKey runs the task one at a time with the other tasks having
+	// the key, in the order they were created.
+	SerialKey string `json:"serial_key,omitempty"`
+	// Pool runs the task on the workers of a pool configured on the server
+	// instead of the default pool; an unknown pool is rejected with a
+	// validation_error *Error.
+	Pool string `json:"pool,omitempty"`
+	// Unique rejects the task with a 409 task_name_conflict *Error while
+	// another active task of the caller has its name.
+	Unique bool `json:"-"`
+}
+
+// query returns the query parameters creating the task.
+func (r CreateRequest) query() url.Values {
+	if !r.Unique {
+		return nil
+	}
+	return url.Values{"unique": []string{"true"}}
+}
+
+// Attachment describes an input or artifact of a task.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	// URL downloads an artifact; inputs cannot be downloaded.
+	URL string `json:"url,omitempty"`
+}
+
+// Upload is a file uploaded together with a new task.
+type Upload struct {
+	Name        string
+	ContentType string
+	Content     io.Reader
+}
+
+// ListOptions filter List; the zero value lists the caller's tasks, or all
+// tasks when the server does not authenticate callers.
+type ListOptions struct {
+	// Owner lists the tasks of an owner, "me" those of the caller.
+	Owner string
+	// All lists the tasks of every owner; admins only.
+	All bool
+	// CreatedBy lists the tasks an identity created, "me" those the caller
+	// created.
+	CreatedBy string
+	ProjectID uuid.UUID
+	// Federated also lists the tasks of the server's peer instances.
+	Federated bool
+}
+
+// ErrNotFound is wrapped by the errors of requests for a missing task.
+var ErrNotFound = errors.New("not found")
+
+// FieldError describes an invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	// Code is the machine-readable error code, e.g. "task_not_found".
+	Code    string       `json:"error"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields"`
+	// ConflictingTaskID is the active task holding the name of a task
+	// created with Unique, for the task_name_conflict code.
+	ConflictingTaskID *uuid.UUID `json:"conflicting_task_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type Client struct {
+	baseURL      string
+	http         *http.Client
+	header       http.Header
+	retries      int
+	backoff      time.Duration
+	pollInterval time.Duration
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests with client, e.g. one with TLS client
+// certificates.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.http = client
+	}
+}
+
+// WithHeader adds a header to every request, e.g. the Authorization
+// header with the admin token.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithRetries retries a failed request up to retries times, pausing
+// backoff before the first retry and doubling it for every following one;
+// 0 disables retries.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithPollInterval sets how often WaitForCompletion checks the task.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// New creates a client of the server at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/") + "/api/v1",
+		http:         http.DefaultClient,
+		header:       http.Header{},
+		retries:      DefaultRetries,
+		backoff:      DefaultBackoff,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Create creates a task; it starts executing right away.
+func (c *Client) Create(ctx context.Context, req CreateRequest) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPost, "/task/create", req.query(), req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CreateFromTemplate creates a task with the definition of a task template.
+func (c *Client) CreateFromTemplate(ctx context.Context, templateID uuid.UUID) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPost, "/task/from-template/"+templateID.String(), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CreateWithAttachments creates a task with uploads as its inputs. The
+// uploads are read into memory first, so that the request can be retried.
+func (c *Client) CreateWithAttachments(ctx context.Context, req CreateRequest, uploads []Upload) (*Task, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := form.WriteField("task", string(fields)); err != nil {
+		return nil, err
+	}
+	for _, upload := range uploads {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "attachments", "filename": upload.Name}))
+		if upload.ContentType != "" {
+			header.Set("Content-Type", upload.ContentType)
+		}
+		part, err := form.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(part, upload.Content); err != nil {
+			return nil, fmt.Errorf("failed to read upload %s: %w", upload.Name, err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var task Task
+	if err := c.doPayload(ctx, http.MethodPost, "/task/create", req.query(), body.Bytes(), form.FormDataContentType(), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Artifact downloads the artifact of a completed task called name.
+func (c *Client) Artifact(ctx context.Context, id uuid.UUID, name string) ([]byte, error) {
+	var content []byte
+	if err := c.do(ctx, http.MethodGet, "/task/"+id.String()+"/artifacts/"+url.PathEscape(name), nil, nil, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodGet, "/task/"+id.String(), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Attempt is one execution of a task.
+type Attempt struct {
+	Number     int        `json:"number"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Outcome is RUNNING, SUCCEEDED, FAILED, CANCELLED or PREEMPTED.
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Attempts returns the execution attempts of a task, oldest first.
+func (c *Client) Attempts(ctx context.Context, id uuid.UUID) ([]Attempt, error) {
+	var list struct {
+		Attempts []Attempt `json:"attempts"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/task/"+id.String()+"/attempts", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Attempts, nil
+}
+
+// LogLine is one line logged about a task.
+type LogLine struct {
+	Seq     int               `json:"seq"`
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// Logs returns the kept lines logged about a task, oldest first, up to
+// limit of them starting with line offset, and the offset of the next
+// page; a zero limit uses the default page size of the server.
+func (c *Client) Logs(ctx context.Context, id uuid.UUID, offset, limit int) ([]LogLine, int, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var page struct {
+		Lines      []LogLine `json:"lines"`
+		NextOffset int       `json:"next_offset"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/task/"+id.String()+"/logs", query, nil, &page); err != nil {
+		return nil, 0, err
+	}
+	return page.Lines, page.NextOffset, nil
+}
+
+// FollowLogs calls handle with the lines logged about a task, starting
+// with line offset, as they are logged, until the task has finished. It
+// returns the final status of the task, or an empty one when the task was
+// deleted meanwhile. A retried stream skips the lines handled already; a
+// stream closed by a shutting down server is retried like a broken one.
+func (c *Client) FollowLogs(ctx context.Context, id uuid.UUID, offset int, handle func(LogLine) error) (Status, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	next := offset
+	var status Status
+	err := c.do(ctx, http.MethodGet, "/task/"+id.String()+"/logs/stream", query, nil, func(body io.Reader) error {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(nil, 1<<20)
+		var event, data string
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = value
+			case "":
+				switch event {
+				case "log":
+					var line LogLine
+					if err := json.Unmarshal([]byte(data), &line); err != nil {
+						return &streamError{fmt.Errorf("failed to decode log line: %w", err)}
+					}
+					if line.Seq < next {
+						break
+					}
+					next = line.Seq + 1
+					if err := handle(line); err != nil {
+						return &streamError{err}
+					}
+				case "end":
+					var end struct {
+						Status Status `json:"status"`
+					}
+					if err := json.Unmarshal([]byte(data), &end); err != nil {
+						return &streamError{fmt.Errorf("failed to decode end of log stream: %w", err)}
+					}
+					status = end.Status
+					return nil
+				case "shutdown":
+					// The server is restarting: reconnect and resume.
+					return io.ErrUnexpectedEOF
+				}
+				event, data = "", ""
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return io.ErrUnexpectedEOF
+	})
+	if streamErr, ok := err.(*streamError); ok {
+		err = streamErr.err
+	}
+	return status, err
+}
+
+// streamError fails a streamed response for a reason retrying the request
+// would not fix, such as an error of the callback handling it.
+type streamError struct {
+	err error
+}
+
+func (e *streamError) Error() string {
+	return e.err.Error()
+}
+
+// List returns every task matching opts. Federated listings are paginated
+// by the server; List requests the pages one after another.
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]Task, error) {
+	query := url.Values{}
+	if opts.Owner != "" {
+		query.Set("owner", opts.Owner)
+	}
+	if opts.All {
+		query.Set("all", "true")
+	}
+	if opts.CreatedBy != "" {
+		query.Set("created_by", opts.CreatedBy)
+	}
+	if opts.ProjectID != uuid.Nil {
+		query.Set("project_id", opts.ProjectID.String())
+	}
+	if !opts.Federated {
+		var list taskList
+		if err := c.do(ctx, http.MethodGet, "/tasks", query, nil, &list); err != nil {
+			return nil, err
+		}
+		return list.Tasks, nil
+	}
+
+	query.Set("federated", "true")
+	query.Set("limit", strconv.Itoa(pageSize))
+	var tasks []Task
+	for {
+		query.Set("offset", strconv.Itoa(len(tasks)))
+		var list taskList
+		if err := c.do(ctx, http.MethodGet, "/tasks", query, nil, &list); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, list.Tasks...)
+		if len(list.Tasks) == 0 || list.Total == nil || len(tasks) >= *list.Total {
+			return tasks, nil
+		}
+	}
+}
+
+type taskList struct {
+	Tasks []Task `json:"tasks"`
+	Total *int   `json:"total"`
+}
+
+// DispatchOrder is the order in which the tasks waiting for a worker of
+// the server get one.
+type DispatchOrder struct {
+	// Rules order the tasks, the first rule deciding first.
+	Rules []string        `json:"rules"`
+	Tasks []DispatchEntry `json:"tasks"`
+}
+
+// DispatchEntry is a task waiting for a worker.
+type DispatchEntry struct {
+	// Position is 1 for the task that gets the next free worker of its
+	// pool; callers other than admins only see their own tasks, so
+	// positions may skip.
+	Position int       `json:"position"`
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Pool     string    `json:"pool"`
+	Owner    string    `json:"owner,omitempty"`
+	Priority Priority  `json:"priority"`
+	// SerialKey is set for a task run one at a time with the other tasks
+	// having the key.
+	SerialKey string `json:"serial_key,omitempty"`
+	// Sequence is the order the task was queued in.
+	Sequence  uint64    `json:"sequence"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QueueOrder returns the waiting tasks in the order the workers of the
+// server will take them up.
+func (c *Client) QueueOrder(ctx context.Context) (*DispatchOrder, error) {
+	var order DispatchOrder
+	if err := c.do(ctx, http.MethodGet, "/tasks/queue-order", nil, nil, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// Delete deletes a task, cancelling it if it is still executing.
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/task/"+id.String(), nil, nil, nil)
+}
+
+// Cancel stops a task that has not finished and returns it with status
+// CANCELLED; unlike Delete, the task is kept. reason, if not empty, is
+// recorded as the task's CancelReason. A finished task fails with a 409
+// task_finished error.
+func (c *Client) Cancel(ctx context.Context, id uuid.UUID, reason string) (*Task, error) {
+	var body any
+	if reason != "" {
+		body = struct {
+			Reason string `json:"reason"`
+		}{reason}
+	}
+	var task Task
+	if err := c.do(ctx, http.MethodPost, "/task/"+id.String()+"/cancel", nil, body, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// SetPriority changes the priority of a task waiting for a worker, moving
+// it within the queue. A task that has started fails with a 409
+// task_not_waiting error.
+func (c *Client) SetPriority(ctx context.Context, id uuid.UUID, priority Priority) (*Task, error) {
+	body := struct {
+		Priority Priority `json:"priority"`
+	}{priority}
+	var task Task
+	if err := c.do(ctx, http.MethodPatch, "/task/"+id.String()+"/priority", nil, body, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// WaitForCompletion polls the task until it has finished and returns its
+// final state. The API has no push notifications of task changes to wait on.
+func (c *Client) WaitForCompletion(ctx context.Context, id uuid.UUID) (*Task, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		task, err := c.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if task.Status.IsFinal() {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// do sends a request, retrying it while it may succeed later. Only
+// rejections that guarantee the request had no effect (429, 503) are
+// retried for POST; GET and DELETE are also retried after network errors
+// and gateway failures. A DELETE retried after an answer got lost may find
+// the task already gone, which counts as success.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	return c.doPayload(ctx, method, path, query, payload, "application/json", out)
+}
+
+// doPayload is do for a body already encoded as contentType. A *[]byte out
+// receives the raw response body, a func(io.Reader) error out reads it as
+// it arrives.
+func (c *Client) doPayload(ctx context.Context, method, path string, query url.Values, payload []byte, contentType string, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		wait, err := c.send(ctx, method, target, payload, contentType, out)
+		if err == nil {
+			return nil
+		}
+		if attempt > 0 && method == http.MethodDelete && errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if attempt == c.retries || !retryable(method, err) {
+			return err
+		}
+
+		if wait < backoff {
+			wait = backoff
+		}
+		backoff *= 2
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// send makes one attempt; for a rejected request it returns the wait the
+// server asked for in Retry-After.
+func (c *Client) send(ctx context.Context, method, target string, payload []byte, contentType string, out any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Code == "" {
+			apiErr.Code = http.StatusText(resp.StatusCode)
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, apiErr
+	}
+	if out == nil {
+		return 0, nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		*raw, err = io.ReadAll(resp.Body)
+		return 0, err
+	}
+	if stream, ok := out.(func(io.Reader) error); ok {
+		return 0, stream(resp.Body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return 0, nil
+}
+
+func retryable(method string, err error) bool {
+	var streamErr *streamError
+	if errors.As(err, &streamErr) {
+		return false
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// A network error: the request may or may not have been processed.
+		return method != http.MethodPost && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method != http.MethodPost
+	default:
+		return false
+	}
+}
@@ -0,0 +1,184 @@
+// Package server embeds task management into another service. The host
+// mounts the routes on its own router and owns the process: listeners,
+// signals and shutdown stay with it.
+//
+//	srv, err := server.New(server.WithConfig(server.DefaultConfig()))
+//	...
+//	if err := srv.Start(ctx); err != nil { ... }
+//	defer srv.Shutdown(context.Background())
+//	srv.Mount(engine.Group("/api/v1"))
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nzb3/workmate_test/internal/app"
+	"github.com/nzb3/workmate_test/internal/config"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/outbox"
+)
+
+type (
+	// Config is the configuration of the embedded server; see the
+	// configuration section of the README for its settings.
+	Config = config.Config
+	// Repository stores tasks; implement it to keep them outside memory.
+	Repository = app.TaskRepository
+	Task       = taskmodel.Task
+	TaskStatus = taskmodel.TaskStatus
+	// EventPublisher receives task events from the outbox; see WithEventPublisher.
+	EventPublisher = outbox.Publisher
+	EventMessage   = outbox.Message
+)
+
+// A Repository wraps these errors when a task is missing or already stored,
+// so that they are told apart from storage failures.
+var (
+	ErrTaskNotFound      = taskmodel.ErrTaskNotFound
+	ErrTaskAlreadyExists = taskmodel.ErrTaskAlreadyExists
+)
+
+// DefaultConfig returns the configuration with every setting at its default.
+func DefaultConfig() *Config {
+	return config.Defaults()
+}
+
+// LoadConfig reads the configuration from the environment and CONFIG_FILE,
+// as the standalone binary does.
+func LoadConfig() (*Config, error) {
+	return config.Load()
+}
+
+type options struct {
+	config     *Config
+	repository Repository
+	publisher  EventPublisher
+}
+
+type Option func(*options)
+
+// WithConfig uses cfg instead of loading the configuration from the
+// environment.
+func WithConfig(cfg *Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// WithRepository stores tasks in repository instead of memory.
+func WithRepository(repository Repository) Option {
+	return func(o *options) {
+		o.repository = repository
+	}
+}
+
+// WithEventPublisher publishes every task event to publisher, at least
+// once and in order; consumers drop redeliveries by EventMessage.ID. The
+// repository must keep an outbox, as the in-memory one does.
+func WithEventPublisher(publisher EventPublisher) Option {
+	return func(o *options) {
+		o.publisher = publisher
+	}
+}
+
+// Server is the task management API without the process around it.
+type Server struct {
+	container *app.DIContainer
+	cancel    context.CancelFunc
+}
+
+func New(opts ...Option) (*Server, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.config == nil {
+		cfg, err := LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		o.config = cfg
+	} else if err := o.config.Validate(); err != nil {
+		return nil, err
+	}
+
+	containerOpts := []app.Option{app.WithConfig(o.config)}
+	if o.repository != nil {
+		containerOpts = append(containerOpts, app.WithTaskRepository(o.repository))
+	}
+	if o.publisher != nil {
+		containerOpts = append(containerOpts, app.WithEventPublisher(o.publisher))
+	}
+
+	container := app.NewDIContainer(containerOpts...)
+	// The outbox records events from the first stored task on.
+	container.OutboxRelay(context.Background())
+
+	return &Server{container: container}, nil
+}
+
+// Mount registers the task, project and template routes on router, which
+// keeps its own middleware.
+func (s *Server) Mount(router gin.IRouter) {
+	s.container.RegisterTaskRoutes(context.Background(), router.Group(""))
+}
+
+// Handler serves the complete API under /api/v1 together with health,
+// metrics and the middleware of the standalone server, for mounting on an
+// http.ServeMux:
+//
+//	mux.Handle("/api/", srv.Handler())
+func (s *Server) Handler() http.Handler {
+	return s.container.GinEngine(context.Background())
+}
+
+// MetricsHandler serves the task metrics in the Prometheus format.
+func (s *Server) MetricsHandler() http.Handler {
+	return s.container.Metrics(context.Background()).Handler()
+}
+
+// Start recovers the tasks a previous run left unfinished and starts the
+// retention cleanup and, with a queue backend, the consumption of queued
+// tasks; both run until Shutdown.
+func (s *Server) Start(ctx context.Context) error {
+	if _, err := s.container.TaskService(ctx).Recover(ctx); err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.container.RetentionService(runCtx).Run(runCtx)
+	if relay := s.container.OutboxRelay(ctx); relay != nil {
+		go relay.Run(runCtx)
+	}
+	go func() {
+		if err := s.container.TaskService(runCtx).Consume(runCtx); err != nil {
+			s.container.Logger(runCtx).Error("Failed to consume the task queue", "error", err)
+		}
+	}()
+	go func() {
+		if err := s.container.TaskService(runCtx).WatchCancellations(runCtx); err != nil {
+			s.container.Logger(runCtx).Error("Failed to watch task cancellations", "error", err)
+		}
+	}()
+
+	s.container.HealthChecker(ctx).MarkReady()
+	return nil
+}
+
+// Shutdown stops accepting tasks and cancels the running ones, waiting
+// for them until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.container.HealthChecker(ctx).MarkDraining()
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	tasks := s.container.TaskService(ctx)
+	tasks.Drain()
+	return tasks.Shutdown(ctx)
+}
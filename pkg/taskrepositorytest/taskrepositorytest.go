@@ -0,0 +1,461 @@
+// Package taskrepositorytest verifies that a task repository behaves the way
+// the service relies on, so that other storage backends can be checked
+// against the same rules as the in-memory one:
+//
+//	func TestRepository(t *testing.T) {
+//		taskrepositorytest.Run(t, func(t *testing.T) server.Repository {
+//			return mystore.New(t.TempDir())
+//		})
+//	}
+package taskrepositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/pkg/server"
+)
+
+// Concurrency is the number of goroutines of the concurrency checks.
+const Concurrency = 50
+
+// Factory returns an empty repository; it is called once per check.
+type Factory func(t *testing.T) server.Repository
+
+// Run runs every check against fresh repositories from newRepository, each
+// as a subtest.
+func Run(t *testing.T, newRepository Factory) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, repo server.Repository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"CreateExisting", testCreateExisting},
+		{"GetMissing", testGetMissing},
+		{"Update", testUpdate},
+		{"UpdateInvalidTransition", testUpdateInvalidTransition},
+		{"UpdateMissing", testUpdateMissing},
+		{"Delete", testDelete},
+		{"GetAll", testGetAll},
+		{"CountByStatus", testCountByStatus},
+		{"Events", testEvents},
+		{"Rebuild", testRebuild},
+		{"CopyOnWrite", testCopyOnWrite},
+		{"CopyOnRead", testCopyOnRead},
+		{"CancelledContext", testCancelledContext},
+		{"ConcurrentCreate", testConcurrentCreate},
+		{"ConcurrentUpdate", testConcurrentUpdate},
+		{"ConcurrentDelete", testConcurrentDelete},
+		{"Ping", testPing},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			c.check(t, newRepository(t))
+		})
+	}
+}
+
+// newTask returns a task as the service creates it: already processing.
+func newTask(opts ...taskmodel.Option) *taskmodel.Task {
+	task := taskmodel.NewTask(append([]taskmodel.Option{taskmodel.WithName("Conformance task")}, opts...)...)
+	task.Status = taskmodel.StatusProcessing
+	return task
+}
+
+func create(t *testing.T, repo server.Repository, opts ...taskmodel.Option) *taskmodel.Task {
+	t.Helper()
+	task := newTask(opts...)
+	require.NoError(t, repo.Create(context.Background(), task))
+	return task
+}
+
+func testCreateAndGet(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	task := newTask(
+		taskmodel.WithType("report"),
+		taskmodel.WithOwner("alice"),
+		taskmodel.WithProject(projectID),
+		taskmodel.WithRequestID("request-1"),
+		taskmodel.WithTraceContext(map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}),
+	)
+	before := time.Now()
+	require.NoError(t, repo.Create(ctx, task))
+	assert.False(t, task.CreatedAt.Before(before), "Create must set CreatedAt")
+
+	got, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, task.ID, got.ID)
+	assert.Equal(t, task.Name, got.Name)
+	assert.Equal(t, "report", got.Type)
+	assert.Equal(t, "alice", got.Owner)
+	assert.Equal(t, projectID, got.ProjectID)
+	assert.Equal(t, "request-1", got.RequestID)
+	assert.Equal(t, taskmodel.StatusProcessing, got.Status)
+	assert.Equal(t, task.TraceContext, got.TraceContext)
+	assert.WithinDuration(t, task.CreatedAt, got.CreatedAt, time.Millisecond)
+	assert.False(t, repo.LastWrite().IsZero(), "LastWrite must be set by Create")
+}
+
+func testCreateExisting(t *testing.T, repo server.Repository) {
+	task := create(t, repo)
+	err := repo.Create(context.Background(), task)
+	assert.ErrorIs(t, err, server.ErrTaskAlreadyExists)
+}
+
+func testGetMissing(t *testing.T, repo server.Repository) {
+	_, err := repo.GetByID(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, server.ErrTaskNotFound)
+}
+
+func testUpdate(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo)
+	written := repo.LastWrite()
+
+	// Staying in a non-final status records progress.
+	task.StartedAt = time.Now()
+	task.ProcessingTime = time.Second
+	require.NoError(t, repo.Update(ctx, task))
+
+	task.Status = taskmodel.StatusDone
+	task.FinishedAt = time.Now()
+	task.ProcessingTime = 2 * time.Second
+	require.NoError(t, repo.Update(ctx, task))
+
+	got, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, taskmodel.StatusDone, got.Status)
+	assert.Equal(t, 2*time.Second, got.ProcessingTime)
+	assert.WithinDuration(t, task.StartedAt, got.StartedAt, time.Millisecond)
+	assert.WithinDuration(t, task.FinishedAt, got.FinishedAt, time.Millisecond)
+	assert.False(t, repo.LastWrite().Before(written), "LastWrite must not go back")
+}
+
+func testUpdateInvalidTransition(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo)
+	task.Status = taskmodel.StatusFailed
+	require.NoError(t, repo.Update(ctx, task))
+
+	// A finished task is final.
+	for _, status := range []taskmodel.TaskStatus{taskmodel.StatusProcessing, taskmodel.StatusDone, taskmodel.StatusFailed} {
+		task.Status = status
+		assert.ErrorIs(t, repo.Update(ctx, task), taskmodel.ErrInvalidTransition, "FAILED -> %s", status)
+	}
+
+	got, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, taskmodel.StatusFailed, got.Status, "a rejected update must not be stored")
+}
+
+func testUpdateMissing(t *testing.T, repo server.Repository) {
+	err := repo.Update(context.Background(), newTask())
+	assert.ErrorIs(t, err, server.ErrTaskNotFound)
+}
+
+func testDelete(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo)
+	require.NoError(t, repo.Delete(ctx, task.ID))
+
+	_, err := repo.GetByID(ctx, task.ID)
+	assert.ErrorIs(t, err, server.ErrTaskNotFound)
+	_, err = repo.Events(ctx, task.ID)
+	assert.ErrorIs(t, err, server.ErrTaskNotFound, "the history is erased with the task")
+	assert.ErrorIs(t, repo.Delete(ctx, task.ID), server.ErrTaskNotFound)
+	assert.ErrorIs(t, repo.Update(ctx, task), server.ErrTaskNotFound)
+
+	// The ID of a deleted task may be reused.
+	require.NoError(t, repo.Create(ctx, task))
+}
+
+func testGetAll(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	tasks, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	want := make(map[uuid.UUID]bool)
+	for range 5 {
+		want[create(t, repo).ID] = true
+	}
+	deleted := create(t, repo)
+	require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+	tasks, err = repo.GetAll(ctx)
+	require.NoError(t, err)
+	got := make(map[uuid.UUID]bool)
+	for _, task := range tasks {
+		got[task.ID] = true
+	}
+	assert.Equal(t, want, got)
+}
+
+func testCountByStatus(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	for range 3 {
+		create(t, repo)
+	}
+	done := create(t, repo)
+	done.Status = taskmodel.StatusDone
+	require.NoError(t, repo.Update(ctx, done))
+	deleted := create(t, repo)
+	require.NoError(t, repo.Delete(ctx, deleted.ID))
+
+	counts, err := repo.CountByStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts[taskmodel.StatusProcessing])
+	assert.Equal(t, 1, counts[taskmodel.StatusDone])
+	assert.Zero(t, counts[taskmodel.StatusFailed])
+}
+
+func testEvents(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo)
+	task.StartedAt = time.Now()
+	require.NoError(t, repo.Update(ctx, task))
+	require.NoError(t, repo.Update(ctx, task))
+	task.Status = taskmodel.StatusDone
+	task.FinishedAt = time.Now()
+	require.NoError(t, repo.Update(ctx, task))
+
+	events, err := repo.Events(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	types := []taskmodel.EventType{taskmodel.EventCreated, taskmodel.EventStarted, taskmodel.EventProgressUpdated, taskmodel.EventCompleted}
+	for i, event := range events {
+		assert.Equal(t, task.ID, event.TaskID)
+		assert.Equal(t, i+1, event.Version)
+		assert.Equal(t, types[i], event.Type)
+	}
+
+	// Replaying the history reproduces the stored task.
+	replayed := &taskmodel.Task{}
+	for _, event := range events {
+		require.NoError(t, replayed.Apply(event))
+	}
+	stored, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Status, replayed.Status)
+	assert.Equal(t, stored.ProcessingTime, replayed.ProcessingTime)
+}
+
+func testRebuild(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	for range 3 {
+		task := create(t, repo)
+		task.Status = taskmodel.StatusDone
+		require.NoError(t, repo.Update(ctx, task))
+	}
+
+	repaired, err := repo.Rebuild(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, repaired, "a consistent repository has nothing to repair")
+}
+
+// testCopyOnWrite checks that the caller keeps ownership of the tasks it
+// stores.
+func testCopyOnWrite(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := newTask(taskmodel.WithTraceContext(map[string]string{"traceparent": "original"}))
+	require.NoError(t, repo.Create(ctx, task))
+	task.Name = "Changed after create"
+	task.Status = taskmodel.StatusFailed
+	task.TraceContext["traceparent"] = "changed"
+
+	got, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Conformance task", got.Name)
+	assert.Equal(t, taskmodel.StatusProcessing, got.Status)
+	assert.Equal(t, "original", got.TraceContext["traceparent"])
+
+	got.ProcessingTime = time.Minute
+	require.NoError(t, repo.Update(ctx, got))
+	got.ProcessingTime = time.Hour
+
+	stored, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, stored.ProcessingTime)
+}
+
+// testCopyOnRead checks that the tasks returned are the caller's to change.
+func testCopyOnRead(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo, taskmodel.WithTraceContext(map[string]string{"traceparent": "original"}))
+
+	got, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	got.Name = "Changed after get"
+	got.Status = taskmodel.StatusFailed
+	got.TraceContext["traceparent"] = "changed"
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	all[0].Name = "Changed after get all"
+
+	events, err := repo.Events(ctx, task.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	if events[0].Task != nil {
+		events[0].Task.Name = "Changed after events"
+	}
+
+	stored, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Conformance task", stored.Name)
+	assert.Equal(t, taskmodel.StatusProcessing, stored.Status)
+	assert.Equal(t, "original", stored.TraceContext["traceparent"])
+	events, err = repo.Events(ctx, task.ID)
+	require.NoError(t, err)
+	require.NotNil(t, events[0].Task)
+	assert.Equal(t, "Conformance task", events[0].Task.Name)
+}
+
+func testCancelledContext(t *testing.T, repo server.Repository) {
+	task := create(t, repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, repo.Create(ctx, newTask()), context.Canceled, "Create")
+	_, err := repo.GetByID(ctx, task.ID)
+	assert.ErrorIs(t, err, context.Canceled, "GetByID")
+	assert.ErrorIs(t, repo.Update(ctx, task), context.Canceled, "Update")
+	_, err = repo.GetAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled, "GetAll")
+	assert.ErrorIs(t, repo.Delete(ctx, task.ID), context.Canceled, "Delete")
+
+	_, err = repo.GetByID(context.Background(), task.ID)
+	assert.NoError(t, err, "a cancelled delete must not remove the task")
+}
+
+func testConcurrentCreate(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	// Every goroutine races to create the same task once and its own task.
+	shared := newTask()
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for i := range Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.Create(ctx, newTask(taskmodel.WithName(fmt.Sprintf("Task %d", i)))))
+			if repo.Create(ctx, shared.Clone()) == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created, "exactly one create of the same ID must succeed")
+	tasks, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tasks, Concurrency+1)
+}
+
+func testConcurrentUpdate(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo)
+
+	// Progress updates race with each other, with readers and with the
+	// updates finishing the task; exactly one final status wins.
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		finished []taskmodel.TaskStatus
+	)
+	for i := range Concurrency {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			update := task.Clone()
+			update.ProcessingTime = time.Duration(i) * time.Millisecond
+			err := repo.Update(ctx, update)
+			if err != nil {
+				assert.ErrorIs(t, err, taskmodel.ErrInvalidTransition)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			update := task.Clone()
+			update.Status = taskmodel.StatusDone
+			if i%2 == 1 {
+				update.Status = taskmodel.StatusFailed
+			}
+			if err := repo.Update(ctx, update); err == nil {
+				mu.Lock()
+				finished = append(finished, update.Status)
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, taskmodel.ErrInvalidTransition)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			got, err := repo.GetByID(ctx, task.ID)
+			if assert.NoError(t, err) {
+				got.ProcessingTime = -1
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, finished, 1, "exactly one update may finish the task")
+	stored, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, finished[0], stored.Status)
+	assert.GreaterOrEqual(t, stored.ProcessingTime, time.Duration(0))
+}
+
+func testConcurrentDelete(t *testing.T, repo server.Repository) {
+	ctx := context.Background()
+	task := create(t, repo)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		deleted int
+	)
+	for range Concurrency {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := repo.Delete(ctx, task.ID); err == nil {
+				mu.Lock()
+				deleted++
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, server.ErrTaskNotFound)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := repo.Update(ctx, task.Clone()); err != nil {
+				assert.ErrorIs(t, err, server.ErrTaskNotFound)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, deleted, "exactly one delete of the same ID must succeed")
+	_, err := repo.GetByID(ctx, task.ID)
+	assert.ErrorIs(t, err, server.ErrTaskNotFound, "an update racing a delete must not bring the task back")
+}
+
+func testPing(t *testing.T, repo server.Repository) {
+	assert.NoError(t, repo.Ping(context.Background()))
+}
@@ -0,0 +1,516 @@
+// Package taskservicetest provides an in-memory stand-in for the task
+// service, so that services built on the task API can be tested without
+// running the whole application. Tasks never execute on their own: they
+// stay QUEUED until the test moves them on.
+//
+//	fake := taskservicetest.New()
+//	host := httptest.NewServer(fake.Handler())
+//	defer host.Close()
+//	// ... let the code under test create a task through host.URL ...
+//	fake.Complete(id, time.Second)
+package taskservicetest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/nzb3/workmate_test/internal/controllers/taskcontroller"
+	"github.com/nzb3/workmate_test/internal/estimate"
+	"github.com/nzb3/workmate_test/internal/middleware"
+	"github.com/nzb3/workmate_test/internal/models/projectmodel"
+	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/service/taskservice"
+	"github.com/nzb3/workmate_test/internal/tasklog"
+	"github.com/nzb3/workmate_test/pkg/server"
+)
+
+// Errors the real service returns; pass them to SetError to make the API
+// answer as it would, e.g. ErrDraining turns task creation into a 503.
+var (
+	ErrTaskNotFound = server.ErrTaskNotFound
+	ErrDraining     = taskservice.ErrDraining
+)
+
+var _ taskcontroller.TaskService = (*Fake)(nil)
+
+// Fake implements the task service the HTTP API is served by. It is safe
+// for concurrent use.
+type Fake struct {
+	mu     sync.Mutex
+	tasks  map[uuid.UUID]*taskmodel.Task
+	events map[uuid.UUID][]taskmodel.Event
+	now    func() time.Time
+	err    error
+}
+
+// New returns a fake without tasks.
+func New() *Fake {
+	return &Fake{
+		tasks:  make(map[uuid.UUID]*taskmodel.Task),
+		events: make(map[uuid.UUID][]taskmodel.Event),
+		now:    time.Now,
+	}
+}
+
+// SetNow replaces the clock stamping created and finished tasks.
+func (f *Fake) SetNow(now func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// SetError makes every call of the service fail with err until it is reset
+// with nil.
+func (f *Fake) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Handler serves the task routes of the API under /api/v1 on top of the
+// fake. Every project ID is accepted as existing.
+func (f *Fake) Handler() http.Handler {
+	engine := gin.New()
+	engine.Use(middleware.Language(), middleware.Timezone())
+	controller := taskcontroller.NewController(f, projects{})
+	controller.RegisterRoutes(engine.Group("/api/v1"))
+	return engine
+}
+
+// Add stores task as it is, in any status, and returns its copy. A missing
+// ID, creation time or status is filled in as CreateTask would.
+func (f *Fake) Add(task *server.Task) *server.Task {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task = task.Clone()
+	if task.ID == uuid.Nil {
+		task.ID = uuid.New()
+	}
+	if task.Type == "" {
+		task.Type = taskmodel.DefaultType
+	}
+	if task.Status == "" {
+		task.Status = taskmodel.StatusQueued
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = f.now()
+	}
+	f.store(task)
+	return task.Clone()
+}
+
+// Tasks returns copies of every stored task, oldest first.
+func (f *Fake) Tasks() []*server.Task {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.list(taskmodel.Filter{})
+}
+
+// Start records that the task was picked up by a worker, moving it to
+// PROCESSING.
+func (f *Fake) Start(id uuid.UUID) error {
+	return f.update(id, func(task *taskmodel.Task) error {
+		if err := task.Transition(taskmodel.StatusProcessing); err != nil {
+			return err
+		}
+		task.StartedAt = f.now()
+		return nil
+	})
+}
+
+// Complete finishes the task with status DONE after processingTime.
+func (f *Fake) Complete(id uuid.UUID, processingTime time.Duration) error {
+	return f.finish(id, taskmodel.StatusDone, processingTime)
+}
+
+// Fail finishes the task with status FAILED after processingTime.
+func (f *Fake) Fail(id uuid.UUID, processingTime time.Duration) error {
+	return f.finish(id, taskmodel.StatusFailed, processingTime)
+}
+
+// finish moves the task to status, starting it first if it is waiting.
+func (f *Fake) finish(id uuid.UUID, status taskmodel.TaskStatus, processingTime time.Duration) error {
+	return f.update(id, func(task *taskmodel.Task) error {
+		if task.IsWaiting() {
+			task.Status = taskmodel.StatusProcessing
+		}
+		if err := task.Transition(status); err != nil {
+			return err
+		}
+		task.FinishedAt = f.now()
+		task.ProcessingTime = processingTime
+		return nil
+	})
+}
+
+// SetStatus forces the task into status, bypassing the state machine, e.g.
+// to move a finished task back to PROCESSING.
+func (f *Fake) SetStatus(id uuid.UUID, status server.TaskStatus) error {
+	return f.update(id, func(task *taskmodel.Task) error {
+		task.Status = status
+		if status.IsFinal() && task.FinishedAt.IsZero() {
+			task.FinishedAt = f.now()
+		}
+		if !status.IsFinal() {
+			task.FinishedAt = time.Time{}
+		}
+		return nil
+	})
+}
+
+// update changes the task and records the event of the change.
+func (f *Fake) update(id uuid.UUID, change func(*taskmodel.Task) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, ok := f.tasks[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, id)
+	}
+	task := stored.Clone()
+	if err := change(task); err != nil {
+		return err
+	}
+	f.tasks[id] = task
+	f.record(taskmodel.ChangeEvent(stored, task, f.now()))
+	return nil
+}
+
+// store saves a new task; the caller holds the lock.
+func (f *Fake) store(task *taskmodel.Task) {
+	f.tasks[task.ID] = task
+	f.events[task.ID] = nil
+	f.record(taskmodel.Event{
+		TaskID: task.ID,
+		Type:   taskmodel.EventCreated,
+		At:     task.CreatedAt,
+		Task:   task.Clone(),
+	})
+}
+
+func (f *Fake) record(event taskmodel.Event) {
+	event.Version = len(f.events[event.TaskID]) + 1
+	f.events[event.TaskID] = append(f.events[event.TaskID], event)
+}
+
+// list returns copies of the matching tasks, oldest first; the caller
+// holds the lock.
+func (f *Fake) list(filter taskmodel.Filter) []*taskmodel.Task {
+	tasks := make([]*taskmodel.Task, 0, len(f.tasks))
+	for _, task := range f.tasks {
+		if filter.Match(task) {
+			tasks = append(tasks, task.Clone())
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID.String() < tasks[j].ID.String()
+	})
+	return tasks
+}
+
+// CreateTask stores a QUEUED task.
+func (f *Fake) CreateTask(ctx context.Context, name string, opts ...taskmodel.Option) (*taskmodel.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	task := taskmodel.NewTask(append([]taskmodel.Option{taskmodel.WithName(name)}, opts...)...)
+	if err := task.Transition(taskmodel.StatusQueued); err != nil {
+		return nil, err
+	}
+	task.CreatedAt = f.now()
+	f.store(task)
+	return task.Clone(), nil
+}
+
+// CreateTaskWithInputs stores a QUEUED task with the uploads as its
+// inputs; their content is read and discarded.
+func (f *Fake) CreateTaskWithInputs(ctx context.Context, name string, uploads []taskservice.Upload, opts ...taskmodel.Option) (*taskmodel.Task, error) {
+	inputs := make([]taskmodel.Attachment, len(uploads))
+	for i, upload := range uploads {
+		if _, err := io.Copy(io.Discard, upload.Content); err != nil {
+			return nil, err
+		}
+		inputs[i] = taskmodel.Attachment{Name: upload.Name, ContentType: upload.ContentType, Size: upload.Size}
+	}
+	return f.CreateTask(ctx, name, append(opts, taskmodel.WithInputs(inputs))...)
+}
+
+// OpenArtifact finds no artifacts, as fake tasks produce none.
+func (f *Fake) OpenArtifact(ctx context.Context, taskID uuid.UUID, name string) (taskmodel.Attachment, io.ReadCloser, error) {
+	if _, err := f.GetTask(ctx, taskID); err != nil {
+		return taskmodel.Attachment{}, nil, err
+	}
+	return taskmodel.Attachment{}, nil, taskservice.ErrArtifactNotFound
+}
+
+// TaskLogs returns no lines, as fake tasks log nothing.
+func (f *Fake) TaskLogs(ctx context.Context, taskID uuid.UUID, offset, limit int) ([]tasklog.Line, int, error) {
+	if _, err := f.GetTask(ctx, taskID); err != nil {
+		return nil, 0, err
+	}
+	return nil, 0, nil
+}
+
+// FollowTaskLogs sends no lines and returns once the task has finished,
+// checking every few milliseconds.
+func (f *Fake) FollowTaskLogs(ctx context.Context, taskID uuid.UUID, offset int, send func([]tasklog.Line) error) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		task, err := f.GetTask(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		if !task.IsActive() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Fake) GetTask(ctx context.Context, taskID uuid.UUID) (*taskmodel.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	task, ok := f.tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, taskID)
+	}
+	return task.Clone(), nil
+}
+
+func (f *Fake) DeleteTask(ctx context.Context, taskID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+
+	if _, ok := f.tasks[taskID]; !ok {
+		return fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, taskID)
+	}
+	delete(f.tasks, taskID)
+	delete(f.events, taskID)
+	return nil
+}
+
+// CancelTask moves a task that has not finished to CANCELLED, recording
+// reason.
+func (f *Fake) CancelTask(ctx context.Context, taskID uuid.UUID, reason string) (*taskmodel.Task, error) {
+	f.mu.Lock()
+	err := f.err
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.update(taskID, func(task *taskmodel.Task) error {
+		if err := task.Transition(taskmodel.StatusCancelled); err != nil {
+			return taskservice.ErrTaskFinished
+		}
+		task.FinishedAt = f.now()
+		task.CancelReason = reason
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f.GetTask(ctx, taskID)
+}
+
+// SetTaskPriority changes the priority of a task that has not started.
+func (f *Fake) SetTaskPriority(ctx context.Context, taskID uuid.UUID, priority taskmodel.Priority) (*taskmodel.Task, error) {
+	f.mu.Lock()
+	err := f.err
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.update(taskID, func(task *taskmodel.Task) error {
+		if !task.IsWaiting() {
+			return taskservice.ErrTaskNotWaiting
+		}
+		task.Priority = priority
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f.GetTask(ctx, taskID)
+}
+
+func (f *Fake) TaskEvents(ctx context.Context, taskID uuid.UUID) ([]taskmodel.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	events, ok := f.events[taskID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", taskmodel.ErrTaskNotFound, taskID)
+	}
+	events = slices.Clone(events)
+	for i, event := range events {
+		if event.Task != nil {
+			events[i].Task = event.Task.Clone()
+		}
+	}
+	return events, nil
+}
+
+func (f *Fake) ListTasks(ctx context.Context, filter taskmodel.Filter) ([]*taskmodel.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.list(filter), nil
+}
+
+// LatencyStats computes the percentiles of the DONE tasks finished within
+// window, as the real service does.
+func (f *Fake) LatencyStats(ctx context.Context, window time.Duration, taskType string) (*taskservice.LatencyStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	since := f.now().Add(-window)
+	var durations []time.Duration
+	for _, task := range f.tasks {
+		if !task.IsDone() || task.FinishedAt.Before(since) || (taskType != "" && task.Type != taskType) {
+			continue
+		}
+		durations = append(durations, task.ProcessingTime)
+	}
+	slices.Sort(durations)
+
+	stats := &taskservice.LatencyStats{Window: window, Count: len(durations)}
+	if len(durations) > 0 {
+		stats.P50 = durations[(len(durations)-1)*50/100]
+		stats.P90 = durations[(len(durations)-1)*90/100]
+		stats.P99 = durations[(len(durations)-1)*99/100]
+	}
+	return stats, nil
+}
+
+// DispatchOrder returns the waiting tasks as the real service would
+// dispatch them: the highest priority first, then oldest first. Sequence
+// numbers the waiting tasks by creation.
+func (f *Fake) DispatchOrder(ctx context.Context) ([]taskservice.DispatchEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var order []taskservice.DispatchEntry
+	for _, task := range f.list(taskmodel.Filter{Statuses: []taskmodel.TaskStatus{taskmodel.StatusPending, taskmodel.StatusQueued}}) {
+		order = append(order, taskservice.DispatchEntry{Task: task, Sequence: uint64(len(order) + 1)})
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Task.Priority > order[j].Task.Priority })
+	for i := range order {
+		order[i].Position = i + 1
+	}
+	return order, nil
+}
+
+// DispatchRules returns the rules of the real service.
+func (f *Fake) DispatchRules() []string {
+	return []string{taskservice.DispatchRuleSerialKey, taskservice.DispatchRulePriority, taskservice.DispatchRuleQueueOrder}
+}
+
+// DurationEstimates summarizes the processing times of the DONE tasks per
+// type, as the real service does for the last estimate.DefaultWindow of
+// them.
+func (f *Fake) DurationEstimates() map[string]estimate.Estimate {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	done := make([]*taskmodel.Task, 0, len(f.tasks))
+	for _, task := range f.tasks {
+		if task.IsDone() {
+			done = append(done, task)
+		}
+	}
+	slices.SortFunc(done, func(a, b *taskmodel.Task) int { return a.FinishedAt.Compare(b.FinishedAt) })
+
+	model := estimate.NewModel(estimate.DefaultWindow, "", slog.Default())
+	for _, task := range done {
+		model.Observe(task.Type, task.ProcessingTime)
+	}
+	return model.Estimates()
+}
+
+// Throughput counts the created, completed and failed tasks per bucket, as
+// the real service does.
+func (f *Fake) Throughput(ctx context.Context, period, bucket time.Duration) ([]taskservice.ThroughputBucket, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	count := int((period + bucket - 1) / bucket)
+	end := f.now().Truncate(bucket).Add(bucket)
+	start := end.Add(-time.Duration(count) * bucket)
+	buckets := make([]taskservice.ThroughputBucket, count)
+	for i := range buckets {
+		buckets[i].Start = start.Add(time.Duration(i) * bucket)
+	}
+	index := func(t time.Time) (int, bool) {
+		if t.IsZero() || t.Before(start) || !t.Before(end) {
+			return 0, false
+		}
+		return int(t.Sub(start) / bucket), true
+	}
+
+	for _, task := range f.tasks {
+		if i, ok := index(task.CreatedAt); ok {
+			buckets[i].Created++
+		}
+		if i, ok := index(task.FinishedAt); ok {
+			switch task.Status {
+			case taskmodel.StatusDone:
+				buckets[i].Completed++
+			case taskmodel.StatusFailed:
+				buckets[i].Failed++
+			}
+		}
+	}
+	return buckets, nil
+}
+
+// projects accepts every project.
+type projects struct{}
+
+func (projects) GetProject(_ context.Context, projectID uuid.UUID) (*projectmodel.Project, error) {
+	return &projectmodel.Project{ID: projectID}, nil
+}
@@ -2,37 +2,91 @@ package e2e
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/nzb3/workmate_test/internal/app"
+	"github.com/nzb3/workmate_test/internal/auth"
+	"github.com/nzb3/workmate_test/internal/bench"
+	"github.com/nzb3/workmate_test/internal/buildinfo"
+	"github.com/nzb3/workmate_test/internal/certs"
+	"github.com/nzb3/workmate_test/internal/clock"
+	"github.com/nzb3/workmate_test/internal/config"
+	"github.com/nzb3/workmate_test/internal/controllers/loadgencontroller"
+	"github.com/nzb3/workmate_test/internal/controllers/taskcontroller"
+	"github.com/nzb3/workmate_test/internal/loadgen"
+	"github.com/nzb3/workmate_test/internal/logger"
+	"github.com/nzb3/workmate_test/internal/middleware"
 	"github.com/nzb3/workmate_test/internal/models/taskmodel"
+	"github.com/nzb3/workmate_test/internal/outbox"
+	"github.com/nzb3/workmate_test/internal/outbox/webhookpublisher"
+	"github.com/nzb3/workmate_test/internal/repository/taskrepository"
+	"github.com/nzb3/workmate_test/internal/requestid"
+	"github.com/nzb3/workmate_test/internal/service/taskservice"
+	"github.com/nzb3/workmate_test/internal/taskctl"
+	"github.com/nzb3/workmate_test/internal/tracing"
+	"github.com/nzb3/workmate_test/pkg/client"
+	"github.com/nzb3/workmate_test/pkg/server"
+	"github.com/nzb3/workmate_test/pkg/taskrepositorytest"
+	"github.com/nzb3/workmate_test/pkg/taskservicetest"
 )
 
+const testAdminToken = "e2e-admin-token"
+
 type E2ETestSuite struct {
 	suite.Suite
-	server  *httptest.Server
-	client  *http.Client
-	baseURL string
-	ctx     context.Context
-	cancel  context.CancelFunc
+	container *app.DIContainer
+	server    *httptest.Server
+	client    *http.Client
+	baseURL   string
+	ctx       context.Context
+	cancel    context.CancelFunc
 }
 
 func (s *E2ETestSuite) SetupSuite() {
 	s.ctx, s.cancel = context.WithCancel(context.Background())
-	container := app.NewDIContainer()
-	engine := container.GinEngine(s.ctx)
+	s.T().Setenv("ADMIN_TOKEN", testAdminToken)
+	s.container = app.NewDIContainer()
+	engine := s.container.GinEngine(s.ctx)
+	s.container.HealthChecker(s.ctx).MarkReady()
 	s.server = httptest.NewServer(engine)
 	s.baseURL = s.server.URL + "/api/v1"
 	s.client = &http.Client{
@@ -59,14 +113,24 @@ type TaskResponse struct {
 	Status         taskmodel.TaskStatus `json:"status"`
 	CreatedAt      string               `json:"created_at"`
 	ProcessingTime int64                `json:"processing_time"`
+	Source         string               `json:"source"`
 }
 
 type TaskListResponse struct {
-	Tasks []TaskResponse `json:"tasks"`
+	Tasks       []TaskResponse `json:"tasks"`
+	Total       int            `json:"total"`
+	Unavailable []string       `json:"unavailable"`
 }
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
+	Error   string       `json:"error"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
 }
 
@@ -131,6 +195,26 @@ func (s *E2ETestSuite) deleteTaskRequest(taskID string) (*http.Response, error)
 	return s.client.Do(req)
 }
 
+func (s *E2ETestSuite) adminRequest(method, path string, body interface{}) (*http.Response, error) {
+	reader := bytes.NewBuffer(nil)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequest(method, s.baseURL+"/admin"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+
+	return s.client.Do(req)
+}
+
 func (s *E2ETestSuite) getErrorResponse(resp *http.Response) (ErrorResponse, error) {
 	var errorResp ErrorResponse
 	err := json.NewDecoder(resp.Body).Decode(&errorResp)
@@ -150,7 +234,7 @@ func (s *E2ETestSuite) TestCreateTask() {
 
 	assert.NotEmpty(s.T(), taskResp.ID)
 	assert.Equal(s.T(), "Test Task", taskResp.Name)
-	assert.Equal(s.T(), taskmodel.StatusProcessing, taskResp.Status)
+	assert.Equal(s.T(), taskmodel.StatusQueued, taskResp.Status)
 	assert.NotEmpty(s.T(), taskResp.CreatedAt)
 	assert.GreaterOrEqual(s.T(), taskResp.ProcessingTime, int64(0))
 
@@ -158,6 +242,33 @@ func (s *E2ETestSuite) TestCreateTask() {
 	assert.NoError(s.T(), err)
 }
 
+func (s *E2ETestSuite) TestCreateTaskRequestID() {
+	body, err := json.Marshal(CreateTaskRequest{Name: "Request ID Test"})
+	require.NoError(s.T(), err)
+
+	req, err := http.NewRequest(http.MethodPost, s.baseURL+"/task/create", bytes.NewBuffer(body))
+	require.NoError(s.T(), err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "e2e-request-42")
+
+	resp, err := s.client.Do(req)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+	assert.Equal(s.T(), "e2e-request-42", resp.Header.Get("X-Request-ID"))
+
+	var taskResp struct {
+		RequestID string `json:"request_id"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&taskResp))
+	assert.Equal(s.T(), "e2e-request-42", taskResp.RequestID)
+
+	healthResp, err := s.client.Get(s.baseURL + "/health")
+	require.NoError(s.T(), err)
+	defer healthResp.Body.Close()
+	assert.NotEmpty(s.T(), healthResp.Header.Get("X-Request-ID"))
+}
+
 func (s *E2ETestSuite) TestGetTask() {
 	taskID := s.createTestTask("Get Task Test")
 
@@ -169,6 +280,7 @@ func (s *E2ETestSuite) TestGetTask() {
 	assert.Equal(s.T(), taskID, taskResp.ID)
 	assert.Equal(s.T(), "Get Task Test", taskResp.Name)
 	assert.Contains(s.T(), []taskmodel.TaskStatus{
+		taskmodel.StatusQueued,
 		taskmodel.StatusProcessing,
 		taskmodel.StatusDone,
 		taskmodel.StatusFailed,
@@ -201,6 +313,14 @@ func (s *E2ETestSuite) TestListTasks() {
 	}
 }
 
+func (s *E2ETestSuite) TestListTasksOwnerMeRequiresAuth() {
+	resp, err := s.client.Get(s.baseURL + "/tasks?owner=me")
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	assert.Equal(s.T(), http.StatusUnauthorized, resp.StatusCode)
+}
+
 func (s *E2ETestSuite) TestDeleteTask() {
 	taskID := s.createTestTask("Delete Task Test")
 
@@ -222,26 +342,36 @@ func (s *E2ETestSuite) TestCreateTaskInvalidInput() {
 		name           string
 		request        interface{}
 		expectedStatus int
+		expectedFields []FieldError
 	}{
 		{
 			name:           "Empty name",
 			request:        CreateTaskRequest{Name: ""},
 			expectedStatus: http.StatusBadRequest,
+			expectedFields: []FieldError{{Field: "name", Rule: "required"}},
 		},
 		{
 			name:           "Too long name",
 			request:        CreateTaskRequest{Name: strings.Repeat("a", 101)},
 			expectedStatus: http.StatusBadRequest,
+			expectedFields: []FieldError{{Field: "name", Rule: "max"}},
 		},
 		{
 			name:           "Invalid JSON",
 			request:        `{"name": }`,
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "Wrong type",
+			request:        map[string]interface{}{"name": 42},
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []FieldError{{Field: "name", Rule: "type"}},
+		},
 		{
 			name:           "Missing name field",
 			request:        map[string]interface{}{},
 			expectedStatus: http.StatusBadRequest,
+			expectedFields: []FieldError{{Field: "name", Rule: "required"}},
 		},
 	}
 
@@ -271,6 +401,12 @@ func (s *E2ETestSuite) TestCreateTaskInvalidInput() {
 				errorResp, err := s.getErrorResponse(resp)
 				require.NoError(t, err)
 				assert.NotEmpty(t, errorResp.Error)
+				require.Len(t, errorResp.Fields, len(tc.expectedFields))
+				for i, expected := range tc.expectedFields {
+					assert.Equal(t, expected.Field, errorResp.Fields[i].Field)
+					assert.Equal(t, expected.Rule, errorResp.Fields[i].Rule)
+					assert.NotEmpty(t, errorResp.Fields[i].Message)
+				}
 			}
 		})
 	}
@@ -326,7 +462,7 @@ func (s *E2ETestSuite) TestTaskLifecycle() {
 	taskID := s.createTestTask("Lifecycle Test Task")
 
 	task := s.getTask(taskID)
-	assert.Equal(s.T(), taskmodel.StatusProcessing, task.Status)
+	assert.True(s.T(), task.Status.IsActive(), task.Status)
 	assert.GreaterOrEqual(s.T(), task.ProcessingTime, int64(0))
 
 	time.Sleep(2 * time.Second)
@@ -419,6 +555,544 @@ func (s *E2ETestSuite) TestTaskProcessingTime() {
 	}
 }
 
+func (s *E2ETestSuite) TestAdminPurge() {
+	from := time.Now().UTC()
+	taskID := s.createTestTask("Purge Test Task")
+
+	resp, err := s.adminRequest(http.MethodPost, "/purge", map[string]interface{}{
+		"created_from": from.Format(time.RFC3339Nano),
+	})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var purgeResp struct {
+		Purged int `json:"purged"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&purgeResp))
+	assert.GreaterOrEqual(s.T(), purgeResp.Purged, 1)
+
+	_, getResp, err := s.getTaskRequest(taskID)
+	require.NoError(s.T(), err)
+	defer getResp.Body.Close()
+	assert.Equal(s.T(), http.StatusNotFound, getResp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestAdminPurgeRequiresFilter() {
+	resp, err := s.adminRequest(http.MethodPost, "/purge", map[string]interface{}{})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestAdminRetentionDryRun() {
+	resp, err := s.adminRequest(http.MethodGet, "/retention/dry-run", nil)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var dryRunResp struct {
+		Rules []interface{} `json:"rules"`
+		Tasks []interface{} `json:"tasks"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&dryRunResp))
+	assert.Empty(s.T(), dryRunResp.Rules)
+	assert.Empty(s.T(), dryRunResp.Tasks)
+}
+
+func (s *E2ETestSuite) TestAdminUnauthorized() {
+	resp, err := s.client.Post(s.baseURL+"/admin/purge", "application/json", strings.NewReader(`{"all":true}`))
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(s.T(), http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestAdminQueue() {
+	resp, err := s.adminRequest(http.MethodGet, "/queue", nil)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var queueResp struct {
+		WorkerCapacity int           `json:"worker_capacity"`
+		Tasks          []interface{} `json:"tasks"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&queueResp))
+	assert.Equal(s.T(), 100, queueResp.WorkerCapacity)
+	assert.NotNil(s.T(), queueResp.Tasks)
+}
+
+func (s *E2ETestSuite) TestAdminStuckTasks() {
+	taskID := s.createTestTask("Stuck Task")
+
+	type stuckResponse struct {
+		Tasks []struct {
+			ID      string   `json:"id"`
+			Reasons []string `json:"reasons"`
+		} `json:"tasks"`
+	}
+
+	require.Eventually(s.T(), func() bool {
+		resp, err := s.adminRequest(http.MethodGet, "/tasks/stuck?threshold=1ms", nil)
+		require.NoError(s.T(), err)
+		defer resp.Body.Close()
+		require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+		var report stuckResponse
+		require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&report))
+		for _, task := range report.Tasks {
+			if task.ID == taskID {
+				return assert.Contains(s.T(), task.Reasons, "running_too_long")
+			}
+		}
+		return false
+	}, 3*time.Second, 100*time.Millisecond)
+
+	resp, err := s.adminRequest(http.MethodGet, "/tasks/stuck", nil)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var report stuckResponse
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&report))
+	for _, task := range report.Tasks {
+		assert.NotEqual(s.T(), taskID, task.ID)
+	}
+}
+
+func (s *E2ETestSuite) TestAdminDrain() {
+	resp, err := s.adminRequest(http.MethodPost, "/drain", nil)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var drainResp struct {
+		Draining bool `json:"draining"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&drainResp))
+	assert.True(s.T(), drainResp.Draining)
+
+	_, createResp, err := s.createTaskRequest("Drained Task")
+	require.NoError(s.T(), err)
+	defer createResp.Body.Close()
+	assert.Equal(s.T(), http.StatusServiceUnavailable, createResp.StatusCode)
+	assert.Equal(s.T(), "5", createResp.Header.Get("Retry-After"))
+
+	errorResp, err := s.getErrorResponse(createResp)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "service_draining", errorResp.Error)
+
+	readyResp, err := s.client.Get(s.server.URL + "/readyz")
+	require.NoError(s.T(), err)
+	defer readyResp.Body.Close()
+	assert.Equal(s.T(), http.StatusServiceUnavailable, readyResp.StatusCode)
+	assert.NotEmpty(s.T(), readyResp.Header.Get("Retry-After"))
+
+	undrainResp, err := s.adminRequest(http.MethodPost, "/undrain", nil)
+	require.NoError(s.T(), err)
+	defer undrainResp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, undrainResp.StatusCode)
+
+	s.createTestTask("Undrained Task")
+}
+
+func (s *E2ETestSuite) TestTimezone() {
+	taskID := s.createTestTask("Timezone Task")
+
+	createdAt := func(query, timezone string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, s.baseURL+"/task/"+taskID+query, nil)
+		require.NoError(s.T(), err)
+		if timezone != "" {
+			req.Header.Set("Accept-Timezone", timezone)
+		}
+		resp, err := s.client.Do(req)
+		require.NoError(s.T(), err)
+		defer resp.Body.Close()
+		var body struct {
+			CreatedAt string `json:"created_at"`
+		}
+		require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body.CreatedAt
+	}
+
+	code, utc := createdAt("", "")
+	require.Equal(s.T(), http.StatusOK, code)
+	assert.True(s.T(), strings.HasSuffix(utc, "Z"), utc)
+
+	_, tokyo := createdAt("?tz=Asia/Tokyo", "America/New_York")
+	assert.True(s.T(), strings.HasSuffix(tokyo, "+09:00"), tokyo)
+	_, newYork := createdAt("", "America/New_York")
+	assert.Contains(s.T(), []string{"-04:00", "-05:00"}, newYork[len(newYork)-6:])
+
+	for _, value := range []string{tokyo, newYork} {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		require.NoError(s.T(), err)
+		expected, err := time.Parse(time.RFC3339Nano, utc)
+		require.NoError(s.T(), err)
+		assert.True(s.T(), parsed.Equal(expected))
+	}
+
+	code, _ = createdAt("?tz=Mars/Olympus_Mons", "")
+	assert.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *E2ETestSuite) TestLocalizedErrors() {
+	request := func(method, path, body, acceptLanguage string) (*http.Response, ErrorResponse) {
+		req, err := http.NewRequest(method, s.baseURL+path, strings.NewReader(body))
+		require.NoError(s.T(), err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		resp, err := s.client.Do(req)
+		require.NoError(s.T(), err)
+		defer resp.Body.Close()
+		var errorResp ErrorResponse
+		require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&errorResp))
+		return resp, errorResp
+	}
+
+	resp, errorResp := request(http.MethodGet, "/task/"+uuid.NewString(), "", "ru-RU, en;q=0.8")
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+	assert.Equal(s.T(), "ru", resp.Header.Get("Content-Language"))
+	assert.Equal(s.T(), "task_not_found", errorResp.Error)
+	assert.Equal(s.T(), "Задача не найдена", errorResp.Message)
+
+	resp, errorResp = request(http.MethodPost, "/task/create", `{}`, "ru")
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(s.T(), "В теле запроса есть недопустимые поля", errorResp.Message)
+	require.NotEmpty(s.T(), errorResp.Fields)
+	assert.Equal(s.T(), "name — обязательное поле", errorResp.Fields[0].Message)
+
+	// Unsupported languages fall back to English.
+	resp, errorResp = request(http.MethodGet, "/task/"+uuid.NewString(), "", "de")
+	assert.Equal(s.T(), "en", resp.Header.Get("Content-Language"))
+	assert.Equal(s.T(), "Task not found", errorResp.Message)
+}
+
+func (s *E2ETestSuite) TestAdminMaintenance() {
+	taskID := s.createTestTask("Maintenance Task")
+
+	resp, err := s.adminRequest(http.MethodPut, "/maintenance", map[string]bool{"enabled": true})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	_, createResp, err := s.createTaskRequest("Rejected Task")
+	require.NoError(s.T(), err)
+	defer createResp.Body.Close()
+	assert.Equal(s.T(), http.StatusServiceUnavailable, createResp.StatusCode)
+	assert.Equal(s.T(), "60", createResp.Header.Get("Retry-After"))
+
+	// Clients are told to come back when the maintenance is expected to end.
+	resp, err = s.adminRequest(http.MethodPut, "/maintenance", map[string]any{
+		"enabled": true,
+		"until":   time.Now().Add(10 * time.Minute),
+	})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	_, createResp, err = s.createTaskRequest("Rejected Task")
+	require.NoError(s.T(), err)
+	defer createResp.Body.Close()
+	retryAfter, err := strconv.Atoi(createResp.Header.Get("Retry-After"))
+	require.NoError(s.T(), err)
+	assert.InDelta(s.T(), 600, retryAfter, 5)
+
+	errorResp, err := s.getErrorResponse(createResp)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), "maintenance", errorResp.Error)
+
+	deleteResp, err := s.deleteTaskRequest(taskID)
+	require.NoError(s.T(), err)
+	defer deleteResp.Body.Close()
+	assert.Equal(s.T(), http.StatusServiceUnavailable, deleteResp.StatusCode)
+
+	s.getTask(taskID)
+
+	resp, err = s.adminRequest(http.MethodPut, "/maintenance", map[string]bool{"enabled": false})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	s.deleteTask(taskID)
+}
+
+func (s *E2ETestSuite) TestAdminLogLevel() {
+	resp, err := s.adminRequest(http.MethodPut, "/loglevel", map[string]string{"level": "debug"})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var levelResp struct {
+		Level string `json:"level"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&levelResp))
+	assert.Equal(s.T(), "debug", levelResp.Level)
+
+	resp, err = s.adminRequest(http.MethodPut, "/loglevel", map[string]string{"level": "verbose"})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = s.adminRequest(http.MethodPut, "/loglevel", map[string]string{"level": "info"})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestAdminFeatureFlags() {
+	resp, err := s.adminRequest(http.MethodPut, "/features/e2e_flag", map[string]bool{"enabled": true})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var flagsResp struct {
+		Flags map[string]bool `json:"flags"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&flagsResp))
+	assert.True(s.T(), flagsResp.Flags["e2e_flag"])
+	assert.True(s.T(), s.container.FeatureFlags(s.ctx).Enabled("e2e_flag"))
+
+	resp, err = s.adminRequest(http.MethodPut, "/features/e2e_flag", map[string]bool{"enabled": false})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.False(s.T(), s.container.FeatureFlags(s.ctx).Enabled("e2e_flag"))
+
+	resp, err = s.adminRequest(http.MethodGet, "/features", nil)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&flagsResp))
+	enabled, ok := flagsResp.Flags["e2e_flag"]
+	assert.True(s.T(), ok)
+	assert.False(s.T(), enabled)
+
+	resp, err = s.adminRequest(http.MethodPut, "/features/Bad%20Flag", map[string]bool{"enabled": true})
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestProjectTasks() {
+	body, err := json.Marshal(map[string]string{"name": "Project Test"})
+	require.NoError(s.T(), err)
+
+	resp, err := s.client.Post(s.baseURL+"/project/create", "application/json", bytes.NewBuffer(body))
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusCreated, resp.StatusCode)
+
+	var project struct {
+		ID string `json:"id"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&project))
+
+	body, err = json.Marshal(map[string]string{"name": "Project Task", "project_id": project.ID})
+	require.NoError(s.T(), err)
+
+	taskResp, err := s.client.Post(s.baseURL+"/task/create", "application/json", bytes.NewBuffer(body))
+	require.NoError(s.T(), err)
+	defer taskResp.Body.Close()
+	require.Equal(s.T(), http.StatusAccepted, taskResp.StatusCode)
+
+	statsResp, err := s.client.Get(s.baseURL + "/project/" + project.ID + "/stats")
+	require.NoError(s.T(), err)
+	defer statsResp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, statsResp.StatusCode)
+
+	var stats struct {
+		Total    int            `json:"total"`
+		ByStatus map[string]int `json:"by_status"`
+	}
+	require.NoError(s.T(), json.NewDecoder(statsResp.Body).Decode(&stats))
+	assert.Equal(s.T(), 1, stats.Total)
+	assert.Equal(s.T(), 1, stats.ByStatus[string(taskmodel.StatusQueued)]+stats.ByStatus[string(taskmodel.StatusProcessing)])
+
+	req, err := http.NewRequest(http.MethodDelete, s.baseURL+"/project/"+project.ID, nil)
+	require.NoError(s.T(), err)
+	deleteResp, err := s.client.Do(req)
+	require.NoError(s.T(), err)
+	defer deleteResp.Body.Close()
+	assert.Equal(s.T(), http.StatusConflict, deleteResp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestCreateTaskUnknownProject() {
+	body, err := json.Marshal(map[string]string{"name": "Orphan Task", "project_id": uuid.New().String()})
+	require.NoError(s.T(), err)
+
+	resp, err := s.client.Post(s.baseURL+"/task/create", "application/json", bytes.NewBuffer(body))
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestMetrics() {
+	s.createTestTask("Metrics Test Task")
+
+	resp, err := s.client.Get(s.server.URL + "/metrics")
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(s.T(), err)
+	assert.Contains(s.T(), string(body), "workmate_tasks_created_total")
+	assert.Contains(s.T(), string(body), "workmate_tasks_queued")
+	assert.Contains(s.T(), string(body), "workmate_workers_utilization_ratio")
+	assert.Contains(s.T(), string(body), "workmate_executor_goroutines")
+	assert.Contains(s.T(), string(body), `workmate_tasks{status="PROCESSING"}`)
+	assert.Contains(s.T(), string(body), `workmate_tasks{status="DONE"}`)
+	assert.Contains(s.T(), string(body), `workmate_http_requests_total{method="POST",route="/api/v1/task/create",status="202"}`)
+}
+
+func (s *E2ETestSuite) TestLatencyStats() {
+	repo := s.container.TaskRepository(s.ctx)
+	for i := 1; i <= 10; i++ {
+		task := taskmodel.NewTask(taskmodel.WithName("Latency Task"), taskmodel.WithType("latency-e2e"))
+		task.Status = taskmodel.StatusDone
+		task.ProcessingTime = time.Duration(i) * time.Minute
+		task.FinishedAt = time.Now()
+		require.NoError(s.T(), repo.Create(s.ctx, task))
+	}
+
+	resp, err := s.client.Get(s.baseURL + "/tasks/stats/latency?window=1h&type=latency-e2e")
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var stats struct {
+		Count      int     `json:"count"`
+		P50Seconds float64 `json:"p50_seconds"`
+		P90Seconds float64 `json:"p90_seconds"`
+		P99Seconds float64 `json:"p99_seconds"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Equal(s.T(), 10, stats.Count)
+	assert.Equal(s.T(), 300.0, stats.P50Seconds)
+	assert.Equal(s.T(), 540.0, stats.P90Seconds)
+	assert.Equal(s.T(), 600.0, stats.P99Seconds)
+
+	badResp, err := s.client.Get(s.baseURL + "/tasks/stats/latency?window=soon")
+	require.NoError(s.T(), err)
+	defer badResp.Body.Close()
+	assert.Equal(s.T(), http.StatusBadRequest, badResp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestThroughputTimeseries() {
+	s.createTestTask("Timeseries Task")
+
+	resp, err := s.client.Get(s.baseURL + "/tasks/stats/timeseries?bucket=1h&period=6h")
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var series struct {
+		Buckets []struct {
+			Start   time.Time `json:"start"`
+			Created int       `json:"created"`
+		} `json:"buckets"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&series))
+	require.Len(s.T(), series.Buckets, 6)
+	assert.GreaterOrEqual(s.T(), series.Buckets[5].Created, 1)
+	assert.True(s.T(), series.Buckets[0].Start.Before(series.Buckets[5].Start))
+
+	badResp, err := s.client.Get(s.baseURL + "/tasks/stats/timeseries?bucket=1s&period=720h")
+	require.NoError(s.T(), err)
+	defer badResp.Body.Close()
+	assert.Equal(s.T(), http.StatusBadRequest, badResp.StatusCode)
+}
+
+func (s *E2ETestSuite) TestProbes() {
+	resp, err := s.client.Get(s.server.URL + "/livez")
+	require.NoError(s.T(), err)
+	resp.Body.Close()
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	readyz := func() (int, string) {
+		resp, err := s.client.Get(s.server.URL + "/readyz")
+		require.NoError(s.T(), err)
+		defer resp.Body.Close()
+
+		var body struct {
+			Status string            `json:"status"`
+			Checks map[string]string `json:"checks"`
+		}
+		require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(s.T(), "ok", body.Checks["task_repository"])
+		return resp.StatusCode, body.Status
+	}
+
+	code, status := readyz()
+	assert.Equal(s.T(), http.StatusOK, code)
+	assert.Equal(s.T(), "ready", status)
+
+	checker := s.container.HealthChecker(s.ctx)
+	checker.MarkDraining()
+	defer checker.MarkReady()
+
+	code, status = readyz()
+	assert.Equal(s.T(), http.StatusServiceUnavailable, code)
+	assert.Equal(s.T(), "draining", status)
+}
+
+func (s *E2ETestSuite) TestHealthChecks() {
+	s.createTestTask("Health Check Task")
+
+	resp, err := s.client.Get(s.baseURL + "/health")
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Status string `json:"status"`
+		Build  struct {
+			Version string `json:"version"`
+		} `json:"build"`
+		Stats struct {
+			UptimeSeconds       float64    `json:"uptime_seconds"`
+			ActiveTasks         *int       `json:"active_tasks"`
+			QueueDepth          *int       `json:"queue_depth"`
+			LastRepositoryWrite *time.Time `json:"last_repository_write"`
+		} `json:"stats"`
+		Checks map[string]struct {
+			Status    string   `json:"status"`
+			LatencyMs *float64 `json:"latency_ms"`
+		} `json:"checks"`
+	}
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(s.T(), "healthy", body.Status)
+	assert.Equal(s.T(), buildinfo.Version, body.Build.Version)
+	assert.Positive(s.T(), body.Stats.UptimeSeconds)
+	require.NotNil(s.T(), body.Stats.ActiveTasks)
+	assert.GreaterOrEqual(s.T(), *body.Stats.ActiveTasks, 1)
+	assert.NotNil(s.T(), body.Stats.QueueDepth)
+	require.NotNil(s.T(), body.Stats.LastRepositoryWrite)
+	assert.WithinDuration(s.T(), time.Now(), *body.Stats.LastRepositoryWrite, time.Minute)
+
+	for _, name := range []string{"task_repository", "project_repository", "template_repository", "workers"} {
+		check, ok := body.Checks[name]
+		require.True(s.T(), ok, "missing check %s", name)
+		assert.Equal(s.T(), "ok", check.Status)
+		assert.NotNil(s.T(), check.LatencyMs)
+	}
+}
+
+func (s *E2ETestSuite) TestVersion() {
+	resp, err := s.client.Get(s.server.URL + "/version")
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	var body buildinfo.Info
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(s.T(), buildinfo.Version, body.Version)
+	assert.NotEmpty(s.T(), body.Commit)
+	assert.NotEmpty(s.T(), body.BuildTime)
+	assert.NotEmpty(s.T(), body.GoVersion)
+}
+
 func (s *E2ETestSuite) createTestTask(name string) string {
 	taskResp, resp, err := s.createTaskRequest(name)
 	require.NoError(s.T(), err)
@@ -450,6 +1124,2343 @@ func (s *E2ETestSuite) deleteTask(id string) {
 	require.Equal(s.T(), http.StatusNoContent, resp.StatusCode)
 }
 
+// countingRepository counts the tasks stored through it.
+type countingRepository struct {
+	*taskrepository.InMemoryTaskRepository
+	created atomic.Int64
+}
+
+func (r *countingRepository) Create(ctx context.Context, task *taskmodel.Task) error {
+	r.created.Add(1)
+	return r.InMemoryTaskRepository.Create(ctx, task)
+}
+
+func TestEmbeddedServer(t *testing.T) {
+	repo := &countingRepository{InMemoryTaskRepository: taskrepository.NewInMemoryTaskRepository()}
+	srv, err := server.New(server.WithConfig(server.DefaultConfig()), server.WithRepository(repo))
+	require.NoError(t, err)
+	require.NoError(t, srv.Start(context.Background()))
+	defer srv.Shutdown(context.Background())
+
+	engine := gin.New()
+	engine.GET("/ping", func(ctx *gin.Context) { ctx.String(http.StatusOK, "pong") })
+	srv.Mount(engine.Group("/embedded"))
+
+	host := httptest.NewServer(engine)
+	defer host.Close()
+
+	resp, err := http.Post(host.URL+"/embedded/task/create", "application/json", strings.NewReader(`{"name":"Embedded Task"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, int64(1), repo.created.Load())
+
+	resp, err = http.Get(host.URL + "/ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", srv.Handler())
+	muxServer := httptest.NewServer(mux)
+	defer muxServer.Close()
+
+	resp, err = http.Get(muxServer.URL + "/api/v1/tasks")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var list TaskListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Len(t, list.Tasks, 1)
+}
+
+// failingRepository fails every read as a broken storage would.
+type failingRepository struct {
+	*taskrepository.InMemoryTaskRepository
+}
+
+func (r *failingRepository) GetByID(ctx context.Context, id uuid.UUID) (*taskmodel.Task, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+// flakyRepository fails the first progress update of every task, failing
+// its first execution attempt.
+type flakyRepository struct {
+	*taskrepository.InMemoryTaskRepository
+	failed sync.Map // [uuid.UUID]bool
+}
+
+func (r *flakyRepository) Update(ctx context.Context, task *taskmodel.Task) error {
+	if task.ProcessingTime > 0 && len(task.Attempts) == 1 && task.Attempts[0].Outcome == taskmodel.AttemptRunning {
+		if _, failed := r.failed.LoadOrStore(task.ID, true); !failed {
+			return errors.New("storage unavailable")
+		}
+	}
+	return r.InMemoryTaskRepository.Update(ctx, task)
+}
+
+func TestTaskAttempts(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.MaxAttempts = 2
+	// The backoff passes on the accelerated clock like the work does.
+	cfg.Tasks.RetryBackoff = time.Minute
+	container := app.NewDIContainer(
+		app.WithConfig(cfg),
+		app.WithClock(clock.NewAccelerated(3000)),
+		app.WithTaskRepository(&flakyRepository{InMemoryTaskRepository: taskrepository.NewInMemoryTaskRepository()}),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Flaky"})
+	require.NoError(t, err)
+	finished, err := c.WaitForCompletion(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusDone, finished.Status)
+
+	attempts, err := c.Attempts(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 1, attempts[0].Number)
+	assert.Equal(t, "FAILED", attempts[0].Outcome)
+	assert.Contains(t, attempts[0].Error, "storage unavailable")
+	require.NotNil(t, attempts[0].FinishedAt)
+	assert.Equal(t, 2, attempts[1].Number)
+	assert.Equal(t, "SUCCEEDED", attempts[1].Outcome)
+	assert.Empty(t, attempts[1].Error)
+	assert.False(t, attempts[1].StartedAt.Before(*attempts[0].FinishedAt))
+
+	// The attempts survive a rebuild from the event history.
+	repaired, err := container.TaskService(ctx).RebuildTasks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, repaired)
+
+	_, err = c.Attempts(ctx, uuid.New())
+	require.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestTaskCancelledDuringRetryBackoff(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.MaxAttempts = 2
+	// Even on the accelerated clock the backoff outlasts the test.
+	cfg.Tasks.RetryBackoff = 24 * time.Hour
+	container := app.NewDIContainer(
+		app.WithConfig(cfg),
+		app.WithClock(clock.NewAccelerated(3000)),
+		app.WithTaskRepository(&flakyRepository{InMemoryTaskRepository: taskrepository.NewInMemoryTaskRepository()}),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Backing Off"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		attempts, err := c.Attempts(ctx, task.ID)
+		return err == nil && len(attempts) == 1 && attempts[0].Outcome == "FAILED"
+	}, 5*time.Second, 5*time.Millisecond)
+
+	_, err = c.Cancel(ctx, task.ID, "")
+	require.NoError(t, err)
+	finished, err := c.WaitForCompletion(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusCancelled, finished.Status)
+
+	// No attempt is started once the task is cancelled.
+	attempts, err := c.Attempts(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "FAILED", attempts[0].Outcome)
+}
+
+func TestTaskLogs(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(
+		app.WithClock(clock.NewAccelerated(3000)),
+		app.WithTaskRepository(&flakyRepository{InMemoryTaskRepository: taskrepository.NewInMemoryTaskRepository()}),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Failing"})
+	require.NoError(t, err)
+	finished, err := c.WaitForCompletion(ctx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, client.StatusFailed, finished.Status)
+
+	// Pages of two lines add up to the whole log, numbered without gaps.
+	var lines []client.LogLine
+	for offset := 0; ; {
+		page, next, err := c.Logs(ctx, task.ID, offset, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		assert.LessOrEqual(t, len(page), 2)
+		lines = append(lines, page...)
+		offset = next
+	}
+	require.NotEmpty(t, lines)
+	for i, line := range lines {
+		assert.Equal(t, i, line.Seq)
+		assert.NotContains(t, line.Attrs, "task_id")
+	}
+	assert.Equal(t, "Task created", lines[0].Message)
+
+	var failure *client.LogLine
+	for i := range lines {
+		if lines[i].Message == "Task failed" {
+			failure = &lines[i]
+		}
+	}
+	require.NotNil(t, failure)
+	assert.Equal(t, "ERROR", failure.Level)
+	assert.Contains(t, failure.Attrs["error"], "storage unavailable")
+
+	// The log goes away with the task.
+	require.NoError(t, c.Delete(ctx, task.ID))
+	_, _, err = c.Logs(ctx, task.ID, 0, 0)
+	require.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestTaskLogStream(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(app.WithClock(clock.NewAccelerated(3000)))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Streamed"})
+	require.NoError(t, err)
+
+	var lines []client.LogLine
+	status, err := c.FollowLogs(ctx, task.ID, 0, func(line client.LogLine) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusDone, status)
+	require.NotEmpty(t, lines)
+	progress := 0
+	for i, line := range lines {
+		assert.Equal(t, i, line.Seq)
+		if line.Message == "Task progress" {
+			progress++
+		}
+	}
+	assert.Positive(t, progress)
+	assert.Equal(t, "Task execution finished", lines[len(lines)-1].Message)
+
+	// A reconnecting client resumes after the last line it received.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host.URL+"/api/v1/task/"+task.ID.String()+"/logs/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", strconv.Itoa(len(lines)-2))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(body), "event: log\n"))
+	assert.Contains(t, string(body), "id: "+strconv.Itoa(len(lines)-1)+"\n")
+	assert.Contains(t, string(body), "event: end\ndata: {\"status\":\"DONE\"}\n\n")
+
+	_, err = c.FollowLogs(ctx, uuid.New(), 0, func(client.LogLine) error { return nil })
+	require.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestTaskLogStreamShutdown(t *testing.T) {
+	ctx := context.Background()
+	// On the real clock the task runs for minutes, far beyond the test.
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()))
+	defer container.TaskService(ctx).Shutdown(ctx)
+	server := container.Server(ctx)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	baseURL := "http://" + listener.Addr().String()
+
+	task, err := client.New(baseURL).Create(ctx, client.CreateRequest{Name: "Streamed"})
+	require.NoError(t, err)
+	resp, err := http.Get(baseURL + "/api/v1/task/" + task.ID.String() + "/logs/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Shutdown does not wait for the task, the stream ends with a shutdown
+	// event instead.
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(shutdownCtx))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "event: log\n")
+	assert.True(t, strings.HasSuffix(string(body), "event: shutdown\ndata: {}\n\n"), string(body))
+}
+
+func TestTaskServiceShutdown(t *testing.T) {
+	ctx := context.Background()
+	// On the real clock the task runs for minutes, far beyond the test.
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()))
+	service := container.TaskService(ctx)
+	task, err := service.CreateTask(ctx, "Unfinished Task")
+	require.NoError(t, err)
+
+	// Shutting down waits for the task first, then interrupts it instead of
+	// abandoning it.
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, service.Wait(waitCtx), context.DeadlineExceeded)
+	require.NoError(t, service.Shutdown(ctx))
+	require.NoError(t, service.Wait(ctx))
+
+	stored, err := service.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, taskmodel.StatusFailed, stored.Status)
+}
+
+func TestLogRedaction(t *testing.T) {
+	redactor, err := logger.NewRedactor(config.Defaults().Log.RedactKeys, []string{`\b\d{4}-\d{4}-\d{4}-\d{4}\b`})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	l := logger.New(&out, logger.FormatJSON, &slog.LevelVar{}, redactor)
+
+	l.With("secret", "with-secret").Info("Calling password=in-message with Authorization: Bearer in.header",
+		"password", "in-attr",
+		"API_KEY", "in-upper-attr",
+		slog.Group("request", "token", "in-group", "url", "/hook?token=in-query&page=2"),
+		"card", "paid with 4111-1111-1111-1111",
+		"task_id", "42",
+	)
+
+	line := out.String()
+	for _, secret := range []string{"with-secret", "in-message", "in.header", "in-attr", "in-upper-attr", "in-group", "in-query", "4111-1111-1111-1111"} {
+		assert.NotContains(t, line, secret)
+	}
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "[REDACTED]", record["password"])
+	assert.Equal(t, "[REDACTED]", record["API_KEY"])
+	assert.Equal(t, "[REDACTED]", record["secret"])
+	assert.Equal(t, map[string]any{"token": "[REDACTED]", "url": "/hook?token=[REDACTED]&page=2"}, record["request"])
+	assert.Equal(t, "paid with [REDACTED]", record["card"])
+	assert.Equal(t, "42", record["task_id"])
+	assert.Equal(t, "Calling password=[REDACTED] with Authorization: [REDACTED]", record["msg"])
+}
+
+// logExporter keeps the log records exported through the OTel bridge.
+type logExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *logExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, record := range records {
+		e.records = append(e.records, record.Clone())
+	}
+	return nil
+}
+
+func (e *logExporter) Shutdown(context.Context) error   { return nil }
+func (e *logExporter) ForceFlush(context.Context) error { return nil }
+
+func TestLogTraceCorrelation(t *testing.T) {
+	exporter := &logExporter{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exporter)))
+	redactor, err := logger.NewRedactor([]string{"password"}, nil)
+	require.NoError(t, err)
+	level := &slog.LevelVar{}
+	var out bytes.Buffer
+	l := logger.New(&out, logger.FormatJSON, level, redactor, otelslog.NewHandler("e2e", otelslog.WithLoggerProvider(provider)))
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("e2e").Start(context.Background(), "task.execute")
+	l.InfoContext(ctx, "Task completed", "task_id", "42", "password", "hunter2")
+	l.DebugContext(ctx, "Task progress")
+	l.Info("Without a span")
+	span.End()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, span.SpanContext().TraceID().String(), line["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), line["span_id"])
+	assert.NotContains(t, lines[1], "trace_id")
+
+	require.Len(t, exporter.records, 2)
+	record := exporter.records[0]
+	assert.Equal(t, "Task completed", record.Body().AsString())
+	assert.Equal(t, span.SpanContext().TraceID(), record.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), record.SpanID())
+	record.WalkAttributes(func(kv otellog.KeyValue) bool {
+		if kv.Key == "password" {
+			assert.NotEqual(t, "hunter2", kv.Value.AsString(), "exported records are redacted")
+		}
+		return true
+	})
+}
+
+func TestRepositoryConformance(t *testing.T) {
+	taskrepositorytest.Run(t, func(*testing.T) server.Repository {
+		return taskrepository.NewInMemoryTaskRepository()
+	})
+}
+
+func TestRepositoryFailureIsInternalError(t *testing.T) {
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithTaskRepository(&failingRepository{taskrepository.NewInMemoryTaskRepository()}),
+	)
+	host := httptest.NewServer(container.GinEngine(context.Background()))
+	defer host.Close()
+
+	resp, err := http.Get(host.URL + "/api/v1/task/" + uuid.New().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	var errorResp ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResp))
+	assert.Equal(t, "internal_error", errorResp.Error)
+}
+
+// slowRepository lists tasks only once the caller gives up.
+type slowRepository struct {
+	*taskrepository.InMemoryTaskRepository
+}
+
+func (r *slowRepository) GetByID(ctx context.Context, id uuid.UUID) (*taskmodel.Task, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRequestTimeout(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Server.RequestTimeout = 50 * time.Millisecond
+	container := app.NewDIContainer(
+		app.WithConfig(cfg),
+		app.WithTaskRepository(&slowRepository{taskrepository.NewInMemoryTaskRepository()}),
+	)
+	host := httptest.NewServer(container.GinEngine(context.Background()))
+	defer host.Close()
+
+	start := time.Now()
+	resp, err := http.Get(host.URL + "/api/v1/task/" + uuid.New().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var errorResp ErrorResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResp))
+	assert.Equal(t, "timeout", errorResp.Error)
+
+	// Listings are served by the read model and do not wait for the store.
+	resp, err = http.Get(host.URL + "/api/v1/tasks")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestClientDisconnect(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Server.RequestTimeout = 0
+	container := app.NewDIContainer(
+		app.WithConfig(cfg),
+		app.WithTaskRepository(&slowRepository{taskrepository.NewInMemoryTaskRepository()}),
+	)
+	host := httptest.NewServer(container.GinEngine(context.Background()))
+	defer host.Close()
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := client.Get(host.URL + "/api/v1/task/" + uuid.New().String())
+	require.Error(t, err)
+
+	// The handler returns as soon as the lookup notices the disconnect.
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(host.URL + "/metrics")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return strings.Contains(string(body), `workmate_http_requests_total{method="GET",route="/api/v1/task/:id",status="499"} 1`)
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestTaskCompletesWithAcceleratedClock(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+
+	resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"Fast Task"}`))
+	require.NoError(t, err)
+	var created TaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	id, err := uuid.Parse(created.ID)
+	require.NoError(t, err)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, container.TaskService(ctx).WaitForTask(waitCtx, id))
+
+	task, err := container.TaskRepository(ctx).GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, taskmodel.StatusDone, task.Status)
+	assert.GreaterOrEqual(t, task.ProcessingTime, 3*time.Minute)
+
+	// A finished task is final: the repository refuses to move it anywhere.
+	for _, status := range []taskmodel.TaskStatus{taskmodel.StatusProcessing, taskmodel.StatusFailed, taskmodel.StatusDone} {
+		task.Status = status
+		err = container.TaskRepository(ctx).Update(ctx, task)
+		assert.ErrorIs(t, err, taskmodel.ErrInvalidTransition, "DONE -> %s", status)
+	}
+}
+
+func TestTaskEvents(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+
+	resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"Tracked Task"}`))
+	require.NoError(t, err)
+	var created TaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	id, err := uuid.Parse(created.ID)
+	require.NoError(t, err)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, container.TaskService(ctx).WaitForTask(waitCtx, id))
+
+	resp, err = http.Get(host.URL + "/api/v1/task/" + created.ID + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var history struct {
+		Events []struct {
+			Version int    `json:"version"`
+			Type    string `json:"type"`
+		} `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+	require.GreaterOrEqual(t, len(history.Events), 3)
+	assert.Equal(t, "created", history.Events[0].Type)
+	assert.Equal(t, "started", history.Events[1].Type)
+	assert.Equal(t, "completed", history.Events[len(history.Events)-1].Type)
+	for i := 1; i < len(history.Events); i++ {
+		assert.Greater(t, history.Events[i].Version, history.Events[i-1].Version)
+	}
+
+	// The stored tasks match their event streams, so nothing is repaired.
+	repaired, err := container.TaskService(ctx).RebuildTasks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, repaired)
+
+	resp, err = http.Get(host.URL + "/api/v1/task/" + uuid.NewString() + "/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// flakyPublisher fails every other publish and records the delivered events.
+type flakyPublisher struct {
+	mu        sync.Mutex
+	calls     int
+	delivered []outbox.Message
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, message outbox.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.calls%2 == 1 {
+		return errors.New("broker unavailable")
+	}
+	p.delivered = append(p.delivered, message)
+	return nil
+}
+
+func TestEventOutbox(t *testing.T) {
+	ctx := context.Background()
+	publisher := &flakyPublisher{}
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+		app.WithEventPublisher(publisher),
+	)
+	relay := container.OutboxRelay(ctx)
+	require.NotNil(t, relay)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+
+	resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"Published Task"}`))
+	require.NoError(t, err)
+	var created TaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	id, err := uuid.Parse(created.ID)
+	require.NoError(t, err)
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, container.TaskService(ctx).WaitForTask(waitCtx, id))
+
+	// Every failed publish is retried on the next pass without losing or
+	// reordering the events behind it.
+	require.Eventually(t, func() bool {
+		_, err := relay.Flush(ctx)
+		return err == nil
+	}, 5*time.Second, time.Millisecond)
+
+	// The outbox carries every progress update, the history only the latest.
+	delivered := make(map[string]taskmodel.EventType)
+	for i, message := range publisher.delivered {
+		assert.Equal(t, i+1, message.Event.Version)
+		delivered[message.ID()] = message.Event.Type
+	}
+	events, err := container.TaskRepository(ctx).Events(ctx, id)
+	require.NoError(t, err)
+	for _, event := range events {
+		assert.Equal(t, event.Type, delivered[fmt.Sprintf("%s/%d", id, event.Version)])
+	}
+}
+
+func TestEventPayload(t *testing.T) {
+	task := taskmodel.NewTask(taskmodel.WithName("Published Task"))
+	task.Status = taskmodel.StatusProcessing
+	created := outbox.Message{Event: taskmodel.Event{
+		TaskID:  task.ID,
+		Version: 1,
+		Type:    taskmodel.EventCreated,
+		At:      time.Now(),
+		Task:    task,
+	}}
+
+	value, err := outbox.Encode(created)
+	require.NoError(t, err)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(value, &payload))
+	assert.EqualValues(t, outbox.SchemaVersion, payload["schema_version"])
+	assert.Equal(t, created.ID(), payload["id"])
+	assert.Equal(t, "created", payload["type"])
+	assert.Equal(t, "Published Task", payload["task"].(map[string]any)["name"])
+	assert.NotContains(t, payload, "finished_at")
+}
+
+func TestEventWebhook(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var bodies []string
+	var headers []http.Header
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("X-Token") == "secret" && r.Header.Get("X-Event-ID") != "" {
+			bodies = append(bodies, string(body))
+			headers = append(headers, r.Header.Clone())
+		}
+	}))
+	defer webhook.Close()
+
+	cfg := config.Defaults()
+	cfg.Events.Webhook.URL = webhook.URL
+	cfg.Events.Webhook.Template = `{{.Type}} {{with .Current}}{{json .Name}}{{end}}`
+	cfg.Events.Webhook.Headers = map[string]string{"X-Token": "secret"}
+	cfg.Events.Webhook.AllowedHosts = []string{"127.0.0.1"}
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	relay := container.OutboxRelay(ctx)
+	require.NotNil(t, relay)
+	tracing.SetupPropagation()
+
+	// The task is created within a traced request, which the webhooks continue.
+	requestCtx, span := sdktrace.NewTracerProvider().Tracer("e2e").Start(requestid.WithRequestID(ctx, "req-hooked"), "POST /tasks")
+	task, err := container.TaskService(ctx).CreateTask(requestCtx, "Hooked Task")
+	span.End()
+	require.NoError(t, err)
+	_, err = relay.Flush(ctx)
+	require.NoError(t, err)
+	require.NoError(t, container.TaskService(ctx).DeleteTask(ctx, task.ID))
+	_, err = relay.Flush(ctx)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, bodies)
+	assert.Equal(t, `created "Hooked Task"`, bodies[0])
+	assert.Equal(t, "deleted ", bodies[len(bodies)-1])
+	assert.Contains(t, headers[0].Get("traceparent"), span.SpanContext().TraceID().String())
+	assert.Equal(t, "req-hooked", headers[0].Get(requestid.Header))
+
+	// Hosts missing from the allowlist are refused at startup.
+	_, err = webhookpublisher.New(webhookpublisher.Config{URL: webhook.URL, AllowedHosts: []string{"*.example.com"}}, nil)
+	assert.ErrorIs(t, err, webhookpublisher.ErrHostNotAllowed)
+}
+
+// channelDispatcher is an in-process queue that delivers a task again
+// until its handler succeeds.
+type channelDispatcher struct {
+	queue chan uuid.UUID
+}
+
+func (d *channelDispatcher) Dispatch(ctx context.Context, taskID uuid.UUID) error {
+	d.queue <- taskID
+	return nil
+}
+
+func (d *channelDispatcher) Consume(ctx context.Context, workers int, handle func(ctx context.Context, taskID uuid.UUID) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case taskID := <-d.queue:
+			go func() {
+				if err := handle(ctx, taskID); err != nil {
+					d.queue <- taskID
+				}
+			}()
+		}
+	}
+}
+
+func TestTaskQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := &channelDispatcher{queue: make(chan uuid.UUID, 10)}
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+		app.WithDispatcher(dispatcher),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+
+	resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"Queued Task"}`))
+	require.NoError(t, err)
+	var created TaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	assert.Equal(t, taskmodel.StatusQueued, created.Status)
+
+	// Nothing runs the task until it is taken from the queue.
+	id, err := uuid.Parse(created.ID)
+	require.NoError(t, err)
+	require.Len(t, dispatcher.queue, 1)
+	task, err := container.TaskService(ctx).GetTask(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, taskmodel.StatusQueued, task.Status)
+	assert.True(t, task.StartedAt.IsZero())
+
+	// A duplicate delivery of a task that already ran is dropped.
+	dispatcher.queue <- id
+	go container.TaskService(ctx).Consume(ctx)
+
+	require.Eventually(t, func() bool {
+		task, err := container.TaskService(ctx).GetTask(ctx, id)
+		return err == nil && task.Status == taskmodel.StatusDone
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(dispatcher.queue) == 0 }, time.Second, 10*time.Millisecond)
+}
+
+// localCoordinator coordinates the containers of one test process.
+type localCoordinator struct {
+	mu       sync.Mutex
+	locks    map[uuid.UUID]bool
+	handlers []func(taskID uuid.UUID)
+}
+
+func (c *localCoordinator) Lock(ctx context.Context, taskID uuid.UUID) (func(), bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.locks[taskID] {
+		return nil, false, nil
+	}
+	c.locks[taskID] = true
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.locks, taskID)
+	}, true, nil
+}
+
+func (c *localCoordinator) Cancel(ctx context.Context, taskID uuid.UUID) error {
+	c.mu.Lock()
+	handlers := slices.Clone(c.handlers)
+	c.mu.Unlock()
+	for _, handle := range handlers {
+		handle(taskID)
+	}
+	return nil
+}
+
+func (c *localCoordinator) Cancellations(ctx context.Context, handle func(taskID uuid.UUID)) error {
+	c.mu.Lock()
+	c.handlers = append(c.handlers, handle)
+	c.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func TestRepositoryClock(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewAccelerated(3000)
+	repository := taskrepository.NewInMemoryTaskRepository(taskrepository.WithClock(clk))
+
+	// The creation time set by the caller is kept.
+	createdAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	task := taskmodel.NewTask(taskmodel.WithName("Clocked Task"))
+	task.CreatedAt = createdAt
+	require.NoError(t, repository.Create(ctx, task))
+	stored, err := repository.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+
+	// Changes are stamped with the injected clock, far ahead of the wall clock.
+	time.Sleep(10 * time.Millisecond)
+	stored.Status = taskmodel.StatusProcessing
+	require.NoError(t, repository.Update(ctx, stored))
+	events, err := repository.Events(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.True(t, events[0].At.Equal(createdAt))
+	assert.True(t, events[1].At.After(time.Now().Add(10*time.Second)))
+	assert.True(t, repository.LastWrite().After(time.Now().Add(10*time.Second)))
+
+	// Tasks created without a creation time get the time they are stored.
+	unstamped := taskmodel.NewTask(taskmodel.WithName("Unstamped Task"))
+	require.NoError(t, repository.Create(ctx, unstamped))
+	assert.True(t, unstamped.CreatedAt.After(time.Now()))
+}
+
+func TestCrossInstanceCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repository := taskrepository.NewInMemoryTaskRepository()
+	dispatcher := &channelDispatcher{queue: make(chan uuid.UUID, 10)}
+	coordinator := &localCoordinator{locks: make(map[uuid.UUID]bool)}
+	newInstance := func() *app.DIContainer {
+		return app.NewDIContainer(
+			app.WithConfig(config.Defaults()),
+			app.WithTaskRepository(repository),
+			app.WithDispatcher(dispatcher),
+			app.WithCoordinator(coordinator),
+		)
+	}
+	api, worker := newInstance(), newInstance()
+	go worker.TaskService(ctx).WatchCancellations(ctx)
+	go worker.TaskService(ctx).Consume(ctx)
+	host := httptest.NewServer(api.GinEngine(ctx))
+	defer host.Close()
+
+	resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"Remote Task"}`))
+	require.NoError(t, err)
+	var created TaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	id, err := uuid.Parse(created.ID)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return worker.TaskService(ctx).RunningTasks() == 1 }, 5*time.Second, 10*time.Millisecond)
+	_, locked, err := coordinator.Lock(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, locked, "the executing instance holds the task lock")
+
+	// Deleting through the API instance stops the executor of the worker.
+	req, err := http.NewRequest(http.MethodDelete, host.URL+"/api/v1/task/"+created.ID, nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	require.Eventually(t, func() bool { return worker.TaskService(ctx).RunningTasks() == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		_, locked, _ := coordinator.Lock(ctx, id)
+		return locked
+	}, 5*time.Second, 10*time.Millisecond, "the lock is released once the executor stopped")
+}
+
+func TestFederatedTaskList(t *testing.T) {
+	ctx := context.Background()
+	createTask := func(baseURL, name string) {
+		resp, err := http.Post(baseURL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"`+name+`"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	peer := httptest.NewServer(app.NewDIContainer(app.WithConfig(config.Defaults())).GinEngine(ctx))
+	defer peer.Close()
+	createTask(peer.URL, "Peer Task")
+
+	cfg := config.Defaults()
+	cfg.Federation.Name = "home"
+	cfg.Federation.Peers = []config.FederationPeer{
+		{Name: "eu", URL: peer.URL},
+		{Name: "down", URL: "http://127.0.0.1:1"},
+	}
+	host := httptest.NewServer(app.NewDIContainer(app.WithConfig(cfg)).GinEngine(ctx))
+	defer host.Close()
+	createTask(host.URL, "Home Task")
+
+	resp, err := http.Get(host.URL + "/api/v1/tasks?federated=true&limit=1")
+	require.NoError(t, err)
+	var list TaskListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The newest task comes first; the unreachable peer does not fail the listing.
+	assert.Equal(t, 2, list.Total)
+	assert.Equal(t, []string{"down"}, list.Unavailable)
+	require.Len(t, list.Tasks, 1)
+	assert.Equal(t, "Home Task", list.Tasks[0].Name)
+	assert.Equal(t, "home", list.Tasks[0].Source)
+
+	resp, err = http.Get(host.URL + "/api/v1/tasks?federated=true&offset=1")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Len(t, list.Tasks, 1)
+	assert.Equal(t, "Peer Task", list.Tasks[0].Name)
+	assert.Equal(t, "eu", list.Tasks[0].Source)
+
+	resp, err = http.Get(host.URL + "/api/v1/tasks?federated=true&limit=5000")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(10*time.Millisecond))
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Client Task"})
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusQueued, task.Status)
+
+	tasks, err := c.List(ctx, client.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, task.ID, tasks[0].ID)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	finished, err := c.WaitForCompletion(waitCtx, task.ID)
+	require.NoError(t, err)
+	assert.True(t, finished.Status.IsFinal())
+	assert.Positive(t, finished.ProcessingTime)
+
+	require.NoError(t, c.Delete(ctx, task.ID))
+	_, err = c.Get(ctx, task.ID)
+	require.ErrorIs(t, err, client.ErrNotFound)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "task_not_found", apiErr.Code)
+
+	_, err = c.Create(ctx, client.CreateRequest{})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.Fields)
+}
+
+func TestTaskServiceFake(t *testing.T) {
+	ctx := context.Background()
+	fake := taskservicetest.New()
+	host := httptest.NewServer(fake.Handler())
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Faked", Type: "report"})
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusQueued, task.Status)
+	require.Len(t, fake.Tasks(), 1)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(t, fake.Complete(task.ID, 3*time.Second))
+	}()
+	finished, err := c.WaitForCompletion(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusDone, finished.Status)
+	assert.Equal(t, 3*time.Second, finished.ProcessingTime)
+	assert.ErrorIs(t, fake.Fail(task.ID, time.Second), taskmodel.ErrInvalidTransition)
+
+	resp, err := http.Get(host.URL + "/api/v1/task/" + task.ID.String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var durations struct {
+		ProcessingTime      int64  `json:"processing_time"`
+		ProcessingTimeMs    int64  `json:"processing_time_ms"`
+		ProcessingTimeHuman string `json:"processing_time_human"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&durations))
+	assert.Equal(t, int64(3*time.Second), durations.ProcessingTime)
+	assert.Equal(t, int64(3000), durations.ProcessingTimeMs)
+	assert.Equal(t, "3s", durations.ProcessingTimeHuman)
+
+	events, err := fake.TaskEvents(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, taskmodel.EventCompleted, events[1].Type)
+
+	failed := fake.Add(&server.Task{Name: "Seeded", Status: taskmodel.StatusFailed})
+	tasks, err := c.List(ctx, client.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	got, err := c.Get(ctx, failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusFailed, got.Status)
+
+	fake.SetError(taskservicetest.ErrDraining)
+	noRetries := client.New(host.URL, client.WithRetries(0, 0))
+	_, err = noRetries.Create(ctx, client.CreateRequest{Name: "Rejected"})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	fake.SetError(nil)
+
+	require.NoError(t, c.Delete(ctx, task.ID))
+	_, err = c.Get(ctx, task.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestTaskExpiration(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewAccelerated(3000)
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()), app.WithClock(clk))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	// Executing takes minutes, so the task expires while it runs.
+	expiresAt := clk.Now().Add(30 * time.Second)
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Expiring", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	require.NotNil(t, task.ExpiresAt)
+	assert.True(t, task.ExpiresAt.Equal(expiresAt))
+
+	finished, err := c.WaitForCompletion(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusFailed, finished.Status)
+	assert.Contains(t, finished.FailureReason, "expired")
+
+	// A task without an expiry runs to completion.
+	plain, err := c.Create(ctx, client.CreateRequest{Name: "Not expiring"})
+	require.NoError(t, err)
+	finished, err = c.WaitForCompletion(ctx, plain.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusDone, finished.Status)
+	assert.Empty(t, finished.FailureReason)
+
+	past := clk.Now().Add(-time.Hour)
+	_, err = c.Create(ctx, client.CreateRequest{Name: "Expired", ExpiresAt: &past})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func TestTaskDeleteAfter(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	task, err := c.Create(ctx, client.CreateRequest{Name: "Short-lived", DeleteAfterSeconds: 60})
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), task.DeleteAfterSeconds)
+	kept, err := c.Create(ctx, client.CreateRequest{Name: "Kept"})
+	require.NoError(t, err)
+
+	_, err = c.WaitForCompletion(ctx, kept.ID)
+	require.NoError(t, err)
+
+	// A minute of the accelerated clock passes within milliseconds.
+	require.Eventually(t, func() bool {
+		_, err := c.Get(ctx, task.ID)
+		var apiErr *client.Error
+		return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = c.Get(ctx, kept.ID)
+	require.NoError(t, err)
+
+	_, err = c.Create(ctx, client.CreateRequest{Name: "Invalid", DeleteAfterSeconds: -1})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func TestTaskDedup(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.DedupWindow = time.Minute
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	first, err := c.Create(ctx, client.CreateRequest{Name: "Submit form"})
+	require.NoError(t, err)
+
+	// The repeated submission answers with the task created before.
+	resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(`{"name":"Submit form"}`))
+	require.NoError(t, err)
+	var repeated client.Task
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&repeated))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/api/v1/task/"+first.ID.String(), resp.Header.Get("Location"))
+	assert.Equal(t, first.ID, repeated.ID)
+
+	// A dedup key replaces the name.
+	keyed, err := c.Create(ctx, client.CreateRequest{Name: "Submit form", DedupKey: "order-42"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, keyed.ID)
+	assert.Equal(t, "order-42", keyed.DedupKey)
+	again, err := c.Create(ctx, client.CreateRequest{Name: "Submit order", DedupKey: "order-42"})
+	require.NoError(t, err)
+	assert.Equal(t, keyed.ID, again.ID)
+
+	// A deleted task is no longer repeated.
+	require.NoError(t, c.Delete(ctx, first.ID))
+	fresh, err := c.Create(ctx, client.CreateRequest{Name: "Submit form"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, fresh.ID)
+}
+
+func TestTaskUniqueNames(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	first, err := c.Create(ctx, client.CreateRequest{Name: "nightly-report", Unique: true})
+	require.NoError(t, err)
+
+	_, err = c.Create(ctx, client.CreateRequest{Name: "nightly-report", Unique: true})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "task_name_conflict", apiErr.Code)
+	require.NotNil(t, apiErr.ConflictingTaskID)
+	assert.Equal(t, first.ID, *apiErr.ConflictingTaskID)
+
+	// Only the submissions asking for it are checked.
+	second, err := c.Create(ctx, client.CreateRequest{Name: "nightly-report"})
+	require.NoError(t, err)
+
+	// Once no active task has the name it is free again.
+	require.NoError(t, c.Delete(ctx, first.ID))
+	require.NoError(t, c.Delete(ctx, second.ID))
+	_, err = c.Create(ctx, client.CreateRequest{Name: "nightly-report", Unique: true})
+	require.NoError(t, err)
+}
+
+func TestTaskCancel(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.Workers = 1
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	running, err := c.Create(ctx, client.CreateRequest{Name: "Running"})
+	require.NoError(t, err)
+	waiting, err := c.Create(ctx, client.CreateRequest{Name: "Waiting"})
+	require.NoError(t, err)
+
+	// The only worker runs the first task while the second waits for it.
+	require.Eventually(t, func() bool {
+		task, err := c.Get(ctx, running.ID)
+		return err == nil && task.Status == client.StatusProcessing
+	}, 5*time.Second, 10*time.Millisecond)
+	task, err := c.Get(ctx, waiting.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusQueued, task.Status)
+
+	// The reason is optional.
+	reasons := map[uuid.UUID]string{waiting.ID: "", running.ID: "Submitted twice"}
+	for _, id := range []uuid.UUID{waiting.ID, running.ID} {
+		cancelled, err := c.Cancel(ctx, id, reasons[id])
+		require.NoError(t, err)
+		assert.Equal(t, client.StatusCancelled, cancelled.Status)
+		assert.True(t, cancelled.Status.IsFinal())
+		assert.Equal(t, reasons[id], cancelled.CancelReason)
+
+		events, err := container.TaskService(ctx).TaskEvents(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, taskmodel.EventCancelled, events[len(events)-1].Type)
+		assert.Equal(t, reasons[id], events[len(events)-1].CancelReason)
+	}
+
+	// A finished task is kept and cannot be cancelled again.
+	_, err = c.Cancel(ctx, running.ID, "")
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "task_finished", apiErr.Code)
+	task, err = c.Get(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusCancelled, task.Status)
+	assert.Equal(t, "Submitted twice", task.CancelReason)
+}
+
+func TestTaskPriority(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.Workers = 1
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	running, err := c.Create(ctx, client.CreateRequest{Name: "Running"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		task, err := c.Get(ctx, running.ID)
+		return err == nil && task.Status == client.StatusProcessing
+	}, 5*time.Second, 10*time.Millisecond)
+
+	first, err := c.Create(ctx, client.CreateRequest{Name: "First"})
+	require.NoError(t, err)
+	assert.Equal(t, client.PriorityNormal, first.Priority)
+	low, err := c.Create(ctx, client.CreateRequest{Name: "Low", Priority: client.PriorityLow})
+	require.NoError(t, err)
+	assert.Equal(t, client.PriorityLow, low.Priority)
+	urgent, err := c.Create(ctx, client.CreateRequest{Name: "Urgent"})
+	require.NoError(t, err)
+
+	bumped, err := c.SetPriority(ctx, urgent.ID, client.PriorityCritical)
+	require.NoError(t, err)
+	assert.Equal(t, client.PriorityCritical, bumped.Priority)
+	assert.Equal(t, client.StatusQueued, bumped.Status)
+
+	queue, err := container.TaskService(ctx).Queue(ctx)
+	require.NoError(t, err)
+	var order []uuid.UUID
+	for _, queued := range queue {
+		order = append(order, queued.Task.ID)
+	}
+	assert.Equal(t, []uuid.UUID{urgent.ID, first.ID, low.ID}, order)
+
+	// The preview reads the queue the workers take tasks from.
+	var preview *client.DispatchOrder
+	require.Eventually(t, func() bool {
+		preview, err = c.QueueOrder(ctx)
+		return err == nil && len(preview.Tasks) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"serial_key", "priority", "queue_order"}, preview.Rules)
+	for i, id := range order {
+		assert.Equal(t, i+1, preview.Tasks[i].Position)
+		assert.Equal(t, id, preview.Tasks[i].ID)
+	}
+	assert.Equal(t, client.PriorityCritical, preview.Tasks[0].Priority)
+	assert.Less(t, preview.Tasks[1].Sequence, preview.Tasks[2].Sequence)
+
+	events, err := container.TaskService(ctx).TaskEvents(ctx, urgent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, taskmodel.EventPriorityChanged, events[len(events)-1].Type)
+	assert.Equal(t, taskmodel.PriorityCritical, events[len(events)-1].Priority)
+
+	// The freed worker takes the bumped task before the earlier ones.
+	_, err = c.Cancel(ctx, running.ID, "")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		task, err := c.Get(ctx, urgent.ID)
+		return err == nil && task.Status == client.StatusProcessing
+	}, 5*time.Second, 10*time.Millisecond)
+	task, err := c.Get(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusQueued, task.Status)
+
+	_, err = c.SetPriority(ctx, urgent.ID, client.PriorityLow)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "task_not_waiting", apiErr.Code)
+
+	_, err = c.SetPriority(ctx, first.ID, "urgent")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "validation_error", apiErr.Code)
+}
+
+func TestSerialKey(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.Workers = 3
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	status := func(id uuid.UUID) client.Status {
+		task, err := c.Get(ctx, id)
+		require.NoError(t, err)
+		return task.Status
+	}
+	processing := func(id uuid.UUID) func() bool {
+		return func() bool { return status(id) == client.StatusProcessing }
+	}
+
+	first, err := c.Create(ctx, client.CreateRequest{Name: "First", SerialKey: "db"})
+	require.NoError(t, err)
+	assert.Equal(t, "db", first.SerialKey)
+	second, err := c.Create(ctx, client.CreateRequest{Name: "Second", SerialKey: "db"})
+	require.NoError(t, err)
+	// The key outranks the priority.
+	third, err := c.Create(ctx, client.CreateRequest{Name: "Third", SerialKey: "db", Priority: client.PriorityCritical})
+	require.NoError(t, err)
+	other, err := c.Create(ctx, client.CreateRequest{Name: "Other"})
+	require.NoError(t, err)
+
+	// A free worker is left while the later tasks of the key wait.
+	require.Eventually(t, processing(first.ID), 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, processing(other.ID), 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, client.StatusQueued, status(second.ID))
+	assert.Equal(t, client.StatusQueued, status(third.ID))
+
+	preview, err := c.QueueOrder(ctx)
+	require.NoError(t, err)
+	require.Len(t, preview.Tasks, 2)
+	assert.Equal(t, second.ID, preview.Tasks[0].ID)
+	assert.Equal(t, third.ID, preview.Tasks[1].ID)
+	assert.Equal(t, "db", preview.Tasks[1].SerialKey)
+
+	_, err = c.Cancel(ctx, first.ID, "")
+	require.NoError(t, err)
+	require.Eventually(t, processing(second.ID), 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, client.StatusQueued, status(third.ID))
+
+	// A task cancelled while waiting does not hold up the next one.
+	fourth, err := c.Create(ctx, client.CreateRequest{Name: "Fourth", SerialKey: "db"})
+	require.NoError(t, err)
+	_, err = c.Cancel(ctx, third.ID, "")
+	require.NoError(t, err)
+	_, err = c.Cancel(ctx, second.ID, "")
+	require.NoError(t, err)
+	require.Eventually(t, processing(fourth.ID), 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, client.StatusCancelled, status(third.ID))
+}
+
+func TestWorkerPools(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.Workers = 1
+	cfg.Tasks.Pools = map[string]int{"cpu": 1}
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	processing := func(id uuid.UUID) func() bool {
+		return func() bool {
+			task, err := c.Get(ctx, id)
+			return err == nil && task.Status == client.StatusProcessing
+		}
+	}
+
+	light, err := c.Create(ctx, client.CreateRequest{Name: "Light"})
+	require.NoError(t, err)
+	assert.Equal(t, "default", light.Pool)
+	require.Eventually(t, processing(light.ID), 5*time.Second, 10*time.Millisecond)
+	waiting, err := c.Create(ctx, client.CreateRequest{Name: "Waiting"})
+	require.NoError(t, err)
+
+	// The busy default pool does not hold up the tasks of another pool.
+	heavy, err := c.Create(ctx, client.CreateRequest{Name: "Heavy", Pool: "cpu"})
+	require.NoError(t, err)
+	assert.Equal(t, "cpu", heavy.Pool)
+	require.Eventually(t, processing(heavy.ID), 5*time.Second, 10*time.Millisecond)
+	next, err := c.Create(ctx, client.CreateRequest{Name: "Next", Pool: "cpu"})
+	require.NoError(t, err)
+
+	var preview *client.DispatchOrder
+	require.Eventually(t, func() bool {
+		preview, err = c.QueueOrder(ctx)
+		return err == nil && len(preview.Tasks) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, waiting.ID, preview.Tasks[0].ID)
+	assert.Equal(t, next.ID, preview.Tasks[1].ID)
+	assert.Equal(t, "cpu", preview.Tasks[1].Pool)
+	// Positions count within a pool.
+	assert.Equal(t, 1, preview.Tasks[0].Position)
+	assert.Equal(t, 1, preview.Tasks[1].Position)
+
+	assert.Equal(t, []taskservice.WorkerPoolStats{
+		{Name: "cpu", Capacity: 1, Running: 1, Queued: 1},
+		{Name: "default", Capacity: 1, Running: 1, Queued: 1},
+	}, container.TaskService(ctx).WorkerPools())
+	assert.Equal(t, 2, container.TaskService(ctx).WorkerCapacity())
+
+	_, err = c.Create(ctx, client.CreateRequest{Name: "Lost", Pool: "gpu"})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "validation_error", apiErr.Code)
+	require.Len(t, apiErr.Fields, 1)
+	assert.Equal(t, "pool", apiErr.Fields[0].Field)
+	assert.Equal(t, "cpu default", apiErr.Fields[0].Param)
+}
+
+func TestPreemption(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.Workers = 1
+	cfg.Tasks.Preemption = true
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	inStatus := func(id uuid.UUID, status client.Status) func() bool {
+		return func() bool {
+			task, err := c.Get(ctx, id)
+			return err == nil && task.Status == status
+		}
+	}
+
+	low, err := c.Create(ctx, client.CreateRequest{Name: "Low", Priority: client.PriorityLow})
+	require.NoError(t, err)
+	require.Eventually(t, inStatus(low.ID, client.StatusProcessing), 5*time.Second, 10*time.Millisecond)
+	normal, err := c.Create(ctx, client.CreateRequest{Name: "Normal"})
+	require.NoError(t, err)
+
+	// The critical task takes the worker of the running task.
+	critical, err := c.Create(ctx, client.CreateRequest{Name: "Critical", Priority: client.PriorityCritical})
+	require.NoError(t, err)
+	require.Eventually(t, inStatus(critical.ID, client.StatusProcessing), 5*time.Second, 10*time.Millisecond)
+	low, err = c.Get(ctx, low.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusQueued, low.Status)
+	assert.Equal(t, 1, low.Preemptions)
+
+	attempts, err := c.Attempts(ctx, low.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "PREEMPTED", attempts[0].Outcome)
+
+	// Critical tasks are not preempted.
+	second, err := c.Create(ctx, client.CreateRequest{Name: "Second critical", Priority: client.PriorityCritical})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		preview, err := c.QueueOrder(ctx)
+		return err == nil && len(preview.Tasks) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	preview, err := c.QueueOrder(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second.ID, normal.ID, low.ID},
+		[]uuid.UUID{preview.Tasks[0].ID, preview.Tasks[1].ID, preview.Tasks[2].ID})
+	assert.True(t, inStatus(critical.ID, client.StatusProcessing)())
+
+	// The preempted task resumes with a new attempt once it gets a worker.
+	for _, id := range []uuid.UUID{critical.ID, second.ID, normal.ID} {
+		_, err = c.Cancel(ctx, id, "")
+		require.NoError(t, err)
+	}
+	require.Eventually(t, inStatus(low.ID, client.StatusProcessing), 5*time.Second, 10*time.Millisecond)
+	attempts, err = c.Attempts(ctx, low.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 2, attempts[1].Number)
+	assert.Equal(t, "RUNNING", attempts[1].Outcome)
+
+	events, err := container.TaskService(ctx).TaskEvents(ctx, low.ID)
+	require.NoError(t, err)
+	var types []taskmodel.EventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Contains(t, types, taskmodel.EventPreempted)
+	repaired, err := container.TaskService(ctx).RebuildTasks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, repaired)
+}
+
+func TestSlowTasks(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.SlowThresholds = map[string]time.Duration{"report": time.Minute}
+	container := app.NewDIContainer(app.WithConfig(cfg), app.WithClock(clock.NewAccelerated(3000)))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	// Tasks take at least three minutes; only reports have a threshold.
+	report, err := c.Create(ctx, client.CreateRequest{Name: "Report", Type: "report"})
+	require.NoError(t, err)
+	assert.False(t, report.Slow)
+	other, err := c.Create(ctx, client.CreateRequest{Name: "Other"})
+	require.NoError(t, err)
+
+	report, err = c.WaitForCompletion(ctx, report.ID)
+	require.NoError(t, err)
+	assert.True(t, report.Slow)
+	other, err = c.WaitForCompletion(ctx, other.ID)
+	require.NoError(t, err)
+	assert.False(t, other.Slow)
+
+	events, err := container.TaskService(ctx).TaskEvents(ctx, report.ID)
+	require.NoError(t, err)
+	var types []taskmodel.EventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Contains(t, types, taskmodel.EventSlow)
+
+	repaired, err := container.TaskService(ctx).RebuildTasks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, repaired)
+
+	resp, err := http.Get(host.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `workmate_tasks_slow_total{type="report"} 1`)
+	assert.NotContains(t, string(body), `workmate_tasks_slow_total{type="default"}`)
+}
+
+func TestTaskCreatedBy(t *testing.T) {
+	ctx := context.Background()
+	service := taskservice.NewService(taskrepository.NewInMemoryTaskRepository())
+	defer service.Shutdown(ctx)
+
+	// Callers authenticate as the principal named by a header, as the
+	// client certificate middleware would.
+	engine := gin.New()
+	engine.Use(func(ctx *gin.Context) {
+		if id := ctx.GetHeader("X-Principal"); id != "" {
+			principal := auth.Principal{ID: id, Admin: id == "admin"}
+			ctx.Request = ctx.Request.WithContext(auth.WithPrincipal(ctx.Request.Context(), principal))
+		}
+	})
+	taskcontroller.NewController(service, nil).RegisterRoutes(engine.Group("/api/v1"))
+	host := httptest.NewServer(engine)
+	defer host.Close()
+	as := func(principal string) *client.Client {
+		return client.New(host.URL, client.WithHeader("X-Principal", principal))
+	}
+
+	alice, err := as("alice").Create(ctx, client.CreateRequest{Name: "Alice's"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alice.CreatedBy)
+	_, err = as("bob").Create(ctx, client.CreateRequest{Name: "Bob's"})
+	require.NoError(t, err)
+	anonymous, err := client.New(host.URL).Create(ctx, client.CreateRequest{Name: "Anonymous"})
+	require.NoError(t, err)
+	assert.Empty(t, anonymous.CreatedBy)
+
+	tasks, err := as("admin").List(ctx, client.ListOptions{All: true, CreatedBy: "alice"})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, alice.ID, tasks[0].ID)
+
+	tasks, err = as("alice").List(ctx, client.ListOptions{CreatedBy: "me"})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "alice", tasks[0].CreatedBy)
+
+	_, err = client.New(host.URL).List(ctx, client.ListOptions{CreatedBy: "me"})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestTaskNameRules(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.NameMaxLength = 20
+	cfg.Tasks.NameCharset = "a-z0-9-"
+	cfg.Tasks.NamePattern = "[a-z].*"
+	cfg.Tasks.NameReservedPrefixes = []string{"system-"}
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL)
+
+	_, err := c.Create(ctx, client.CreateRequest{Name: "nightly-report"})
+	require.NoError(t, err)
+
+	for name, rule := range map[string]string{
+		"a-very-long-task-name-indeed": "max",
+		"Nightly report":               "charset",
+		"1-nightly-report":             "pattern",
+		"system-cleanup":               "reserved_prefix",
+	} {
+		_, err := c.Create(ctx, client.CreateRequest{Name: name})
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr, name)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode, name)
+		require.Len(t, apiErr.Fields, 1, name)
+		assert.Equal(t, "name", apiErr.Fields[0].Field, name)
+		assert.Equal(t, rule, apiErr.Fields[0].Rule, name)
+	}
+}
+
+func TestTaskAttachments(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Blob.Backend = config.BlobBackendDisk
+	cfg.Blob.Dir = t.TempDir()
+	cfg.Blob.MaxUploadSize = 4096
+	cfg.Blob.EncryptionKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	container := app.NewDIContainer(app.WithConfig(cfg), app.WithClock(clock.NewAccelerated(3000)))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	task, err := c.CreateWithAttachments(ctx, client.CreateRequest{Name: "With inputs"}, []client.Upload{
+		{Name: "orders.csv", ContentType: "text/csv", Content: strings.NewReader("id,total\n1,10\n")},
+		{Name: "notes.txt", Content: strings.NewReader("hello")},
+	})
+	require.NoError(t, err)
+	require.Len(t, task.Inputs, 2)
+	assert.Equal(t, "orders.csv", task.Inputs[0].Name)
+	assert.Equal(t, "text/csv", task.Inputs[0].ContentType)
+	assert.Equal(t, int64(14), task.Inputs[0].Size)
+
+	task, err = c.WaitForCompletion(ctx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, client.StatusDone, task.Status)
+	require.Len(t, task.Artifacts, 1)
+	assert.Equal(t, "result.json", task.Artifacts[0].Name)
+	assert.Equal(t, "/api/v1/task/"+task.ID.String()+"/artifacts/result.json", task.Artifacts[0].URL)
+
+	content, err := c.Artifact(ctx, task.ID, "result.json")
+	require.NoError(t, err)
+	var result struct {
+		TaskID uuid.UUID `json:"task_id"`
+		Inputs []struct {
+			Name string `json:"name"`
+			Size int64  `json:"size"`
+		} `json:"inputs"`
+	}
+	require.NoError(t, json.Unmarshal(content, &result))
+	assert.Equal(t, task.ID, result.TaskID)
+	require.Len(t, result.Inputs, 2)
+	assert.Equal(t, "notes.txt", result.Inputs[1].Name)
+	assert.Equal(t, int64(5), result.Inputs[1].Size)
+
+	_, err = c.Artifact(ctx, task.ID, "missing.txt")
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "artifact_not_found", apiErr.Code)
+
+	_, err = c.CreateWithAttachments(ctx, client.CreateRequest{Name: "Too large"}, []client.Upload{
+		{Name: "big.bin", Content: bytes.NewReader(make([]byte, 8192))},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, apiErr.StatusCode)
+
+	// The files are encrypted on disk.
+	stored := 0
+	require.NoError(t, filepath.WalkDir(cfg.Blob.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		stored++
+		assert.NotContains(t, string(data), "id,total", path)
+		assert.NotContains(t, string(data), task.ID.String(), path)
+		return err
+	}))
+	assert.Equal(t, 3, stored)
+
+	require.NoError(t, c.Delete(ctx, task.ID))
+	entries, err := os.ReadDir(filepath.Join(cfg.Blob.Dir, "inputs"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestTaskTemplates(t *testing.T) {
+	ctx := context.Background()
+	// The real clock keeps {date} in the task names at today's date.
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	baseURL := host.URL + "/api/v1"
+	c := client.New(host.URL)
+
+	body, err := json.Marshal(map[string]any{
+		"name_pattern":         "Report {date} #{seq}",
+		"type":                 "report",
+		"priority":             "high",
+		"delete_after_seconds": 60,
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(baseURL+"/template/create", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var template struct {
+		ID        uuid.UUID `json:"id"`
+		Instances int       `json:"instances"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&template))
+	assert.Equal(t, "/api/v1/template/"+template.ID.String(), resp.Header.Get("Location"))
+
+	date := time.Now().UTC().Format(time.DateOnly)
+	for seq := 1; seq <= 2; seq++ {
+		task, err := c.CreateFromTemplate(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("Report %s #%d", date, seq), task.Name)
+		assert.Equal(t, "report", task.Type)
+		assert.Equal(t, int64(60), task.DeleteAfterSeconds)
+		assert.Equal(t, client.PriorityHigh, task.Priority)
+	}
+
+	// Unknown priorities are rejected.
+	body, err = json.Marshal(map[string]any{"name_pattern": "Report", "priority": "urgent"})
+	require.NoError(t, err)
+	invalidResp, err := http.Post(baseURL+"/template/create", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer invalidResp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, invalidResp.StatusCode)
+
+	body, err = json.Marshal(map[string]any{"name_pattern": "Renamed {seq}"})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPut, baseURL+"/template/"+template.ID.String(), bytes.NewBuffer(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	updateResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	require.NoError(t, json.NewDecoder(updateResp.Body).Decode(&template))
+	assert.Equal(t, 2, template.Instances)
+
+	task, err := c.CreateFromTemplate(ctx, template.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed 3", task.Name)
+	assert.Empty(t, task.DeleteAfterSeconds)
+	assert.Equal(t, client.PriorityNormal, task.Priority)
+
+	req, err = http.NewRequest(http.MethodDelete, baseURL+"/template/"+template.ID.String(), nil)
+	require.NoError(t, err)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer deleteResp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+
+	_, err = c.CreateFromTemplate(ctx, template.ID)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "template_not_found", apiErr.Code)
+}
+
+func TestTaskMaxRuntime(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	// The timeouts run on the wall clock: 50ms is well below the minutes
+	// of work, a second is well above them.
+	cfg.Tasks.Timeout = 50 * time.Millisecond
+	cfg.Tasks.MaxRuntime = 10 * time.Second
+	container := app.NewDIContainer(app.WithConfig(cfg), app.WithClock(clock.NewAccelerated(3000)))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	short, err := c.Create(ctx, client.CreateRequest{Name: "Default timeout"})
+	require.NoError(t, err)
+	long, err := c.Create(ctx, client.CreateRequest{Name: "Long running", MaxRuntimeSeconds: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), long.MaxRuntimeSeconds)
+
+	finished, err := c.WaitForCompletion(ctx, short.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusFailed, finished.Status)
+	finished, err = c.WaitForCompletion(ctx, long.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.StatusDone, finished.Status)
+
+	_, err = c.Create(ctx, client.CreateRequest{Name: "Too long", MaxRuntimeSeconds: 11})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	require.Len(t, apiErr.Fields, 1)
+	assert.Equal(t, "max_runtime_seconds", apiErr.Fields[0].Field)
+	assert.Equal(t, "10", apiErr.Fields[0].Param)
+}
+
+func TestDurationEstimates(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.EstimateFile = filepath.Join(t.TempDir(), "durations.json")
+
+	container := app.NewDIContainer(app.WithConfig(cfg), app.WithClock(clock.NewAccelerated(3000)))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	c := client.New(host.URL, client.WithPollInterval(5*time.Millisecond))
+
+	for i := range 2 {
+		task, err := c.Create(ctx, client.CreateRequest{Name: fmt.Sprintf("Report %d", i), Type: "report"})
+		require.NoError(t, err)
+		_, err = c.WaitForCompletion(ctx, task.ID)
+		require.NoError(t, err)
+	}
+	require.NoError(t, container.DurationModel(ctx).Save())
+
+	type estimates struct {
+		Types []struct {
+			Type        string  `json:"type"`
+			Count       int     `json:"count"`
+			MeanSeconds float64 `json:"mean_seconds"`
+			P50Seconds  float64 `json:"p50_seconds"`
+		} `json:"types"`
+	}
+	get := func(url string) estimates {
+		resp, err := http.Get(url + "/api/v1/tasks/stats/durations")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body estimates
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	before := get(host.URL)
+	require.Len(t, before.Types, 1)
+	assert.Equal(t, "report", before.Types[0].Type)
+	assert.Equal(t, 2, before.Types[0].Count)
+	// Executing takes 3 to 5 minutes.
+	assert.GreaterOrEqual(t, before.Types[0].P50Seconds, 170.0)
+
+	// A restarted instance picks the durations up from the file.
+	restarted := app.NewDIContainer(app.WithConfig(cfg), app.WithClock(clock.NewAccelerated(3000)))
+	restartedHost := httptest.NewServer(restarted.GinEngine(ctx))
+	defer restartedHost.Close()
+	assert.Equal(t, before, get(restartedHost.URL))
+}
+
+func TestTaskctl(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(
+		app.WithConfig(config.Defaults()),
+		app.WithClock(clock.NewAccelerated(3000)),
+	)
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	t.Setenv("TASKCTL_CONFIG", "")
+
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := taskctl.Run(ctx, append([]string{"--server", host.URL}, args...), &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+
+	code, out := run("create", "CLI Task", "--wait", "--interval", "10ms", "-o", "json")
+	require.Equal(t, taskctl.ExitOK, code, out)
+	var task client.Task
+	require.NoError(t, json.Unmarshal([]byte(out), &task))
+	assert.Equal(t, "CLI Task", task.Name)
+	assert.True(t, task.Status.IsFinal())
+
+	code, out = run("list")
+	require.Equal(t, taskctl.ExitOK, code, out)
+	assert.Contains(t, out, "STATUS")
+	assert.Contains(t, out, task.ID.String())
+
+	code, out = run("cancel", task.ID.String())
+	assert.Equal(t, taskctl.ExitError, code, out)
+
+	code, _ = run("delete", task.ID.String())
+	assert.Equal(t, taskctl.ExitOK, code)
+	code, _ = run("get", task.ID.String())
+	assert.Equal(t, taskctl.ExitNotFound, code)
+	code, _ = run("get", "not-a-uuid")
+	assert.Equal(t, taskctl.ExitUsage, code)
+
+	var stdout, stderr bytes.Buffer
+	code = taskctl.Run(ctx, []string{"--server", "http://127.0.0.1:1", "list"}, &stdout, &stderr)
+	assert.Equal(t, taskctl.ExitUnavailable, code)
+}
+
+func TestBench(t *testing.T) {
+	ctx := context.Background()
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+
+	results, err := bench.Run(ctx, bench.Options{
+		Server:      host.URL,
+		Concurrency: 2,
+		Duration:    100 * time.Millisecond,
+		Operations:  []string{bench.OpList, bench.OpGet},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, op := range []string{bench.OpGet, bench.OpList} {
+		result := results[i]
+		assert.Equal(t, op, result.Operation)
+		assert.Positive(t, result.Requests, op)
+		assert.Zero(t, result.Errors, op)
+		assert.LessOrEqual(t, result.P50, result.P99, op)
+		assert.LessOrEqual(t, result.P99, result.Max, op)
+	}
+
+	_, err = bench.Run(ctx, bench.Options{Concurrency: 1, Duration: time.Second, Operations: []string{"update"}})
+	assert.Error(t, err)
+}
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Compression.Encodings = []string{"br", "gzip"}
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	for i := range 30 {
+		_, err := container.TaskService(ctx).CreateTask(ctx, fmt.Sprintf("Compressed task %d", i))
+		require.NoError(t, err)
+	}
+
+	get := func(path, acceptEncoding string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, host.URL+path, nil)
+		require.NoError(t, err)
+		// Setting the header stops the transport from decompressing gzip.
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+	decode := func(body []byte) TaskListResponse {
+		var list TaskListResponse
+		require.NoError(t, json.Unmarshal(body, &list))
+		return list
+	}
+
+	resp, body := get("/api/v1/tasks", "gzip")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Contains(t, resp.Header.Values("Vary"), "Accept-Encoding")
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Len(t, decode(plain).Tasks, 30)
+	assert.Less(t, len(body), len(plain))
+
+	resp, body = get("/api/v1/tasks", "gzip;q=0.5, br")
+	assert.Equal(t, "br", resp.Header.Get("Content-Encoding"))
+	plain, err = io.ReadAll(brotli.NewReader(bytes.NewReader(body)))
+	require.NoError(t, err)
+	assert.Len(t, decode(plain).Tasks, 30)
+
+	resp, body = get("/api/v1/tasks", "identity")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.Len(t, decode(body).Tasks, 30)
+
+	// Small responses are not worth compressing.
+	resp, body = get("/version", "gzip, br")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.True(t, json.Valid(body))
+}
+
+func TestLoadShedding(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Defaults()
+	cfg.Tasks.Workers = 1
+	cfg.Shedding.QueueDepth = 2
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+	defer container.TaskService(ctx).Shutdown(ctx)
+
+	create := func(priority string) *http.Response {
+		body := `{"name":"Shed task","priority":"` + priority + `"}`
+		resp, err := http.Post(host.URL+"/api/v1/task/create", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	c := client.New(host.URL, client.WithRetries(0, 0))
+	var ids []uuid.UUID
+	for range 3 {
+		task, err := c.Create(ctx, client.CreateRequest{Name: "Shed task"})
+		require.NoError(t, err)
+		ids = append(ids, task.ID)
+	}
+	require.Eventually(t, func() bool {
+		return container.TaskService(ctx).QueuedTasks() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Positive(t, container.TaskService(ctx).QueueWait())
+
+	resp := create("low")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "5", resp.Header.Get("Retry-After"))
+
+	assert.Equal(t, http.StatusServiceUnavailable, create("normal").StatusCode)
+	assert.Equal(t, http.StatusAccepted, create("high").StatusCode)
+
+	// Reads are never shed.
+	list, err := http.Get(host.URL + "/api/v1/tasks")
+	require.NoError(t, err)
+	list.Body.Close()
+	assert.Equal(t, http.StatusOK, list.StatusCode)
+
+	// Once the queue has been worked off, new tasks are accepted again.
+	for _, id := range ids {
+		_, err := container.TaskService(ctx).CancelTask(ctx, id, "")
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		return container.TaskService(ctx).QueuedTasks() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Zero(t, container.TaskService(ctx).QueueWait())
+	assert.Equal(t, http.StatusAccepted, create("low").StatusCode)
+}
+
+func TestUI(t *testing.T) {
+	container := app.NewDIContainer(app.WithConfig(config.Defaults()))
+	host := httptest.NewServer(container.GinEngine(context.Background()))
+	defer host.Close()
+
+	resp, err := http.Get(host.URL + "/ui")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.NoError(t, container.FeatureFlags(context.Background()).Set("ui", true))
+	resp, err = http.Get(host.URL + "/ui")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	assert.Contains(t, string(body), "/task/create")
+	assert.Contains(t, string(body), `"/cancel"`)
+}
+
+func TestLoadgen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg := config.Defaults()
+	cfg.Admin.Token = testAdminToken
+	cfg.Dev.Enabled = true
+	container := app.NewDIContainer(app.WithConfig(cfg))
+	host := httptest.NewServer(container.GinEngine(ctx))
+	defer host.Close()
+
+	seeded, err := loadgen.Seed(ctx, container.TaskService(ctx), 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, seeded)
+
+	admin := func(method, body string) (int, loadgencontroller.LoadgenResponse) {
+		req, err := http.NewRequest(method, host.URL+"/api/v1/admin/loadgen", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var status loadgencontroller.LoadgenResponse
+		_ = json.NewDecoder(resp.Body).Decode(&status)
+		return resp.StatusCode, status
+	}
+
+	code, _ := admin(http.MethodPost, `{"rate": 5000}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, status := admin(http.MethodPost, `{"rate": 200, "type": "synthetic"}`)
+	require.Equal(t, http.StatusAccepted, code)
+	assert.True(t, status.Running)
+	assert.Equal(t, "synthetic", status.Type)
+
+	assert.Eventually(t, func() bool {
+		_, status := admin(http.MethodGet, "")
+		return status.Created >= 10
+	}, 5*time.Second, 20*time.Millisecond)
+
+	code, status = admin(http.MethodDelete, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, status.Running)
+
+	counts, err := container.TaskRepository(ctx).CountByStatus(ctx)
+	require.NoError(t, err)
+	stored := 0
+	for _, n := range counts {
+		stored += n
+	}
+	assert.Equal(t, 3+status.Created, stored)
+
+	// Outside dev mode the generator does not exist.
+	cfg = config.Defaults()
+	cfg.Admin.Token = testAdminToken
+	prod := httptest.NewServer(app.NewDIContainer(app.WithConfig(cfg)).GinEngine(ctx))
+	defer prod.Close()
+	req, err := http.NewRequest(http.MethodGet, prod.URL+"/api/v1/admin/loadgen", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestOpenAPI(t *testing.T) {
+	ctx := context.Background()
+	engine := app.NewDIContainer(app.WithConfig(config.Defaults())).GinEngine(ctx)
+	host := httptest.NewServer(engine)
+	defer host.Close()
+
+	resp, err := http.Get(host.URL + "/openapi.json")
+	require.NoError(t, err)
+	var spec struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage        `json:"paths"`
+		Components struct{ Schemas map[string]json.RawMessage } `json:"components"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+
+	// Every route is documented, whether or not its handler was described.
+	for _, route := range engine.Routes() {
+		if strings.Contains(route.Path, "*") {
+			continue
+		}
+		path := route.Path
+		for _, segment := range strings.Split(route.Path, "/") {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				path = strings.Replace(path, segment, "{"+name+"}", 1)
+			}
+		}
+		assert.Contains(t, spec.Paths[path], strings.ToLower(route.Method), route.Method+" "+route.Path)
+	}
+
+	assert.Contains(t, string(spec.Paths["/api/v1/tasks"]["get"]), "#/components/schemas/taskcontroller.TaskListResponse")
+	var request struct {
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			MaxLength int `json:"maxLength"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(spec.Components.Schemas["taskcontroller.CreateTaskRequest"], &request))
+	assert.Equal(t, []string{"name"}, request.Required)
+	// Name lengths depend on the configuration, other limits are documented.
+	assert.Zero(t, request.Properties["name"].MaxLength)
+	assert.Equal(t, 100, request.Properties["dedup_key"].MaxLength)
+}
+
+// newCert issues a certificate for template, signed by parent or
+// self-signed when parent is nil.
+func newCert(t *testing.T, template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	issuer, signer := template, any(key)
+	if parent != nil {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writeCert stores cert as PEM files named after name in dir.
+func writeCert(t *testing.T, dir, name string, cert tls.Certificate) (certFile, keyFile string) {
+	t.Helper()
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600))
+	return certFile, keyFile
+}
+
+func TestCertificateRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "server", newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "first"},
+	}, nil))
+
+	reloader, err := certs.NewReloader(certFile, keyFile, slog.Default())
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{GetCertificate: reloader.GetCertificate})
+	require.NoError(t, err)
+	defer listener.Close()
+	go http.Serve(listener, http.NotFoundHandler())
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	served := func() string {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	// rotate makes the files look newer than the loaded certificate even on
+	// file systems with coarse modification times.
+	rotate := func(age time.Duration) {
+		for _, path := range []string{certFile, keyFile} {
+			require.NoError(t, os.Chtimes(path, time.Now().Add(age), time.Now().Add(age)))
+		}
+	}
+	assert.Equal(t, "first", served())
+
+	writeCert(t, dir, "server", newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "second"},
+	}, nil))
+	rotate(time.Minute)
+	assert.Eventually(t, func() bool { return served() == "second" }, 5*time.Second, 10*time.Millisecond)
+
+	// A broken certificate is not picked up, the previous one stays in use.
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0o600))
+	rotate(2 * time.Minute)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "second", served())
+}
+
+func TestClientCertIdentity(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ca := newCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Workmate CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil)
+	caFile, _ := writeCert(t, dir, "ca", ca)
+	certFile, keyFile := writeCert(t, dir, "server", newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca))
+	clientCert := func(serial int64, commonName string) tls.Certificate {
+		return newCert(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Workmate"}},
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, &ca)
+	}
+	alice, root := clientCert(3, "alice"), clientCert(4, "root")
+
+	newContainer := func(identity string, admins ...string) *app.DIContainer {
+		cfg := config.Defaults()
+		cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile = certFile, keyFile, caFile
+		cfg.TLS.ClientIdentity = identity
+		cfg.Admin.Identities = admins
+		return app.NewDIContainer(app.WithConfig(cfg))
+	}
+
+	t.Run("common name", func(t *testing.T) {
+		container := newContainer(middleware.IdentityFromCommonName, "root")
+		defer container.TaskService(ctx).Shutdown(ctx)
+		server := container.Server(ctx)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.ServeTLS(listener, "", "")
+		defer server.Close()
+		baseURL := "https://" + listener.Addr().String()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.Leaf)
+		httpClient := func(cert tls.Certificate) *http.Client {
+			return &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}},
+			}}
+		}
+
+		task, err := client.New(baseURL, client.WithHTTPClient(httpClient(alice))).Create(ctx, client.CreateRequest{Name: "Owned Task"})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", task.Owner)
+
+		for path, want := range map[string]int{
+			"/livez":                           http.StatusOK,
+			"/metrics":                         http.StatusOK,
+			"/openapi.json":                    http.StatusOK,
+			"/api/v1/admin/features":           http.StatusForbidden,
+			"/api/v1/task/" + task.ID.String(): http.StatusOK,
+		} {
+			resp, err := httpClient(alice).Get(baseURL + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, want, resp.StatusCode, path)
+		}
+
+		resp, err := httpClient(root).Get(baseURL + "/api/v1/admin/features")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("subject", func(t *testing.T) {
+		container := newContainer(middleware.IdentityFromSubject, "CN=root,O=Workmate")
+		defer container.TaskService(ctx).Shutdown(ctx)
+		engine := container.GinEngine(ctx)
+		serve := func(method, path string, cert *tls.Certificate) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			if cert != nil {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert.Leaf, ca.Leaf}}}
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api/v1/admin/features", &root).Code)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/api/v1/admin/features", &alice).Code)
+
+		// Probes, metrics, the spec and the UI are identified like the API.
+		for _, path := range []string{"/livez", "/readyz", "/metrics", "/openapi.json", "/ui", "/api/v1/tasks"} {
+			recorder := serve(http.MethodGet, path, nil)
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, path)
+			assert.Contains(t, recorder.Body.String(), "unauthorized", path)
+		}
+	})
+}
+
 func TestMain(m *testing.M) {
 	m.Run()
 }